@@ -0,0 +1,356 @@
+// Package accessibility tracks elevator and escalator outages and answers whether stations are step-free right now.
+package accessibility
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+// Outage an elevator or escalator that is out of service because of an alert
+type Outage struct {
+	FacilityID   string
+	FacilityType mbta.FacilityType
+	StationID    string
+	AlertID      string
+	Header       string
+	Start        time.Time // Start of the alert's current active period, or when the outage was first seen
+	End          time.Time // When the alert went away. Zero while the outage is ongoing
+}
+
+// Ongoing whether the facility is still out of service
+func (o Outage) Ongoing() bool {
+	return o.End.IsZero()
+}
+
+// Duration how long the facility has been or was out of service
+func (o Outage) Duration(now time.Time) time.Duration {
+	if !o.Ongoing() {
+		return o.End.Sub(o.Start)
+	}
+	return now.Sub(o.Start)
+}
+
+// FacilityStatus an elevator or escalator and its current outage, if any
+type FacilityStatus struct {
+	Facility         *mbta.Facility
+	Outage           *Outage // nil when in service
+	AlternateService string  // How to get around the facility when it's out, from the facility's alternate-service-text property
+}
+
+// InService whether the facility is working
+func (f FacilityStatus) InService() bool {
+	return f.Outage == nil
+}
+
+// StationStatus the accessibility of a station right now
+type StationStatus struct {
+	StationID          string
+	Station            *mbta.Stop // nil if the facilities didn't include their stop
+	WheelchairBoarding mbta.WheelchairBoardingType
+	Elevators          []FacilityStatus
+	Escalators         []FacilityStatus
+	StepFree           bool // The station is wheelchair accessible and none of its elevators are out
+}
+
+// Tracker combines elevator and escalator facilities with closure alerts
+type Tracker struct {
+	client *mbta.Client
+
+	mu         sync.Mutex
+	facilities map[string]*mbta.Facility
+	stations   map[string]*mbta.Stop
+	open       map[outageKey]*Outage
+	resolved   []Outage // Ended outages not returned by ResolvedOutages yet
+	updatedAt  time.Time
+}
+
+type outageKey struct {
+	facilityID string
+	alertID    string
+}
+
+// NewTracker creates a Tracker that fetches facilities and alerts with client
+func NewTracker(client *mbta.Client) *Tracker {
+	return &Tracker{
+		client:     client,
+		facilities: make(map[string]*mbta.Facility),
+		stations:   make(map[string]*mbta.Stop),
+		open:       make(map[outageKey]*Outage),
+	}
+}
+
+// Refresh fetches elevators, escalators and alerts and updates the outages
+func (t *Tracker) Refresh() error {
+	return t.RefreshWithContext(context.Background())
+}
+
+// RefreshWithContext fetches elevators, escalators and alerts given a context and updates the outages
+func (t *Tracker) RefreshWithContext(ctx context.Context) error {
+	facilities, _, err := t.client.Facilities.GetAllFacilitiesWithContext(ctx, &mbta.GetAllFacilitiesRequestConfig{
+		Include:     []mbta.FacilityInclude{mbta.FacilityIncludeStop},
+		FilterTypes: []string{string(mbta.FacilityElevator), string(mbta.FacilityEscalator)},
+	})
+	if err != nil {
+		return err
+	}
+	alerts, _, err := t.client.Alerts.GetAllAlertsWithContext(ctx, &mbta.GetAllAlertsRequestConfig{
+		FilterActivity: []mbta.AlertActivityType{mbta.AlertActivityFilterAll},
+	})
+	if err != nil {
+		return err
+	}
+	parents, err := t.fetchParentStations(ctx, facilities)
+	if err != nil {
+		return err
+	}
+	t.update(time.Now(), facilities, parents, alerts)
+	return nil
+}
+
+// fetchParentStations fetches the parent stations of the facilities' stops, which only have their id when the facilities
+// include their stop, by id. Stations that aren't found are left out
+func (t *Tracker) fetchParentStations(ctx context.Context, facilities []*mbta.Facility) (map[string]*mbta.Stop, error) {
+	var ids []string
+	for _, f := range facilities {
+		if f.Stop != nil && f.Stop.ParentStation != nil && f.Stop.ParentStation.ID != "" && !f.Stop.ParentStation.Has(mbta.StopFieldWheelchairBoarding) {
+			ids = append(ids, f.Stop.ParentStation.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	stops, err := t.client.Stops.GetStopsWithContext(ctx, ids)
+	var missing mbta.MissingIDsError
+	if err != nil && !xerrors.As(err, &missing) {
+		return nil, err
+	}
+	parents := make(map[string]*mbta.Stop, len(stops))
+	for _, stop := range stops {
+		if stop != nil {
+			parents[stop.ID] = stop
+		}
+	}
+	return parents, nil
+}
+
+// Update replaces the known facilities and updates the outages from already fetched alerts.
+// Facilities should include their stop to know each station's wheelchair boarding. The parent stations of their stops
+// only have their id then, RefreshWithContext fetches them
+func (t *Tracker) Update(now time.Time, facilities []*mbta.Facility, alerts []*mbta.Alert) {
+	t.update(now, facilities, nil, alerts)
+}
+
+// update is Update with the facilities' stops' parent stations, by id, to use for the ones that only have their id
+func (t *Tracker) update(now time.Time, facilities []*mbta.Facility, parents map[string]*mbta.Stop, alerts []*mbta.Alert) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updatedAt = now
+
+	t.facilities = make(map[string]*mbta.Facility)
+	t.stations = make(map[string]*mbta.Stop)
+	for _, f := range facilities {
+		if f.Type != mbta.FacilityElevator && f.Type != mbta.FacilityEscalator {
+			continue
+		}
+		t.facilities[f.ID] = f
+		if station := stationOf(f); station != nil {
+			if parent, ok := parents[station.ID]; ok {
+				station = parent
+			}
+			if known, ok := t.stations[station.ID]; !ok || known.Name == "" {
+				t.stations[station.ID] = station
+			}
+		}
+	}
+
+	seen := make(map[outageKey]bool)
+	for _, alert := range alerts {
+		if alert.Effect != mbta.AlertEffectElevatorClosure && alert.Effect != mbta.AlertEffectEscalatorClosure {
+			continue
+		}
+		if !alert.ActiveAt(now) {
+			continue
+		}
+		for _, entity := range alert.InformedEntity {
+			if entity.FacilityID == nil {
+				continue
+			}
+			key := outageKey{facilityID: *entity.FacilityID, alertID: alert.ID}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := t.open[key]; ok {
+				continue
+			}
+			outage := &Outage{
+				FacilityID: key.facilityID,
+				AlertID:    alert.ID,
+				Header:     alert.Header,
+				Start:      periodStart(alert, now),
+			}
+			if f, ok := t.facilities[key.facilityID]; ok {
+				outage.FacilityType = f.Type
+				if station := stationOf(f); station != nil {
+					outage.StationID = station.ID
+				}
+			}
+			if outage.StationID == "" && entity.StopID != nil {
+				outage.StationID = *entity.StopID
+			}
+			t.open[key] = outage
+		}
+	}
+
+	for key, outage := range t.open {
+		if !seen[key] {
+			outage.End = now
+			t.resolved = append(t.resolved, *outage)
+			delete(t.open, key)
+		}
+	}
+}
+
+// Outages the ongoing outages, longest first
+func (t *Tracker) Outages() []Outage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	outages := make([]Outage, 0, len(t.open))
+	for _, o := range t.open {
+		outages = append(outages, *o)
+	}
+	sortOutages(outages)
+	return outages
+}
+
+// ResolvedOutages the outages that ended since the last call, in the order they ended. Each is only returned once, the
+// tracker doesn't keep them after that
+func (t *Tracker) ResolvedOutages() []Outage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	resolved := t.resolved
+	t.resolved = nil
+	return resolved
+}
+
+// Station the accessibility of a station. Stations without elevators or escalators aren't known
+func (t *Tracker) Station(id string) (StationStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := t.stationStatuses()
+	status, ok := statuses[id]
+	return status, ok
+}
+
+// Stations the accessibility of every station with elevators or escalators, by station id
+func (t *Tracker) Stations() []StationStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := t.stationStatuses()
+	list := make([]StationStatus, 0, len(statuses))
+	for _, s := range statuses {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StationID < list[j].StationID })
+	return list
+}
+
+// StepFree whether the station can be used without stairs or escalators right now. The second value is false for unknown stations
+func (t *Tracker) StepFree(stationID string) (bool, bool) {
+	status, ok := t.Station(stationID)
+	return status.StepFree, ok
+}
+
+func (t *Tracker) stationStatuses() map[string]StationStatus {
+	byFacility := make(map[string]*Outage)
+	for _, o := range t.open {
+		if known, ok := byFacility[o.FacilityID]; !ok || o.Start.Before(known.Start) {
+			byFacility[o.FacilityID] = o
+		}
+	}
+
+	statuses := make(map[string]StationStatus)
+	ids := make([]string, 0, len(t.facilities))
+	for id := range t.facilities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		f := t.facilities[id]
+		station := stationOf(f)
+		if station == nil {
+			continue
+		}
+		status, ok := statuses[station.ID]
+		if !ok {
+			status = StationStatus{StationID: station.ID}
+			if s := t.stations[station.ID]; s != nil && s.Name != "" {
+				status.Station = s
+				status.WheelchairBoarding = s.WheelchairBoarding
+			}
+		}
+		fs := FacilityStatus{Facility: f}
+		if props, err := f.Elevator(); err == nil {
+			fs.AlternateService = props.AlternateServiceText
+		}
+		if o, ok := byFacility[id]; ok {
+			outage := *o
+			fs.Outage = &outage
+		}
+		if f.Type == mbta.FacilityElevator {
+			status.Elevators = append(status.Elevators, fs)
+		} else {
+			status.Escalators = append(status.Escalators, fs)
+		}
+		statuses[station.ID] = status
+	}
+
+	for id, status := range statuses {
+		status.StepFree = status.WheelchairBoarding == mbta.WheelchairBoardingAccessible
+		for _, e := range status.Elevators {
+			if !e.InService() {
+				status.StepFree = false
+			}
+		}
+		statuses[id] = status
+	}
+	return statuses
+}
+
+// stationOf the parent station of the facility's stop, or the stop itself
+func stationOf(f *mbta.Facility) *mbta.Stop {
+	if f.Stop == nil || f.Stop.ID == "" {
+		return nil
+	}
+	if f.Stop.ParentStation != nil && f.Stop.ParentStation.ID != "" {
+		return f.Stop.ParentStation
+	}
+	return f.Stop
+}
+
+// periodStart the start of the alert's active period that contains now
+func periodStart(alert *mbta.Alert, now time.Time) time.Time {
+	for _, period := range alert.ActivePeriod {
+		if !now.Before(period.Start.Time) && (period.End == nil || now.Before(period.End.Time)) {
+			return period.Start.Time
+		}
+	}
+	return now
+}
+
+func sortOutages(outages []Outage) {
+	sort.Slice(outages, func(i, j int) bool {
+		if !outages[i].Start.Equal(outages[j].Start) {
+			return outages[i].Start.Before(outages[j].Start)
+		}
+		if outages[i].FacilityID != outages[j].FacilityID {
+			return outages[i].FacilityID < outages[j].FacilityID
+		}
+		return outages[i].AlertID < outages[j].AlertID
+	})
+}
@@ -0,0 +1,154 @@
+package accessibility
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+var testNow = time.Date(2019, time.June, 3, 8, 0, 0, 0, time.UTC)
+
+func testFacilities() []*mbta.Facility {
+	alewife := &mbta.Stop{ID: "place-alfcl", Name: "Alewife", WheelchairBoarding: mbta.WheelchairBoardingAccessible}
+	davis := &mbta.Stop{ID: "place-davis", Name: "Davis", WheelchairBoarding: mbta.WheelchairBoardingAccessible}
+	return []*mbta.Facility{
+		{ID: "elevator-1", Type: mbta.FacilityElevator, Stop: alewife, Properties: []mbta.FacilityProperty{{Name: "alternate-service-text", Value: "Use the elevator at the busway"}}},
+		{ID: "elevator-2", Type: mbta.FacilityElevator, Stop: alewife},
+		{ID: "escalator-1", Type: mbta.FacilityEscalator, Stop: alewife},
+		{ID: "escalator-2", Type: mbta.FacilityEscalator, Stop: davis},
+		{ID: "parking", Type: mbta.FacilityParkingArea, Stop: alewife},
+	}
+}
+
+func closure(id string, effect mbta.AlertEffectType, facilityID string, start time.Time) *mbta.Alert {
+	return &mbta.Alert{
+		ID:             id,
+		Effect:         effect,
+		Header:         "Closed for maintenance",
+		InformedEntity: []mbta.AlertInformedEntity{{FacilityID: &facilityID, Activities: []mbta.AlertActivityType{mbta.AlertActivityUsingWheelchair}}},
+		ActivePeriod:   []mbta.AlertActivePeriod{{Start: mbta.TimeISO8601{Time: start}}},
+	}
+}
+
+func TestTracker_Update(t *testing.T) {
+	tracker := NewTracker(nil)
+	facilities := testFacilities()
+	tracker.Update(testNow, facilities, nil)
+
+	stepFree, found := tracker.StepFree("place-alfcl")
+	assert(t, found, "expected alewife to be known")
+	assert(t, stepFree, "expected alewife to be step-free")
+	_, found = tracker.StepFree("place-pktrm")
+	assert(t, !found, "expected park street to be unknown")
+
+	alerts := []*mbta.Alert{
+		closure("elevator-out", mbta.AlertEffectElevatorClosure, "elevator-1", testNow.Add(-2*time.Hour)),
+		closure("escalator-out", mbta.AlertEffectEscalatorClosure, "escalator-2", testNow.Add(-time.Hour)),
+		closure("tomorrow", mbta.AlertEffectElevatorClosure, "elevator-2", testNow.Add(24*time.Hour)),
+		{ID: "delay", Effect: mbta.AlertEffectDelay},
+	}
+	tracker.Update(testNow, facilities, alerts)
+
+	outages := tracker.Outages()
+	equals(t, 2, len(outages))
+	equals(t, Outage{FacilityID: "elevator-1", FacilityType: mbta.FacilityElevator, StationID: "place-alfcl", AlertID: "elevator-out", Header: "Closed for maintenance", Start: testNow.Add(-2 * time.Hour)}, outages[0])
+	equals(t, 2*time.Hour, outages[0].Duration(testNow))
+
+	alewife, found := tracker.Station("place-alfcl")
+	assert(t, found, "expected alewife to be known")
+	assert(t, !alewife.StepFree, "expected alewife to not be step-free with an elevator out")
+	equals(t, 2, len(alewife.Elevators))
+	equals(t, 1, len(alewife.Escalators))
+	assert(t, !alewife.Elevators[0].InService(), "expected elevator-1 to be out")
+	equals(t, "Use the elevator at the busway", alewife.Elevators[0].AlternateService)
+	assert(t, alewife.Elevators[1].InService(), "expected elevator-2 to be working")
+
+	// escalator outages don't stop a station from being step-free
+	stepFree, _ = tracker.StepFree("place-davis")
+	assert(t, stepFree, "expected davis to be step-free")
+	equals(t, []string{"place-alfcl", "place-davis"}, []string{tracker.Stations()[0].StationID, tracker.Stations()[1].StationID})
+
+	later := testNow.Add(30 * time.Minute)
+	tracker.Update(later, facilities, alerts[1:])
+	resolved := tracker.ResolvedOutages()
+	equals(t, 1, len(resolved))
+	equals(t, "elevator-1", resolved[0].FacilityID)
+	equals(t, 150*time.Minute, resolved[0].Duration(later.Add(time.Hour)))
+	// reported outages aren't kept
+	equals(t, 0, len(tracker.ResolvedOutages()))
+	stepFree, _ = tracker.StepFree("place-alfcl")
+	assert(t, stepFree, "expected alewife to be step-free again")
+	equals(t, 1, len(tracker.Outages()))
+}
+
+func TestTracker_UpdateInaccessibleStation(t *testing.T) {
+	tracker := NewTracker(nil)
+	tracker.Update(testNow, []*mbta.Facility{
+		{ID: "escalator-1", Type: mbta.FacilityEscalator, Stop: &mbta.Stop{ID: "place-bbsta", Name: "Back Bay", WheelchairBoarding: mbta.WheelchairBoardingInaccessible}},
+		{ID: "escalator-2", Type: mbta.FacilityEscalator, Stop: &mbta.Stop{ID: "place-sstat"}},
+	}, nil)
+
+	stepFree, found := tracker.StepFree("place-bbsta")
+	assert(t, found && !stepFree, "expected back bay to not be step-free")
+
+	// without the stop included there's no wheelchair boarding info
+	southStation, found := tracker.Station("place-sstat")
+	assert(t, found, "expected south station to be known")
+	equals(t, mbta.WheelchairBoardingNoInfo, southStation.WheelchairBoarding)
+	assert(t, southStation.Station == nil, "expected no stop")
+	assert(t, !southStation.StepFree, "expected south station to not be step-free")
+}
+
+func TestTracker_RefreshParentStations(t *testing.T) {
+	responses := map[string]string{
+		"/facilities": `{"data": [{"type": "facility", "id": "elevator-1", "attributes": {"type": "ELEVATOR"},
+			"relationships": {"stop": {"data": {"type": "stop", "id": "70061"}}}}],
+			"included": [{"type": "stop", "id": "70061", "attributes": {"name": "Alewife", "wheelchair_boarding": 1},
+			"relationships": {"parent_station": {"data": {"type": "stop", "id": "place-alfcl"}}}}]}`,
+		"/alerts": `{"data": []}`,
+		"/stops":  `{"data": [{"type": "stop", "id": "place-alfcl", "attributes": {"name": "Alewife", "wheelchair_boarding": 1}}]}`,
+	}
+	var stopsQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stops" {
+			stopsQuery = r.URL.Query().Get("filter[id]")
+		}
+		w.Write([]byte(responses[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	tracker := NewTracker(mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL}))
+	ok(t, tracker.Refresh())
+
+	// the parent station only had its id in the facilities response, so it was fetched
+	equals(t, "place-alfcl", stopsQuery)
+	alewife, found := tracker.Station("place-alfcl")
+	assert(t, found, "expected alewife to be known")
+	equals(t, mbta.WheelchairBoardingAccessible, alewife.WheelchairBoarding)
+	assert(t, alewife.StepFree, "expected alewife to be step-free")
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("\n\n\texp: %#v\n\n\tgot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,443 @@
+package analytics
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const (
+	defaultEarlyThreshold = -1 * time.Minute
+	defaultLateThreshold  = 5 * time.Minute
+)
+
+// AdherenceConfig the window around the scheduled time that counts as on time. A nil threshold is the default one,
+// set it with Duration to choose any other, including 0 for no tolerance at all
+type AdherenceConfig struct {
+	EarlyThreshold *time.Duration // Arriving earlier than this (a negative duration) is early. Defaults to -1 minute
+	LateThreshold  *time.Duration // Arriving later than this is late. Defaults to 5 minutes
+}
+
+// Duration returns a pointer to d, for the optional thresholds of Config and AdherenceConfig
+func Duration(d time.Duration) *time.Duration {
+	return &d
+}
+
+// ActualSource where the actual time of a stop event came from
+type ActualSource string
+
+const (
+	// ActualSourceVehicle the vehicle was observed arriving at the stop
+	ActualSourceVehicle ActualSource = "vehicle"
+	// ActualSourcePrediction the last prediction recorded for the stop before the vehicle got there
+	ActualSourcePrediction ActualSource = "prediction"
+)
+
+// StopAdherence how a trip's actual arrival at a stop compared to its schedule
+type StopAdherence struct {
+	TripID       string
+	RouteID      string
+	StopID       string
+	StopSequence int
+	Scheduled    time.Time     // Scheduled arrival (or departure for the first stop)
+	Actual       time.Time     // Observed or last predicted arrival
+	Lateness     time.Duration // Actual - Scheduled, negative when early
+	Source       ActualSource  // Where Actual came from
+	OnTime       bool          // Lateness was within the AdherenceConfig thresholds
+}
+
+// LatenessDistribution summary statistics of a set of lateness values
+type LatenessDistribution struct {
+	Count int
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+}
+
+// AdherenceSummary on-time performance for a trip or a route
+type AdherenceSummary struct {
+	ID            string // The trip or route ID
+	RouteID       string // The route of the trip, same as ID for route summaries
+	Observations  int    // Number of stop events compared against the schedule
+	OnTime        int
+	Early         int
+	Late          int
+	OnTimePercent float64 // OnTime / Observations * 100
+	Lateness      LatenessDistribution
+}
+
+// CancelledTrip a trip with stops predicted as ScheduleRelationshipCancelled
+type CancelledTrip struct {
+	TripID         string
+	RouteID        string
+	CancelledStops int  // Number of stops that were predicted as cancelled
+	ScheduledStops int  // Number of stops the trip was scheduled to serve
+	FullyCancelled bool // Every scheduled stop was cancelled (or there was no schedule recorded for the trip)
+}
+
+// AdherenceReport schedule adherence for everything recorded
+type AdherenceReport struct {
+	Stops     []StopAdherence
+	Trips     []AdherenceSummary
+	Routes    []AdherenceSummary
+	Cancelled []CancelledTrip
+}
+
+type tripStop struct {
+	tripID       string
+	stopSequence int
+}
+
+type recordedSchedule struct {
+	TripID       string    `json:"trip_id"`
+	RouteID      string    `json:"route_id"`
+	StopID       string    `json:"stop_id"`
+	StopSequence int       `json:"stop_sequence"`
+	DirectionID  int       `json:"direction_id"`
+	Time         time.Time `json:"time"`
+}
+
+type recordedPrediction struct {
+	TripID       string    `json:"trip_id"`
+	RouteID      string    `json:"route_id"`
+	StopID       string    `json:"stop_id"`
+	StopSequence int       `json:"stop_sequence"`
+	Time         time.Time `json:"time"`
+	Cancelled    bool      `json:"cancelled,omitempty"`
+	ObservedAt   time.Time `json:"observed_at"`
+}
+
+// recording everything an AdherenceRecorder has seen, in the format it is saved in
+type recording struct {
+	Schedules   []recordedSchedule   `json:"schedules"`
+	Predictions []recordedPrediction `json:"predictions"`
+	Arrivals    []ArrivalEvent       `json:"arrivals"`
+}
+
+// AdherenceRecorder records schedules, predictions and vehicle arrivals over a service day and reports how well the trips kept to their schedules.
+// It is safe for concurrent use
+type AdherenceRecorder struct {
+	early time.Duration
+	late  time.Duration
+
+	mu          sync.Mutex
+	schedules   map[tripStop]recordedSchedule
+	predictions map[tripStop]recordedPrediction // latest prediction seen before the vehicle arrived
+	arrivals    map[tripStop]ArrivalEvent
+	detector    *arrivalDetector
+}
+
+// NewAdherenceRecorder creates a new, empty, AdherenceRecorder
+func NewAdherenceRecorder(config AdherenceConfig) *AdherenceRecorder {
+	early, late := defaultEarlyThreshold, defaultLateThreshold
+	if config.EarlyThreshold != nil {
+		early = *config.EarlyThreshold
+	}
+	if config.LateThreshold != nil {
+		late = *config.LateThreshold
+	}
+	return &AdherenceRecorder{
+		early:       early,
+		late:        late,
+		schedules:   make(map[tripStop]recordedSchedule),
+		predictions: make(map[tripStop]recordedPrediction),
+		arrivals:    make(map[tripStop]ArrivalEvent),
+		detector: newArrivalDetector(func(stop *mbta.Stop) (string, bool) {
+			if stop == nil {
+				return "", false
+			}
+			return stop.ID, true
+		}),
+	}
+}
+
+// RecordSchedules records the scheduled times, schedules without a Trip are ignored
+func (r *AdherenceRecorder) RecordSchedules(schedules []*mbta.Schedule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, schedule := range schedules {
+		if schedule.Trip == nil {
+			continue
+		}
+		t := scheduledTimeOf(schedule)
+		rec := recordedSchedule{
+			TripID:       schedule.Trip.ID,
+			RouteID:      routeIDOf(schedule.Route),
+			StopSequence: schedule.StopSequence,
+			DirectionID:  schedule.DirectionID,
+			Time:         t,
+		}
+		if schedule.Stop != nil {
+			rec.StopID = schedule.Stop.ID
+		}
+		r.schedules[tripStop{rec.TripID, rec.StopSequence}] = rec
+	}
+}
+
+// RecordPredictions records the predictions as seen at observedAt. Only the latest prediction for each trip and stop is kept, and
+// predictions for stops the vehicle has already been seen arriving at are ignored
+func (r *AdherenceRecorder) RecordPredictions(observedAt time.Time, predictions []*mbta.Prediction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, prediction := range predictions {
+		if prediction.Trip == nil {
+			continue
+		}
+		key := tripStop{prediction.Trip.ID, prediction.StopSequence}
+		if _, arrived := r.arrivals[key]; arrived {
+			continue
+		}
+		if last, ok := r.predictions[key]; ok && last.ObservedAt.After(observedAt) {
+			continue
+		}
+		rec := recordedPrediction{
+			TripID:       prediction.Trip.ID,
+			RouteID:      routeIDOf(prediction.Route),
+			StopSequence: prediction.StopSequence,
+			ObservedAt:   observedAt,
+		}
+		if prediction.Stop != nil {
+			rec.StopID = prediction.Stop.ID
+		}
+		if prediction.ArrivalTime != nil {
+			rec.Time = prediction.ArrivalTime.Time
+		} else if prediction.DepartureTime != nil {
+			rec.Time = prediction.DepartureTime.Time
+		}
+		rec.Cancelled = prediction.ScheduleRelationship != nil && *prediction.ScheduleRelationship == mbta.ScheduleRelationshipCancelled
+		r.predictions[key] = rec
+	}
+}
+
+// RecordVehicles records the arrivals revealed by a new snapshot of vehicles, see Analyzer.Arrivals for how arrivals are detected.
+// Snapshots must be recorded in time order
+func (r *AdherenceRecorder) RecordVehicles(snapshot Snapshot) {
+	r.mu.Lock()
+	events := r.detector.observe(snapshot)
+	r.mu.Unlock()
+	r.RecordArrivals(events)
+}
+
+// RecordArrivals records arrivals that were detected elsewhere, e.g. by an Analyzer. Arrivals without a TripID are ignored
+func (r *AdherenceRecorder) RecordArrivals(arrivals []ArrivalEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, arrival := range arrivals {
+		if arrival.TripID == "" {
+			continue
+		}
+		key := tripStop{arrival.TripID, arrival.StopSequence}
+		if existing, ok := r.arrivals[key]; ok && !existing.Inferred {
+			continue
+		}
+		r.arrivals[key] = arrival
+	}
+}
+
+// Report joins everything recorded to the schedules by trip and stop sequence
+func (r *AdherenceRecorder) Report() *AdherenceReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &AdherenceReport{}
+	for key, schedule := range r.schedules {
+		if schedule.Time.IsZero() {
+			continue
+		}
+		adherence := StopAdherence{
+			TripID:       schedule.TripID,
+			RouteID:      schedule.RouteID,
+			StopID:       schedule.StopID,
+			StopSequence: schedule.StopSequence,
+			Scheduled:    schedule.Time,
+		}
+		if arrival, ok := r.arrivals[key]; ok {
+			adherence.Actual = arrival.Time
+			adherence.Source = ActualSourceVehicle
+		} else if prediction, ok := r.predictions[key]; ok && !prediction.Cancelled && !prediction.Time.IsZero() {
+			adherence.Actual = prediction.Time
+			adherence.Source = ActualSourcePrediction
+		} else {
+			continue
+		}
+		adherence.Lateness = adherence.Actual.Sub(adherence.Scheduled)
+		adherence.OnTime = adherence.Lateness >= r.early && adherence.Lateness <= r.late
+		report.Stops = append(report.Stops, adherence)
+	}
+	sort.Slice(report.Stops, func(i, j int) bool {
+		a, b := report.Stops[i], report.Stops[j]
+		if a.TripID != b.TripID {
+			return a.TripID < b.TripID
+		}
+		return a.StopSequence < b.StopSequence
+	})
+
+	report.Trips = r.summarize(report.Stops, func(s StopAdherence) string { return s.TripID })
+	report.Routes = r.summarize(report.Stops, func(s StopAdherence) string { return s.RouteID })
+	report.Cancelled = r.cancelledTrips()
+	return report
+}
+
+func (r *AdherenceRecorder) summarize(stops []StopAdherence, keyOf func(StopAdherence) string) []AdherenceSummary {
+	grouped := make(map[string][]StopAdherence)
+	var keys []string
+	for _, s := range stops {
+		key := keyOf(s)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], s)
+	}
+	sort.Strings(keys)
+
+	summaries := make([]AdherenceSummary, 0, len(keys))
+	for _, key := range keys {
+		group := grouped[key]
+		summary := AdherenceSummary{ID: key, RouteID: group[0].RouteID, Observations: len(group)}
+		lateness := make([]time.Duration, len(group))
+		for i, s := range group {
+			lateness[i] = s.Lateness
+			switch {
+			case s.OnTime:
+				summary.OnTime++
+			case s.Lateness < r.early:
+				summary.Early++
+			default:
+				summary.Late++
+			}
+		}
+		summary.OnTimePercent = float64(summary.OnTime) / float64(summary.Observations) * 100
+		summary.Lateness = NewLatenessDistribution(lateness)
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func (r *AdherenceRecorder) cancelledTrips() []CancelledTrip {
+	trips := make(map[string]*CancelledTrip)
+	for _, prediction := range r.predictions {
+		if !prediction.Cancelled {
+			continue
+		}
+		trip, ok := trips[prediction.TripID]
+		if !ok {
+			trip = &CancelledTrip{TripID: prediction.TripID, RouteID: prediction.RouteID}
+			trips[prediction.TripID] = trip
+		}
+		trip.CancelledStops++
+	}
+	for _, schedule := range r.schedules {
+		if trip, ok := trips[schedule.TripID]; ok {
+			trip.ScheduledStops++
+			if trip.RouteID == "" {
+				trip.RouteID = schedule.RouteID
+			}
+		}
+	}
+
+	cancelled := make([]CancelledTrip, 0, len(trips))
+	for _, trip := range trips {
+		trip.FullyCancelled = trip.ScheduledStops == 0 || trip.CancelledStops >= trip.ScheduledStops
+		cancelled = append(cancelled, *trip)
+	}
+	sort.Slice(cancelled, func(i, j int) bool { return cancelled[i].TripID < cancelled[j].TripID })
+	return cancelled
+}
+
+// NewLatenessDistribution computes the distribution of the given lateness values
+func NewLatenessDistribution(lateness []time.Duration) LatenessDistribution {
+	if len(lateness) == 0 {
+		return LatenessDistribution{}
+	}
+	sorted := make([]time.Duration, len(lateness))
+	copy(sorted, lateness)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	percentile := func(p float64) time.Duration {
+		// nearest-rank
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return sorted[rank]
+	}
+	return LatenessDistribution{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(50),
+		P90:   percentile(90),
+		P95:   percentile(95),
+	}
+}
+
+// Save writes everything recorded so far as JSON so that it can be reloaded with LoadAdherenceRecorder
+func (r *AdherenceRecorder) Save(w io.Writer) error {
+	r.mu.Lock()
+	rec := recording{
+		Schedules:   make([]recordedSchedule, 0, len(r.schedules)),
+		Predictions: make([]recordedPrediction, 0, len(r.predictions)),
+		Arrivals:    make([]ArrivalEvent, 0, len(r.arrivals)),
+	}
+	for _, s := range r.schedules {
+		rec.Schedules = append(rec.Schedules, s)
+	}
+	for _, p := range r.predictions {
+		rec.Predictions = append(rec.Predictions, p)
+	}
+	for _, a := range r.arrivals {
+		rec.Arrivals = append(rec.Arrivals, a)
+	}
+	r.mu.Unlock()
+
+	// stable output so saved days can be diffed
+	sort.Slice(rec.Schedules, func(i, j int) bool {
+		return lessTripStop(rec.Schedules[i].TripID, rec.Schedules[i].StopSequence, rec.Schedules[j].TripID, rec.Schedules[j].StopSequence)
+	})
+	sort.Slice(rec.Predictions, func(i, j int) bool {
+		return lessTripStop(rec.Predictions[i].TripID, rec.Predictions[i].StopSequence, rec.Predictions[j].TripID, rec.Predictions[j].StopSequence)
+	})
+	sort.Slice(rec.Arrivals, func(i, j int) bool {
+		return lessTripStop(rec.Arrivals[i].TripID, rec.Arrivals[i].StopSequence, rec.Arrivals[j].TripID, rec.Arrivals[j].StopSequence)
+	})
+	return json.NewEncoder(w).Encode(rec)
+}
+
+// LoadAdherenceRecorder reads data written by AdherenceRecorder.Save into a new AdherenceRecorder, so that reports can be rerun offline
+func LoadAdherenceRecorder(rd io.Reader, config AdherenceConfig) (*AdherenceRecorder, error) {
+	var rec recording
+	if err := json.NewDecoder(rd).Decode(&rec); err != nil {
+		return nil, err
+	}
+	r := NewAdherenceRecorder(config)
+	for _, s := range rec.Schedules {
+		r.schedules[tripStop{s.TripID, s.StopSequence}] = s
+	}
+	for _, p := range rec.Predictions {
+		r.predictions[tripStop{p.TripID, p.StopSequence}] = p
+	}
+	for _, a := range rec.Arrivals {
+		r.arrivals[tripStop{a.TripID, a.StopSequence}] = a
+	}
+	return r, nil
+}
+
+func lessTripStop(tripA string, seqA int, tripB string, seqB int) bool {
+	if tripA != tripB {
+		return tripA < tripB
+	}
+	return seqA < seqB
+}
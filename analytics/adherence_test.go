@@ -0,0 +1,124 @@
+package analytics
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+func adherenceSchedule(trip string, seq int, minutes int) *mbta.Schedule {
+	return &mbta.Schedule{
+		ArrivalTime:  &mbta.TimeISO8601{Time: testStart.Add(time.Duration(minutes) * time.Minute)},
+		StopSequence: seq,
+		Route:        &mbta.Route{ID: "1"},
+		Stop:         &mbta.Stop{ID: "stop-" + strconv.Itoa(seq)},
+		Trip:         &mbta.Trip{ID: trip},
+	}
+}
+
+func adherencePrediction(trip string, seq int, minutes int, relationship *mbta.PredictionScheduleRelationshipType) *mbta.Prediction {
+	arrival := mbta.TimeISO8601{Time: testStart.Add(time.Duration(minutes) * time.Minute)}
+	return &mbta.Prediction{
+		ArrivalTime:          &arrival,
+		ScheduleRelationship: relationship,
+		StopSequence:         seq,
+		Route:                &mbta.Route{ID: "1"},
+		Trip:                 &mbta.Trip{ID: trip},
+	}
+}
+
+func newTestAdherenceRecorder() *AdherenceRecorder {
+	r := NewAdherenceRecorder(AdherenceConfig{})
+	r.RecordSchedules([]*mbta.Schedule{
+		adherenceSchedule("t1", 1, 0),
+		adherenceSchedule("t1", 2, 10),
+		adherenceSchedule("t1", 3, 20),
+		adherenceSchedule("t2", 1, 30),
+		adherenceSchedule("t2", 2, 40),
+	})
+
+	cancelled := mbta.ScheduleRelationshipCancelled
+	r.RecordPredictions(testStart, []*mbta.Prediction{
+		adherencePrediction("t1", 3, 21, nil),
+		adherencePrediction("t2", 1, 0, &cancelled),
+		adherencePrediction("t2", 2, 0, &cancelled),
+	})
+	// newer prediction for t1 stop 3 wins
+	r.RecordPredictions(testStart.Add(15*time.Minute), []*mbta.Prediction{adherencePrediction("t1", 3, 27, nil)})
+
+	vehicle := func(status mbta.VehicleStatus, stop string, seq int, minutes int) Snapshot {
+		return Snapshot{
+			Time: testStart.Add(time.Duration(minutes) * time.Minute),
+			Vehicles: []*mbta.Vehicle{&mbta.Vehicle{
+				ID: "v1", CurrentStatus: status, CurrentStopSequence: &seq,
+				Route: &mbta.Route{ID: "1"}, Trip: &mbta.Trip{ID: "t1"}, Stop: &mbta.Stop{ID: stop},
+			}},
+		}
+	}
+	r.RecordVehicles(vehicle(mbta.StoppedAt, "stop-1", 1, 0))
+	r.RecordVehicles(vehicle(mbta.StoppedAt, "stop-2", 2, 12))
+	return r
+}
+
+func TestAdherenceRecorder_Report(t *testing.T) {
+	report := newTestAdherenceRecorder().Report()
+
+	equals(t, []StopAdherence{
+		{TripID: "t1", RouteID: "1", StopID: "stop-1", StopSequence: 1, Scheduled: testStart, Actual: testStart, Source: ActualSourceVehicle, OnTime: true},
+		{TripID: "t1", RouteID: "1", StopID: "stop-2", StopSequence: 2, Scheduled: testStart.Add(10 * time.Minute), Actual: testStart.Add(12 * time.Minute), Lateness: 2 * time.Minute, Source: ActualSourceVehicle, OnTime: true},
+		{TripID: "t1", RouteID: "1", StopID: "stop-3", StopSequence: 3, Scheduled: testStart.Add(20 * time.Minute), Actual: testStart.Add(27 * time.Minute), Lateness: 7 * time.Minute, Source: ActualSourcePrediction},
+	}, report.Stops)
+
+	equals(t, 1, len(report.Routes))
+	route := report.Routes[0]
+	equals(t, "1", route.ID)
+	equals(t, 3, route.Observations)
+	equals(t, 2, route.OnTime)
+	equals(t, 1, route.Late)
+	equals(t, LatenessDistribution{Count: 3, Min: 0, Max: 7 * time.Minute, Mean: 3 * time.Minute, P50: 2 * time.Minute, P90: 7 * time.Minute, P95: 7 * time.Minute}, route.Lateness)
+
+	equals(t, []CancelledTrip{{TripID: "t2", RouteID: "1", CancelledStops: 2, ScheduledStops: 2, FullyCancelled: true}}, report.Cancelled)
+}
+
+func TestAdherenceRecorder_SaveLoad(t *testing.T) {
+	original := newTestAdherenceRecorder()
+	var buf bytes.Buffer
+	ok(t, original.Save(&buf))
+
+	reloaded, err := LoadAdherenceRecorder(&buf, AdherenceConfig{})
+	ok(t, err)
+	expected, actual := original.Report(), reloaded.Report()
+	equals(t, len(expected.Stops), len(actual.Stops))
+	for i := range expected.Stops {
+		assert(t, expected.Stops[i].Actual.Equal(actual.Stops[i].Actual), "stop %d actual changed: %v != %v", i, expected.Stops[i].Actual, actual.Stops[i].Actual)
+		equals(t, expected.Stops[i].Lateness, actual.Stops[i].Lateness)
+	}
+	equals(t, expected.Routes, actual.Routes)
+	equals(t, expected.Cancelled, actual.Cancelled)
+
+	// stricter thresholds on a rerun
+	strict, err := LoadAdherenceRecorder(bytes.NewReader(mustSave(t, original)), AdherenceConfig{LateThreshold: Duration(time.Minute)})
+	ok(t, err)
+	equals(t, 1, strict.Report().Routes[0].OnTime)
+
+	// no tolerance at all, rather than the defaults
+	exact, err := LoadAdherenceRecorder(bytes.NewReader(mustSave(t, original)), AdherenceConfig{EarlyThreshold: Duration(0), LateThreshold: Duration(0)})
+	ok(t, err)
+	equals(t, 1, exact.Report().Routes[0].OnTime)
+}
+
+func mustSave(t *testing.T, r *AdherenceRecorder) []byte {
+	var buf bytes.Buffer
+	ok(t, r.Save(&buf))
+	return buf.Bytes()
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
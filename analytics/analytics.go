@@ -0,0 +1,408 @@
+// Package analytics computes service-quality metrics such as observed arrivals, headways and bunching from MBTA vehicle snapshots.
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const (
+	defaultBunchingRatio     = 0.25
+	defaultGapRatio          = 1.5
+	defaultBunchingThreshold = 2 * time.Minute
+	defaultGapThreshold      = 20 * time.Minute
+)
+
+// Snapshot all of the vehicles returned by one poll of the vehicles endpoint
+type Snapshot struct {
+	Time     time.Time       // When the snapshot was taken. Used for vehicles that have no UpdatedAt
+	Vehicles []*mbta.Vehicle // The vehicles seen in the snapshot
+}
+
+// Config thresholds used to flag bunching and gaps. A nil one is the default one, set it with Float64 or Duration to
+// choose any other, including 0
+type Config struct {
+	BunchingRatio     *float64       // A headway shorter than this fraction of the scheduled headway is bunched. Defaults to 0.25
+	GapRatio          *float64       // A headway longer than this multiple of the scheduled headway is a gap. Defaults to 1.5
+	BunchingThreshold *time.Duration // Used instead of BunchingRatio when there is no scheduled headway. Defaults to 2 minutes
+	GapThreshold      *time.Duration // Used instead of GapRatio when there is no scheduled headway. Defaults to 20 minutes
+}
+
+// Float64 returns a pointer to f, for the optional ratios of Config
+func Float64(f float64) *float64 {
+	return &f
+}
+
+// ArrivalEvent a vehicle observed arriving at a stop
+type ArrivalEvent struct {
+	StopID       string    `json:"stop_id"`            // The route stop that was arrived at
+	DirectionID  int       `json:"direction_id"`       // Direction in which the vehicle was traveling: 0 or 1
+	VehicleID    string    `json:"vehicle_id"`         // The vehicle that arrived
+	TripID       string    `json:"trip_id"`            // The trip the vehicle was on, if known
+	RouteID      string    `json:"route_id"`           // The route the vehicle was on, if known
+	StopSequence int       `json:"stop_sequence"`      // The sequence of the stop in the trip
+	Time         time.Time `json:"time"`               // When the vehicle arrived
+	Inferred     bool      `json:"inferred,omitempty"` // The vehicle was never seen STOPPED_AT the stop, it was seen heading to it and then past it
+}
+
+// Headway the time between two consecutive arrivals at a stop in the same direction
+type Headway struct {
+	StopID            string        // The stop the headway was observed at
+	DirectionID       int           // Direction in which the vehicles were traveling: 0 or 1
+	VehicleID         string        // The vehicle that arrived
+	PreviousVehicleID string        // The vehicle that arrived before it
+	Time              time.Time     // When VehicleID arrived
+	Actual            time.Duration // Time since the previous arrival
+	Scheduled         time.Duration // The scheduled headway at that time, 0 if there is no schedule for the stop
+	Bunched           bool          // The vehicle arrived too soon after the previous one
+	Gap               bool          // The vehicle arrived too long after the previous one
+}
+
+// StopSummary aggregated headway metrics for a stop in a direction
+type StopSummary struct {
+	StopID               string        // The stop being summarized
+	DirectionID          int           // Direction in which the vehicles were traveling: 0 or 1
+	Arrivals             int           // Number of observed arrivals
+	MeanHeadway          time.Duration // Mean of the observed headways
+	MeanScheduledHeadway time.Duration // Mean of the scheduled headways the observed ones were compared against
+	Bunched              int           // Number of bunched headways
+	Gaps                 int           // Number of headways that were gaps
+}
+
+// Report everything computed from a set of snapshots
+type Report struct {
+	Arrivals  []ArrivalEvent
+	Headways  []Headway
+	Summaries []StopSummary
+}
+
+// Analyzer computes arrivals and headways for the stops of a route
+type Analyzer struct {
+	bunchingRatio     float64
+	gapRatio          float64
+	bunchingThreshold time.Duration
+	gapThreshold      time.Duration
+
+	stops     []*mbta.Stop
+	aliases   map[string]string             // stop (or parent station) id -> route stop id
+	scheduled map[stopDirection][]time.Time // sorted scheduled arrivals per stop and direction
+}
+
+type stopDirection struct {
+	stopID      string
+	directionID int
+}
+
+// NewAnalyzer creates a new Analyzer for the given route stops. schedules may be nil, in which case headways are only compared against the Config thresholds
+func NewAnalyzer(stops []*mbta.Stop, schedules []*mbta.Schedule, config Config) *Analyzer {
+	a := &Analyzer{
+		bunchingRatio:     defaultBunchingRatio,
+		gapRatio:          defaultGapRatio,
+		bunchingThreshold: defaultBunchingThreshold,
+		gapThreshold:      defaultGapThreshold,
+		stops:             stops,
+		aliases:           make(map[string]string),
+		scheduled:         make(map[stopDirection][]time.Time),
+	}
+	if config.BunchingRatio != nil {
+		a.bunchingRatio = *config.BunchingRatio
+	}
+	if config.GapRatio != nil {
+		a.gapRatio = *config.GapRatio
+	}
+	if config.BunchingThreshold != nil {
+		a.bunchingThreshold = *config.BunchingThreshold
+	}
+	if config.GapThreshold != nil {
+		a.gapThreshold = *config.GapThreshold
+	}
+	for _, stop := range stops {
+		a.aliases[stop.ID] = stop.ID
+	}
+	for _, stop := range stops {
+		if stop.ParentStation != nil && stop.ParentStation.ID != "" {
+			if _, ok := a.aliases[stop.ParentStation.ID]; !ok {
+				a.aliases[stop.ParentStation.ID] = stop.ID
+			}
+		}
+	}
+
+	for _, schedule := range schedules {
+		stopID, ok := a.routeStopID(schedule.Stop)
+		if !ok {
+			continue
+		}
+		t := scheduledTimeOf(schedule)
+		if t.IsZero() {
+			continue
+		}
+		key := stopDirection{stopID, schedule.DirectionID}
+		a.scheduled[key] = append(a.scheduled[key], t)
+	}
+	for _, times := range a.scheduled {
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	}
+	return a
+}
+
+// Analyze detects arrivals in the snapshots and computes the headways and per stop summaries from them
+func (a *Analyzer) Analyze(snapshots []Snapshot) *Report {
+	arrivals := a.Arrivals(snapshots)
+	headways := a.Headways(arrivals)
+	return &Report{
+		Arrivals:  arrivals,
+		Headways:  headways,
+		Summaries: a.Summaries(arrivals, headways),
+	}
+}
+
+// routeStopID maps a stop (or its parent station) onto one of the route's stops
+func (a *Analyzer) routeStopID(stop *mbta.Stop) (string, bool) {
+	if stop == nil {
+		return "", false
+	}
+	if id, ok := a.aliases[stop.ID]; ok {
+		return id, true
+	}
+	if stop.ParentStation != nil {
+		if id, ok := a.aliases[stop.ParentStation.ID]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+type vehicleState struct {
+	stopID   string
+	status   mbta.VehicleStatus
+	sequence int
+	tripID   string
+	time     time.Time
+}
+
+// arrivalDetector turns vehicle positions into arrival events as they are observed
+type arrivalDetector struct {
+	stopID func(*mbta.Stop) (string, bool) // maps the vehicle's stop to the stop id used in events, ignoring the vehicle if false
+	last   map[string]vehicleState
+}
+
+func newArrivalDetector(stopID func(*mbta.Stop) (string, bool)) *arrivalDetector {
+	return &arrivalDetector{stopID: stopID, last: make(map[string]vehicleState)}
+}
+
+// observe processes a snapshot, which must be newer than the ones observed before it, and returns the arrivals it revealed
+func (d *arrivalDetector) observe(snapshot Snapshot) []ArrivalEvent {
+	var events []ArrivalEvent
+	for _, vehicle := range snapshot.Vehicles {
+		stopID, ok := d.stopID(vehicle.Stop)
+		if !ok {
+			continue
+		}
+		seenAt := vehicle.UpdatedAt.Time
+		if seenAt.IsZero() {
+			seenAt = snapshot.Time
+		}
+		current := vehicleState{
+			stopID:   stopID,
+			status:   vehicle.CurrentStatus,
+			sequence: stopSequenceOf(vehicle),
+			tripID:   tripIDOf(vehicle.Trip),
+			time:     seenAt,
+		}
+		prev, seen := d.last[vehicle.ID]
+		if seen && !seenAt.After(prev.time) {
+			// stale position that was already processed
+			continue
+		}
+		d.last[vehicle.ID] = current
+
+		event := ArrivalEvent{
+			DirectionID: vehicle.DirectionID,
+			VehicleID:   vehicle.ID,
+			TripID:      current.tripID,
+			RouteID:     routeIDOf(vehicle.Route),
+		}
+		sameTrip := seen && prev.tripID == current.tripID
+		if sameTrip && prev.status != mbta.StoppedAt && prev.stopID != current.stopID && current.sequence > prev.sequence {
+			// passed prev.stopID between snapshots without being seen stopped there
+			inferred := event
+			inferred.StopID = prev.stopID
+			inferred.StopSequence = prev.sequence
+			inferred.Time = prev.time.Add(seenAt.Sub(prev.time) / 2)
+			inferred.Inferred = true
+			events = append(events, inferred)
+		}
+		if current.status == mbta.StoppedAt && !(sameTrip && prev.status == mbta.StoppedAt && prev.stopID == current.stopID) {
+			event.StopID = current.stopID
+			event.StopSequence = current.sequence
+			event.Time = seenAt
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Arrivals detects arrivals from the vehicles' CurrentStatus transitions. Snapshots are processed in time order.
+// A vehicle arrives at a stop when it is first seen STOPPED_AT it. If a vehicle is seen INCOMING_AT or IN_TRANSIT_TO a stop and then
+// heading to a later stop on the same trip without being seen STOPPED_AT it, an inferred arrival is recorded halfway between the two snapshots
+func (a *Analyzer) Arrivals(snapshots []Snapshot) []ArrivalEvent {
+	ordered := make([]Snapshot, len(snapshots))
+	copy(ordered, snapshots)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Time.Before(ordered[j].Time) })
+
+	var events []ArrivalEvent
+	detector := newArrivalDetector(a.routeStopID)
+	for _, snapshot := range ordered {
+		events = append(events, detector.observe(snapshot)...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// Headways computes the headway between each pair of consecutive arrivals at the same stop in the same direction
+func (a *Analyzer) Headways(arrivals []ArrivalEvent) []Headway {
+	byStop := make(map[stopDirection][]ArrivalEvent)
+	var keys []stopDirection
+	for _, arrival := range arrivals {
+		key := stopDirection{arrival.StopID, arrival.DirectionID}
+		if _, ok := byStop[key]; !ok {
+			keys = append(keys, key)
+		}
+		byStop[key] = append(byStop[key], arrival)
+	}
+
+	var headways []Headway
+	for _, key := range keys {
+		events := byStop[key]
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+		for i := 1; i < len(events); i++ {
+			h := Headway{
+				StopID:            key.stopID,
+				DirectionID:       key.directionID,
+				VehicleID:         events[i].VehicleID,
+				PreviousVehicleID: events[i-1].VehicleID,
+				Time:              events[i].Time,
+				Actual:            events[i].Time.Sub(events[i-1].Time),
+				Scheduled:         a.ScheduledHeadway(key.stopID, key.directionID, events[i].Time),
+			}
+			if h.Scheduled > 0 {
+				h.Bunched = float64(h.Actual) < a.bunchingRatio*float64(h.Scheduled)
+				h.Gap = float64(h.Actual) > a.gapRatio*float64(h.Scheduled)
+			} else {
+				h.Bunched = h.Actual < a.bunchingThreshold
+				h.Gap = h.Actual > a.gapThreshold
+			}
+			headways = append(headways, h)
+		}
+	}
+
+	sort.SliceStable(headways, func(i, j int) bool { return headways[i].Time.Before(headways[j].Time) })
+	return headways
+}
+
+// ScheduledHeadway the scheduled time between the two scheduled arrivals at the stop that surround t. Returns 0 if there aren't two scheduled arrivals to compare
+func (a *Analyzer) ScheduledHeadway(stopID string, directionID int, t time.Time) time.Duration {
+	times := a.scheduled[stopDirection{stopID, directionID}]
+	if len(times) < 2 {
+		return 0
+	}
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(t) })
+	switch {
+	case i == 0:
+		i = 1
+	case i == len(times):
+		i = len(times) - 1
+	}
+	return times[i].Sub(times[i-1])
+}
+
+// Summaries aggregates arrivals and headways per stop and direction, in the order of the route's stops
+func (a *Analyzer) Summaries(arrivals []ArrivalEvent, headways []Headway) []StopSummary {
+	summaries := make(map[stopDirection]*StopSummary)
+	get := func(key stopDirection) *StopSummary {
+		s, ok := summaries[key]
+		if !ok {
+			s = &StopSummary{StopID: key.stopID, DirectionID: key.directionID}
+			summaries[key] = s
+		}
+		return s
+	}
+
+	for _, arrival := range arrivals {
+		get(stopDirection{arrival.StopID, arrival.DirectionID}).Arrivals++
+	}
+	counts := make(map[stopDirection][2]int)
+	for _, h := range headways {
+		key := stopDirection{h.StopID, h.DirectionID}
+		s := get(key)
+		c := counts[key]
+		s.MeanHeadway += h.Actual
+		c[0]++
+		if h.Scheduled > 0 {
+			s.MeanScheduledHeadway += h.Scheduled
+			c[1]++
+		}
+		if h.Bunched {
+			s.Bunched++
+		}
+		if h.Gap {
+			s.Gaps++
+		}
+		counts[key] = c
+	}
+
+	var result []StopSummary
+	for _, stop := range a.stops {
+		for _, directionID := range []int{0, 1} {
+			key := stopDirection{stop.ID, directionID}
+			s, ok := summaries[key]
+			if !ok {
+				continue
+			}
+			if c := counts[key]; c[0] > 0 {
+				s.MeanHeadway /= time.Duration(c[0])
+				if c[1] > 0 {
+					s.MeanScheduledHeadway /= time.Duration(c[1])
+				}
+			}
+			result = append(result, *s)
+		}
+	}
+	return result
+}
+
+func tripIDOf(trip *mbta.Trip) string {
+	if trip == nil {
+		return ""
+	}
+	return trip.ID
+}
+
+func routeIDOf(route *mbta.Route) string {
+	if route == nil {
+		return ""
+	}
+	return route.ID
+}
+
+// stopSequenceOf the vehicle's current stop sequence, 0 when it isn't on a trip
+func stopSequenceOf(vehicle *mbta.Vehicle) int {
+	if vehicle.CurrentStopSequence == nil {
+		return 0
+	}
+	return *vehicle.CurrentStopSequence
+}
+
+// scheduledTimeOf the scheduled arrival time, or the departure time at the first stop of a trip. Zero when it has neither
+func scheduledTimeOf(schedule *mbta.Schedule) time.Time {
+	if schedule.ArrivalTime != nil {
+		return schedule.ArrivalTime.Time
+	}
+	if schedule.DepartureTime != nil {
+		return schedule.DepartureTime.Time
+	}
+	return time.Time{}
+}
@@ -0,0 +1,154 @@
+package analytics
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+var (
+	testStart = time.Date(2019, time.June, 3, 8, 0, 0, 0, time.UTC)
+	testStops = []*mbta.Stop{
+		&mbta.Stop{ID: "place-a"},
+		&mbta.Stop{ID: "place-b"},
+		&mbta.Stop{ID: "place-c"},
+	}
+)
+
+func testVehicle(id string, trip string, status mbta.VehicleStatus, stopID string, sequence int) *mbta.Vehicle {
+	return &mbta.Vehicle{
+		ID:                  id,
+		CurrentStatus:       status,
+		CurrentStopSequence: &sequence,
+		Route:               &mbta.Route{ID: "Red"},
+		Trip:                &mbta.Trip{ID: trip},
+		// platform stops whose parent station is on the route
+		Stop: &mbta.Stop{ID: stopID + "-platform", ParentStation: &mbta.Stop{ID: stopID}},
+	}
+}
+
+func testSnapshot(minutes int, vehicles ...*mbta.Vehicle) Snapshot {
+	return Snapshot{Time: testStart.Add(time.Duration(minutes) * time.Minute), Vehicles: vehicles}
+}
+
+func testSchedules() []*mbta.Schedule {
+	var schedules []*mbta.Schedule
+	for _, minutes := range []int{0, 10, 20, 30} {
+		schedules = append(schedules, &mbta.Schedule{
+			ArrivalTime: &mbta.TimeISO8601{Time: testStart.Add(time.Duration(minutes) * time.Minute)},
+			Stop:        &mbta.Stop{ID: "place-b"},
+		})
+	}
+	return schedules
+}
+
+func TestAnalyzer_Arrivals(t *testing.T) {
+	analyzer := NewAnalyzer(testStops, nil, Config{})
+	snapshots := []Snapshot{
+		testSnapshot(2, testVehicle("v1", "t1", mbta.InTransitTo, "place-b", 2)),
+		testSnapshot(0, testVehicle("v1", "t1", mbta.StoppedAt, "place-a", 1)),
+		testSnapshot(3, testVehicle("v1", "t1", mbta.StoppedAt, "place-b", 2)),
+		testSnapshot(4, testVehicle("v1", "t1", mbta.StoppedAt, "place-b", 2)),
+		testSnapshot(5, testVehicle("v1", "t1", mbta.IncomingAt, "place-c", 3)),
+		testSnapshot(7, testVehicle("v1", "t2", mbta.InTransitTo, "place-a", 1)),
+	}
+
+	actual := analyzer.Arrivals(snapshots)
+	expected := []ArrivalEvent{
+		{StopID: "place-a", VehicleID: "v1", TripID: "t1", RouteID: "Red", StopSequence: 1, Time: testStart},
+		{StopID: "place-b", VehicleID: "v1", TripID: "t1", RouteID: "Red", StopSequence: 2, Time: testStart.Add(3 * time.Minute)},
+	}
+	equals(t, expected, actual)
+
+	// seen heading to c, then to a later stop: inferred arrival at c
+	snapshots = append(snapshots[:5], testSnapshot(7, testVehicle("v1", "t1", mbta.InTransitTo, "place-d", 4)))
+	analyzer = NewAnalyzer(append(testStops, &mbta.Stop{ID: "place-d"}), nil, Config{})
+	actual = analyzer.Arrivals(snapshots)
+	equals(t, 3, len(actual))
+	equals(t, ArrivalEvent{StopID: "place-c", VehicleID: "v1", TripID: "t1", RouteID: "Red", StopSequence: 3, Time: testStart.Add(6 * time.Minute), Inferred: true}, actual[2])
+}
+
+func TestAnalyzer_Headways(t *testing.T) {
+	analyzer := NewAnalyzer(testStops, testSchedules(), Config{})
+	arrivals := []ArrivalEvent{
+		{StopID: "place-b", VehicleID: "v1", Time: testStart.Add(1 * time.Minute)},
+		{StopID: "place-b", VehicleID: "v2", Time: testStart.Add(3 * time.Minute)},
+		{StopID: "place-b", VehicleID: "v3", Time: testStart.Add(13 * time.Minute)},
+		{StopID: "place-b", VehicleID: "v4", Time: testStart.Add(29 * time.Minute)},
+		{StopID: "place-c", VehicleID: "v1", Time: testStart.Add(4 * time.Minute)},
+	}
+
+	expected := []Headway{
+		{StopID: "place-b", VehicleID: "v2", PreviousVehicleID: "v1", Time: testStart.Add(3 * time.Minute), Actual: 2 * time.Minute, Scheduled: 10 * time.Minute, Bunched: true},
+		{StopID: "place-b", VehicleID: "v3", PreviousVehicleID: "v2", Time: testStart.Add(13 * time.Minute), Actual: 10 * time.Minute, Scheduled: 10 * time.Minute},
+		{StopID: "place-b", VehicleID: "v4", PreviousVehicleID: "v3", Time: testStart.Add(29 * time.Minute), Actual: 16 * time.Minute, Scheduled: 10 * time.Minute, Gap: true},
+	}
+	actual := analyzer.Headways(arrivals)
+	equals(t, expected, actual)
+
+	summaries := analyzer.Summaries(arrivals, actual)
+	equals(t, []StopSummary{
+		{StopID: "place-b", Arrivals: 4, MeanHeadway: 28 * time.Minute / 3, MeanScheduledHeadway: 10 * time.Minute, Bunched: 1, Gaps: 1},
+		{StopID: "place-c", Arrivals: 1},
+	}, summaries)
+}
+
+func TestAnalyzer_HeadwaysConfig(t *testing.T) {
+	arrivals := []ArrivalEvent{
+		{StopID: "place-b", VehicleID: "v1", Time: testStart.Add(1 * time.Minute)},
+		{StopID: "place-b", VehicleID: "v2", Time: testStart.Add(11 * time.Minute)},
+		{StopID: "place-c", VehicleID: "v1", Time: testStart.Add(4 * time.Minute)},
+		{StopID: "place-c", VehicleID: "v2", Time: testStart.Add(5 * time.Minute)},
+	}
+
+	// thresholds of 0 are used as they are rather than replaced by the defaults: no headway is bunched, every one is a gap
+	analyzer := NewAnalyzer(testStops, testSchedules(), Config{
+		BunchingRatio:     Float64(0),
+		GapRatio:          Float64(0),
+		BunchingThreshold: Duration(0),
+		GapThreshold:      Duration(0),
+	})
+	expected := []Headway{
+		{StopID: "place-c", VehicleID: "v2", PreviousVehicleID: "v1", Time: testStart.Add(5 * time.Minute), Actual: time.Minute, Gap: true},
+		{StopID: "place-b", VehicleID: "v2", PreviousVehicleID: "v1", Time: testStart.Add(11 * time.Minute), Actual: 10 * time.Minute, Scheduled: 10 * time.Minute, Gap: true},
+	}
+	equals(t, expected, analyzer.Headways(arrivals))
+
+	// with the defaults, the minute at c is bunched
+	analyzer = NewAnalyzer(testStops, testSchedules(), Config{})
+	expected[0].Gap, expected[0].Bunched = false, true
+	expected[1].Gap = false
+	equals(t, expected, analyzer.Headways(arrivals))
+}
+
+func TestWriteHeadwaysCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteHeadwaysCSV(&buf, []Headway{
+		{StopID: "place-b", DirectionID: 1, VehicleID: "v2", PreviousVehicleID: "v1", Time: testStart, Actual: 90 * time.Second, Scheduled: 10 * time.Minute, Bunched: true},
+	})
+	ok(t, err)
+	expected := strings.Join([]string{
+		"stop_id,direction_id,vehicle_id,previous_vehicle_id,time,actual_seconds,scheduled_seconds,bunched,gap",
+		"place-b,1,v2,v1,2019-06-03T08:00:00Z,90,600,true,false",
+		"",
+	}, "\n")
+	equals(t, expected, buf.String())
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("\n\n\texp: %#v\n\n\tgot: %#v", exp, act)
+	}
+}
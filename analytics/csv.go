@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteArrivalsCSV writes the arrivals as CSV with a header row. Times are RFC3339 in UTC
+func WriteArrivalsCSV(w io.Writer, arrivals []ArrivalEvent) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"stop_id", "direction_id", "vehicle_id", "trip_id", "route_id", "stop_sequence", "time", "inferred"})
+	for _, a := range arrivals {
+		cw.Write([]string{
+			a.StopID,
+			strconv.Itoa(a.DirectionID),
+			a.VehicleID,
+			a.TripID,
+			a.RouteID,
+			strconv.Itoa(a.StopSequence),
+			formatTime(a.Time),
+			strconv.FormatBool(a.Inferred),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHeadwaysCSV writes the headways as CSV with a header row. Durations are in seconds
+func WriteHeadwaysCSV(w io.Writer, headways []Headway) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"stop_id", "direction_id", "vehicle_id", "previous_vehicle_id", "time", "actual_seconds", "scheduled_seconds", "bunched", "gap"})
+	for _, h := range headways {
+		cw.Write([]string{
+			h.StopID,
+			strconv.Itoa(h.DirectionID),
+			h.VehicleID,
+			h.PreviousVehicleID,
+			formatTime(h.Time),
+			formatSeconds(h.Actual),
+			formatSeconds(h.Scheduled),
+			strconv.FormatBool(h.Bunched),
+			strconv.FormatBool(h.Gap),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummariesCSV writes the stop summaries as CSV with a header row. Durations are in seconds
+func WriteSummariesCSV(w io.Writer, summaries []StopSummary) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"stop_id", "direction_id", "arrivals", "mean_headway_seconds", "mean_scheduled_headway_seconds", "bunched", "gaps"})
+	for _, s := range summaries {
+		cw.Write([]string{
+			s.StopID,
+			strconv.Itoa(s.DirectionID),
+			strconv.Itoa(s.Arrivals),
+			formatSeconds(s.MeanHeadway),
+			formatSeconds(s.MeanScheduledHeadway),
+			strconv.Itoa(s.Bunched),
+			strconv.Itoa(s.Gaps),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
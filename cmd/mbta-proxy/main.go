@@ -0,0 +1,77 @@
+// Command mbta-proxy serves the MBTA v3 API to internal consumers through one API key, see package proxy.
+//
+//	mbta-proxy -addr :8080 -api-key KEY -consumers consumers.json
+//
+// The consumers file is a JSON list of the services allowed to use the proxy, each with the key it sends like an
+// API key and its quota:
+//
+//	[{"name": "web", "key": "secret", "requests_per_minute": 600}]
+//
+// Without it, anyone who can reach the proxy can use it without a quota.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"github.com/mellena1/mbta-v3-go/proxy"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	apiKey := flag.String("api-key", os.Getenv("MBTA_API_KEY"), "MBTA API key, defaults to $MBTA_API_KEY")
+	baseURL := flag.String("base-url", "", "upstream API URL, defaults to the MBTA's")
+	consumersPath := flag.String("consumers", "", "JSON file of the consumers and their quotas")
+	cacheTTL := flag.Duration("cache-ttl", 5*time.Second, "how long a response is served from the cache before it is revalidated")
+	maxCacheEntries := flag.Int("max-cache-entries", 1000, "most responses cached")
+	flag.Parse()
+
+	var consumers []proxy.Consumer
+	if *consumersPath != "" {
+		b, err := ioutil.ReadFile(*consumersPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &consumers); err != nil {
+			log.Fatalf("%s: %v", *consumersPath, err)
+		}
+	}
+
+	client := mbta.NewClient(mbta.ClientConfig{BaseURL: *baseURL, APIKey: *apiKey, UserAgent: "mbta-proxy"})
+	server := &http.Server{
+		Addr: *addr,
+		Handler: proxy.New(proxy.Config{
+			Client:          client,
+			Consumers:       consumers,
+			CacheTTL:        *cacheTTL,
+			MaxCacheEntries: *maxCacheEntries,
+		}),
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan struct{})
+	go func() {
+		<-stop
+		// streams never go idle, so they get cut off after the timeout
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+		close(stopped)
+	}()
+
+	log.Printf("mbta-proxy listening on %s for %d consumers", *addr, len(consumers))
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
+}
@@ -0,0 +1,26 @@
+module github.com/mellena1/mbta-v3-go/contrib/mbtaotel
+
+go 1.20
+
+require (
+	github.com/mellena1/mbta-v3-go v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/google/jsonapi v0.0.0-20181016150055-d0428f63eb51 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
+
+replace github.com/google/jsonapi => github.com/mellena1/go.jsonapi v1.2.2
+
+// until the client has a tagged release, build against the one in this repository
+replace github.com/mellena1/mbta-v3-go => ../..
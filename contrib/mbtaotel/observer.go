@@ -0,0 +1,108 @@
+// Package mbtaotel traces and measures the API calls an mbta.Client makes with OpenTelemetry.
+// It's its own module so that the client doesn't depend on the OpenTelemetry libraries:
+//
+//	observer, err := mbtaotel.NewObserver(otel.GetTracerProvider(), otel.GetMeterProvider())
+//	client := mbta.NewClient(mbta.ClientConfig{Observer: observer})
+package mbtaotel
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/mellena1/mbta-v3-go/contrib/mbtaotel"
+
+// Observer an mbta.Observer that wraps every API call in a client span named after the service method
+// (e.g. "mbta.Vehicles.GetAllVehicles"), covering the request and the decoding of the response, and records these metrics,
+// all with mbta.service and mbta.method attributes:
+//   - mbta.client.requests: requests, with an http.status_code attribute (0 when no response was received)
+//   - mbta.client.request.duration: histogram of how long requests took in seconds, including decoding
+//   - mbta.client.rate_limit.remaining: the x-ratelimit-remaining header of the latest response
+//   - mbta.client.cache_hits: conditional requests answered with 304 Not Modified
+//   - mbta.client.decode_errors: responses whose body couldn't be decoded
+type Observer struct {
+	tracer       trace.Tracer
+	requests     metric.Int64Counter
+	duration     metric.Float64Histogram
+	cacheHits    metric.Int64Counter
+	decodeErrors metric.Int64Counter
+
+	rateLimit int64 // -1 until a response has the header
+}
+
+// NewObserver creates an Observer that creates spans with tp and instruments with mp
+func NewObserver(tp trace.TracerProvider, mp metric.MeterProvider) (*Observer, error) {
+	meter := mp.Meter(instrumentationName)
+	o := &Observer{tracer: tp.Tracer(instrumentationName), rateLimit: -1}
+	var err error
+	if o.requests, err = meter.Int64Counter("mbta.client.requests", metric.WithDescription("API requests")); err != nil {
+		return nil, err
+	}
+	if o.duration, err = meter.Float64Histogram("mbta.client.request.duration", metric.WithUnit("s"),
+		metric.WithDescription("How long API requests took, including decoding the response")); err != nil {
+		return nil, err
+	}
+	if o.cacheHits, err = meter.Int64Counter("mbta.client.cache_hits", metric.WithDescription("Conditional API requests answered with 304 Not Modified")); err != nil {
+		return nil, err
+	}
+	if o.decodeErrors, err = meter.Int64Counter("mbta.client.decode_errors", metric.WithDescription("API responses whose body couldn't be decoded")); err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge("mbta.client.rate_limit.remaining",
+		metric.WithDescription("Requests left in the current rate limit window, from the latest response"),
+		metric.WithInt64Callback(func(ctx context.Context, observer metric.Int64Observer) error {
+			if remaining := atomic.LoadInt64(&o.rateLimit); remaining >= 0 {
+				observer.Observe(remaining)
+			}
+			return nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// RequestStarted implements mbta.Observer by starting the span
+func (o *Observer) RequestStarted(ctx context.Context, info mbta.RequestInfo) context.Context {
+	ctx, _ = o.tracer.Start(ctx, "mbta."+info.Service+"."+info.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mbta.service", info.Service),
+			attribute.String("mbta.method", info.Method),
+			attribute.String("http.method", "GET"),
+			attribute.String("http.url", info.URL.String()),
+		))
+	return ctx
+}
+
+// RequestDone implements mbta.Observer by ending the span and recording the metrics
+func (o *Observer) RequestDone(ctx context.Context, info mbta.RequestInfo, result mbta.RequestResult) {
+	span := trace.SpanFromContext(ctx)
+	if result.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.status_code", result.StatusCode))
+	}
+	if result.Err != nil && !result.CacheHit {
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+	}
+	span.End()
+
+	attrs := metric.WithAttributes(attribute.String("mbta.service", info.Service), attribute.String("mbta.method", info.Method))
+	o.requests.Add(ctx, 1, attrs, metric.WithAttributes(attribute.Int("http.status_code", result.StatusCode)))
+	o.duration.Record(ctx, result.Duration.Seconds(), attrs)
+	if result.RateLimitRemaining >= 0 {
+		atomic.StoreInt64(&o.rateLimit, int64(result.RateLimitRemaining))
+	}
+	if result.CacheHit {
+		o.cacheHits.Add(ctx, 1, attrs)
+	}
+	if result.DecodeError {
+		o.decodeErrors.Add(ctx, 1, attrs)
+	}
+}
@@ -0,0 +1,87 @@
+package mbtaotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-remaining", "42")
+		if r.URL.Path == "/vehicles/broken" {
+			fmt.Fprint(w, "not json")
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"type": "vehicle", "id": "y1", "attributes": {"latitude": 42.1, "longitude": -71.1}}]}`)
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	o, err := NewObserver(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)), sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL, Observer: o})
+
+	if _, _, err := client.Vehicles.GetAllVehicles(&mbta.GetAllVehiclesRequestConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := client.Vehicles.GetVehicle("broken", &mbta.GetVehicleRequestConfig{}); err == nil {
+		t.Fatal("expected a decode error")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	if name := ended[0].Name(); name != "mbta.Vehicles.GetAllVehicles" {
+		t.Errorf("unexpected span name %q", name)
+	}
+	if status := ended[0].Status().Code; status != codes.Unset {
+		t.Errorf("expected the first span to succeed, got %v", status)
+	}
+	if status := ended[1].Status().Code; status != codes.Error {
+		t.Errorf("expected the second span to fail, got %v", status)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	metrics := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	requests := metrics["mbta.client.requests"].Data.(metricdata.Sum[int64])
+	if len(requests.DataPoints) != 2 {
+		t.Fatalf("expected a data point per method, got %d", len(requests.DataPoints))
+	}
+	decodeErrors := metrics["mbta.client.decode_errors"].Data.(metricdata.Sum[int64])
+	if len(decodeErrors.DataPoints) != 1 || decodeErrors.DataPoints[0].Value != 1 {
+		t.Fatalf("expected one decode error, got %+v", decodeErrors.DataPoints)
+	}
+	if method, _ := decodeErrors.DataPoints[0].Attributes.Value(attribute.Key("mbta.method")); method.AsString() != "GetVehicle" {
+		t.Errorf("expected the decode error on GetVehicle, got %q", method.AsString())
+	}
+	rateLimit := metrics["mbta.client.rate_limit.remaining"].Data.(metricdata.Gauge[int64])
+	if len(rateLimit.DataPoints) != 1 || rateLimit.DataPoints[0].Value != 42 {
+		t.Fatalf("expected 42 requests remaining, got %+v", rateLimit.DataPoints)
+	}
+	if _, ok := metrics["mbta.client.request.duration"]; !ok {
+		t.Fatal("expected request durations")
+	}
+}
@@ -0,0 +1,27 @@
+module github.com/mellena1/mbta-v3-go/contrib/mbtaprom
+
+go 1.20
+
+require (
+	github.com/mellena1/mbta-v3-go v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.19.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/google/jsonapi v0.0.0-20181016150055-d0428f63eb51 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+	google.golang.org/protobuf v1.32.0 // indirect
+)
+
+replace github.com/google/jsonapi => github.com/mellena1/go.jsonapi v1.2.2
+
+// until the client has a tagged release, build against the one in this repository
+replace github.com/mellena1/mbta-v3-go => ../..
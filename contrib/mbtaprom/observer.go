@@ -0,0 +1,109 @@
+// Package mbtaprom records Prometheus metrics for the API calls an mbta.Client makes.
+// It's its own module so that the client doesn't depend on the Prometheus libraries:
+//
+//	observer := mbtaprom.NewObserver("")
+//	prometheus.MustRegister(observer)
+//	client := mbta.NewClient(mbta.ClientConfig{Observer: observer})
+package mbtaprom
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultNamespace = "mbta_client"
+
+// Observer an mbta.Observer and prometheus.Collector with these metrics, all labelled by service and method:
+//   - requests_total: requests by status code ("error" when no response was received)
+//   - request_duration_seconds: histogram of how long requests took, including decoding
+//   - rate_limit_remaining: the x-ratelimit-remaining header of the latest response (not labelled)
+//   - cache_hits_total: conditional requests answered with 304 Not Modified
+//   - decode_errors_total: responses whose body couldn't be decoded
+type Observer struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	rateLimit    prometheus.Gauge
+	cacheHits    *prometheus.CounterVec
+	decodeErrors *prometheus.CounterVec
+}
+
+// NewObserver creates an Observer whose metric names start with namespace, "mbta_client" if it's empty
+func NewObserver(namespace string) *Observer {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	labels := []string{"service", "method"}
+	return &Observer{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "API requests by service, method and status code.",
+		}, append(labels, "status")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "How long API requests took, including decoding the response.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		rateLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_remaining",
+			Help:      "Requests left in the current rate limit window, from the latest response.",
+		}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Conditional API requests answered with 304 Not Modified.",
+		}, labels),
+		decodeErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "decode_errors_total",
+			Help:      "API responses whose body couldn't be decoded.",
+		}, labels),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (o *Observer) Describe(ch chan<- *prometheus.Desc) {
+	o.requests.Describe(ch)
+	o.duration.Describe(ch)
+	o.rateLimit.Describe(ch)
+	o.cacheHits.Describe(ch)
+	o.decodeErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (o *Observer) Collect(ch chan<- prometheus.Metric) {
+	o.requests.Collect(ch)
+	o.duration.Collect(ch)
+	o.rateLimit.Collect(ch)
+	o.cacheHits.Collect(ch)
+	o.decodeErrors.Collect(ch)
+}
+
+// RequestStarted implements mbta.Observer
+func (o *Observer) RequestStarted(ctx context.Context, info mbta.RequestInfo) context.Context {
+	return ctx
+}
+
+// RequestDone implements mbta.Observer
+func (o *Observer) RequestDone(ctx context.Context, info mbta.RequestInfo, result mbta.RequestResult) {
+	status := "error"
+	if result.StatusCode != 0 {
+		status = strconv.Itoa(result.StatusCode)
+	}
+	o.requests.WithLabelValues(info.Service, info.Method, status).Inc()
+	o.duration.WithLabelValues(info.Service, info.Method).Observe(result.Duration.Seconds())
+	if result.RateLimitRemaining >= 0 {
+		o.rateLimit.Set(float64(result.RateLimitRemaining))
+	}
+	if result.CacheHit {
+		o.cacheHits.WithLabelValues(info.Service, info.Method).Inc()
+	}
+	if result.DecodeError {
+		o.decodeErrors.WithLabelValues(info.Service, info.Method).Inc()
+	}
+}
@@ -0,0 +1,50 @@
+package mbtaprom
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserver(t *testing.T) {
+	o := NewObserver("")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(o)
+
+	info := mbta.RequestInfo{Service: "Vehicles", Method: "GetAllVehicles", URL: &url.URL{Path: "/vehicles"}}
+	ctx := o.RequestStarted(context.Background(), info)
+	o.RequestDone(ctx, info, mbta.RequestResult{StatusCode: http.StatusOK, Duration: 200 * time.Millisecond, RateLimitRemaining: 998})
+	o.RequestDone(ctx, info, mbta.RequestResult{StatusCode: http.StatusNotModified, RateLimitRemaining: 997, CacheHit: true, Err: mbta.ErrNotModified})
+	o.RequestDone(ctx, info, mbta.RequestResult{StatusCode: http.StatusOK, RateLimitRemaining: -1, DecodeError: true})
+	o.RequestDone(ctx, info, mbta.RequestResult{RateLimitRemaining: -1, Err: context.Canceled})
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP mbta_client_requests_total API requests by service, method and status code.
+# TYPE mbta_client_requests_total counter
+mbta_client_requests_total{method="GetAllVehicles",service="Vehicles",status="200"} 2
+mbta_client_requests_total{method="GetAllVehicles",service="Vehicles",status="304"} 1
+mbta_client_requests_total{method="GetAllVehicles",service="Vehicles",status="error"} 1
+# HELP mbta_client_rate_limit_remaining Requests left in the current rate limit window, from the latest response.
+# TYPE mbta_client_rate_limit_remaining gauge
+mbta_client_rate_limit_remaining 997
+# HELP mbta_client_cache_hits_total Conditional API requests answered with 304 Not Modified.
+# TYPE mbta_client_cache_hits_total counter
+mbta_client_cache_hits_total{method="GetAllVehicles",service="Vehicles"} 1
+# HELP mbta_client_decode_errors_total API responses whose body couldn't be decoded.
+# TYPE mbta_client_decode_errors_total counter
+mbta_client_decode_errors_total{method="GetAllVehicles",service="Vehicles"} 1
+`), "mbta_client_requests_total", "mbta_client_rate_limit_remaining", "mbta_client_cache_hits_total", "mbta_client_decode_errors_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(o, "mbta_client_request_duration_seconds"); n != 1 {
+		t.Fatalf("expected one duration histogram, got %d", n)
+	}
+}
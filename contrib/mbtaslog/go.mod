@@ -0,0 +1,16 @@
+module github.com/mellena1/mbta-v3-go/contrib/mbtaslog
+
+go 1.21
+
+require github.com/mellena1/mbta-v3-go v0.0.0-00010101000000-000000000000
+
+require (
+	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/google/jsonapi v0.0.0-20181016150055-d0428f63eb51 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
+
+replace github.com/google/jsonapi => github.com/mellena1/go.jsonapi v1.2.2
+
+// until the client has a tagged release, build against the one in this repository
+replace github.com/mellena1/mbta-v3-go => ../..
@@ -0,0 +1,72 @@
+// Package mbtaslog logs the API calls an mbta.Client makes with log/slog.
+// It's its own module so that the client can keep building with Go versions older than log/slog:
+//
+//	client := mbta.NewClient(mbta.ClientConfig{Logger: mbtaslog.NewLogger(slog.Default())})
+//
+// Every call is logged at Level with the service method, the request URL (the query string the request config was
+// encoded to, with the API key redacted), the status code, how long it took and the size of the response.
+// Failed calls are logged at warn instead, with the details of a mbta.BadRequestError. When the slog.Logger has
+// debug enabled, the whole response body is logged at debug as well
+package mbtaslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+// Logger an mbta.Logger writing to a slog.Logger
+type Logger struct {
+	logger *slog.Logger
+
+	Level slog.Level // The level successful calls are logged at. Defaults to info
+}
+
+// NewLogger creates a Logger writing to logger
+func NewLogger(logger *slog.Logger) *Logger {
+	return &Logger{logger: logger, Level: slog.LevelInfo}
+}
+
+// WantsBody implements mbta.Logger by asking for the body when debug is enabled
+func (l *Logger) WantsBody(ctx context.Context) bool {
+	return l.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// LogRequest implements mbta.Logger
+func (l *Logger) LogRequest(ctx context.Context, entry mbta.RequestLog) {
+	attrs := []slog.Attr{
+		slog.String("service", entry.Service),
+		slog.String("method", entry.Method),
+		slog.String("http_method", entry.HTTPMethod),
+		slog.String("url", entry.URL),
+		slog.Int("status", entry.StatusCode),
+		slog.Duration("duration", entry.Duration),
+		slog.Int64("response_size", entry.ResponseSize),
+	}
+	level := l.Level
+	msg := "mbta request"
+	if entry.Err != nil && !errors.Is(entry.Err, mbta.ErrNotModified) {
+		level = slog.LevelWarn
+		msg = "mbta request failed"
+		attrs = append(attrs, slog.String("error", entry.Err.Error()))
+		var badRequest mbta.BadRequestError
+		if errors.As(entry.Err, &badRequest) {
+			attrs = append(attrs, slog.Group("bad_request",
+				slog.String("parameter", badRequest.SourceParameter),
+				slog.String("code", badRequest.Code),
+				slog.String("detail", badRequest.Detail),
+			))
+		}
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+
+	if entry.Body != nil {
+		l.logger.LogAttrs(ctx, slog.LevelDebug, "mbta response body",
+			slog.String("service", entry.Service),
+			slog.String("method", entry.Method),
+			slog.String("body", string(entry.Body)),
+		)
+	}
+}
@@ -0,0 +1,76 @@
+package mbtaslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewLogger(slog.New(handler))
+	ctx := context.Background()
+	if logger.WantsBody(ctx) {
+		t.Fatal("expected no body below debug")
+	}
+
+	logger.LogRequest(ctx, mbta.RequestLog{
+		Service:      "Vehicles",
+		Method:       "GetAllVehicles",
+		HTTPMethod:   http.MethodGet,
+		URL:          "https://api-v3.mbta.com/vehicles?filter%5Broute%5D=Red",
+		StatusCode:   http.StatusOK,
+		Duration:     150 * time.Millisecond,
+		ResponseSize: 1234,
+	})
+	logger.LogRequest(ctx, mbta.RequestLog{
+		Service:    "Vehicles",
+		Method:     "GetAllVehicles",
+		HTTPMethod: http.MethodGet,
+		URL:        "https://api-v3.mbta.com/vehicles?filter%5Broute_type%5D=9",
+		StatusCode: http.StatusBadRequest,
+		Err:        mbta.BadRequestError{SourceParameter: "filter[route_type]", Code: "bad_request", Detail: "Bad Request"},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		`level=INFO msg="mbta request" service=Vehicles method=GetAllVehicles http_method=GET url="https://api-v3.mbta.com/vehicles?filter%5Broute%5D=Red" status=200 duration=150ms response_size=1234`,
+		`level=WARN msg="mbta request failed" service=Vehicles method=GetAllVehicles http_method=GET url="https://api-v3.mbta.com/vehicles?filter%5Broute_type%5D=9" status=400 duration=0s response_size=0 error="parameter \"filter[route_type]\" caused error [bad_request]: (Bad Request)" bad_request.parameter=filter[route_type] bad_request.code=bad_request bad_request.detail="Bad Request"`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d:\nexpected %s\n     got %s", i, expected[i], lines[i])
+		}
+	}
+}
+
+func TestLoggerDebugBody(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	ctx := context.Background()
+	if !logger.WantsBody(ctx) {
+		t.Fatal("expected the body at debug")
+	}
+
+	logger.LogRequest(ctx, mbta.RequestLog{Service: "Stops", Method: "GetStop", StatusCode: http.StatusOK, Body: []byte(`{"data": {}}`)})
+	if !strings.Contains(buf.String(), `"msg":"mbta response body"`) || !strings.Contains(buf.String(), `"body":"{\"data\": {}}"`) {
+		t.Fatalf("expected the body to be logged, got %s", buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// WriteCSV writes the resources in slice, e.g. a []*mbta.Prediction, as CSV with a header row of the column names.
+// Nulls are empty, timestamps are RFC3339 in UTC and JSON columns hold their JSON text
+func WriteCSV(w io.Writer, slice interface{}) error {
+	s, rows, err := Rows(slice)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	header := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		header[i] = c.Name
+	}
+	cw.Write(header)
+	record := make([]string, len(s.Columns))
+	for _, row := range rows {
+		for i, value := range row {
+			record[i] = formatText(value)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatText a row value as plain text, "" for null
+func formatText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case json.RawMessage:
+		return string(v)
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+var (
+	testEastern = time.FixedZone("EDT", -4*60*60)
+	testTime    = time.Date(2019, time.June, 3, 8, 0, 0, 0, testEastern)
+)
+
+func strPtr(s string) *string { return &s }
+
+func float32Ptr(f float32) *float32 { return &f }
+
+func intPtr(i int) *int { return &i }
+
+func occupancyPtr(o mbta.OccupancyStatus) *mbta.OccupancyStatus { return &o }
+
+func testVehicles() []*mbta.Vehicle {
+	return []*mbta.Vehicle{
+		{
+			ID:                  "y1234",
+			Bearing:             float32Ptr(45.3),
+			CurrentStatus:       mbta.InTransitTo,
+			CurrentStopSequence: intPtr(3),
+			DirectionID:         1,
+			Label:               "1234",
+			Latitude:            42.35,
+			Longitude:           -71.06,
+			Speed:               float32Ptr(4.5),
+			OccupancyStatus:     occupancyPtr(mbta.OccupancyFewSeatsAvailable),
+			Carriages:           []mbta.Carriage{{Label: "1234", OccupancyStatus: mbta.OccupancyFewSeatsAvailable}},
+			Revenue:             mbta.Revenue,
+			UpdatedAt:           mbta.TimeISO8601{Time: testTime},
+			Route:               &mbta.Route{ID: "1"},
+			Stop:                &mbta.Stop{ID: "64"},
+			Trip:                &mbta.Trip{ID: "40516429"},
+		},
+		{
+			ID:        "y5678",
+			Label:     "5678, \"spare\"",
+			UpdatedAt: mbta.TimeISO8601{Time: testTime},
+			Route:     &mbta.Route{ID: "1"},
+		},
+	}
+}
+
+func columnNames(s *Schema) []string {
+	names := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func TestSchemaOf(t *testing.T) {
+	s, err := SchemaOf([]*mbta.Prediction{})
+	ok(t, err)
+	equals(t, "prediction", s.Table)
+	equals(t, []string{"id", "arrival_time", "departure_time", "direction_id", "schedule_relationship", "status", "stop_sequence",
+		"route_id", "schedule_id", "stop_id", "trip_id", "vehicle_id", "alerts_ids"}, columnNames(s))
+	equals(t, Column{Name: "arrival_time", Type: ColumnTimestamp, Nullable: true}, s.Columns[1])
+	equals(t, Column{Name: "direction_id", Type: ColumnInteger}, s.Columns[3])
+	equals(t, Column{Name: "schedule_relationship", Type: ColumnText, Nullable: true}, s.Columns[4])
+	equals(t, Column{Name: "stop_id", Type: ColumnText, Nullable: true, References: "stop"}, s.Columns[9])
+	equals(t, Column{Name: "alerts_ids", Type: ColumnJSON, Nullable: true, References: "alert"}, s.Columns[12])
+
+	// every resource type has a schema
+	for _, v := range []interface{}{mbta.Alert{}, mbta.Facility{}, mbta.Line{}, mbta.Prediction{}, mbta.Route{}, mbta.RoutePattern{},
+		mbta.Schedule{}, mbta.Service{}, mbta.Shape{}, mbta.Stop{}, mbta.Trip{}, mbta.Vehicle{}} {
+		_, err := SchemaOf(v)
+		ok(t, err)
+	}
+
+	_, err = SchemaOf([]string{})
+	assert(t, xerrors.Is(err, ErrNotResource), "expected ErrNotResource, got %v", err)
+}
+
+func TestRows(t *testing.T) {
+	alerts := []*mbta.Alert{{
+		ID:             "1",
+		URL:            &mbta.JSONURL{URL: &url.URL{Scheme: "https", Host: "www.mbta.com", Path: "/alerts"}},
+		UpdatedAt:      mbta.TimeISO8601{Time: testTime},
+		Severity:       7,
+		InformedEntity: []mbta.AlertInformedEntity{{StopID: strPtr("place-harsq"), Activities: []mbta.AlertActivityType{mbta.AlertActivityBoard}}},
+		ActivePeriod:   []mbta.AlertActivePeriod{{Start: mbta.TimeISO8601{Time: testTime}}},
+	}}
+	s, rows, err := Rows(alerts)
+	ok(t, err)
+	equals(t, 1, len(rows))
+	row := make(map[string]interface{})
+	for i, c := range s.Columns {
+		row[c.Name] = rows[0][i]
+	}
+	equals(t, "https://www.mbta.com/alerts", row["url"])
+	equals(t, time.Date(2019, time.June, 3, 12, 0, 0, 0, time.UTC), row["updated_at"])
+	equals(t, int64(7), row["severity"])
+	equals(t, nil, row["timeframe"])
+	equals(t, nil, row["created_at"])
+	equals(t, json.RawMessage(`[{"stop":"place-harsq","activities":["BOARD"]}]`), row["informed_entity"])
+	equals(t, json.RawMessage(`[{"start":"2019-06-03T08:00:00-04:00","end":null}]`), row["active_period"])
+
+	lines := []*mbta.Line{{ID: "line-Red", Routes: []*mbta.Route{{ID: "Red"}, {ID: "Mattapan"}}}, {ID: "line-1"}}
+	s, rows, err = Rows(lines)
+	ok(t, err)
+	equals(t, "routes_ids", s.Columns[len(s.Columns)-1].Name)
+	equals(t, json.RawMessage(`["Red","Mattapan"]`), rows[0][len(s.Columns)-1])
+	equals(t, nil, rows[1][len(s.Columns)-1])
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	ok(t, WriteCSV(&buf, testVehicles()))
+	equals(t, `id,bearing,current_status,current_stop_sequence,direction_id,label,latitude,longitude,speed,occupancy_status,carriages,revenue,updated_at,route_id,stop_id,trip_id
+y1234,45.3,IN_TRANSIT_TO,3,1,1234,42.35,-71.06,4.5,FEW_SEATS_AVAILABLE,"[{""label"":""1234"",""occupancy_status"":""FEW_SEATS_AVAILABLE"",""occupancy_percentage"":null}]",REVENUE,2019-06-03T12:00:00Z,1,64,40516429
+y5678,,,,0,"5678, ""spare""",0,0,,,,,2019-06-03T12:00:00Z,1,,
+`, buf.String())
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	ok(t, WriteNDJSON(&buf, testVehicles()[:1]))
+	equals(t, `{"id":"y1234","bearing":45.3,"current_status":"IN_TRANSIT_TO","current_stop_sequence":3,"direction_id":1,"label":"1234","latitude":42.35,"longitude":-71.06,"speed":4.5,"occupancy_status":"FEW_SEATS_AVAILABLE","carriages":[{"label":"1234","occupancy_status":"FEW_SEATS_AVAILABLE","occupancy_percentage":null}],"revenue":"REVENUE","updated_at":"2019-06-03T12:00:00Z","route_id":"1","stop_id":"64","trip_id":"40516429"}
+`, buf.String())
+}
+
+func TestWriteSQL(t *testing.T) {
+	stops := []*mbta.Stop{{
+		ID:                 "70068",
+		Name:               "Harvard's",
+		Latitude:           42.373362,
+		Longitude:          -71.118956,
+		Municipality:       strPtr("Cambridge"),
+		WheelchairBoarding: mbta.WheelchairBoardingAccessible,
+		ParentStation:      &mbta.Stop{ID: "place-harsq"},
+	}}
+
+	var buf bytes.Buffer
+	ok(t, WriteSQL(&buf, stops, Postgres))
+	equals(t, `CREATE TABLE IF NOT EXISTS "stop" (
+  "id" TEXT PRIMARY KEY,
+  "address" TEXT,
+  "at_street" TEXT,
+  "description" TEXT,
+  "latitude" DOUBLE PRECISION NOT NULL,
+  "location_type" BIGINT NOT NULL,
+  "longitude" DOUBLE PRECISION NOT NULL,
+  "municipality" TEXT,
+  "name" TEXT NOT NULL,
+  "on_street" TEXT,
+  "platform_code" TEXT,
+  "platform_name" TEXT,
+  "vehicle_type" BIGINT,
+  "wheelchair_boarding" BIGINT NOT NULL,
+  "parent_station_id" TEXT,
+  "child_stops_ids" JSONB,
+  "connecting_stops_ids" JSONB,
+  "facilities_ids" JSONB,
+  "recommended_transfers_ids" JSONB,
+  "zone_id" TEXT
+);
+INSERT INTO "stop" ("id", "address", "at_street", "description", "latitude", "location_type", "longitude", "municipality", "name", "on_street", "platform_code", "platform_name", "vehicle_type", "wheelchair_boarding", "parent_station_id", "child_stops_ids", "connecting_stops_ids", "facilities_ids", "recommended_transfers_ids", "zone_id") VALUES ('70068', NULL, NULL, NULL, 42.373362, 0, -71.118956, 'Cambridge', 'Harvard''s', NULL, NULL, NULL, NULL, 1, 'place-harsq', NULL, NULL, NULL, NULL, NULL) ON CONFLICT ("id") DO UPDATE SET "address" = excluded."address", "at_street" = excluded."at_street", "description" = excluded."description", "latitude" = excluded."latitude", "location_type" = excluded."location_type", "longitude" = excluded."longitude", "municipality" = excluded."municipality", "name" = excluded."name", "on_street" = excluded."on_street", "platform_code" = excluded."platform_code", "platform_name" = excluded."platform_name", "vehicle_type" = excluded."vehicle_type", "wheelchair_boarding" = excluded."wheelchair_boarding", "parent_station_id" = excluded."parent_station_id", "child_stops_ids" = excluded."child_stops_ids", "connecting_stops_ids" = excluded."connecting_stops_ids", "facilities_ids" = excluded."facilities_ids", "recommended_transfers_ids" = excluded."recommended_transfers_ids", "zone_id" = excluded."zone_id";
+`, buf.String())
+
+	buf.Reset()
+	schedules := []*mbta.Schedule{{ID: "s1", DepartureTime: &mbta.TimeISO8601{Time: testTime}}}
+	ok(t, WriteSQL(&buf, schedules, SQLite))
+	assert(t, bytes.Contains(buf.Bytes(), []byte(`"departure_time" TEXT,`)), "expected a nullable TEXT timestamp column, got %s", buf.String())
+	assert(t, bytes.Contains(buf.Bytes(), []byte(`VALUES ('s1', NULL, '2019-06-03T12:00:00Z', 0,`)), "expected a UTC timestamp literal, got %s", buf.String())
+
+	equals(t, ErrUnknownDialect, WriteSQL(&buf, schedules, Dialect(7)))
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("exp: %#v\n\ngot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,43 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// WriteNDJSON writes the resources in slice, e.g. a []*mbta.Vehicle, as newline-delimited JSON: one object per line
+// with the columns as keys, in schema order. Timestamps are RFC3339 strings in UTC and JSON columns are embedded as is
+func WriteNDJSON(w io.Writer, slice interface{}) error {
+	s, rows, err := Rows(slice)
+	if err != nil {
+		return err
+	}
+	keys := make([][]byte, len(s.Columns))
+	for i, c := range s.Columns {
+		key, _ := json.Marshal(c.Name)
+		keys[i] = key
+	}
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		bw.WriteByte('{')
+		for i, value := range row {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			if t, ok := value.(time.Time); ok {
+				value = t.Format(time.RFC3339)
+			}
+			b, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			bw.Write(b)
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
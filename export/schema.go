@@ -0,0 +1,318 @@
+// Package export writes API results as normalized rows for loading into a warehouse: CSV, newline-delimited JSON,
+// and SQL DDL plus INSERTs for Postgres and SQLite.
+//
+// Schemas are derived from the jsonapi struct tags of the mbta types, so new fields are exported without changes here:
+// the primary id becomes the "id" column, attributes become columns of the same name, to-one relations become
+// "<relation>_id" foreign-key columns, and to-many relations become "<relation>_ids" JSON arrays of ids.
+// TimeISO8601 values become UTC timestamps and attributes that aren't scalars (e.g. an alert's informed entities) become JSON.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+// ErrNotResource the value isn't a slice of mbta resources, i.e. structs with a jsonapi primary tag
+var ErrNotResource = errors.New("not a slice of jsonapi resources")
+
+// ColumnType the type of the values in a column
+type ColumnType int
+
+const (
+	// ColumnText string values
+	ColumnText ColumnType = iota
+	// ColumnInteger int64 values
+	ColumnInteger
+	// ColumnReal float64 values
+	ColumnReal
+	// ColumnBoolean bool values
+	ColumnBoolean
+	// ColumnTimestamp time.Time values, in UTC
+	ColumnTimestamp
+	// ColumnJSON json.RawMessage values
+	ColumnJSON
+)
+
+// Column a column of a table
+type Column struct {
+	Name       string
+	Type       ColumnType
+	Nullable   bool
+	References string // For foreign-key columns, the table of the related resource
+}
+
+// Schema the table a resource type is exported to
+type Schema struct {
+	Table   string // The jsonapi type, e.g. "route_pattern"
+	Columns []Column
+
+	fields []field
+}
+
+type fieldKind int
+
+const (
+	fieldPrimary fieldKind = iota
+	fieldAttr
+	fieldRelation
+	fieldRelations
+)
+
+type field struct {
+	index int
+	kind  fieldKind
+}
+
+var (
+	timeType    = reflect.TypeOf(mbta.TimeISO8601{})
+	jsonURLType = reflect.TypeOf(mbta.JSONURL{})
+
+	schemasMu sync.Mutex
+	schemas   = make(map[reflect.Type]*Schema)
+)
+
+// SchemaOf the schema of a resource type. v can be a resource, a pointer to one, or a slice of either, e.g. []*mbta.Alert
+func SchemaOf(v interface{}) (*Schema, error) {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) (*Schema, error) {
+	if t == nil {
+		return nil, ErrNotResource
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if s, ok := schemas[t]; ok {
+		return s, nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, xerrors.Errorf("%s: %w", t, ErrNotResource)
+	}
+
+	s := &Schema{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("jsonapi"), ",")
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case "primary":
+			s.Table = tag[1]
+			s.Columns = append(s.Columns, Column{Name: "id", Type: ColumnText})
+			s.fields = append(s.fields, field{index: i, kind: fieldPrimary})
+		case "attr":
+			columnType, nullable := attrColumnType(f.Type)
+			s.Columns = append(s.Columns, Column{Name: tag[1], Type: columnType, Nullable: nullable})
+			s.fields = append(s.fields, field{index: i, kind: fieldAttr})
+		case "relation":
+			related := f.Type
+			kind := fieldRelation
+			name := tag[1] + "_id"
+			columnType := ColumnText
+			if related.Kind() == reflect.Slice {
+				related = related.Elem()
+				kind = fieldRelations
+				name = tag[1] + "_ids"
+				columnType = ColumnJSON
+			}
+			s.Columns = append(s.Columns, Column{Name: name, Type: columnType, Nullable: true, References: relatedTable(related)})
+			s.fields = append(s.fields, field{index: i, kind: kind})
+		}
+	}
+	if s.Table == "" {
+		return nil, xerrors.Errorf("%s: %w", t, ErrNotResource)
+	}
+	schemas[t] = s
+	return s, nil
+}
+
+// relatedTable the jsonapi type of a related resource, found without building its schema since relations can be cyclic
+func relatedTable(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("jsonapi"), ",")
+		if len(tag) >= 2 && tag[0] == "primary" {
+			return tag[1]
+		}
+	}
+	return ""
+}
+
+func attrColumnType(t reflect.Type) (ColumnType, bool) {
+	if t.Kind() == reflect.Ptr {
+		columnType, _ := attrColumnType(t.Elem())
+		return columnType, true
+	}
+	switch {
+	case t == timeType:
+		// the API's null times are pointers, but a zero time is written as NULL too rather than as year 1
+		return ColumnTimestamp, true
+	case t == jsonURLType:
+		return ColumnText, false
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return ColumnText, false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ColumnInteger, false
+	case reflect.Float32, reflect.Float64:
+		return ColumnReal, false
+	case reflect.Bool:
+		return ColumnBoolean, false
+	}
+	return ColumnJSON, true
+}
+
+// Rows the schema of the resources in slice, e.g. a []*mbta.Vehicle, and a row of column values for each of them.
+// Values are nil, string, int64, float64, bool, time.Time (in UTC) or json.RawMessage, depending on the column's type
+func Rows(slice interface{}) (*Schema, [][]interface{}, error) {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, xerrors.Errorf("%T: %w", slice, ErrNotResource)
+	}
+	s, err := schemaOf(v.Type())
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([][]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		resource := v.Index(i)
+		for resource.Kind() == reflect.Ptr {
+			resource = resource.Elem()
+		}
+		if !resource.IsValid() {
+			continue
+		}
+		row, err := s.row(resource)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows = append(rows, row)
+	}
+	return s, rows, nil
+}
+
+func (s *Schema) row(resource reflect.Value) ([]interface{}, error) {
+	row := make([]interface{}, len(s.fields))
+	for i, f := range s.fields {
+		v := resource.Field(f.index)
+		var err error
+		switch f.kind {
+		case fieldPrimary:
+			row[i] = v.String()
+		case fieldAttr:
+			row[i], err = attrValue(v, s.Columns[i].Type)
+			if err != nil {
+				return nil, xerrors.Errorf("%s %s: %s: %w", s.Table, row[0], s.Columns[i].Name, err)
+			}
+		case fieldRelation:
+			if id := relatedID(v); id != "" {
+				row[i] = id
+			}
+		case fieldRelations:
+			if v.Len() == 0 {
+				continue
+			}
+			ids := make([]string, 0, v.Len())
+			for j := 0; j < v.Len(); j++ {
+				if id := relatedID(v.Index(j)); id != "" {
+					ids = append(ids, id)
+				}
+			}
+			b, _ := json.Marshal(ids)
+			row[i] = json.RawMessage(b)
+		}
+	}
+	return row, nil
+}
+
+// relatedID the primary id of a related resource, "" if there isn't one
+func relatedID(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if strings.HasPrefix(v.Type().Field(i).Tag.Get("jsonapi"), "primary,") {
+			return v.Field(i).String()
+		}
+	}
+	return ""
+}
+
+func attrValue(v reflect.Value, columnType ColumnType) (interface{}, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch columnType {
+	case ColumnTimestamp:
+		t := v.Interface().(mbta.TimeISO8601).Time
+		if t.IsZero() {
+			return nil, nil
+		}
+		return t.UTC(), nil
+	case ColumnJSON:
+		if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+			return nil, nil
+		}
+		if v.CanAddr() {
+			// TimeISO8601 only marshals through a pointer
+			v = v.Addr()
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return json.RawMessage(b), nil
+	}
+	if v.Type() == jsonURLType {
+		u := v.Interface().(mbta.JSONURL).URL
+		if u == nil {
+			return nil, nil
+		}
+		return u.String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32:
+		// through the decimal string so that e.g. a float32 bearing of 45.3 isn't exported as 45.29999923706055
+		return float32ToFloat64(float32(v.Float())), nil
+	case reflect.Float64:
+		return v.Float(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, xerrors.Errorf("unsupported attribute type %s", v.Type())
+}
+
+func float32ToFloat64(f float32) float64 {
+	parsed, _ := strconv.ParseFloat(strconv.FormatFloat(float64(f), 'g', -1, 32), 64)
+	return parsed
+}
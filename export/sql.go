@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect the SQL database the statements are written for
+type Dialect int
+
+const (
+	// Postgres PostgreSQL: timestamps are TIMESTAMPTZ and JSON columns are JSONB
+	Postgres Dialect = iota
+	// SQLite SQLite 3.24 or later: timestamps are RFC3339 TEXT, JSON columns are TEXT and booleans are 0 or 1
+	SQLite
+)
+
+// ErrUnknownDialect the Dialect isn't one of the constants
+var ErrUnknownDialect = errors.New("unknown SQL dialect")
+
+var columnTypes = map[Dialect]map[ColumnType]string{
+	Postgres: {
+		ColumnText:      "TEXT",
+		ColumnInteger:   "BIGINT",
+		ColumnReal:      "DOUBLE PRECISION",
+		ColumnBoolean:   "BOOLEAN",
+		ColumnTimestamp: "TIMESTAMPTZ",
+		ColumnJSON:      "JSONB",
+	},
+	SQLite: {
+		ColumnText:      "TEXT",
+		ColumnInteger:   "INTEGER",
+		ColumnReal:      "REAL",
+		ColumnBoolean:   "INTEGER",
+		ColumnTimestamp: "TEXT",
+		ColumnJSON:      "TEXT",
+	},
+}
+
+// WriteCreateTable writes a CREATE TABLE IF NOT EXISTS statement for the schema.
+// Foreign-key columns aren't declared as constraints, so that tables can be loaded in any order and from partial exports
+func WriteCreateTable(w io.Writer, s *Schema, dialect Dialect) error {
+	types, ok := columnTypes[dialect]
+	if !ok {
+		return ErrUnknownDialect
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", quoteIdent(s.Table))
+	for i, c := range s.Columns {
+		fmt.Fprintf(&b, "  %s %s", quoteIdent(c.Name), types[c.Type])
+		if c.Name == "id" {
+			b.WriteString(" PRIMARY KEY")
+		} else if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		if i < len(s.Columns)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(");\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSQL writes the CREATE TABLE statement for the resources in slice, e.g. a []*mbta.Trip, followed by an INSERT for each of them.
+// Rows that already exist (by id) are updated, so the output of repeated exports can be loaded into the same table
+func WriteSQL(w io.Writer, slice interface{}, dialect Dialect) error {
+	s, rows, err := Rows(slice)
+	if err != nil {
+		return err
+	}
+	if err := WriteCreateTable(w, s, dialect); err != nil {
+		return err
+	}
+
+	names := make([]string, len(s.Columns))
+	var updates []string
+	for i, c := range s.Columns {
+		names[i] = quoteIdent(c.Name)
+		if c.Name != "id" {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", names[i], names[i]))
+		}
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", quoteIdent(s.Table), strings.Join(names, ", "))
+	suffix := `) ON CONFLICT ("id") DO NOTHING;` + "\n"
+	if len(updates) > 0 {
+		suffix = fmt.Sprintf(`) ON CONFLICT ("id") DO UPDATE SET %s;`+"\n", strings.Join(updates, ", "))
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		bw.WriteString(prefix)
+		for i, value := range row {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			bw.WriteString(sqlLiteral(value, dialect))
+		}
+		bw.WriteString(suffix)
+	}
+	return bw.Flush()
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func quoteString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func sqlLiteral(value interface{}, dialect Dialect) string {
+	switch v := value.(type) {
+	case string:
+		return quoteString(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if dialect == SQLite {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return quoteString(v.Format(time.RFC3339))
+	case json.RawMessage:
+		return quoteString(string(v))
+	}
+	return "NULL"
+}
@@ -5,6 +5,7 @@ go 1.12
 require (
 	github.com/google/go-querystring v1.0.0
 	github.com/google/jsonapi v0.0.0-20181016150055-d0428f63eb51
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/xerrors v0.0.0-20190513163551-3ee3066db522
 )
 
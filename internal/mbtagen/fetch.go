@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publishedSpecURL where the v3 API publishes its OpenAPI document
+const publishedSpecURL = "https://api-v3.mbta.com/docs/swagger/swagger.json"
+
+// specSource where a vendored document came from, written next to it as <name>.source.json
+type specSource struct {
+	URL     string `json:"url"`
+	Fetched string `json:"fetched"` // The date it was fetched, e.g. 2019-05-14
+	SHA256  string `json:"sha256"`  // Of the document as fetched
+}
+
+// sourcePath the path of the source record of the document at specPath
+func sourcePath(specPath string) string {
+	return strings.TrimSuffix(specPath, ".json") + ".source.json"
+}
+
+// fetchSpec downloads the document at url to specPath as it is, and records where it came from
+func fetchSpec(url, specPath string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var doc swaggerDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	source, err := json.MarshalIndent(specSource{
+		URL:     url,
+		Fetched: time.Now().UTC().Format("2006-01-02"),
+		SHA256:  fmt.Sprintf("%x", sha256.Sum256(b)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(specPath, b, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sourcePath(specPath), append(source, '\n'), 0644)
+}
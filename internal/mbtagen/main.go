@@ -0,0 +1,103 @@
+// Command mbtagen generates the mbta package's services and models from the v3 OpenAPI document.
+//
+// For each endpoint in the config it writes <path>_gen.go with the API path, the service type, the model, the Include
+// and SortBy enums, the request configs and the list, get and batch methods. The config (mbtagen.json) holds what the
+// document can't say about the Go code, e.g. which enum type an attribute is or which attributes can be null even
+// though the document doesn't mark them x-nullable. Everything else, like enums, nested attribute types and custom
+// unmarshalling, stays in the hand-written files next to the generated ones.
+//
+// The document is vendored as the API publishes it, never edited: Go-specific descriptions and overrides go in the
+// config. With -fetch it downloads the current one first and records its URL, the date and its sha256 in
+// swagger.source.json, which the mbta package's conformance test checks it against. The mbta package's services stay
+// hand-written until that document is vendored; then, from the mbta directory,
+//
+//	go run ../internal/mbtagen -fetch -spec swagger.json -config mbtagen.json
+//
+// writes the generated files, and the declarations they replace come out of the hand-written ones.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "swagger.json", "the OpenAPI document")
+	configPath := flag.String("config", "mbtagen.json", "the generator config")
+	out := flag.String("out", ".", "directory to write the generated files to")
+	fetch := flag.Bool("fetch", false, "download the document from -url to -spec before generating")
+	url := flag.String("url", publishedSpecURL, "where the API publishes the document, for -fetch")
+	flag.Parse()
+
+	if *fetch {
+		if err := fetchSpec(*url, *specPath); err != nil {
+			fmt.Fprintln(os.Stderr, "mbtagen:", err)
+			os.Exit(1)
+		}
+	}
+
+	files, err := generate(*specPath, *configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "mbtagen:", err)
+		os.Exit(1)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(*out, name), src, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "mbtagen:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generate the source of every generated file by file name
+func generate(specPath, configPath string) (map[string][]byte, error) {
+	var doc swaggerDoc
+	if err := readJSON(specPath, &doc); err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := readJSON(configPath, &cfg); err != nil {
+		return nil, err
+	}
+	resources, err := buildResources(&doc, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	source := filepath.Base(specPath)
+	files := make(map[string][]byte, len(resources)+1)
+	for _, r := range resources {
+		src, err := render(resourceTemplate, struct {
+			*resource
+			Source string
+		}{r, source})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", r.Path, err)
+		}
+		files[r.File+"_gen.go"] = src
+	}
+	src, err := render(clientTemplate, struct {
+		Resources []*resource
+		Source    string
+	}{resources, source})
+	if err != nil {
+		return nil, err
+	}
+	files["client_gen.go"] = src
+	return files, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// the generator's tests run on a cut-down document and config, the mbta package's services aren't generated until the
+// published document is vendored there
+const (
+	testSpec   = "testdata/swagger.json"
+	testConfig = "testdata/mbtagen.json"
+)
+
+func TestGenerate(t *testing.T) {
+	files, err := generate(testSpec, testConfig)
+	ok(t, err)
+	equals(t, 2, len(files))
+	for name, src := range files {
+		golden, err := ioutil.ReadFile(filepath.Join("testdata", name+".golden"))
+		ok(t, err)
+		assert(t, bytes.Equal(golden, src), "%s doesn't match testdata/%s.golden:\n%s", name, name, src)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for name, exp := range map[string]string{
+		"stop":                 "Stop",
+		"route_patterns":       "RoutePatterns",
+		"direction_id":         "DirectionID",
+		"representative_trip":  "RepresentativeTrip",
+		"url":                  "URL",
+		"wheelchair_boarding_": "WheelchairBoarding",
+	} {
+		equals(t, exp, goName(name))
+	}
+}
+
+func TestComment(t *testing.T) {
+	equals(t, "Direction in which trip is traveling: `0` or `1`", comment("Direction in which trip is traveling: `0` or `1`."))
+	equals(t, "Filter by route type(s)", comment("Filter by route type(s).\n\nMultiple values **MUST** be a comma-separated list."))
+	equals(t, "See GTFS stops.txt", comment("See [GTFS](https://github.com/google/transit) stops.txt"))
+	equals(t, "Multiple values MUST be a comma-separated list", comment("Multiple values **MUST** be a\n  comma-separated list."))
+	equals(t, "Ends in an ellipsis..", comment("Ends in an ellipsis.."))
+}
+
+func TestBuildResourcesErrors(t *testing.T) {
+	var doc swaggerDoc
+	ok(t, readJSON(testSpec, &doc))
+
+	_, err := buildResources(&doc, &config{Resources: []resourceConfig{{Path: "/nope"}}})
+	assert(t, err != nil, "expected an error for a path that isn't in the document")
+
+	// without the config's relationships the shape's stops and route have no Go type
+	_, err = buildResources(&doc, &config{Resources: []resourceConfig{{Path: "/shapes"}}})
+	assert(t, err != nil, "expected an error for a relationship with no Go type")
+
+	relationships := map[string]string{"route": "Route", "stop": "Stop"}
+	_, err = buildResources(&doc, &config{Relationships: relationships, Resources: []resourceConfig{{Path: "/shapes", Nullable: []string{"nope"}}}})
+	assert(t, err != nil, "expected an error for a nullable attribute that isn't in the document")
+}
+
+func TestFetchSpec(t *testing.T) {
+	const published = `{"paths": {"/stops": {}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(published))
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "mbtagen")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	specPath := filepath.Join(dir, "swagger.json")
+	ok(t, fetchSpec(server.URL+"/docs/swagger/swagger.json", specPath))
+	b, err := ioutil.ReadFile(specPath)
+	ok(t, err)
+	equals(t, published, string(b))
+	var source specSource
+	ok(t, readJSON(filepath.Join(dir, "swagger.source.json"), &source))
+	equals(t, server.URL+"/docs/swagger/swagger.json", source.URL)
+	equals(t, 10, len(source.Fetched))
+	equals(t, fmt.Sprintf("%x", sha256.Sum256([]byte(published))), source.SHA256)
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("exp: %#v\n\ngot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// config the Go specifics the OpenAPI document can't express, read from mbtagen.json
+type config struct {
+	Parameters    map[string]string `json:"parameters"`    // Go field name for a query parameter, for all endpoints, e.g. "filter[route]": "FilterRouteIDs"
+	Relationships map[string]string `json:"relationships"` // Go type of JSON:API types that don't have an endpoint, e.g. "zone": "Zone"
+	Resources     []resourceConfig  `json:"resources"`
+}
+
+// resourceConfig an endpoint to generate. Everything but Path is optional
+type resourceConfig struct {
+	Path        string                     `json:"path"`         // Collection path, e.g. "/stops"
+	Service     string                     `json:"service"`      // Name of the service type, if not the model's name followed by Service
+	ServiceNote string                     `json:"service_note"` // Extra line for the service type's doc comment
+	ListNote    string                     `json:"list_note"`    // Extra line for the list methods' doc comments
+	Validate    bool                       `json:"validate"`     // Whether the list config has a hand-written validate method the list method calls first
+	ByValue     bool                       `json:"by_value"`     // Whether the methods take their config by value instead of as a pointer
+	Attributes  map[string]string          `json:"attributes"`   // Go type of attributes the schema doesn't determine, e.g. enums. Nullable attributes still get a pointer
+	Params      map[string]parameterConfig `json:"parameters"`   // Overrides for the endpoint's query parameters
+	Comments    map[string]string          `json:"comments"`     // Doc comment of an attribute, relationship or query parameter by name, instead of the document's description, e.g. to name the Go identifiers involved
+	Nullable    []string                   `json:"nullable"`     // Attributes the API sends as null that the document doesn't mark x-nullable, "name[]" for the items of an array attribute
+}
+
+type parameterConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// resource everything the templates need for one endpoint
+type resource struct {
+	Path          string
+	File          string // Base name of the generated file, without _gen.go
+	PathConst     string
+	Service       string
+	ServiceNote   string
+	ListNote      string
+	Validate      bool
+	ByValue       bool
+	Model         string
+	Plural        string // As used in method names, e.g. RoutePatterns
+	Type          string // JSON:API type
+	Noun          string // e.g. "a route pattern"
+	Nouns         string // e.g. "route patterns"
+	Var           string // Variable names in the methods, e.g. routePattern
+	Vars          string
+	Description   string
+	Attributes    []field
+	Relationships []field
+	Fields        []constant // Field name constants of the attributes and relationships, for Fieldsets
+	IncludeType   string
+	Includes      []constant
+	SortType      string
+	Sorts         []constant
+	List          *requestConfig
+	Show          *requestConfig
+	Batch         bool // Whether the list endpoint can filter by id, for the batch Get methods
+}
+
+type field struct {
+	Name    string
+	Type    string
+	Tag     string
+	Comment string
+}
+
+type constant struct {
+	Name  string
+	Value string
+}
+
+type requestConfig struct {
+	Name   string
+	Fields []field
+}
+
+// ConfigType the config parameter's type in the method signatures
+func (c *requestConfig) ConfigType(byValue bool) string {
+	if byValue {
+		return c.Name
+	}
+	return "*" + c.Name
+}
+
+// buildResources works out every configured endpoint from the document
+func buildResources(doc *swaggerDoc, cfg *config) ([]*resource, error) {
+	modelsByType := make(map[string]string)
+	for t, model := range cfg.Relationships {
+		modelsByType[t] = model
+	}
+	definitions := make(map[string]*schema)
+	for _, def := range doc.Definitions {
+		if t := def.resourceType(); t != "" {
+			definitions[t] = def
+		}
+	}
+	var resources []*resource
+	for _, rc := range cfg.Resources {
+		if _, ok := doc.Paths[rc.Path]["get"]; !ok {
+			return nil, fmt.Errorf("%s is not a path of the document", rc.Path)
+		}
+		t, def, err := listResourceType(rc.Path, definitions)
+		if err != nil {
+			return nil, err
+		}
+		r := &resource{
+			Path:        rc.Path,
+			File:        strings.TrimPrefix(rc.Path, "/"),
+			PathConst:   lowerFirst(goName(strings.TrimPrefix(rc.Path, "/"))) + "APIPath",
+			ServiceNote: rc.ServiceNote,
+			ListNote:    rc.ListNote,
+			Validate:    rc.Validate,
+			ByValue:     rc.ByValue,
+			Model:       goName(t),
+			Plural:      goName(strings.TrimPrefix(rc.Path, "/")),
+			Type:        t,
+			Noun:        article(words(t)),
+			Nouns:       words(strings.TrimPrefix(rc.Path, "/")),
+			Description: def.Description,
+		}
+		r.Var, r.Vars = lowerFirst(r.Model), lowerFirst(r.Plural)
+		r.Service = rc.Service
+		if r.Service == "" {
+			r.Service = r.Model + "Service"
+		}
+		modelsByType[t] = r.Model
+		resources = append(resources, r)
+	}
+	for i, r := range resources {
+		rc := cfg.Resources[i]
+		def := definitions[r.Type]
+		if err := r.buildFields(def, rc, modelsByType); err != nil {
+			return nil, err
+		}
+		list := doc.Paths[r.Path]["get"]
+		r.buildEnums(list)
+		var err error
+		r.List, err = r.buildConfig("GetAll"+r.Plural+"RequestConfig", list, rc, cfg.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		_, r.Batch = list.parameter("filter[id]")
+		if show, ok := doc.Paths[r.Path+"/{id}"]["get"]; ok {
+			r.Show, err = r.buildConfig("Get"+r.Model+"RequestConfig", show, rc, cfg.Parameters)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			r.Batch = false
+		}
+	}
+	return resources, nil
+}
+
+// listResourceType the JSON:API type a collection path returns. The document doesn't link paths to definitions in a
+// way that's easy to follow, so it goes by the path's name: /route_patterns returns route_pattern
+func listResourceType(path string, definitions map[string]*schema) (string, *schema, error) {
+	name := strings.TrimPrefix(path, "/")
+	for _, t := range []string{strings.TrimSuffix(name, "s"), strings.TrimSuffix(name, "ies") + "y"} {
+		if def, ok := definitions[t]; ok {
+			return t, def, nil
+		}
+	}
+	return "", nil, fmt.Errorf("%s: no resource definition for the path", path)
+}
+
+func (r *resource) buildFields(def *schema, rc resourceConfig, modelsByType map[string]string) error {
+	attrs := def.Properties.get("attributes")
+	if err := rc.markNullable(r.Path, attrs); err != nil {
+		return err
+	}
+	if attrs != nil {
+		for _, name := range attrs.Properties.names {
+			s := attrs.Properties.get(name)
+			typ, ok := rc.Attributes[name]
+			if !ok {
+				typ, ok = schemaType(s)
+				if !ok {
+					return fmt.Errorf("%s: attribute %s needs a Go type in the config", r.Path, name)
+				}
+			}
+			if s.Nullable && !strings.HasPrefix(typ, "[]") {
+				typ = "*" + typ
+			}
+			r.addFieldConst(name)
+			r.Attributes = append(r.Attributes, field{
+				Name:    goName(name),
+				Type:    typ,
+				Tag:     fmt.Sprintf(`jsonapi:"attr,%s"`, name),
+				Comment: rc.comment(name, s.Description),
+			})
+		}
+	}
+	rels := def.Properties.get("relationships")
+	if rels != nil {
+		for _, name := range rels.Properties.names {
+			s := rels.Properties.get(name)
+			data := s.Properties.get("data")
+			if data == nil {
+				return fmt.Errorf("%s: relationship %s has no data schema", r.Path, name)
+			}
+			many := data.Type == "array"
+			if many {
+				data = data.Items
+			}
+			var target string
+			if t := data.Properties.get("type"); t != nil {
+				target, _ = t.Example.(string)
+			}
+			model, ok := modelsByType[target]
+			if !ok {
+				return fmt.Errorf("%s: relationship %s is to %q, which isn't generated or in the config's relationships", r.Path, name, target)
+			}
+			typ := "*" + model
+			if many {
+				typ = "[]" + typ
+			}
+			r.addFieldConst(name)
+			r.Relationships = append(r.Relationships, field{
+				Name:    goName(name),
+				Type:    typ,
+				Tag:     fmt.Sprintf(`jsonapi:"relation,%s"`, name),
+				Comment: rc.comment(name, s.Description),
+			})
+		}
+	}
+	return nil
+}
+
+// markNullable marks the config's nullable attributes in the schema, as if the document did
+func (rc resourceConfig) markNullable(path string, attrs *schema) error {
+	for _, name := range rc.Nullable {
+		items := strings.HasSuffix(name, "[]")
+		var s *schema
+		if attrs != nil {
+			s = attrs.Properties.get(strings.TrimSuffix(name, "[]"))
+		}
+		if s != nil && items {
+			s = s.Items
+		}
+		if s == nil {
+			return fmt.Errorf("%s: nullable attribute %s isn't in the document", path, name)
+		}
+		s.Nullable = true
+	}
+	return nil
+}
+
+// comment the doc comment for the named attribute, relationship or query parameter
+func (rc resourceConfig) comment(name, description string) string {
+	if c, ok := rc.Comments[name]; ok {
+		return comment(c)
+	}
+	return comment(description)
+}
+
+func (r *resource) addFieldConst(name string) {
+	r.Fields = append(r.Fields, constant{Name: r.Model + "Field" + goName(name), Value: name})
+}
+
+// schemaType the Go type for a schema that doesn't need one from the config
+func schemaType(s *schema) (string, bool) {
+	switch s.Type {
+	case "integer":
+		return "int", true
+	case "number":
+		if s.Format == "float" {
+			return "float32", true
+		}
+		return "float64", true
+	case "boolean":
+		return "bool", true
+	case "string":
+		switch s.Format {
+		case "date", "date-time":
+			return "TimeISO8601", true
+		case "uri":
+			return "JSONURL", true
+		}
+		return "string", true
+	case "array":
+		if s.Items == nil {
+			return "", false
+		}
+		item, ok := schemaType(s.Items)
+		if s.Items.Nullable {
+			item = "*" + item
+		}
+		return "[]" + item, ok
+	}
+	return "", false
+}
+
+func (r *resource) buildEnums(list operation) {
+	if p, ok := list.parameter("include"); ok {
+		r.IncludeType = r.Model + "Include"
+		for _, include := range p.includes() {
+			r.Includes = append(r.Includes, constant{
+				Name:  r.IncludeType + goName(strings.Replace(include, ".", "_", -1)),
+				Value: include,
+			})
+		}
+	}
+	if p, ok := list.parameter("sort"); ok {
+		r.SortType = r.Plural + "SortByType"
+		options := append([]string(nil), p.Enum...)
+		sort.SliceStable(options, func(i, j int) bool {
+			return strings.TrimPrefix(options[i], "-") < strings.TrimPrefix(options[j], "-")
+		})
+		for _, option := range options {
+			direction := "Ascending"
+			if strings.HasPrefix(option, "-") {
+				direction = "Descending"
+			}
+			r.Sorts = append(r.Sorts, constant{
+				Name:  r.Plural + "SortBy" + goName(strings.TrimPrefix(option, "-")) + direction,
+				Value: option,
+			})
+		}
+	}
+}
+
+func (r *resource) buildConfig(name string, op operation, rc resourceConfig, names map[string]string) (*requestConfig, error) {
+	c := &requestConfig{Name: name}
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		f := field{Comment: rc.comment(p.Name, p.Description)}
+		list := p.isList()
+		switch {
+		case p.Name == "sort":
+			f.Name, f.Type, list = "Sort", r.SortType, false
+		case p.Name == "include":
+			f.Name, f.Type, list = "Include", "[]"+r.IncludeType, true
+		case strings.HasPrefix(p.Name, "fields["):
+			f.Name, f.Type, list = "Fields", "[]string", true
+		case strings.HasPrefix(p.Name, "page["):
+			f.Name, f.Type, list = "Page"+goName(bracketed(p.Name)), "string", false
+		case strings.HasPrefix(p.Name, "filter["):
+			f.Name, f.Type = "Filter"+goName(bracketed(p.Name)), "string"
+			if list {
+				f.Type = "[]string"
+			}
+		default:
+			return nil, fmt.Errorf("%s: don't know what to call the %q parameter", name, p.Name)
+		}
+		if n, ok := names[p.Name]; ok {
+			f.Name = n
+		}
+		if override, ok := rc.Params[p.Name]; ok {
+			if override.Name != "" {
+				f.Name = override.Name
+			}
+			if override.Type != "" {
+				f.Type = override.Type
+			}
+		}
+		tag := p.Name
+		if list || strings.HasPrefix(f.Type, "[]") {
+			tag += ",comma"
+		}
+		f.Tag = fmt.Sprintf(`url:"%s,omitempty"`, tag)
+		c.Fields = append(c.Fields, f)
+		if f.Name == "Fields" {
+			// the document only lists fields[] of the endpoint's own type, a Fieldset covers included types too
+			c.Fields = append(c.Fields, field{
+				Name:    "Fieldset",
+				Type:    "Fieldset",
+				Tag:     `url:"fields,omitempty"`,
+				Comment: "Fields to include for each resource type, included ones too. Replaces Fields for " + r.Type + " if it has an entry for it",
+			})
+		}
+	}
+	return c, nil
+}
+
+// bracketed the part of a parameter name in brackets, e.g. route for filter[route]
+func bracketed(name string) string {
+	return strings.TrimSuffix(name[strings.Index(name, "[")+1:], "]")
+}
+
+// initialisms words that are all caps in Go names
+var initialisms = map[string]string{
+	"id":  "ID",
+	"ids": "IDs",
+	"url": "URL",
+}
+
+// goName the exported Go name for a snake_case name, e.g. DirectionID for direction_id
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if initialism, ok := initialisms[part]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// words a snake_case name as words, e.g. "route patterns"
+func words(name string) string {
+	return strings.Replace(name, "_", " ", -1)
+}
+
+// article the noun with "a" or "an" before it
+func article(noun string) string {
+	if strings.ContainsAny(noun[:1], "aeiou") {
+		return "an " + noun
+	}
+	return "a " + noun
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// swaggerDoc the parts of the v3 OpenAPI (Swagger 2.0) document the generator reads
+type swaggerDoc struct {
+	Paths       map[string]map[string]operation `json:"paths"`
+	Definitions map[string]*schema              `json:"definitions"`
+}
+
+type operation struct {
+	Parameters []parameter `json:"parameters"`
+}
+
+type parameter struct {
+	Name        string   `json:"name"`
+	In          string   `json:"in"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+}
+
+type schema struct {
+	Type        string      `json:"type"`
+	Format      string      `json:"format"`
+	Description string      `json:"description"`
+	Nullable    bool        `json:"x-nullable"`
+	Example     interface{} `json:"example"`
+	Items       *schema     `json:"items"`
+	Properties  properties  `json:"properties"`
+}
+
+// properties the properties of an object schema in the order the document lists them, which is the order the
+// generated struct fields are in
+type properties struct {
+	names   []string
+	schemas map[string]*schema
+}
+
+func (p *properties) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("properties must be an object, not %v", tok)
+	}
+	p.schemas = make(map[string]*schema)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		var s schema
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		p.names = append(p.names, name)
+		p.schemas[name] = &s
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func (p properties) get(name string) *schema {
+	return p.schemas[name]
+}
+
+// resourceType the JSON:API type of a resource definition, e.g. "stop" for StopResource
+func (s *schema) resourceType() string {
+	t := s.Properties.get("type")
+	if t == nil {
+		return ""
+	}
+	example, _ := t.Example.(string)
+	return example
+}
+
+func (op operation) parameter(name string) (parameter, bool) {
+	for _, p := range op.Parameters {
+		if p.Name == name && p.In == "query" {
+			return p, true
+		}
+	}
+	return parameter{}, false
+}
+
+// includeOption a "* `name`" bullet of the include parameter's description
+var includeOption = regexp.MustCompile("(?m)^\\s*\\*\\s+`([a-z_.]+)`")
+
+// includes the relationship paths the include parameter lists, in order
+func (p parameter) includes() []string {
+	var includes []string
+	for _, m := range includeOption.FindAllStringSubmatch(p.Description, -1) {
+		includes = append(includes, m[1])
+	}
+	return includes
+}
+
+// isList whether the parameter takes a comma separated list of values
+func (p parameter) isList() bool {
+	return strings.Contains(p.Description, "comma-separated")
+}
+
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// comment the first paragraph of a description as a single line comment, without markdown or a final period
+func comment(description string) string {
+	paragraph := strings.SplitN(strings.TrimSpace(description), "\n\n", 2)[0]
+	paragraph = markdownLink.ReplaceAllString(paragraph, "$1")
+	paragraph = strings.Replace(paragraph, "**", "", -1)
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if strings.HasSuffix(paragraph, ".") && !strings.HasSuffix(paragraph, "..") {
+		paragraph = strings.TrimSuffix(paragraph, ".")
+	}
+	return paragraph
+}
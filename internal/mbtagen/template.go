@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"words": words,
+	"receiver": func(model string) string {
+		return strings.ToLower(model[:1])
+	},
+	"comment": func(text string) string {
+		return "// " + strings.Join(strings.Split(strings.TrimSpace(text), "\n"), "\n// ")
+	},
+}
+
+var resourceTemplate = template.Must(template.New("resource").Funcs(funcs).Parse(`// Code generated by mbtagen from {{.Source}}. DO NOT EDIT.
+
+package mbta
+
+import (
+	"context"
+{{- if .Show}}
+	"fmt"
+{{- end}}
+	"net/http"
+)
+
+const {{.PathConst}} = "{{.Path}}"
+
+// {{.Service}} handling all of the {{words .Type}} related API calls
+{{- if .ServiceNote}}
+{{comment .ServiceNote}}
+{{- end}}
+type {{.Service}} service
+
+// {{.Model}} holds all the info about a given MBTA {{words .Type}}
+{{- if .Description}}
+{{comment .Description}}
+{{- end}}
+type {{.Model}} struct {
+	ID string ` + "`" + `jsonapi:"primary,{{.Type}}"` + "`" + `
+{{- range .Attributes}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+{{- range .Relationships}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+	fieldPresence
+}
+
+// {{.Model}}Field an attribute or relationship of {{.Noun}}, for a Fieldset
+type {{.Model}}Field string
+
+const (
+{{- range .Fields}}
+	{{.Name}} {{$.Model}}Field = "{{.Value}}"
+{{- end}}
+)
+
+func (f {{.Model}}Field) resourceType() string { return "{{.Type}}" }
+func (f {{.Model}}Field) fieldName() string    { return string(f) }
+
+// Has whether the {{words .Type}} has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func ({{receiver .Model}} *{{.Model}}) Has(field {{.Model}}Field) bool { return {{receiver .Model}}.has(string(field)) }
+{{- if .IncludeType}}
+
+// {{.IncludeType}} all of the includes for {{.Noun}} request
+type {{.IncludeType}} string
+
+const (
+{{- range .Includes}}
+	{{.Name}} {{$.IncludeType}} = "{{.Value}}"
+{{- end}}
+)
+{{- end}}
+{{- if .SortType}}
+
+// {{.SortType}} all of the possible ways to sort by for a GetAll{{.Plural}} request
+type {{.SortType}} string
+
+const (
+{{- range .Sorts}}
+	{{.Name}} {{$.SortType}} = "{{.Value}}"
+{{- end}}
+)
+{{- end}}
+
+// {{.List.Name}} extra options for the GetAll{{.Plural}} request
+type {{.List.Name}} struct {
+{{- range .List.Fields}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+
+// GetAll{{.Plural}} returns all {{.Nouns}} from the mbta API
+{{- if .ListNote}}
+{{comment .ListNote}}
+{{- end}}
+func (s *{{.Service}}) GetAll{{.Plural}}(config {{.List.ConfigType .ByValue}}) ([]*{{.Model}}, *http.Response, error) {
+	return s.GetAll{{.Plural}}WithContext(context.Background(), config)
+}
+
+// GetAll{{.Plural}}WithContext returns all {{.Nouns}} from the mbta API given a context
+{{- if .ListNote}}
+{{comment .ListNote}}
+{{- end}}
+func (s *{{.Service}}) GetAll{{.Plural}}WithContext(ctx context.Context, config {{.List.ConfigType .ByValue}}) ([]*{{.Model}}, *http.Response, error) {
+{{- if .Validate}}
+	if err := config.validate(); err != nil {
+		return nil, nil, err
+	}
+{{end}}
+	u, err := addOptions({{.PathConst}}, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	untyped, resp, err := s.client.doManyPayload(req, &{{.Model}}{})
+	{{.Vars}} := make([]*{{.Model}}, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		{{.Vars}}[i] = untyped[i].(*{{.Model}})
+	}
+	return {{.Vars}}, resp, err
+}
+{{- if .Show}}
+
+// {{.Show.Name}} extra options for the Get{{.Model}} request
+type {{.Show.Name}} struct {
+{{- range .Show.Fields}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `{{if .Comment}} // {{.Comment}}{{end}}
+{{- end}}
+}
+
+// Get{{.Model}} returns {{.Noun}} from the mbta API
+func (s *{{.Service}}) Get{{.Model}}(id string, config {{.Show.ConfigType .ByValue}}) (*{{.Model}}, *http.Response, error) {
+	return s.Get{{.Model}}WithContext(context.Background(), id, config)
+}
+
+// Get{{.Model}}WithContext returns {{.Noun}} from the mbta API given a context
+func (s *{{.Service}}) Get{{.Model}}WithContext(ctx context.Context, id string, config {{.Show.ConfigType .ByValue}}) (*{{.Model}}, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
+	path := fmt.Sprintf("%s/%s", {{.PathConst}}, id)
+	u, err := addOptions(path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var {{.Var}} {{.Model}}
+	resp, err := s.client.doSinglePayload(req, &{{.Var}})
+	return &{{.Var}}, resp, err
+}
+{{- end}}
+{{- if .Batch}}
+
+// Get{{.Plural}} returns the {{.Nouns}} with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *{{.Service}}) Get{{.Plural}}(ids []string) ([]*{{.Model}}, error) {
+	return s.Get{{.Plural}}WithContext(context.Background(), ids)
+}
+
+// Get{{.Plural}}WithContext returns the {{.Nouns}} with the given IDs from the mbta API given a context
+func (s *{{.Service}}) Get{{.Plural}}WithContext(ctx context.Context, ids []string) ([]*{{.Model}}, error) {
+	untyped, err := s.client.batchGet(ctx, {{.PathConst}}, ids, &{{.Model}}{}, func(ids []string) interface{} {
+		return &{{.List.Name}}{FilterIDs: ids}
+	})
+	{{.Vars}} := make([]*{{.Model}}, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		{{.Vars}}[i], _ = untyped[i].(*{{.Model}})
+	}
+	return {{.Vars}}, err
+}
+{{- end}}
+`))
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by mbtagen from {{.Source}}. DO NOT EDIT.
+
+package mbta
+
+// serviceMethods the Client field and the list and get methods for each API path
+var serviceMethods = map[string][3]string{
+{{- range .Resources}}
+	{{.PathConst}}: {"{{.Plural}}", "GetAll{{.Plural}}", "{{if .Show}}Get{{.Model}}{{end}}"},
+{{- end}}
+}
+
+// selfLinkPaths the collection path of each JSON:API type that can be fetched by id, for the self links of MarshalPayload
+var selfLinkPaths = map[string]string{
+{{- range .Resources}}{{if .Show}}
+	"{{.Type}}": {{.PathConst}},
+{{- end}}{{end}}
+}
+`))
+
+// render executes a template and gofmts the result
+func render(t *template.Template, data interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,181 @@
+package mbta
+
+import (
+	"sort"
+	"time"
+)
+
+// defaultAlertActivities the activities used when an EntityQuery has none, the same default the API uses for FilterActivity
+var defaultAlertActivities = []AlertActivityType{AlertActivityBoard, AlertActivityExit, AlertActivityRide}
+
+// EntityQuery a part of the system to check alerts against, e.g. boarding route X in direction 1 at stop Y at time T.
+// Empty fields aren't checked, so an informed entity that only narrows a field the query leaves empty still matches. Set as many fields as are known for precise results
+type EntityQuery struct {
+	RouteID     string
+	RouteType   *RouteType
+	DirectionID *int
+	StopID      string
+	TripID      string
+	FacilityID  string
+	Activities  []AlertActivityType // What the rider is doing. Defaults to BOARD, EXIT and RIDE. AlertActivityFilterAll matches any activity
+	Time        time.Time           // When the rider is doing it. The zero time matches alerts regardless of their ActivePeriod
+}
+
+// Matches whether the informed entity applies to the query: every field the entity sets must match the query, and it must share an activity with it
+func (e AlertInformedEntity) Matches(q EntityQuery) bool {
+	if e.RouteID != nil && q.RouteID != "" && *e.RouteID != q.RouteID {
+		return false
+	}
+	if e.RouteType != nil && q.RouteType != nil && *e.RouteType != *q.RouteType {
+		return false
+	}
+	if e.DirectionID != nil && q.DirectionID != nil && *e.DirectionID != *q.DirectionID {
+		return false
+	}
+	if e.StopID != nil && q.StopID != "" && *e.StopID != q.StopID {
+		return false
+	}
+	if e.TripID != nil && q.TripID != "" && *e.TripID != q.TripID {
+		return false
+	}
+	if e.FacilityID != nil && q.FacilityID != "" && *e.FacilityID != q.FacilityID {
+		return false
+	}
+	return e.hasActivity(q.Activities)
+}
+
+// hasActivity an entity without activities applies to all of them
+func (e AlertInformedEntity) hasActivity(activities []AlertActivityType) bool {
+	if len(e.Activities) == 0 {
+		return true
+	}
+	if len(activities) == 0 {
+		activities = defaultAlertActivities
+	}
+	for _, want := range activities {
+		if want == AlertActivityFilterAll {
+			return true
+		}
+		for _, a := range e.Activities {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveAt whether the alert is in effect at t. Alerts without an ActivePeriod are always in effect, and a period without an End never ends
+func (a *Alert) ActiveAt(t time.Time) bool {
+	if len(a.ActivePeriod) == 0 {
+		return true
+	}
+	for _, period := range a.ActivePeriod {
+		if t.Before(period.Start.Time) {
+			continue
+		}
+		if period.End == nil || t.Before(period.End.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+// Affects whether the alert is active at the query's time and any of its informed entities matches the query
+func (a *Alert) Affects(q EntityQuery) bool {
+	if !q.Time.IsZero() && !a.ActiveAt(q.Time) {
+		return false
+	}
+	for _, e := range a.InformedEntity {
+		if e.Matches(q) {
+			return true
+		}
+	}
+	return false
+}
+
+// AlertIndex answers which alerts affect a trip, stop, route or facility without checking every alert
+type AlertIndex struct {
+	alerts     []*Alert
+	byTrip     map[string][]int // trip id -> alerts with an entity for that trip. "" holds alerts with an entity for any trip
+	byStop     map[string][]int
+	byRoute    map[string][]int
+	byFacility map[string][]int
+}
+
+// NewAlertIndex builds an AlertIndex from alerts, e.g. the result of GetAllAlerts
+func NewAlertIndex(alerts []*Alert) *AlertIndex {
+	idx := &AlertIndex{
+		alerts:     alerts,
+		byTrip:     make(map[string][]int),
+		byStop:     make(map[string][]int),
+		byRoute:    make(map[string][]int),
+		byFacility: make(map[string][]int),
+	}
+	add := func(m map[string][]int, id *string, i int) {
+		key := ""
+		if id != nil {
+			key = *id
+		}
+		if l := m[key]; len(l) == 0 || l[len(l)-1] != i {
+			m[key] = append(l, i)
+		}
+	}
+	for i, alert := range alerts {
+		for _, e := range alert.InformedEntity {
+			add(idx.byTrip, e.TripID, i)
+			add(idx.byStop, e.StopID, i)
+			add(idx.byRoute, e.RouteID, i)
+			add(idx.byFacility, e.FacilityID, i)
+		}
+	}
+	return idx
+}
+
+// Affecting the alerts that affect the query, in the order they were given to NewAlertIndex
+func (idx *AlertIndex) Affecting(q EntityQuery) []*Alert {
+	candidates := idx.candidates(q)
+	var affecting []*Alert
+	for _, i := range candidates {
+		if idx.alerts[i].Affects(q) {
+			affecting = append(affecting, idx.alerts[i])
+		}
+	}
+	return affecting
+}
+
+// candidates alerts that could match the query, using the most selective field the query has
+func (idx *AlertIndex) candidates(q EntityQuery) []int {
+	var m map[string][]int
+	var key string
+	switch {
+	case q.TripID != "":
+		m, key = idx.byTrip, q.TripID
+	case q.StopID != "":
+		m, key = idx.byStop, q.StopID
+	case q.FacilityID != "":
+		m, key = idx.byFacility, q.FacilityID
+	case q.RouteID != "":
+		m, key = idx.byRoute, q.RouteID
+	default:
+		all := make([]int, len(idx.alerts))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+
+	// an alert can be in both lists when it has entities with and without the field
+	seen := make(map[int]bool)
+	var candidates []int
+	for _, list := range [][]int{m[key], m[""]} {
+		for _, i := range list {
+			if !seen[i] {
+				seen[i] = true
+				candidates = append(candidates, i)
+			}
+		}
+	}
+	sort.Ints(candidates)
+	return candidates
+}
@@ -0,0 +1,98 @@
+package mbta
+
+import (
+	"testing"
+	"time"
+)
+
+func testAlerts() []*Alert {
+	start := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+	end := TimeISO8601{Time: start.Add(24 * time.Hour)}
+	direction := 1
+	bus := RouteTypeBus
+	return []*Alert{
+		{
+			ID: "red-shuttle",
+			InformedEntity: []AlertInformedEntity{
+				{RouteID: strPtr("Red"), StopID: strPtr("place-jfk"), Activities: []AlertActivityType{AlertActivityBoard, AlertActivityExit, AlertActivityRide}},
+				{RouteID: strPtr("Red"), StopID: strPtr("place-andrw"), Activities: []AlertActivityType{AlertActivityBoard, AlertActivityExit, AlertActivityRide}},
+			},
+			ActivePeriod: []AlertActivePeriod{{Start: TimeISO8601{Time: start}, End: &end}},
+		},
+		{
+			ID:             "red-delay-southbound",
+			InformedEntity: []AlertInformedEntity{{RouteID: strPtr("Red"), DirectionID: &direction, Activities: []AlertActivityType{AlertActivityBoard, AlertActivityExit, AlertActivityRide}}},
+			ActivePeriod:   []AlertActivePeriod{{Start: TimeISO8601{Time: start.Add(48 * time.Hour)}}},
+		},
+		{
+			ID:             "jfk-elevator",
+			InformedEntity: []AlertInformedEntity{{StopID: strPtr("place-jfk"), FacilityID: strPtr("elevator-1"), Activities: []AlertActivityType{AlertActivityUsingWheelchair}}},
+		},
+		{
+			ID:             "all-buses",
+			InformedEntity: []AlertInformedEntity{{RouteType: &bus, Activities: []AlertActivityType{AlertActivityBoard}}},
+		},
+		{
+			ID:             "trip-cancelled",
+			InformedEntity: []AlertInformedEntity{{TripID: strPtr("trip-1"), RouteID: strPtr("Red")}},
+		},
+	}
+}
+
+func alertIDs(alerts []*Alert) []string {
+	var ids []string
+	for _, a := range alerts {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func TestAlert_Affects(t *testing.T) {
+	alerts := testAlerts()
+	shuttle := alerts[0]
+	during := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	assert(t, shuttle.Affects(EntityQuery{RouteID: "Red", StopID: "place-jfk", Time: during}), "expected the shuttle to affect JFK")
+	assert(t, shuttle.Affects(EntityQuery{RouteID: "Red"}), "expected the shuttle to affect the Red line")
+	assert(t, !shuttle.Affects(EntityQuery{RouteID: "Red", StopID: "place-jfk", Time: during.Add(24 * time.Hour)}), "expected the shuttle to be over")
+	assert(t, !shuttle.Affects(EntityQuery{RouteID: "Orange", StopID: "place-jfk"}), "expected the shuttle to not affect the Orange line")
+	assert(t, !shuttle.Affects(EntityQuery{RouteID: "Red", StopID: "place-pktrm"}), "expected the shuttle to not affect Park Street")
+	assert(t, !shuttle.Affects(EntityQuery{StopID: "place-jfk", Activities: []AlertActivityType{AlertActivityParkCar}}), "expected the shuttle to not affect parking")
+
+	delay := alerts[1]
+	north, south := 0, 1
+	assert(t, delay.Affects(EntityQuery{RouteID: "Red", DirectionID: &south, Time: during.Add(100 * 24 * time.Hour)}), "expected an open ended period")
+	assert(t, !delay.Affects(EntityQuery{RouteID: "Red", DirectionID: &north}), "expected the delay to not affect the other direction")
+	assert(t, !delay.Affects(EntityQuery{RouteID: "Red", Time: during}), "expected the delay to not have started")
+
+	elevator := alerts[2]
+	assert(t, !elevator.Affects(EntityQuery{StopID: "place-jfk"}), "expected the default activities to skip wheelchair alerts")
+	assert(t, elevator.Affects(EntityQuery{StopID: "place-jfk", Activities: []AlertActivityType{AlertActivityBoard, AlertActivityUsingWheelchair}}), "expected the elevator to affect wheelchair users")
+	assert(t, elevator.Affects(EntityQuery{StopID: "place-jfk", Activities: []AlertActivityType{AlertActivityFilterAll}}), "expected ALL to match any activity")
+
+	subway, bus := RouteTypeHeavyRail, RouteTypeBus
+	buses := alerts[3]
+	assert(t, buses.Affects(EntityQuery{RouteID: "1", RouteType: &bus}), "expected the bus alert to affect a bus")
+	assert(t, !buses.Affects(EntityQuery{RouteID: "Red", RouteType: &subway}), "expected the bus alert to not affect the subway")
+	assert(t, !buses.Affects(EntityQuery{RouteID: "1", RouteType: &bus, Activities: []AlertActivityType{AlertActivityExit}}), "expected the bus alert to only affect boarding")
+
+	cancelled := alerts[4]
+	assert(t, cancelled.Affects(EntityQuery{TripID: "trip-1", StopID: "place-jfk"}), "expected an entity without activities to match")
+	assert(t, !cancelled.Affects(EntityQuery{TripID: "trip-2", RouteID: "Red"}), "expected the cancellation to only affect its trip")
+}
+
+func TestAlertIndex_Affecting(t *testing.T) {
+	idx := NewAlertIndex(testAlerts())
+	south := 1
+	heavyRail := RouteTypeHeavyRail
+	wheelchair := []AlertActivityType{AlertActivityBoard, AlertActivityUsingWheelchair}
+	during := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	equals(t, []string{"red-shuttle", "jfk-elevator", "trip-cancelled"}, alertIDs(idx.Affecting(EntityQuery{RouteID: "Red", RouteType: &heavyRail, StopID: "place-jfk", Activities: wheelchair, Time: during})))
+	equals(t, []string{"red-shuttle", "red-delay-southbound", "trip-cancelled"}, alertIDs(idx.Affecting(EntityQuery{RouteID: "Red", RouteType: &heavyRail, DirectionID: &south})))
+	// without a route type, alerts for every bus can't be ruled out
+	equals(t, []string{"red-shuttle", "red-delay-southbound", "all-buses", "trip-cancelled"}, alertIDs(idx.Affecting(EntityQuery{RouteID: "Red", DirectionID: &south})))
+	equals(t, []string{"trip-cancelled"}, alertIDs(idx.Affecting(EntityQuery{RouteID: "Red", RouteType: &heavyRail, TripID: "trip-1", StopID: "place-pktrm", Time: during})))
+	equals(t, []string{"jfk-elevator"}, alertIDs(idx.Affecting(EntityQuery{FacilityID: "elevator-1", StopID: "place-jfk", TripID: "trip-2", Activities: []AlertActivityType{AlertActivityUsingWheelchair}, Time: during})))
+	equals(t, 0, len(idx.Affecting(EntityQuery{RouteID: "Red", RouteType: &heavyRail, StopID: "place-pktrm", TripID: "trip-2", Time: during})))
+}
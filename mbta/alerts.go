@@ -11,6 +11,199 @@ const alertsAPIPath = "/alerts"
 // AlertService handling all of the alert related API calls
 type AlertService service
 
+// Alert holds all the info about a given MBTA alert
+type Alert struct {
+	ID             string                `jsonapi:"primary,alert"`
+	URL            *JSONURL              `jsonapi:"attr,url"`             // A URL for extra details, such as outline construction or maintenance plans
+	UpdatedAt      TimeISO8601           `jsonapi:"attr,updated_at"`      // Date/Time alert last updated
+	Timeframe      *string               `jsonapi:"attr,timeframe"`       // Summarizes when an alert is in effect
+	ShortHeader    string                `jsonapi:"attr,short_header"`    // A shortened version of */attributes/header
+	Severity       int                   `jsonapi:"attr,severity"`        // How severe the alert it from least (0) to most (10) severe
+	ServiceEffect  string                `jsonapi:"attr,service_effect"`  // Summarizes the service and the impact to that service
+	Lifecycle      AlertLifecycleType    `jsonapi:"attr,lifecycle"`       // Identifies whether alert is a new or old, in effect or upcoming
+	InformedEntity []AlertInformedEntity `jsonapi:"attr,informed_entity"` // Object representing a particular part of the system affected by an alert
+	Header         string                `jsonapi:"attr,header"`          // This plain-text string will be highlighted, for example in boldface
+	Effect         AlertEffectType       `jsonapi:"attr,effect"`          // The effect of this problem on the affected entity
+	Description    *string               `jsonapi:"attr,description"`     // This plain-text string will be formatted as the body of the alert (or shown on an explicit “expand” request by the user). The information in the description should add to the information of the header
+	CreatedAt      TimeISO8601           `jsonapi:"attr,created_at"`      // Date/Time alert created
+	Cause          AlertCauseType        `jsonapi:"attr,cause"`           // What is causing the alert
+	Banner         *string               `jsonapi:"attr,banner"`          // Set if alert is meant to be displayed prominently, such as the top of every page
+	ActivePeriod   []AlertActivePeriod   `jsonapi:"attr,active_period"`   // Date/Time ranges when alert is active
+	fieldPresence
+}
+
+// AlertField an attribute or relationship of an alert, for a Fieldset
+type AlertField string
+
+const (
+	AlertFieldURL            AlertField = "url"
+	AlertFieldUpdatedAt      AlertField = "updated_at"
+	AlertFieldTimeframe      AlertField = "timeframe"
+	AlertFieldShortHeader    AlertField = "short_header"
+	AlertFieldSeverity       AlertField = "severity"
+	AlertFieldServiceEffect  AlertField = "service_effect"
+	AlertFieldLifecycle      AlertField = "lifecycle"
+	AlertFieldInformedEntity AlertField = "informed_entity"
+	AlertFieldHeader         AlertField = "header"
+	AlertFieldEffect         AlertField = "effect"
+	AlertFieldDescription    AlertField = "description"
+	AlertFieldCreatedAt      AlertField = "created_at"
+	AlertFieldCause          AlertField = "cause"
+	AlertFieldBanner         AlertField = "banner"
+	AlertFieldActivePeriod   AlertField = "active_period"
+)
+
+func (f AlertField) resourceType() string { return "alert" }
+func (f AlertField) fieldName() string    { return string(f) }
+
+// Has whether the alert has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (a *Alert) Has(field AlertField) bool { return a.has(string(field)) }
+
+// AlertInclude all of the includes for an alert request
+type AlertInclude string
+
+const (
+	AlertIncludeStops      AlertInclude = "stops"
+	AlertIncludeRoutes     AlertInclude = "routes"
+	AlertIncludeTrips      AlertInclude = "trips"
+	AlertIncludeFacilities AlertInclude = "facilities"
+)
+
+// AlertsSortByType all of the possible ways to sort by for a GetAllAlerts request
+type AlertsSortByType string
+
+const (
+	AlertsSortByActivePeriodAscending    AlertsSortByType = "active_period"
+	AlertsSortByActivePeriodDescending   AlertsSortByType = "-active_period"
+	AlertsSortByBannerAscending          AlertsSortByType = "banner"
+	AlertsSortByBannerDescending         AlertsSortByType = "-banner"
+	AlertsSortByCauseAscending           AlertsSortByType = "cause"
+	AlertsSortByCauseDescending          AlertsSortByType = "-cause"
+	AlertsSortByCreatedAtAscending       AlertsSortByType = "created_at"
+	AlertsSortByCreatedAtDescending      AlertsSortByType = "-created_at"
+	AlertsSortByDescriptionAscending     AlertsSortByType = "description"
+	AlertsSortByDescriptionDescending    AlertsSortByType = "-description"
+	AlertsSortByEffectAscending          AlertsSortByType = "effect"
+	AlertsSortByEffectDescending         AlertsSortByType = "-effect"
+	AlertsSortByHeaderAscending          AlertsSortByType = "header"
+	AlertsSortByHeaderDescending         AlertsSortByType = "-header"
+	AlertsSortByInformedEntityAscending  AlertsSortByType = "informed_entity"
+	AlertsSortByInformedEntityDescending AlertsSortByType = "-informed_entity"
+	AlertsSortByLifecycleAscending       AlertsSortByType = "lifecycle"
+	AlertsSortByLifecycleDescending      AlertsSortByType = "-lifecycle"
+	AlertsSortByServiceEffectAscending   AlertsSortByType = "service_effect"
+	AlertsSortByServiceEffectDescending  AlertsSortByType = "-service_effect"
+	AlertsSortBySeverityAscending        AlertsSortByType = "severity"
+	AlertsSortBySeverityDescending       AlertsSortByType = "-severity"
+	AlertsSortByShortHeaderAscending     AlertsSortByType = "short_header"
+	AlertsSortByShortHeaderDescending    AlertsSortByType = "-short_header"
+	AlertsSortByTimeframeAscending       AlertsSortByType = "timeframe"
+	AlertsSortByTimeframeDescending      AlertsSortByType = "-timeframe"
+	AlertsSortByUpdatedAtAscending       AlertsSortByType = "updated_at"
+	AlertsSortByUpdatedAtDescending      AlertsSortByType = "-updated_at"
+	AlertsSortByURLAscending             AlertsSortByType = "url"
+	AlertsSortByURLDescending            AlertsSortByType = "-url"
+)
+
+// GetAllAlertsRequestConfig extra options for the GetAllAlerts request
+type GetAllAlertsRequestConfig struct {
+	PageOffset        string              `url:"page[offset],omitempty"`             // Offset (0-based) of first element in the page
+	PageLimit         string              `url:"page[limit],omitempty"`              // Max number of elements to return
+	Sort              AlertsSortByType    `url:"sort,omitempty"`                     // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields            []string            `url:"fields[alert],comma,omitempty"`      // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset          Fieldset            `url:"fields,omitempty"`                   // Fields to include for each resource type, included ones too. Replaces Fields for alert if it has an entry for it
+	Include           []AlertInclude      `url:"include,comma,omitempty"`            // Relationships to include
+	FilterActivity    []AlertActivityType `url:"filter[activity],comma,omitempty"`   // Filter to alerts for only those activities If the filter is not given OR it is empty, then defaults to ["BOARD", "EXIT", “RIDE”]. If the value AlertActivityFilterAll is used then all alerts will be returned, not just those with the default activities
+	FilterRouteType   []RouteType         `url:"filter[route_type],comma,omitempty"` // Filter by route_type
+	FilterDirectionID string              `url:"filter[direction_id],omitempty"`     // Filter by direction of travel along the route
+	FilterRouteIDs    []string            `url:"filter[route],comma,omitempty"`      // Filter by route IDs
+	FilterStopIDs     []string            `url:"filter[stop],comma,omitempty"`       // Filter by stop IDs
+	FilterTripIDs     []string            `url:"filter[trip],comma,omitempty"`       // Filter by trip IDs
+	FilterFacilityIDs []string            `url:"filter[facility],comma,omitempty"`   // Filter by facility IDs
+	FilterIDs         []string            `url:"filter[id],comma,omitempty"`         // Filter by multiple IDs
+	FilterBanner      string              `url:"filter[banner],comma,omitempty"`     // When combined with other filters, filters by alerts with or without a banner. MUST be “true” or "false"
+	FilterDateTime    *TimeISO8601        `url:"filter[datetime],omitempty"`         // Filter to alerts that are active at a given time. Additionally, set `TimeISO8601.Now = true` to filter to alerts that are currently active
+	FilterLifecycle   []string            `url:"filter[lifecycle],comma,omitempty"`  // Filters by an alert’s lifecycle
+	FilterSeverity    []string            `url:"filter[severity],comma,omitempty"`   // Filters alerts by list of severities
+}
+
+// GetAllAlerts returns all alerts from the mbta API
+func (s *AlertService) GetAllAlerts(config *GetAllAlertsRequestConfig) ([]*Alert, *http.Response, error) {
+	return s.GetAllAlertsWithContext(context.Background(), config)
+}
+
+// GetAllAlertsWithContext returns all alerts from the mbta API given a context
+func (s *AlertService) GetAllAlertsWithContext(ctx context.Context, config *GetAllAlertsRequestConfig) ([]*Alert, *http.Response, error) {
+	u, err := addOptions(alertsAPIPath, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	untyped, resp, err := s.client.doManyPayload(req, &Alert{})
+	alerts := make([]*Alert, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		alerts[i] = untyped[i].(*Alert)
+	}
+	return alerts, resp, err
+}
+
+// GetAlertRequestConfig extra options for the GetAlert request
+type GetAlertRequestConfig struct {
+	Fields   []string       `url:"fields[alert],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset       `url:"fields,omitempty"`              // Fields to include for each resource type, included ones too. Replaces Fields for alert if it has an entry for it
+	Include  []AlertInclude `url:"include,comma,omitempty"`       // Relationships to include
+}
+
+// GetAlert returns an alert from the mbta API
+func (s *AlertService) GetAlert(id string, config *GetAlertRequestConfig) (*Alert, *http.Response, error) {
+	return s.GetAlertWithContext(context.Background(), id, config)
+}
+
+// GetAlertWithContext returns an alert from the mbta API given a context
+func (s *AlertService) GetAlertWithContext(ctx context.Context, id string, config *GetAlertRequestConfig) (*Alert, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
+	path := fmt.Sprintf("%s/%s", alertsAPIPath, id)
+	u, err := addOptions(path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var alert Alert
+	resp, err := s.client.doSinglePayload(req, &alert)
+	return &alert, resp, err
+}
+
+// GetAlerts returns the alerts with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *AlertService) GetAlerts(ids []string) ([]*Alert, error) {
+	return s.GetAlertsWithContext(context.Background(), ids)
+}
+
+// GetAlertsWithContext returns the alerts with the given IDs from the mbta API given a context
+func (s *AlertService) GetAlertsWithContext(ctx context.Context, ids []string) ([]*Alert, error) {
+	untyped, err := s.client.batchGet(ctx, alertsAPIPath, ids, &Alert{}, func(ids []string) interface{} {
+		return &GetAllAlertsRequestConfig{FilterIDs: ids}
+	})
+	alerts := make([]*Alert, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		alerts[i], _ = untyped[i].(*Alert)
+	}
+	return alerts, err
+}
+
 // AlertLifecycleType Identifies whether alert is a new or old, in effect or upcoming
 type AlertLifecycleType string
 
@@ -132,34 +325,15 @@ const (
 	AlertCauseWeather                    AlertCauseType = "WEATHER"
 )
 
-// Alert holds all the info about a given MBTA Alert
-type Alert struct {
-	ID             string                `jsonapi:"primary,alert"`
-	URL            *JSONURL              `jsonapi:"attr,url"`             // A URL for extra details, such as outline construction or maintenance plans
-	UpdatedAt      TimeISO8601           `jsonapi:"attr,updated_at"`      // Date/Time alert last updated
-	Timeframe      *string               `jsonapi:"attr,timeframe"`       // Summarizes when an alert is in effect.
-	ShortHeader    string                `jsonapi:"attr,short_header"`    // A shortened version of */attributes/header.
-	Severity       int                   `jsonapi:"attr,severity"`        // How severe the alert it from least (0) to most (10) severe.
-	ServiceEffect  string                `jsonapi:"attr,service_effect"`  // Summarizes the service and the impact to that service
-	Lifecycle      AlertLifecycleType    `jsonapi:"attr,lifecycle"`       // Identifies whether alert is a new or old, in effect or upcoming
-	InformedEntity []AlertInformedEntity `jsonapi:"attr,informed_entity"` // Object representing a particular part of the system affected by an alert
-	Header         string                `jsonapi:"attr,header"`          // This plain-text string will be highlighted, for example in boldface
-	Effect         AlertEffectType       `jsonapi:"attr,effect"`          // The effect of this problem on the affected entity
-	Description    *string               `jsonapi:"attr,description"`     // This plain-text string will be formatted as the body of the alert (or shown on an explicit “expand” request by the user). The information in the description should add to the information of the header
-	CreatedAt      TimeISO8601           `jsonapi:"attr,created_at"`      // Date/Time alert created
-	Cause          AlertCauseType        `jsonapi:"attr,cause"`           // What is causing the alert
-	Banner         *string               `jsonapi:"attr,banner"`          // Set if alert is meant to be displayed prominently, such as the top of every page
-	ActivePeriod   []AlertActivePeriod   `jsonapi:"attr,active_period"`   // Date/Time ranges when alert is active
-}
-
-// AlertInformedEntity Object representing a particular part of the system affected by an alert
+// AlertInformedEntity Object representing a particular part of the system affected by an alert. The API leaves out
+// the parts that don't apply, which are nil
 type AlertInformedEntity struct {
-	TripID      *string             `json:"trip"`
-	StopID      *string             `json:"stop"`
-	RouteType   *RouteType          `json:"route_type"`
-	RouteID     *string             `json:"route"`
-	FacilityID  *string             `json:"facility"`
-	DirectionID *int                `json:"direction_id"`
+	TripID      *string             `json:"trip,omitempty"`
+	StopID      *string             `json:"stop,omitempty"`
+	RouteType   *RouteType          `json:"route_type,omitempty"`
+	RouteID     *string             `json:"route,omitempty"`
+	FacilityID  *string             `json:"facility,omitempty"`
+	DirectionID *int                `json:"direction_id,omitempty"`
 	Activities  []AlertActivityType `json:"activities"`
 }
 
@@ -169,124 +343,8 @@ type AlertActivePeriod struct {
 	End   *TimeISO8601 `json:"end"`   // End Date
 }
 
-// AlertInclude all of the includes for a alert request
-type AlertInclude string
-
-const (
-	AlertIncludeStops      AlertInclude = includeStops
-	AlertIncludeRoutes     AlertInclude = includeRoutes
-	AlertIncludeTrips      AlertInclude = includeTrips
-	AlertIncludeFacilities AlertInclude = includeFacilities
-)
-
-// AlertsSortByType all of the possible ways to sort by for a GetAllAlerts request
-type AlertsSortByType string
-
+// Names from before the sort and include constants were renamed consistently
 const (
-	AlertsSortByActivePeriodAscending    AlertsSortByType = "active_period"
-	AlertsSortByActivePeriodDescending   AlertsSortByType = "-active_period"
-	AlertsSortByBannerDesending          AlertsSortByType = "banner"
-	AlertsSortByBannerDescending         AlertsSortByType = "-banner"
-	AlertsSortByCauseAscending           AlertsSortByType = "cause"
-	AlertsSortByCauseDescending          AlertsSortByType = "-cause"
-	AlertsSortByCreatedAtAscending       AlertsSortByType = "created_at"
-	AlertsSortByCreatedAtDescending      AlertsSortByType = "-created_at"
-	AlertsSortByDescriptionAscending     AlertsSortByType = "description"
-	AlertsSortByDescriptionDescending    AlertsSortByType = "-description"
-	AlertsSortByEffectAscending          AlertsSortByType = "effect"
-	AlertsSortByEffectDescending         AlertsSortByType = "-effect"
-	AlertsSortByHeaderAscending          AlertsSortByType = "header"
-	AlertsSortByHeaderDescending         AlertsSortByType = "-header"
-	AlertsSortByInformedEntityAscending  AlertsSortByType = "informed_entity"
-	AlertsSortByInformedEntityDescending AlertsSortByType = "-informed_entity"
-	AlertsSortByLifecycleAscending       AlertsSortByType = "lifecycle"
-	AlertsSortByLifecycleDescending      AlertsSortByType = "-lifecycle"
-	AlertsSortByServiceEffectAscending   AlertsSortByType = "service_effect"
-	AlertsSortByServiceEffectDescending  AlertsSortByType = "-service_effect"
-	AlertsSortBySeverityAscending        AlertsSortByType = "severity"
-	AlertsSortBySeverityDescending       AlertsSortByType = "-severity"
-	AlertsSortByShortHeaderAscending     AlertsSortByType = "short_header"
-	AlertsSortByShortHeaderDescending    AlertsSortByType = "-short_header"
-	AlertsSortByTimeframeAscending       AlertsSortByType = "timeframe"
-	AlertsSortByTimeframeDescending      AlertsSortByType = "-timeframe"
-	AlertsSortByUpdatedAtAscending       AlertsSortByType = "updated_at"
-	AlertsSortByUpdatedAtDescending      AlertsSortByType = "-updated_at"
-	AlertsSortByURLAscending             AlertsSortByType = "url"
-	AlertsSortByURLDescending            AlertsSortByType = "-url"
+	// Deprecated: use AlertsSortByBannerAscending
+	AlertsSortByBannerDesending = AlertsSortByBannerAscending
 )
-
-// GetAllAlertsRequestConfig extra options for the GetAllAlerts request
-type GetAllAlertsRequestConfig struct {
-	PageOffset        string              `url:"page[offset],omitempty"`             // Offset (0-based) of first element in the page
-	PageLimit         string              `url:"page[limit],omitempty"`              // Max number of elements to return
-	Sort              AlertsSortByType    `url:"sort,omitempty"`                     // Results can be sorted by the id or any RoutesSortByType
-	Fields            []string            `url:"fields[alert],comma,omitempty"`      // Fields to include with the response. Note that fields can also be selected for included data types
-	Include           []AlertInclude      `url:"include,comma,omitempty"`            // Include extra data in response
-	FilterActivity    []AlertActivityType `url:"filter[activity],comma,omitempty"`   // Filter to alerts for only those activities If the filter is not given OR it is empty, then defaults to ["BOARD", "EXIT", “RIDE”]. If the value AlertActivityFilterAll is used then all alerts will be returned, not just those with the default activities
-	FilterRouteType   []RouteType         `url:"filter[route_type],comma,omitempty"` // Filter by route_type
-	FilterDirectionID string              `url:"filter[direction_id],omitempty"`     // Filter by direction of travel along the route
-	FilterRouteIDs    []string            `url:"filter[route],omitempty"`            // Filter by route IDs
-	FilterStopIDs     []string            `url:"filter[stop],omitempty"`             // Filter by stop IDs
-	FilterTripIDs     []string            `url:"filter[trip],omitempty"`             // Filter by trip IDs
-	FilterFacilityIDs []string            `url:"filter[facility],omitempty"`         // Filter by facility IDs
-	FilterIDs         []string            `url:"filter[id],comma,omitempty"`         // Filter by multiple IDs
-	FilterBanner      string              `url:"filter[banner],comma,omitempty"`     // When combined with other filters, filters by alerts with or without a banner. MUST be “true” or "false"
-	FilterDateTime    *TimeISO8601        `url:"filter[datetime],omitempty"`         // Filter to alerts that are active at a given time. Additionally, set `TimeISO8601.Now = true` to filter to alerts that are currently active.
-	FilterLifecycle   []string            `url:"filter[lifecycle],comma,omitempty"`  // Filters by an alert’s lifecycle
-	FilterSeverity    []string            `url:"filter[severity],comma,omitempty"`   // Filters alerts by list of severities
-}
-
-// GetAllAlerts returns all alerts from the mbta API
-func (s *AlertService) GetAllAlerts(config *GetAllAlertsRequestConfig) ([]*Alert, *http.Response, error) {
-	return s.GetAllAlertsWithContext(context.Background(), config)
-}
-
-// GetAllAlertsWithContext returns all alerts from the mbta API given a context
-func (s *AlertService) GetAllAlertsWithContext(ctx context.Context, config *GetAllAlertsRequestConfig) ([]*Alert, *http.Response, error) {
-	u, err := addOptions(alertsAPIPath, config)
-	if err != nil {
-		return nil, nil, err
-	}
-	req, err := s.client.newGETRequest(u)
-	if err != nil {
-		return nil, nil, err
-	}
-	req = req.WithContext(ctx)
-
-	untypedAlerts, resp, err := s.client.doManyPayload(req, &Alert{})
-	alerts := make([]*Alert, len(untypedAlerts))
-	for i := 0; i < len(untypedAlerts); i++ {
-		alerts[i] = untypedAlerts[i].(*Alert)
-	}
-
-	return alerts, resp, err
-}
-
-// GetAlertRequestConfig extra options for the GetAlert request
-type GetAlertRequestConfig struct {
-	Fields  []string       `url:"fields[alert],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []AlertInclude `url:"include,comma,omitempty"`       // Include extra data in response
-}
-
-// GetAlert return an alert from the mbta API
-func (s *AlertService) GetAlert(id string, config *GetAlertRequestConfig) (*Alert, *http.Response, error) {
-	return s.GetAlertWithContext(context.Background(), id, config)
-}
-
-// GetAlertWithContext return an alert from the mbta API given a context
-func (s *AlertService) GetAlertWithContext(ctx context.Context, id string, config *GetAlertRequestConfig) (*Alert, *http.Response, error) {
-	path := fmt.Sprintf("%s/%s", alertsAPIPath, id)
-	u, err := addOptions(path, config)
-	if err != nil {
-		return nil, nil, err
-	}
-	req, err := s.client.newGETRequest(u)
-	if err != nil {
-		return nil, nil, err
-	}
-	req = req.WithContext(ctx)
-
-	var alert Alert
-	resp, err := s.client.doSinglePayload(req, &alert)
-	return &alert, resp, err
-}
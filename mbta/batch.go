@@ -0,0 +1,133 @@
+package mbta
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// filterIDsQuery what a FilterIDs config adds to a URL before the IDs themselves
+const filterIDsQuery = "?filter%5Bid%5D="
+
+// batchGet fetches the resources of type v with the given IDs from path, splitting them into as many filter[id]
+// requests as it takes to keep each URL under the client's MaxURLLength and running up to BatchConcurrency of them at once.
+// newConfig returns the GetAll request config for a chunk of IDs. The results are in the same order as ids, with nil where an
+// ID wasn't found, in which case the error is a MissingIDsError. Any other error fails the whole batch.
+// It backs the batch methods of the services, e.g. StopService.GetStops, rather than being a generic Client.BatchGet[T]:
+// the module supports Go 1.12, which has no type parameters, so each service wraps it with its own model type
+func (c *Client) batchGet(ctx context.Context, path string, ids []string, v interface{}, newConfig func(ids []string) interface{}) ([]interface{}, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" {
+			return nil, ErrMustSpecifyID
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		found    = make(map[string]interface{}, len(unique))
+		sem      = make(chan struct{}, c.batchConcurrency)
+	)
+	for _, chunk := range c.chunkIDs(path, unique) {
+		chunk := chunk
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			vals, err := c.getMany(ctx, path, newConfig(chunk), v)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			for _, val := range vals {
+				found[resourceID(val)] = val
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	results := make([]interface{}, len(ids))
+	var missing []string
+	for i, id := range ids {
+		val, ok := found[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		results[i] = val
+	}
+	if len(missing) > 0 {
+		return results, MissingIDsError{IDs: missing}
+	}
+	return results, nil
+}
+
+// chunkIDs splits ids into groups that fit in a filter[id] query on path without going over the client's MaxURLLength.
+// An ID too long to fit with any other gets a group of its own
+func (c *Client) chunkIDs(path string, ids []string) [][]string {
+	rel, _ := url.Parse(path)
+	base := len(c.BaseURL.ResolveReference(rel).String()) + len(filterIDsQuery)
+	var chunks [][]string
+	var chunk []string
+	length := base
+	for _, id := range ids {
+		idLength := len(url.QueryEscape(id))
+		if len(chunk) > 0 {
+			idLength += len("%2C")
+		}
+		if len(chunk) > 0 && length+idLength > c.maxURLLength {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			length = base
+			idLength -= len("%2C")
+		}
+		chunk = append(chunk, id)
+		length += idLength
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// getMany makes a GetAll request for path with config, decoding into resources of type v
+func (c *Client) getMany(ctx context.Context, path string, config interface{}, v interface{}) ([]interface{}, error) {
+	u, err := addOptions(path, config)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newGETRequest(u)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	vals, _, err := c.doManyPayload(req, v)
+	return vals, err
+}
+
+// resourceID the ID field every model has
+func resourceID(v interface{}) string {
+	return reflect.ValueOf(v).Elem().FieldByName("ID").String()
+}
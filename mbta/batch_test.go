@@ -0,0 +1,98 @@
+package mbta
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetStops(t *testing.T) {
+	var (
+		mu                    sync.Mutex
+		requests              [][]string
+		inFlight, maxInFlight int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		mu.Lock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		ids := strings.Split(r.URL.Query().Get("filter[id]"), ",")
+		requests = append(requests, ids)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+
+		var data []string
+		// answer in reverse to make sure the order comes from the IDs asked for
+		for i := len(ids) - 1; i >= 0; i-- {
+			if !strings.HasPrefix(ids[i], "missing") {
+				data = append(data, fmt.Sprintf(`{"type": "stop", "id": "%s", "attributes": {"name": "Stop %s"}}`, ids[i], ids[i]))
+			}
+		}
+		fmt.Fprintf(w, `{"data": [%s]}`, strings.Join(data, ","))
+	}))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, MaxURLLength: len(server.URL) + 60, BatchConcurrency: 2})
+	mbtaClient.client = server.Client()
+
+	var ids []string
+	for i := 0; i < 20; i++ {
+		ids = append(ids, fmt.Sprintf("place-%02d", i))
+	}
+	ids = append(ids, "missing-1", "place-03", "missing-2")
+	stops, err := mbtaClient.Stops.GetStops(ids)
+	equals(t, MissingIDsError{IDs: []string{"missing-1", "missing-2"}}, err)
+
+	equals(t, len(ids), len(stops))
+	for i, id := range ids {
+		if strings.HasPrefix(id, "missing") {
+			assert(t, stops[i] == nil, "expected no stop for %s", id)
+			continue
+		}
+		equals(t, id, stops[i].ID)
+	}
+
+	asked := make(map[string]int)
+	for _, chunk := range requests {
+		url := server.URL + "/stops" + filterIDsQuery + strings.Join(chunk, "%2C")
+		assert(t, len(url) <= len(server.URL)+60, "URL too long: %s", url)
+		for _, id := range chunk {
+			asked[id]++
+		}
+	}
+	assert(t, len(requests) > 1, "expected the IDs to be split, got %d request(s)", len(requests))
+	equals(t, 22, len(asked))
+	equals(t, 1, asked["place-03"])
+	assert(t, maxInFlight <= 2, "expected at most 2 requests at once, got %d", maxInFlight)
+}
+
+func TestGetStopsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	stops, err := mbtaClient.Stops.GetStops([]string{"place-sstat", "place-dwnxg"})
+	equals(t, ErrRateLimitExceeded, err)
+	equals(t, 0, len(stops))
+
+	_, err = mbtaClient.Stops.GetStops([]string{"place-sstat", ""})
+	equals(t, ErrMustSpecifyID, err)
+}
+
+func TestChunkIDs(t *testing.T) {
+	mbtaClient := NewClient(ClientConfig{BaseURL: "https://example.com", MaxURLLength: len("https://example.com/stops"+filterIDsQuery) + 10})
+	equals(t, [][]string{{"a", "b", "c"}, {"d e", "f"}, {"a-very-long-id"}, {"g"}},
+		mbtaClient.chunkIDs(stopsAPIPath, []string{"a", "b", "c", "d e", "f", "a-very-long-id", "g"}))
+}
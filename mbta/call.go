@@ -0,0 +1,112 @@
+package mbta
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// call tracks one API call for the Observer and the Logger
+type call struct {
+	client      *Client
+	info        RequestInfo
+	ctx         context.Context
+	start       time.Time
+	captureBody bool
+	body        *trackedBody
+}
+
+// trackedBody counts the bytes read from a response body and keeps a copy of them when the Logger wants it
+type trackedBody struct {
+	io.ReadCloser
+	size int64
+	copy *bytes.Buffer
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.copy != nil {
+		b.copy.Write(p[:n])
+	}
+	return n, err
+}
+
+// startCall tells the Observer about the request, returning the request to send and the call to finish with done
+func (c *Client) startCall(req *http.Request) (*http.Request, *call) {
+	cl := &call{client: c, ctx: req.Context(), start: time.Now()}
+	if c.observer == nil && c.logger == nil {
+		return req, cl
+	}
+	cl.info = requestInfo(req)
+	if c.observer != nil {
+		cl.ctx = c.observer.RequestStarted(cl.ctx, cl.info)
+		req = req.WithContext(cl.ctx)
+	}
+	if c.logger != nil {
+		cl.captureBody = c.logger.WantsBody(cl.ctx)
+	}
+	return req, cl
+}
+
+func (cl *call) wrapBody(resp *http.Response) {
+	if cl.client.observer == nil && cl.client.logger == nil {
+		return
+	}
+	cl.body = &trackedBody{ReadCloser: resp.Body}
+	if cl.captureBody {
+		cl.body.copy = &bytes.Buffer{}
+	}
+	resp.Body = cl.body
+}
+
+// done reports the outcome of the call. resp is nil when no response was received
+func (cl *call) done(resp *http.Response, err error, decodeErr bool) {
+	c := cl.client
+	if c.observer == nil && c.logger == nil {
+		return
+	}
+	duration := time.Since(cl.start)
+	if c.observer != nil {
+		result := RequestResult{
+			Duration:           duration,
+			RateLimitRemaining: -1,
+			CacheHit:           err == ErrNotModified,
+			DecodeError:        decodeErr,
+			Err:                err,
+		}
+		if resp != nil {
+			result.StatusCode = resp.StatusCode
+			if remaining, err := strconv.Atoi(resp.Header.Get("x-ratelimit-remaining")); err == nil {
+				result.RateLimitRemaining = remaining
+			}
+		}
+		c.observer.RequestDone(cl.ctx, cl.info, result)
+	}
+	if c.logger != nil {
+		entry := RequestLog{
+			Service:    cl.info.Service,
+			Method:     cl.info.Method,
+			HTTPMethod: http.MethodGet,
+			URL:        redactURL(cl.info.URL),
+			Duration:   duration,
+			Err:        err,
+		}
+		if resp != nil {
+			entry.StatusCode = resp.StatusCode
+		}
+		if cl.body != nil {
+			if cl.body.copy != nil && err != ErrNotModified {
+				// the whole body, even the parts nothing decoded, e.g. of a 429
+				io.Copy(ioutil.Discard, cl.body)
+				entry.Body = cl.body.copy.Bytes()
+			}
+			entry.ResponseSize = cl.body.size
+		}
+		c.logger.LogRequest(cl.ctx, entry)
+	}
+}
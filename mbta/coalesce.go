@@ -0,0 +1,103 @@
+package mbta
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// sharedCall an API call whose result goes to every identical call made while it is in flight, and for the
+// coalescer's TTL after it succeeds
+type sharedCall struct {
+	done chan struct{}
+
+	result interface{} // The decoded model pointer, or the []interface{} of them
+	resp   *http.Response
+	err    error
+}
+
+// coalescer shares the results of identical calls, see ClientConfig.CoalesceRequests
+type coalescer struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+func newCoalescer(ttl time.Duration) *coalescer {
+	return &coalescer{ttl: ttl, calls: make(map[string]*sharedCall)}
+}
+
+// coalesceKey identifies identical requests: the same canonical URL, built by addOptions, and the same condition
+func coalesceKey(req *http.Request) string {
+	lastModified, _ := req.Context().Value(ifModifiedSinceKey{}).(string)
+	return req.URL.String() + "\n" + lastModified
+}
+
+// do returns the result of the identical call in flight or still fresh, or makes the call with fn and shares its
+// result. If the call it waited for was cancelled by its own caller, it makes the call again, and if fn panicked, it
+// returns ErrCallPanicked while the panic goes on in the caller that made the call
+func (co *coalescer) do(req *http.Request, fn func() (interface{}, *http.Response, error)) (interface{}, *http.Response, error) {
+	key := coalesceKey(req)
+	ctx := req.Context()
+	for {
+		co.mu.Lock()
+		sc, ok := co.calls[key]
+		if !ok {
+			sc = &sharedCall{done: make(chan struct{})}
+			co.calls[key] = sc
+			co.mu.Unlock()
+			co.call(key, sc, fn)
+			return sc.result, sc.resp, sc.err
+		}
+		co.mu.Unlock()
+
+		select {
+		case <-sc.done:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		if isContextError(sc.err) && ctx.Err() == nil {
+			continue
+		}
+		return sc.result, sc.resp, sc.err
+	}
+}
+
+// call makes the call with fn and finishes sc with its result, or with ErrCallPanicked if fn panics
+func (co *coalescer) call(key string, sc *sharedCall, fn func() (interface{}, *http.Response, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			sc.result, sc.resp, sc.err = nil, nil, xerrors.Errorf("%v: %w", r, ErrCallPanicked)
+			co.finish(key, sc)
+			panic(r)
+		}
+	}()
+	sc.result, sc.resp, sc.err = fn()
+	co.finish(key, sc)
+}
+
+// finish hands the result of a call to the ones waiting for it, keeping it for the TTL if it succeeded
+func (co *coalescer) finish(key string, sc *sharedCall) {
+	close(sc.done)
+	if sc.err != nil || co.ttl <= 0 {
+		co.forget(key, sc)
+		return
+	}
+	time.AfterFunc(co.ttl, func() { co.forget(key, sc) })
+}
+
+func (co *coalescer) forget(key string, sc *sharedCall) {
+	co.mu.Lock()
+	if co.calls[key] == sc {
+		delete(co.calls, key)
+	}
+	co.mu.Unlock()
+}
+
+func isContextError(err error) bool {
+	return xerrors.Is(err, context.Canceled) || xerrors.Is(err, context.DeadlineExceeded)
+}
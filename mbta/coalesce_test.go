@@ -0,0 +1,185 @@
+package mbta
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// coalesceServer serves the fixtures, counting the requests and holding each of them until hold returns
+func coalesceServer(t *testing.T, hits *int32, hold func(r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if hold != nil {
+			hold(r)
+		}
+		b, err := ioutil.ReadFile(httpPathToTestData(r.URL.Path))
+		ok(t, err)
+		w.Write(b)
+	}))
+}
+
+func TestCoalesceRequests(t *testing.T) {
+	var hits int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := coalesceServer(t, &hits, func(r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, Observer: observer, CoalesceRequests: true, CoalesceTTL: time.Minute})
+	mbtaClient.client = server.Client()
+
+	config := &GetAllVehiclesRequestConfig{FilterRouteIDs: []string{"Red"}}
+	results := make([][]*Vehicle, 5)
+	var wg sync.WaitGroup
+	for i := range results {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vehicles, _, err := mbtaClient.Vehicles.GetAllVehicles(config)
+			ok(t, err)
+			results[i] = vehicles
+		}()
+	}
+	<-started
+	close(release)
+	wg.Wait()
+
+	equals(t, int32(1), atomic.LoadInt32(&hits))
+	equals(t, 1, len(observer.started))
+	for _, vehicles := range results[1:] {
+		assert(t, len(vehicles) > 0, "expected vehicles")
+		// the models are shared, each caller has its own slice
+		assert(t, vehicles[0] == results[0][0], "expected the same model")
+		assert(t, &vehicles[0] != &results[0][0], "expected a slice per caller")
+	}
+
+	// a different URL is another request, the same one is still fresh
+	_, _, err := mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRouteIDs: []string{"Orange"}})
+	ok(t, err)
+	_, _, err = mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRouteIDs: []string{"Red"}})
+	ok(t, err)
+	equals(t, int32(2), atomic.LoadInt32(&hits))
+
+	// the single model is copied into each caller's
+	first, _, err := mbtaClient.Vehicles.GetVehicle("y1772", nil)
+	ok(t, err)
+	second, _, err := mbtaClient.Vehicles.GetVehicle("y1772", nil)
+	ok(t, err)
+	equals(t, int32(3), atomic.LoadInt32(&hits))
+	assert(t, first != second, "expected a model per caller")
+	equals(t, first, second)
+}
+
+func TestCoalesceRequestsTTL(t *testing.T) {
+	var hits int32
+	server := coalesceServer(t, &hits, nil)
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, CoalesceRequests: true})
+	mbtaClient.client = server.Client()
+	for i := 0; i < 2; i++ {
+		_, _, err := mbtaClient.Vehicles.GetAllVehicles(nil)
+		ok(t, err)
+	}
+	// without a TTL, only the calls in flight share
+	equals(t, int32(2), atomic.LoadInt32(&hits))
+
+	mbtaClient = NewClient(ClientConfig{BaseURL: server.URL, CoalesceRequests: true, CoalesceTTL: 20 * time.Millisecond})
+	mbtaClient.client = server.Client()
+	for i := 0; i < 2; i++ {
+		_, _, err := mbtaClient.Vehicles.GetAllVehicles(nil)
+		ok(t, err)
+	}
+	equals(t, int32(3), atomic.LoadInt32(&hits))
+	time.Sleep(50 * time.Millisecond)
+	_, _, err := mbtaClient.Vehicles.GetAllVehicles(nil)
+	ok(t, err)
+	equals(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestCoalesceRequestsCancelled(t *testing.T) {
+	var hits int32
+	started := make(chan struct{}, 10)
+	server := coalesceServer(t, &hits, func(r *http.Request) {
+		if atomic.LoadInt32(&hits) == 1 {
+			started <- struct{}{}
+			<-r.Context().Done()
+		}
+	})
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, CoalesceRequests: true})
+	mbtaClient.client = server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, _, err := mbtaClient.Vehicles.GetAllVehiclesWithContext(ctx, nil)
+		leaderErr <- err
+	}()
+	<-started
+	followerErr := make(chan error, 1)
+	go func() {
+		vehicles, _, err := mbtaClient.Vehicles.GetAllVehicles(nil)
+		if err == nil && len(vehicles) == 0 {
+			err = xerrors.New("no vehicles")
+		}
+		followerErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	// the caller that gave up gets its error, the one waiting for it makes the request again
+	assert(t, xerrors.Is(<-leaderErr, context.Canceled), "expected the leader to be cancelled")
+	ok(t, <-followerErr)
+	equals(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestCoalesceRequestsPanicked(t *testing.T) {
+	co := newCoalescer(time.Minute)
+	req := httptest.NewRequest(http.MethodGet, "/vehicles", nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderPanic := make(chan interface{}, 1)
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		co.do(req, func() (interface{}, *http.Response, error) {
+			close(started)
+			<-release
+			panic("observer failed")
+		})
+	}()
+	<-started
+	followerErr := make(chan error, 1)
+	go func() {
+		_, _, err := co.do(req, func() (interface{}, *http.Response, error) {
+			return nil, nil, xerrors.New("expected to wait for the call in flight")
+		})
+		followerErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	// the caller that made the call panics, the one waiting for it gets an error
+	equals(t, "observer failed", <-leaderPanic)
+	err := <-followerErr
+	assert(t, xerrors.Is(err, ErrCallPanicked), "expected ErrCallPanicked, got %v", err)
+
+	// and the call isn't kept, the next one is made again
+	result, _, err := co.do(req, func() (interface{}, *http.Response, error) { return "again", nil, nil })
+	ok(t, err)
+	equals(t, "again", result)
+}
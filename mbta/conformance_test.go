@@ -0,0 +1,518 @@
+package mbta
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The conformance check compares the client against the v3 OpenAPI (Swagger 2.0) document in swagger.json. It only
+// means something for the document the API publishes, vendored unmodified, so swagger.source.json records where and
+// when it was fetched and its sha256: the check fails if either is missing or swagger.json was edited since. To
+// vendor the current document:
+//
+//	go run ../internal/mbtagen -fetch -spec swagger.json -config mbtagen.json
+//
+// then fix what the check reports. It reports:
+//   - *APIPath constants that aren't paths of the API
+//   - *RequestConfig url tags that aren't parameters of the endpoint, lists that aren't comma separated and fields
+//     whose type can't be encoded as a query value
+//   - *Include constants the endpoint doesn't list as includes and *SortByType constants that aren't sort options
+//   - jsonapi tags naming attributes or relationships the resource doesn't have
+//   - nullable attributes whose field can't tell null from a value
+
+const (
+	swaggerPath       = "swagger.json"
+	swaggerSourcePath = "swagger.source.json"
+)
+
+// swaggerSource where swagger.json came from
+type swaggerSource struct {
+	URL     string `json:"url"`
+	Fetched string `json:"fetched"` // The date it was fetched, e.g. 2019-05-14
+	SHA256  string `json:"sha256"`  // Of swagger.json as fetched
+}
+
+// configPaths the API path each request config is for. Every *RequestConfig needs an entry
+var configPaths = map[string]string{
+	"GetAllAlertsRequestConfig":         "/alerts",
+	"GetAlertRequestConfig":             "/alerts/{id}",
+	"GetAllFacilitiesRequestConfig":     "/facilities",
+	"GetFacilityRequestConfig":          "/facilities/{id}",
+	"GetAllLinesRequestConfig":          "/lines",
+	"GetLineRequestConfig":              "/lines/{id}",
+	"GetAllLiveFacilitiesRequestConfig": "/live_facilities",
+	"GetLiveFacilityRequestConfig":      "/live_facilities/{id}",
+	"GetAllPredictionsRequestConfig":    "/predictions",
+	"GetAllRoutePatternsRequestConfig":  "/route_patterns",
+	"GetRoutePatternRequestConfig":      "/route_patterns/{id}",
+	"GetAllRoutesRequestConfig":         "/routes",
+	"GetRouteRequestConfig":             "/routes/{id}",
+	"GetAllSchedulesRequestConfig":      "/schedules",
+	"GetAllServicesRequestConfig":       "/services",
+	"GetServiceRequestConfig":           "/services/{id}",
+	"GetAllShapesRequestConfig":         "/shapes",
+	"GetShapeRequestConfig":             "/shapes/{id}",
+	"GetAllStopsRequestConfig":          "/stops",
+	"GetStopRequestConfig":              "/stops/{id}",
+	"GetAllTripsRequestConfig":          "/trips",
+	"GetTripRequestConfig":              "/trips/{id}",
+	"GetAllVehiclesRequestConfig":       "/vehicles",
+	"GetVehicleRequestConfig":           "/vehicles/{id}",
+}
+
+// queryEncoders the struct types that encode themselves as a query value
+var queryEncoders = map[string]bool{
+	"TimeISO8601": true,
+}
+
+type swaggerDoc struct {
+	Paths       map[string]map[string]swaggerOperation `json:"paths"`
+	Definitions map[string]swaggerSchema               `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Parameters []swaggerParameter `json:"parameters"`
+}
+
+type swaggerParameter struct {
+	Name        string   `json:"name"`
+	In          string   `json:"in"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+}
+
+type swaggerSchema struct {
+	Nullable   bool                     `json:"x-nullable"`
+	Example    interface{}              `json:"example"`
+	Enum       []interface{}            `json:"enum"`
+	Properties map[string]swaggerSchema `json:"properties"`
+}
+
+// includeOption a "* `name`" bullet of the include parameter's description
+var includeOption = regexp.MustCompile("(?m)^\\s*\\*\\s+`([a-z_.]+)`")
+
+func (op swaggerOperation) parameter(name string) (swaggerParameter, bool) {
+	for _, p := range op.Parameters {
+		if p.Name == name && p.In == "query" {
+			return p, true
+		}
+	}
+	return swaggerParameter{}, false
+}
+
+func (op swaggerOperation) includes() map[string]bool {
+	includes := make(map[string]bool)
+	if p, ok := op.parameter("include"); ok {
+		for _, m := range includeOption.FindAllStringSubmatch(p.Description, -1) {
+			includes[m[1]] = true
+		}
+		for _, e := range p.Enum {
+			includes[e] = true
+		}
+	}
+	return includes
+}
+
+// hasFieldsets whether the operation takes a fields[TYPE] parameter
+func (op swaggerOperation) hasFieldsets() bool {
+	for _, p := range op.Parameters {
+		if p.In == "query" && strings.HasPrefix(p.Name, "fields[") {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceType the JSON:API type of a resource definition, e.g. "stop" for StopResource
+func (s swaggerSchema) resourceType() string {
+	t := s.Properties["type"]
+	if example, ok := t.Example.(string); ok {
+		return example
+	}
+	if len(t.Enum) == 1 {
+		if enum, ok := t.Enum[0].(string); ok {
+			return enum
+		}
+	}
+	return ""
+}
+
+// clientSource what the conformance check needs from the package's source
+type clientSource struct {
+	stringConsts map[string]string            // Value of every string constant by name
+	typedConsts  map[string]map[string]string // Name to value of the constants of each named type
+	structs      map[string]*ast.StructType
+}
+
+func parseClientSource(dir string) (*clientSource, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	src := &clientSource{
+		stringConsts: make(map[string]string),
+		typedConsts:  make(map[string]map[string]string),
+		structs:      make(map[string]*ast.StructType),
+	}
+	type typedConst struct{ name, typ, ident string }
+	var pending []typedConst
+	for _, file := range pkgs["mbta"].Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						src.structs[spec.Name.Name] = st
+					}
+				case *ast.ValueSpec:
+					if gen.Tok != token.CONST || len(spec.Values) != len(spec.Names) {
+						continue
+					}
+					typ := ""
+					if ident, ok := spec.Type.(*ast.Ident); ok {
+						typ = ident.Name
+					}
+					for i, name := range spec.Names {
+						switch v := spec.Values[i].(type) {
+						case *ast.BasicLit:
+							if v.Kind != token.STRING {
+								continue
+							}
+							value, _ := strconv.Unquote(v.Value)
+							src.stringConsts[name.Name] = value
+							if typ != "" {
+								pending = append(pending, typedConst{name.Name, typ, name.Name})
+							}
+						case *ast.Ident:
+							if typ != "" {
+								pending = append(pending, typedConst{name.Name, typ, v.Name})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, c := range pending {
+		value, ok := src.stringConsts[c.ident]
+		if !ok {
+			continue
+		}
+		if src.typedConsts[c.typ] == nil {
+			src.typedConsts[c.typ] = make(map[string]string)
+		}
+		src.typedConsts[c.typ][c.name] = value
+	}
+	return src, nil
+}
+
+// elemType the name of the named type at the bottom of slices and pointers, and whether it was a slice
+func elemType(expr ast.Expr) (string, bool) {
+	isSlice := false
+	for {
+		switch e := expr.(type) {
+		case *ast.ArrayType:
+			isSlice = true
+			expr = e.Elt
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name, isSlice
+		default:
+			return "", isSlice
+		}
+	}
+}
+
+// nullableType whether a field of the type can tell null apart from a value: pointers and slices are nil
+func nullableType(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.StarExpr, *ast.ArrayType:
+		return true
+	}
+	return false
+}
+
+func fieldName(field *ast.Field) string {
+	if len(field.Names) == 0 {
+		return ""
+	}
+	return field.Names[0].Name
+}
+
+func fieldTag(field *ast.Field, key string) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	tag, _ := strconv.Unquote(field.Tag.Value)
+	return reflect.StructTag(tag).Lookup(key)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// conformanceMismatches everything in src that doesn't match doc
+func conformanceMismatches(src *clientSource, doc *swaggerDoc) []string {
+	var mismatches []string
+	report := func(format string, a ...interface{}) {
+		mismatches = append(mismatches, fmt.Sprintf(format, a...))
+	}
+
+	for _, name := range sortedKeys(src.stringConsts) {
+		if strings.HasSuffix(name, "APIPath") {
+			if _, ok := doc.Paths[src.stringConsts[name]]; !ok {
+				report("%s: %q is not an API path", name, src.stringConsts[name])
+			}
+		}
+	}
+
+	structNames := make([]string, 0, len(src.structs))
+	for name := range src.structs {
+		structNames = append(structNames, name)
+	}
+	sort.Strings(structNames)
+
+	for _, name := range structNames {
+		if !strings.HasSuffix(name, "RequestConfig") {
+			continue
+		}
+		path, ok := configPaths[name]
+		if !ok {
+			report("%s: no API path for this config, add it to configPaths", name)
+			continue
+		}
+		op, ok := doc.Paths[path]["get"]
+		if !ok {
+			report("%s: %s is not an API path", name, path)
+			continue
+		}
+		for _, field := range src.structs[name].Fields.List {
+			tag, ok := fieldTag(field, "url")
+			if !ok || tag == "-" {
+				continue
+			}
+			opts := strings.Split(tag, ",")
+			if typ, _ := elemType(field.Type); typ == "Fieldset" {
+				// sets fields[TYPE] for any type, the document only lists the endpoint's own
+				if !op.hasFieldsets() {
+					report("%s.%s: %s doesn't take sparse fieldsets", name, fieldName(field), path)
+				}
+				continue
+			}
+			param, ok := op.parameter(opts[0])
+			if !ok {
+				report("%s.%s: %q is not a parameter of %s", name, fieldName(field), opts[0], path)
+				continue
+			}
+			typ, isSlice := elemType(field.Type)
+			if isSlice && !strings.Contains(tag, ",comma") {
+				report("%s.%s: %q is a comma separated list, the url tag needs the comma option", name, fieldName(field), opts[0])
+			}
+			if _, isStruct := src.structs[typ]; isStruct && !queryEncoders[typ] {
+				report("%s.%s: a %s can't be encoded as the %q parameter", name, fieldName(field), typ, opts[0])
+				continue
+			}
+			switch opts[0] {
+			case "include":
+				includes := op.includes()
+				consts := src.typedConsts[typ]
+				for _, c := range sortedKeys(consts) {
+					if !includes[consts[c]] {
+						report("%s.%s: %s (%q) is not an include of %s", name, fieldName(field), c, consts[c], path)
+					}
+				}
+			case "sort":
+				options := make(map[string]bool, len(param.Enum))
+				for _, e := range param.Enum {
+					options[e] = true
+				}
+				consts := src.typedConsts[typ]
+				for _, c := range sortedKeys(consts) {
+					if !options[consts[c]] {
+						report("%s.%s: %s (%q) is not a sort option of %s", name, fieldName(field), c, consts[c], path)
+					}
+				}
+			}
+		}
+	}
+
+	resources := make(map[string]swaggerSchema)
+	for _, def := range doc.Definitions {
+		if t := def.resourceType(); t != "" {
+			resources[t] = def
+		}
+	}
+	for _, name := range structNames {
+		var resourceType string
+		var fields []*ast.Field
+		for _, field := range src.structs[name].Fields.List {
+			tag, ok := fieldTag(field, "jsonapi")
+			if !ok {
+				continue
+			}
+			if strings.HasPrefix(tag, "primary,") {
+				resourceType = strings.TrimPrefix(tag, "primary,")
+			} else {
+				fields = append(fields, field)
+			}
+		}
+		if resourceType == "" || len(fields) == 0 {
+			// not a resource, or only the target of relationships (e.g. Zone)
+			continue
+		}
+		def, ok := resources[resourceType]
+		if !ok {
+			report("%s: %q is not a resource type", name, resourceType)
+			continue
+		}
+		for _, field := range fields {
+			tag, _ := fieldTag(field, "jsonapi")
+			parts := strings.Split(tag, ",")
+			if len(parts) < 2 {
+				continue
+			}
+			switch parts[0] {
+			case "attr":
+				attr, ok := def.Properties["attributes"].Properties[parts[1]]
+				if !ok {
+					report("%s.%s: %q is not an attribute of %s", name, fieldName(field), parts[1], resourceType)
+					continue
+				}
+				if attr.Nullable && !nullableType(field.Type) {
+					report("%s.%s: %q can be null, it needs to be a pointer", name, fieldName(field), parts[1])
+				}
+			case "relation":
+				if _, ok := def.Properties["relationships"].Properties[parts[1]]; !ok {
+					report("%s.%s: %q is not a relationship of %s", name, fieldName(field), parts[1], resourceType)
+				}
+			}
+		}
+	}
+	return mismatches
+}
+
+func TestConformance(t *testing.T) {
+	b, err := ioutil.ReadFile(swaggerPath)
+	sb, sourceErr := ioutil.ReadFile(swaggerSourcePath)
+	if err == nil {
+		err = sourceErr
+	}
+	if err != nil {
+		t.Fatalf("the published OpenAPI document isn't vendored, run go run ../internal/mbtagen -fetch -spec %s -config mbtagen.json in mbta: %v",
+			swaggerPath, err)
+	}
+	var source swaggerSource
+	ok(t, json.Unmarshal(sb, &source))
+	if source.URL == "" || source.Fetched == "" || source.SHA256 == "" {
+		t.Fatalf("%s doesn't say where and when %s was fetched, vendor it with mbtagen -fetch", swaggerSourcePath, swaggerPath)
+	}
+	if sum := fmt.Sprintf("%x", sha256.Sum256(b)); sum != source.SHA256 {
+		t.Fatalf("%s changed since it was fetched from %s on %s (sha256 %s, not %s): vendor it again instead of editing it, and put what's Go-specific in mbtagen.json",
+			swaggerPath, source.URL, source.Fetched, sum, source.SHA256)
+	}
+	var doc swaggerDoc
+	ok(t, json.Unmarshal(b, &doc))
+	src, err := parseClientSource(".")
+	ok(t, err)
+
+	for _, mismatch := range conformanceMismatches(src, &doc) {
+		t.Error(mismatch)
+	}
+}
+
+func TestConformanceReportsDrift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conformance")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	ok(t, ioutil.WriteFile(filepath.Join(dir, "stops.go"), []byte(`package mbta
+
+const stopsAPIPath = "/stop"
+
+const includeStop = "stop"
+
+type StopInclude string
+
+const StopIncludeParentStation StopInclude = includeStop
+
+type StopsSortByType string
+
+type StopLocationType string
+
+const StopLocationStation StopLocationType = "station"
+
+const StopsSortByNameDescending StopsSortByType = "-name"
+
+type Route struct {
+	ID string `+"`jsonapi:\"primary,route\"`"+`
+}
+
+type Stop struct {
+	ID       string           `+"`jsonapi:\"primary,stop\"`"+`
+	Name     string           `+"`jsonapi:\"attr,title\"`"+`
+	Address  string           `+"`jsonapi:\"attr,address\"`"+`
+	Location StopLocationType `+"`jsonapi:\"attr,location\"`"+`
+}
+
+type GetAllStopsRequestConfig struct {
+	Sort         StopsSortByType `+"`url:\"sort,omitempty\"`"+`
+	Include      []StopInclude   `+"`url:\"include,comma,omitempty\"`"+`
+	FilterRoutes []Route         `+"`url:\"filter[route],comma,omitempty\"`"+`
+	FilterIDs    []string        `+"`url:\"filter[id],omitempty\"`"+`
+	FilterDate   string          `+"`url:\"filter[data],omitempty\"`"+`
+}
+`), 0644))
+
+	var doc swaggerDoc
+	ok(t, json.Unmarshal([]byte(`{
+		"paths": {"/stops": {"get": {"parameters": [
+			{"name": "sort", "in": "query", "enum": ["name"]},
+			{"name": "include", "in": "query", "description": "Relationships to include.\n\n* `+"`parent_station`"+`\n"},
+			{"name": "filter[route]", "in": "query"},
+			{"name": "filter[id]", "in": "query"}
+		]}}},
+		"definitions": {"StopResource": {"properties": {
+			"type": {"example": "stop"},
+			"attributes": {"properties": {"name": {}, "address": {"x-nullable": true}, "location": {"x-nullable": true}}}
+		}}}
+	}`), &doc))
+	src, err := parseClientSource(dir)
+	ok(t, err)
+
+	equals(t, []string{
+		`stopsAPIPath: "/stop" is not an API path`,
+		`GetAllStopsRequestConfig.Sort: StopsSortByNameDescending ("-name") is not a sort option of /stops`,
+		`GetAllStopsRequestConfig.Include: StopIncludeParentStation ("stop") is not an include of /stops`,
+		`GetAllStopsRequestConfig.FilterRoutes: a Route can't be encoded as the "filter[route]" parameter`,
+		`GetAllStopsRequestConfig.FilterIDs: "filter[id]" is a comma separated list, the url tag needs the comma option`,
+		`GetAllStopsRequestConfig.FilterDate: "filter[data]" is not a parameter of /stops`,
+		`Stop.Name: "title" is not an attribute of stop`,
+		`Stop.Address: "address" can be null, it needs to be a pointer`,
+		`Stop.Location: "location" can be null, it needs to be a pointer`,
+	}, conformanceMismatches(src, &doc))
+}
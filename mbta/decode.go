@@ -0,0 +1,233 @@
+package mbta
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/google/jsonapi"
+)
+
+// unmarshalPayload decodes a document with one resource into model, a pointer to a model struct, see payloadDecoder
+func unmarshalPayload(r io.Reader, model interface{}) error {
+	d := newPayloadDecoder(reflect.TypeOf(model))
+	b, err := d.prepare(r, false)
+	if err != nil {
+		return err
+	}
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(b), model); err != nil {
+		return err
+	}
+	d.finish(reflect.ValueOf(model), d.primary)
+	return nil
+}
+
+// unmarshalManyPayload decodes a document with a list of resources into models of type t, a pointer to a model struct,
+// see payloadDecoder
+func unmarshalManyPayload(r io.Reader, t reflect.Type) ([]interface{}, error) {
+	d := newPayloadDecoder(t)
+	b, err := d.prepare(r, true)
+	if err != nil {
+		return nil, err
+	}
+	models, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(b), t)
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range models {
+		d.finish(reflect.ValueOf(model), d.primary)
+	}
+	return models, nil
+}
+
+// payloadDecoder decodes what jsonapi can't: which fields each resource had, for the models' Has methods, and
+// attributes that are pointers to string enums, e.g. Prediction.ScheduleRelationship, which it only fills by value.
+// prepare records the fields and takes the enums out of the document before jsonapi decodes it, and finish sets both
+// on the models it decoded
+type payloadDecoder struct {
+	types    map[string]reflect.Type                 // The model struct of each JSON:API type the models can have
+	primary  map[resourceIdentifier]*decodedResource // The resources of the primary data
+	included map[resourceIdentifier]*decodedResource // The included resources
+}
+
+// decodedResource what payloadDecoder records of a resource
+type decodedResource struct {
+	present     map[string]bool   // The fields the resource had, nil when it had all of its model's
+	withoutData map[string]bool   // The to-one relationships it had without data
+	enums       map[string]string // The string enum attributes taken out of it
+}
+
+func newPayloadDecoder(t reflect.Type) *payloadDecoder {
+	d := &payloadDecoder{
+		types:    make(map[string]reflect.Type),
+		primary:  make(map[resourceIdentifier]*decodedResource),
+		included: make(map[resourceIdentifier]*decodedResource),
+	}
+	d.addType(t.Elem())
+	return d
+}
+
+// addType adds the model struct t and the models of its relationships to the types
+func (d *payloadDecoder) addType(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		kind, name := jsonapiTag(t.Field(i))
+		if kind == "primary" {
+			if _, ok := d.types[name]; ok {
+				return
+			}
+			d.types[name] = t
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if kind, _ := jsonapiTag(t.Field(i)); kind == "relation" {
+			related := t.Field(i).Type
+			if related.Kind() == reflect.Slice {
+				related = related.Elem()
+			}
+			d.addType(related.Elem())
+		}
+	}
+}
+
+// prepare reads a document, records the fields of its resources and returns it without the string enum attributes if
+// it had any
+func (d *payloadDecoder) prepare(r io.Reader, many bool) ([]byte, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Data     json.RawMessage `json:"data"`
+		Included []*jsonapi.Node `json:"included"`
+	}
+	if err := unmarshalNumbers(b, &doc); err != nil {
+		return nil, err
+	}
+	var data interface{}
+	var nodes []*jsonapi.Node
+	if many {
+		if err := unmarshalNumbers(doc.Data, &nodes); err != nil {
+			return nil, err
+		}
+		data = nodes
+	} else {
+		var node *jsonapi.Node
+		if err := unmarshalNumbers(doc.Data, &node); err != nil {
+			return nil, err
+		}
+		nodes, data = []*jsonapi.Node{node}, node
+	}
+
+	changed := false
+	for _, node := range nodes {
+		changed = d.record(d.primary, node) || changed
+	}
+	for _, node := range doc.Included {
+		changed = d.record(d.included, node) || changed
+	}
+	if !changed {
+		return b, nil
+	}
+	return json.Marshal(map[string]interface{}{"data": data, "included": doc.Included})
+}
+
+// record records the fields of the node in resources, and takes the string enum attributes that are pointers in its
+// model out of its attributes. It returns whether it took any
+func (d *payloadDecoder) record(resources map[resourceIdentifier]*decodedResource, node *jsonapi.Node) bool {
+	if node == nil {
+		return false
+	}
+	t, ok := d.types[node.Type]
+	if !ok {
+		return false
+	}
+	resource := &decodedResource{present: make(map[string]bool), enums: make(map[string]string)}
+	complete := true
+	for i := 0; i < t.NumField(); i++ {
+		var found bool
+		kind, name := jsonapiTag(t.Field(i))
+		switch kind {
+		case "attr":
+			_, found = node.Attributes[name]
+			if value, ok := node.Attributes[name].(string); ok && isEnumPointer(t.Field(i).Type) {
+				resource.enums[name] = value
+				delete(node.Attributes, name)
+			}
+		case "relation":
+			var rel interface{}
+			rel, found = node.Relationships[name]
+			if link, ok := rel.(map[string]interface{}); ok && t.Field(i).Type.Kind() != reflect.Slice {
+				if _, hasData := link["data"]; !hasData {
+					if resource.withoutData == nil {
+						resource.withoutData = make(map[string]bool)
+					}
+					resource.withoutData[name] = true
+				}
+			}
+		default:
+			continue
+		}
+		if found {
+			resource.present[name] = true
+		}
+		complete = complete && found
+	}
+	if complete {
+		resource.present = nil
+	}
+	resources[resourceIdentifier{Type: node.Type, ID: node.ID}] = resource
+	return len(resource.enums) > 0
+}
+
+// finish sets what was recorded of the resources on model and on the models it's related to, which jsonapi decoded
+// from the included resources. Related resources that weren't included only have their id
+func (d *payloadDecoder) finish(model reflect.Value, resources map[resourceIdentifier]*decodedResource) {
+	if model.IsNil() {
+		return
+	}
+	resource, ok := resources[identifierOf(model)]
+	if !ok {
+		resource = &decodedResource{present: make(map[string]bool)}
+	}
+	if p, ok := model.Interface().(interface {
+		setPresent(present, withoutData map[string]bool)
+	}); ok {
+		p.setPresent(resource.present, resource.withoutData)
+	}
+	v := model.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		kind, name := jsonapiTag(v.Type().Field(i))
+		field := v.Field(i)
+		switch kind {
+		case "attr":
+			if value, ok := resource.enums[name]; ok {
+				enum := reflect.New(field.Type().Elem())
+				enum.Elem().SetString(value)
+				field.Set(enum)
+			}
+		case "relation":
+			if field.Kind() == reflect.Slice {
+				for j := 0; j < field.Len(); j++ {
+					d.finish(field.Index(j), d.included)
+				}
+			} else {
+				d.finish(field, d.included)
+			}
+		}
+	}
+}
+
+// isEnumPointer whether t is a pointer to a string type other than string itself, i.e. to a string enum
+func isEnumPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.String && t.Elem() != reflect.TypeOf("")
+}
+
+// unmarshalNumbers unmarshals JSON like json.Unmarshal, but keeps numbers as json.Number so they're written back as
+// they were
+func unmarshalNumbers(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
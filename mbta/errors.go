@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 var (
@@ -13,6 +14,15 @@ var (
 	ErrForbidden         = errors.New("forbidden")
 	ErrMustSpecifyID     = errors.New("must specify an id (cannot be an empty string)")
 	ErrInvalidConfig     = errors.New("config options are invalid")
+	ErrNotModified       = errors.New("not modified since the time given to WithIfModifiedSince")
+	ErrNotStation        = errors.New("stop is not a station")
+	ErrNotModel          = errors.New("not a model or a slice of models")
+	ErrInvalidInclude    = errors.New("no such relationship to include")
+	ErrInvalidPath       = errors.New("not an API path")
+	ErrCallPanicked      = errors.New("the identical call this one waited for panicked")
+
+	ErrWrongFacilityType       = errors.New("facility is not of the requested type")
+	ErrInvalidFacilityProperty = errors.New("facility property has an invalid value")
 )
 
 // BadRequestError error type holding the returned info about the bad request
@@ -26,6 +36,16 @@ func (e BadRequestError) Error() string {
 	return fmt.Sprintf("parameter \"%s\" caused error [%s]: (%s)", e.SourceParameter, e.Code, e.Detail)
 }
 
+// MissingIDsError error type returned by the batch methods (e.g. GetStops) when some of the IDs weren't found.
+// The results for the IDs that were found are still returned
+type MissingIDsError struct {
+	IDs []string // The IDs that weren't found, in the order they were asked for
+}
+
+func (e MissingIDsError) Error() string {
+	return fmt.Sprintf("%d id(s) not found: %s", len(e.IDs), strings.Join(e.IDs, ", "))
+}
+
 func getBadRequestError(body io.Reader) BadRequestError {
 	var jsonErr struct {
 		Errors []struct {
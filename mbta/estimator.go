@@ -0,0 +1,285 @@
+package mbta
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCannotEstimate there is not enough data to estimate arrivals for the vehicle
+var ErrCannotEstimate = errors.New("not enough data to estimate arrivals")
+
+const (
+	defaultSpeedWeight      = 0.5
+	defaultSpeedDecayMeters = 1000.0
+	defaultMinSpeed         = 0.5
+	defaultSpeedWindow      = 5 * time.Minute
+	speedSmoothing          = 0.5
+)
+
+// ArrivalEstimate a client-side estimate of when a vehicle will arrive at a stop on its trip.
+// These are computed by this library, not the MBTA, and are meant as a fallback for when there are no predictions (e.g. ScheduleRelationshipNoData)
+type ArrivalEstimate struct {
+	Vehicle        *Vehicle    // Vehicle the estimate is for
+	Trip           *Trip       // Trip the vehicle is on
+	Stop           *Stop       // Stop the vehicle is estimated to arrive at
+	StopSequence   int         // The sequence of the stop in the trip
+	ArrivalTime    TimeISO8601 // Estimated time of arrival at the stop
+	DistanceMeters float64     // Distance left to travel along the trip's shape to get to the stop
+	ClientEstimate bool        // Always true, marks the arrival as estimated by this library instead of predicted by the MBTA
+}
+
+// ArrivalEstimator estimates arrival times for vehicles by projecting them onto their trip's shape and blending their recent speed with the scheduled time between stops
+type ArrivalEstimator struct {
+	client *Client
+
+	SpeedWeight      float64       // How much the vehicle's recent speed counts against the schedule (0-1) for a stop right in front of it. Defaults to 0.5
+	SpeedDecayMeters float64       // Distance over which the influence of the vehicle's recent speed halves. Defaults to 1000
+	MinSpeed         float64       // Speeds (meters per second) at or below this are treated as stopped and not used. Defaults to 0.5
+	SpeedWindow      time.Duration // How long a vehicle's position counts towards its recent speed. Older ones, and the ones of its previous trips, are forgotten. Defaults to 5 minutes
+
+	mu           sync.Mutex
+	observations map[string]vehicleObservation // The last position of each vehicle, by vehicle id
+	swept        time.Time                     // When observations were last swept of the ones older than the SpeedWindow
+}
+
+type vehicleObservation struct {
+	tripID    string
+	along     float64
+	updatedAt time.Time
+	speed     float64
+}
+
+// NewArrivalEstimator creates a new ArrivalEstimator that fetches trips, shapes and schedules using the given Client
+func NewArrivalEstimator(client *Client) *ArrivalEstimator {
+	return &ArrivalEstimator{
+		client:           client,
+		SpeedWeight:      defaultSpeedWeight,
+		SpeedDecayMeters: defaultSpeedDecayMeters,
+		MinSpeed:         defaultMinSpeed,
+		SpeedWindow:      defaultSpeedWindow,
+		observations:     make(map[string]vehicleObservation),
+	}
+}
+
+// EstimateArrivals estimates when the vehicle will arrive at each of the stops left on its trip
+func (e *ArrivalEstimator) EstimateArrivals(vehicle *Vehicle) ([]*ArrivalEstimate, error) {
+	return e.EstimateArrivalsWithContext(context.Background(), vehicle)
+}
+
+// EstimateArrivalsWithContext estimates when the vehicle will arrive at each of the stops left on its trip given a context
+func (e *ArrivalEstimator) EstimateArrivalsWithContext(ctx context.Context, vehicle *Vehicle) ([]*ArrivalEstimate, error) {
+	if vehicle == nil || vehicle.Trip == nil || vehicle.Trip.ID == "" {
+		return nil, ErrCannotEstimate
+	}
+
+	trip, _, err := e.client.Trips.GetTripWithContext(ctx, vehicle.Trip.ID, GetTripRequestConfig{Include: []TripInclude{TripIncludeShape}})
+	if err != nil {
+		return nil, err
+	}
+	if trip.Shape == nil {
+		return nil, ErrCannotEstimate
+	}
+
+	schedules, _, err := e.client.Schedules.GetAllSchedulesWithContext(ctx, &GetAllSchedulesRequestConfig{
+		Sort:          SchedulesSortByStopSequenceAscending,
+		Include:       []ScheduleInclude{ScheduleIncludeStop},
+		FilterTripIDs: []string{trip.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	estimates, err := e.Estimate(vehicle, trip.Shape, schedules)
+	for _, estimate := range estimates {
+		estimate.Trip = trip
+	}
+	return estimates, err
+}
+
+// Estimate estimates when the vehicle will arrive at each of the stops left on its trip using an already fetched shape and schedules.
+// The schedules must have their Stop included so that the stops can be placed on the shape
+func (e *ArrivalEstimator) Estimate(vehicle *Vehicle, shape *Shape, schedules []*Schedule) ([]*ArrivalEstimate, error) {
+	if vehicle == nil || vehicle.CurrentStopSequence == nil || shape == nil {
+		return nil, ErrCannotEstimate
+	}
+	points, err := shape.Points()
+	if err != nil {
+		return nil, err
+	}
+	if len(points) < 2 {
+		return nil, ErrCannotEstimate
+	}
+	path := newPolylinePath(points)
+
+	stops := make([]*Schedule, 0, len(schedules))
+	for _, schedule := range schedules {
+		if schedule.Stop != nil && (schedule.Stop.Latitude != 0 || schedule.Stop.Longitude != 0) {
+			stops = append(stops, schedule)
+		}
+	}
+	if len(stops) == 0 {
+		return nil, ErrCannotEstimate
+	}
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].StopSequence < stops[j].StopSequence })
+
+	// place the stops on the shape, always moving forward so that loops don't match the wrong side
+	stopAlong := make([]float64, len(stops))
+	prev := 0.0
+	for i, schedule := range stops {
+		stopAlong[i], _ = path.project(LatLng{Latitude: schedule.Stop.Latitude, Longitude: schedule.Stop.Longitude}, prev, path.length())
+		prev = stopAlong[i]
+	}
+
+	// the stop the vehicle is at or heading to
+	sequence := *vehicle.CurrentStopSequence
+	next := sort.Search(len(stops), func(i int) bool { return stops[i].StopSequence >= sequence })
+	var vehicleAlong float64
+	if vehicle.CurrentStatus == StoppedAt && next < len(stops) && stops[next].StopSequence == sequence {
+		vehicleAlong = stopAlong[next]
+		next++
+	} else {
+		minAlong, maxAlong := 0.0, path.length()
+		if next > 0 {
+			minAlong = stopAlong[next-1]
+		}
+		if next < len(stops) {
+			maxAlong = stopAlong[next]
+		}
+		vehicleAlong, _ = path.project(LatLng{Latitude: vehicle.Latitude, Longitude: vehicle.Longitude}, minAlong, maxAlong)
+	}
+
+	speed := e.recentSpeed(vehicle, vehicleAlong)
+	vehicleSchedTime, haveVehicleSchedTime := scheduledTimeAt(stops, stopAlong, vehicleAlong)
+
+	var estimates []*ArrivalEstimate
+	for i := next; i < len(stops); i++ {
+		remaining := math.Max(0, stopAlong[i]-vehicleAlong)
+
+		var travel float64
+		speedOK := speed > e.MinSpeed
+		stopSchedTime := scheduledTime(stops[i])
+		schedOK := haveVehicleSchedTime && !stopSchedTime.IsZero() && !stopSchedTime.Before(vehicleSchedTime)
+		switch {
+		case speedOK && schedOK:
+			weight := e.SpeedWeight * math.Pow(0.5, remaining/e.SpeedDecayMeters)
+			travel = weight*(remaining/speed) + (1-weight)*stopSchedTime.Sub(vehicleSchedTime).Seconds()
+		case speedOK:
+			travel = remaining / speed
+		case schedOK:
+			travel = stopSchedTime.Sub(vehicleSchedTime).Seconds()
+		default:
+			continue
+		}
+
+		estimates = append(estimates, &ArrivalEstimate{
+			Vehicle:        vehicle,
+			Trip:           vehicle.Trip,
+			Stop:           stops[i].Stop,
+			StopSequence:   stops[i].StopSequence,
+			ArrivalTime:    timeToTimeISO8601(vehicle.UpdatedAt.Time.Add(time.Duration(travel * float64(time.Second)))),
+			DistanceMeters: remaining,
+			ClientEstimate: true,
+		})
+	}
+	if len(estimates) == 0 && next < len(stops) {
+		return nil, ErrCannotEstimate
+	}
+	return estimates, nil
+}
+
+// recentSpeed smooths the vehicle's reported speed with the speed it has been moving along the shape since it was last seen
+func (e *ArrivalEstimator) recentSpeed(vehicle *Vehicle, along float64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.observations == nil {
+		e.observations = make(map[string]vehicleObservation)
+	}
+
+	speed := -1.0
+	if vehicle.Speed != nil {
+		speed = float64(*vehicle.Speed)
+	}
+
+	tripID := ""
+	if vehicle.Trip != nil {
+		tripID = vehicle.Trip.ID
+	}
+	e.forgetStale(vehicle.UpdatedAt.Time)
+	last, seen := e.observations[vehicle.ID]
+	if seen && (last.tripID != tripID || vehicle.UpdatedAt.Time.Sub(last.updatedAt) > e.SpeedWindow) {
+		// on another trip, or seen too long ago to tell how fast it's going now
+		delete(e.observations, vehicle.ID)
+		seen = false
+	}
+	if seen && vehicle.UpdatedAt.Time.After(last.updatedAt) {
+		observed := (along - last.along) / vehicle.UpdatedAt.Time.Sub(last.updatedAt).Seconds()
+		if observed >= 0 {
+			if speed < 0 {
+				speed = observed
+			} else {
+				speed = (speed + observed) / 2
+			}
+		}
+		if last.speed >= 0 && speed >= 0 {
+			speed = speedSmoothing*speed + (1-speedSmoothing)*last.speed
+		}
+	} else if seen {
+		// same (or older) position as before, nothing new to learn
+		return last.speed
+	}
+
+	e.observations[vehicle.ID] = vehicleObservation{tripID: tripID, along: along, updatedAt: vehicle.UpdatedAt.Time, speed: speed}
+	return speed
+}
+
+// forgetStale removes the observations older than the SpeedWindow, e.g. of vehicles that went out of service, at most
+// once per window. now is the time of the latest vehicle update
+func (e *ArrivalEstimator) forgetStale(now time.Time) {
+	if now.Sub(e.swept) < e.SpeedWindow {
+		return
+	}
+	for id, observation := range e.observations {
+		if now.Sub(observation.updatedAt) > e.SpeedWindow {
+			delete(e.observations, id)
+		}
+	}
+	e.swept = now
+}
+
+// scheduledTime the scheduled arrival time of a schedule, falling back to the departure time for the first stop of a trip
+func scheduledTime(schedule *Schedule) time.Time {
+	if schedule.ArrivalTime != nil {
+		return schedule.ArrivalTime.Time
+	}
+	if schedule.DepartureTime != nil {
+		return schedule.DepartureTime.Time
+	}
+	return time.Time{}
+}
+
+// scheduledTimeAt interpolates when the trip is scheduled to be at a distance along its shape
+func scheduledTimeAt(stops []*Schedule, stopAlong []float64, along float64) (time.Time, bool) {
+	for i := 1; i < len(stops); i++ {
+		if along > stopAlong[i] && i < len(stops)-1 {
+			continue
+		}
+		from, to := scheduledTime(stops[i-1]), scheduledTime(stops[i])
+		if from.IsZero() || to.IsZero() {
+			return time.Time{}, false
+		}
+		frac := 0.0
+		if span := stopAlong[i] - stopAlong[i-1]; span > 0 {
+			frac = math.Max(0, math.Min(1, (along-stopAlong[i-1])/span))
+		}
+		return from.Add(time.Duration(frac * float64(to.Sub(from)))), true
+	}
+	if len(stops) == 1 {
+		t := scheduledTime(stops[0])
+		return t, !t.IsZero()
+	}
+	return time.Time{}, false
+}
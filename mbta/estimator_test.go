@@ -0,0 +1,159 @@
+package mbta
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func estimatorTestData() (*Shape, []*Schedule) {
+	shape := &Shape{
+		ID: "test-shape",
+		Polyline: EncodePolyline([]LatLng{
+			{Latitude: 42.00, Longitude: -71.0},
+			{Latitude: 42.02, Longitude: -71.0},
+		}),
+	}
+	start := time.Date(2019, time.June, 3, 10, 0, 0, 0, time.UTC)
+	schedules := []*Schedule{
+		&Schedule{
+			DepartureTime: timeISO8601Ptr(start),
+			StopSequence:  1,
+			Stop:          &Stop{ID: "a", Latitude: 42.00, Longitude: -71.0},
+		},
+		&Schedule{
+			ArrivalTime:   timeISO8601Ptr(start.Add(5 * time.Minute)),
+			DepartureTime: timeISO8601Ptr(start.Add(5 * time.Minute)),
+			StopSequence:  2,
+			Stop:          &Stop{ID: "b", Latitude: 42.01, Longitude: -71.0},
+		},
+		&Schedule{
+			ArrivalTime:   timeISO8601Ptr(start.Add(10 * time.Minute)),
+			DepartureTime: timeISO8601Ptr(start.Add(10 * time.Minute)),
+			StopSequence:  3,
+			Stop:          &Stop{ID: "c", Latitude: 42.02, Longitude: -71.0},
+		},
+	}
+	return shape, schedules
+}
+
+func TestArrivalEstimator_Estimate(t *testing.T) {
+	shape, schedules := estimatorTestData()
+	updatedAt := time.Date(2019, time.June, 3, 10, 3, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		vehicle  *Vehicle
+		expected []time.Time
+		stops    []string
+	}{
+		{
+			name: "schedule only",
+			vehicle: &Vehicle{
+				ID: "v1", CurrentStatus: InTransitTo, CurrentStopSequence: intPtr(2),
+				Latitude: 42.005, Longitude: -71.0, UpdatedAt: timeToTimeISO8601(updatedAt),
+				Trip: &Trip{ID: "t1"},
+			},
+			// halfway between a and b is scheduled for 10:02:30, so the vehicle is running 30 seconds late
+			expected: []time.Time{updatedAt.Add(150 * time.Second), updatedAt.Add(450 * time.Second)},
+			stops:    []string{"b", "c"},
+		},
+		{
+			name: "speed and schedule",
+			vehicle: &Vehicle{
+				ID: "v2", CurrentStatus: InTransitTo, CurrentStopSequence: intPtr(2),
+				Latitude: 42.005, Longitude: -71.0, Speed: float32Ptr(5), UpdatedAt: timeToTimeISO8601(updatedAt),
+				Trip: &Trip{ID: "t1"},
+			},
+			expected: []time.Time{updatedAt.Add(136 * time.Second), updatedAt.Add(432 * time.Second)},
+			stops:    []string{"b", "c"},
+		},
+		{
+			name: "stopped at a stop",
+			vehicle: &Vehicle{
+				ID: "v3", CurrentStatus: StoppedAt, CurrentStopSequence: intPtr(2),
+				Latitude: 42.01, Longitude: -71.0, UpdatedAt: timeToTimeISO8601(updatedAt),
+				Trip: &Trip{ID: "t1"},
+			},
+			expected: []time.Time{updatedAt.Add(5 * time.Minute)},
+			stops:    []string{"c"},
+		},
+	}
+
+	for _, test := range tests {
+		estimator := NewArrivalEstimator(nil)
+		actual, err := estimator.Estimate(test.vehicle, shape, schedules)
+		ok(t, err)
+		equals(t, len(test.expected), len(actual))
+		for i, estimate := range actual {
+			equals(t, test.stops[i], estimate.Stop.ID)
+			assert(t, estimate.ClientEstimate, "%s: estimates must be flagged as client-side", test.name)
+			diff := estimate.ArrivalTime.Time.Sub(test.expected[i])
+			assert(t, math.Abs(diff.Seconds()) < 2, "%s: stop %s expected %v got %v", test.name, estimate.Stop.ID, test.expected[i], estimate.ArrivalTime.Time)
+		}
+	}
+}
+
+func TestArrivalEstimator_EstimateRecentSpeed(t *testing.T) {
+	shape, schedules := estimatorTestData()
+	estimator := NewArrivalEstimator(nil)
+	estimator.SpeedWeight = 1
+
+	first := time.Date(2019, time.June, 3, 10, 1, 0, 0, time.UTC)
+	vehicle := &Vehicle{
+		ID: "v1", CurrentStatus: InTransitTo, CurrentStopSequence: intPtr(2),
+		Latitude: 42.0025, Longitude: -71.0, UpdatedAt: timeToTimeISO8601(first),
+		Trip: &Trip{ID: "t1"},
+	}
+	_, err := estimator.Estimate(vehicle, shape, schedules)
+	ok(t, err)
+
+	// moved ~278m in 60 seconds without reporting a speed
+	vehicle = &Vehicle{
+		ID: "v1", CurrentStatus: InTransitTo, CurrentStopSequence: intPtr(2),
+		Latitude: 42.005, Longitude: -71.0, UpdatedAt: timeToTimeISO8601(first.Add(time.Minute)),
+		Trip: &Trip{ID: "t1"},
+	}
+	actual, err := estimator.Estimate(vehicle, shape, schedules)
+	ok(t, err)
+	assert(t, len(actual) == 2, "expected 2 estimates, got %d", len(actual))
+	assert(t, actual[0].DistanceMeters > 550 && actual[0].DistanceMeters < 560, "unexpected distance %v", actual[0].DistanceMeters)
+	// at full speed weight the first stop is ~556m away at ~4.6m/s, blended down only by distance decay
+	assert(t, actual[0].ArrivalTime.Time.Before(vehicle.UpdatedAt.Time.Add(150*time.Second)), "recent speed should pull the estimate in, got %v", actual[0].ArrivalTime.Time)
+}
+
+func TestArrivalEstimator_ForgetsObservations(t *testing.T) {
+	shape, schedules := estimatorTestData()
+	estimator := NewArrivalEstimator(nil)
+	first := time.Date(2019, time.June, 3, 10, 1, 0, 0, time.UTC)
+	estimate := func(id, tripID string, updatedAt time.Time) {
+		t.Helper()
+		_, err := estimator.Estimate(&Vehicle{
+			ID: id, CurrentStatus: InTransitTo, CurrentStopSequence: intPtr(2),
+			Latitude: 42.0025, Longitude: -71.0, UpdatedAt: timeToTimeISO8601(updatedAt),
+			Trip: &Trip{ID: tripID},
+		}, shape, schedules)
+		ok(t, err)
+	}
+
+	estimate("v1", "t1", first)
+	estimate("v2", "t1", first)
+	equals(t, 2, len(estimator.observations))
+
+	// on another trip, v1's last position is replaced rather than used
+	estimate("v1", "t2", first.Add(time.Minute))
+	equals(t, "t2", estimator.observations["v1"].tripID)
+
+	// v2 wasn't seen for longer than the window
+	estimate("v1", "t2", first.Add(time.Minute+estimator.SpeedWindow))
+	equals(t, 1, len(estimator.observations))
+	_, found := estimator.observations["v2"]
+	assert(t, !found, "expected v2 to be forgotten")
+}
+
+func TestArrivalEstimator_EstimateNoData(t *testing.T) {
+	shape, _ := estimatorTestData()
+	estimator := NewArrivalEstimator(nil)
+	_, err := estimator.Estimate(&Vehicle{ID: "v1"}, shape, nil)
+	equals(t, ErrCannotEstimate, err)
+}
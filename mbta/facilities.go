@@ -9,69 +9,50 @@ import (
 
 const facilitiesAPIPath = "/facilities"
 
-// FacilityService service handling all of the facility related API calls
+// FacilityService handling all of the facility related API calls
 // Note: This spec is not yet finalized by the MBTA, so this may change/break depending on what the MBTA does.
 type FacilityService service
 
-// FacilityType enum for the possible facility types
-type FacilityType string
+// Facility holds all the info about a given MBTA facility
+type Facility struct {
+	ID           string             `jsonapi:"primary,facility"`
+	Type         FacilityType       `jsonapi:"attr,type"`              // The type of the facility
+	ShortName    string             `jsonapi:"attr,short_name"`        // Short name of the facility
+	Properties   []FacilityProperty `jsonapi:"attr,properties"`        // Name/value pair for additional facility information
+	Name         string             `jsonapi:"attr,name"`              // Name of the facility
+	Longitude    *float64           `jsonapi:"attr,longitude"`         // Longitude of the facility. Degrees East, in the WGS-84 coordinate system
+	Latitude     *float64           `jsonapi:"attr,latitude"`          // Latitude of the facility. Degrees North, in the WGS-84 coordinate system
+	Stop         *Stop              `jsonapi:"relation,stop"`          // Stop that the current facility is linked with. Only includes id by default, use Include config option to get all data
+	LiveFacility *LiveFacility      `jsonapi:"relation,live_facility"` // Real-time properties of the facility, e.g. open parking spaces. Only set when included with FacilityIncludeLiveFacility
+	fieldPresence
+}
+
+// FacilityField an attribute or relationship of a facility, for a Fieldset
+type FacilityField string
 
 const (
-	FacilityBikeStorage                 FacilityType = "BIKE_STORAGE"
-	FacilityBridgePlate                 FacilityType = "BRIDGE_PLATE"
-	FacilityElectricCarChargers         FacilityType = "ELECTRIC_CAR_CHARGERS"
-	FacilityElevatedSubplatform         FacilityType = "ELEVATED_SUBPLATFORM"
-	FacilityElevator                    FacilityType = "ELEVATOR"
-	FacilityEscalator                   FacilityType = "ESCALATOR"
-	FacilityFareMediaAssistanceFacility FacilityType = "FARE_MEDIA_ASSISTANCE_FACILITY"
-	FacilityFareMediaAssistant          FacilityType = "FARE_MEDIA_ASSISTANT"
-	FacilityFareVendingMachine          FacilityType = "FARE_VENDING_MACHINE"
-	FacilityFareVendingRetailer         FacilityType = "FARE_VENDING_RETAILER"
-	FacilityFullyElevatedPlatform       FacilityType = "FULLY_ELEVATED_PLATFORM"
-	FacilityOther                       FacilityType = "OTHER"
-	FacilityParkingArea                 FacilityType = "PARKING_AREA"
-	FacilityPickDrop                    FacilityType = "PICK_DROP"
-	FacilityPortableBoardingLift        FacilityType = "PORTABLE_BOARDING_LIFT"
-	FacilityRamp                        FacilityType = "RAMP"
-	FacilityTaxiStand                   FacilityType = "TAXI_STAND"
-	FacilityTicketWindow                FacilityType = "TICKET_WINDOW"
+	FacilityFieldType         FacilityField = "type"
+	FacilityFieldShortName    FacilityField = "short_name"
+	FacilityFieldProperties   FacilityField = "properties"
+	FacilityFieldName         FacilityField = "name"
+	FacilityFieldLongitude    FacilityField = "longitude"
+	FacilityFieldLatitude     FacilityField = "latitude"
+	FacilityFieldStop         FacilityField = "stop"
+	FacilityFieldLiveFacility FacilityField = "live_facility"
 )
 
-// FacilityProperty Name/value pair for additional facility information
-type FacilityProperty struct {
-	Name  string `json:"name"`  // The name of the property
-	Value string `json:"value"` // The value of the property
-}
-
-// UnmarshalJSON unmarshal into FacilityProperty
-func (f *FacilityProperty) UnmarshalJSON(b []byte) error {
-	var tmp struct {
-		Name  string      `json:"name"`
-		Value json.Number `json:"value"` // could be num or string
-	}
-	json.Unmarshal(b, &tmp)
-	f.Name = tmp.Name
-	f.Value = tmp.Value.String()
-	return nil
-}
+func (f FacilityField) resourceType() string { return "facility" }
+func (f FacilityField) fieldName() string    { return string(f) }
 
-// Facility holds all info about a given MBTA Facility
-type Facility struct {
-	ID         string             `jsonapi:"primary,facility"`
-	Type       FacilityType       `jsonapi:"attr,type"`       // The type of the facility
-	ShortName  string             `jsonapi:"attr,short_name"` // Short name of the facility
-	Properties []FacilityProperty `jsonapi:"attr,properties"` // Name/value pair for additional facility information
-	Name       string             `jsonapi:"attr,name"`       // Name of the facility
-	Longitude  *float64           `jsonapi:"attr,longitude"`  // Longitude of the facility. Degrees East, in the WGS-84 coordinate system
-	Latitude   *float64           `jsonapi:"attr,latitude"`   // Latitude of the facility. Degrees North, in the WGS-84 coordinate system
-	Stop       *Stop              `jsonapi:"relation,stop"`   // Stop that the current facility is linked with. Only includes id by default, use Include config option to get all data
-}
+// Has whether the facility has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (f *Facility) Has(field FacilityField) bool { return f.has(string(field)) }
 
 // FacilityInclude all of the includes for a facility request
 type FacilityInclude string
 
 const (
-	FacilityIncludeStop FacilityInclude = includeStop
+	FacilityIncludeStop         FacilityInclude = "stop"
+	FacilityIncludeLiveFacility FacilityInclude = "live_facility"
 )
 
 // FacilitiesSortByType all of the possible ways to sort by for a GetAllFacilities request
@@ -96,9 +77,10 @@ const (
 type GetAllFacilitiesRequestConfig struct {
 	PageOffset    string               `url:"page[offset],omitempty"`           // Offset (0-based) of first element in the page
 	PageLimit     string               `url:"page[limit],omitempty"`            // Max number of elements to return
-	Sort          FacilitiesSortByType `url:"sort,omitempty"`                   // Results can be sorted by the id or any StopsSortByType
-	Fields        []string             `url:"fields[facility],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include       []FacilityInclude    `url:"include,comma,omitempty"`          // Include extra data in response
+	Sort          FacilitiesSortByType `url:"sort,omitempty"`                   // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields        []string             `url:"fields[facility],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset      Fieldset             `url:"fields,omitempty"`                 // Fields to include for each resource type, included ones too. Replaces Fields for facility if it has an entry for it
+	Include       []FacilityInclude    `url:"include,comma,omitempty"`          // Relationships to include
 	FilterStopIDs []string             `url:"filter[stop],comma,omitempty"`     // Filter by stop ID
 	FilterTypes   []string             `url:"filter[type],comma,omitempty"`     // Filter by multiple types
 }
@@ -120,18 +102,19 @@ func (s *FacilityService) GetAllFacilitiesWithContext(ctx context.Context, confi
 	}
 	req = req.WithContext(ctx)
 
-	untypedFacilities, resp, err := s.client.doManyPayload(req, &Facility{})
-	facilities := make([]*Facility, len(untypedFacilities))
-	for i := 0; i < len(untypedFacilities); i++ {
-		facilities[i] = untypedFacilities[i].(*Facility)
+	untyped, resp, err := s.client.doManyPayload(req, &Facility{})
+	facilities := make([]*Facility, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		facilities[i] = untyped[i].(*Facility)
 	}
 	return facilities, resp, err
 }
 
 // GetFacilityRequestConfig extra options for the GetFacility request
 type GetFacilityRequestConfig struct {
-	Fields  []string      `url:"fields[facility],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []StopInclude `url:"include,comma,omitempty"`          // Include extra data in response (parentstation)
+	Fields   []string          `url:"fields[facility],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset          `url:"fields,omitempty"`                 // Fields to include for each resource type, included ones too. Replaces Fields for facility if it has an entry for it
+	Include  []FacilityInclude `url:"include,comma,omitempty"`          // Relationships to include
 }
 
 // GetFacility returns a facility from the mbta API
@@ -160,3 +143,72 @@ func (s *FacilityService) GetFacilityWithContext(ctx context.Context, id string,
 	resp, err := s.client.doSinglePayload(req, &facility)
 	return &facility, resp, err
 }
+
+// FacilityType enum for the possible facility types
+type FacilityType string
+
+const (
+	FacilityBikeStorage                 FacilityType = "BIKE_STORAGE"
+	FacilityBridgePlate                 FacilityType = "BRIDGE_PLATE"
+	FacilityElectricCarChargers         FacilityType = "ELECTRIC_CAR_CHARGERS"
+	FacilityElevatedSubplatform         FacilityType = "ELEVATED_SUBPLATFORM"
+	FacilityElevator                    FacilityType = "ELEVATOR"
+	FacilityEscalator                   FacilityType = "ESCALATOR"
+	FacilityFareMediaAssistanceFacility FacilityType = "FARE_MEDIA_ASSISTANCE_FACILITY"
+	FacilityFareMediaAssistant          FacilityType = "FARE_MEDIA_ASSISTANT"
+	FacilityFareVendingMachine          FacilityType = "FARE_VENDING_MACHINE"
+	FacilityFareVendingRetailer         FacilityType = "FARE_VENDING_RETAILER"
+	FacilityFullyElevatedPlatform       FacilityType = "FULLY_ELEVATED_PLATFORM"
+	FacilityOther                       FacilityType = "OTHER"
+	FacilityParkingArea                 FacilityType = "PARKING_AREA"
+	FacilityPickDrop                    FacilityType = "PICK_DROP"
+	FacilityPortableBoardingLift        FacilityType = "PORTABLE_BOARDING_LIFT"
+	FacilityRamp                        FacilityType = "RAMP"
+	FacilityTaxiStand                   FacilityType = "TAXI_STAND"
+	FacilityTicketWindow                FacilityType = "TICKET_WINDOW"
+)
+
+// FacilityProperty Name/value pair for additional facility information
+type FacilityProperty struct {
+	Name  string `json:"name"`  // The name of the property
+	Value string `json:"value"` // The value of the property
+}
+
+// UnmarshalJSON unmarshal into FacilityProperty. Values can be strings or numbers (and in theory any JSON value);
+// strings are kept as is, null becomes "" and anything else is kept as its JSON text
+func (f *FacilityProperty) UnmarshalJSON(b []byte) error {
+	var tmp struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(b, &tmp); err != nil {
+		return err
+	}
+	f.Name = tmp.Name
+	f.Value = ""
+	if len(tmp.Value) == 0 || string(tmp.Value) == "null" {
+		return nil
+	}
+	if tmp.Value[0] == '"' {
+		return json.Unmarshal(tmp.Value, &f.Value)
+	}
+	f.Value = string(tmp.Value)
+	return nil
+}
+
+// MarshalJSON the reverse of UnmarshalJSON: a Value that is JSON text other than a string, e.g. a number, is written
+// as is and anything else as a string. So a string value that looks like a number, e.g. "2", comes back as a number
+func (f *FacilityProperty) MarshalJSON() ([]byte, error) {
+	value := json.RawMessage(f.Value)
+	if f.Value == "" || f.Value[0] == '"' || !json.Valid(value) {
+		b, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = b
+	}
+	return json.Marshal(struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}{f.Name, value})
+}
@@ -32,9 +32,9 @@ func TestGetFacility(t *testing.T) {
 				Value: "781-455-7500",
 			},
 		},
-		Stop: &Stop{
-			ID: "place-NB-0127",
-		},
+		Stop: &Stop{ID: "place-NB-0127", fieldPresence: presentFields()},
+
+		fieldPresence: missingFields(FacilityFieldLiveFacility),
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", facilitiesAPIPath, "park-NB-0127")))
 	defer server.Close()
@@ -66,9 +66,9 @@ func TestGetAllFacilities(t *testing.T) {
 					Value: "23151",
 				},
 			},
-			Stop: &Stop{
-				ID: "place-portr",
-			},
+			Stop: &Stop{ID: "place-portr", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(FacilityFieldLiveFacility),
 		},
 		&Facility{
 			ID:        "retailsale-302029",
@@ -88,6 +88,8 @@ func TestGetAllFacilities(t *testing.T) {
 				},
 			},
 			Stop: nil,
+
+			fieldPresence: missingFields(FacilityFieldLiveFacility),
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, facilitiesAPIPath))
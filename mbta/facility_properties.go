@@ -0,0 +1,185 @@
+package mbta
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// FacilityPropertyFlag enum for the yes/no facility properties, e.g. whether a parking area is enclosed or attended
+type FacilityPropertyFlag int
+
+const (
+	// FacilityPropertyNoInfo No information
+	FacilityPropertyNoInfo FacilityPropertyFlag = iota
+	// FacilityPropertyYes Yes
+	FacilityPropertyYes
+	// FacilityPropertyNo No
+	FacilityPropertyNo
+)
+
+// Property returns the first value of the property with the given name
+func (f *Facility) Property(name string) (string, bool) {
+	for _, p := range f.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// PropertyValues returns every value of a property that can repeat (e.g. payment-form-accepted), in order
+func (f *Facility) PropertyValues(name string) []string {
+	var values []string
+	for _, p := range f.Properties {
+		if p.Name == name {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+// ParkingAreaProperties typed properties of a FacilityParkingArea
+type ParkingAreaProperties struct {
+	Capacity           int                  // Number of spaces
+	CapacityAccessible int                  // Number of accessible spaces
+	Enclosed           FacilityPropertyFlag // Whether the parking area is a garage
+	Attended           FacilityPropertyFlag // Whether the parking area is staffed
+	OvernightAllowed   string               // Whether and how overnight parking is allowed
+	FeeHourly          string               // Hourly rate, as written by the MBTA (e.g. "$2.00")
+	FeeDaily           string               // Daily rate, as written by the MBTA (e.g. "$9.00")
+	FeeMonthly         string               // Monthly permit rate, as written by the MBTA
+	PaymentForms       []string             // Accepted forms of payment (e.g. cash, credit-debit-card, mobile-app)
+	PaymentApps        []string             // Names of the apps that can be used to pay
+	PaymentAppIDs      []string             // Lot ids to enter in the payment apps
+	PaymentAppURL      string               // Where to get the payment app
+	Owner              string
+	Operator           string
+	Contact            string
+	ContactPhone       string
+	ContactURL         string
+}
+
+// ParkingArea returns the typed properties of a parking area facility
+func (f *Facility) ParkingArea() (*ParkingAreaProperties, error) {
+	if err := f.checkType(FacilityParkingArea); err != nil {
+		return nil, err
+	}
+	p := &ParkingAreaProperties{
+		OvernightAllowed: f.stringProperty("overnight-allowed"),
+		FeeHourly:        f.stringProperty("fee-hourly"),
+		FeeDaily:         f.stringProperty("fee-daily"),
+		FeeMonthly:       f.stringProperty("fee-monthly"),
+		PaymentForms:     f.PropertyValues("payment-form-accepted"),
+		PaymentApps:      f.PropertyValues("payment-app"),
+		PaymentAppIDs:    f.PropertyValues("payment-app-id"),
+		PaymentAppURL:    f.stringProperty("payment-app-url"),
+		Owner:            f.stringProperty("owner"),
+		Operator:         f.stringProperty("operator"),
+		Contact:          f.stringProperty("contact"),
+		ContactPhone:     f.stringProperty("contact-phone"),
+		ContactURL:       f.stringProperty("contact-url"),
+	}
+	var err error
+	if p.Capacity, err = f.intProperty("capacity"); err != nil {
+		return nil, err
+	}
+	if p.CapacityAccessible, err = f.intProperty("capacity-accessible"); err != nil {
+		return nil, err
+	}
+	if p.Enclosed, err = f.flagProperty("enclosed"); err != nil {
+		return nil, err
+	}
+	if p.Attended, err = f.flagProperty("attended"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// BikeStorageProperties typed properties of a FacilityBikeStorage
+type BikeStorageProperties struct {
+	Capacity int                  // Number of bikes that can be stored
+	Enclosed FacilityPropertyFlag // Whether the storage is covered
+	Secured  FacilityPropertyFlag // Whether the storage is locked, e.g. a Pedal & Park cage
+	Owner    string
+	Operator string
+}
+
+// BikeStorage returns the typed properties of a bike storage facility
+func (f *Facility) BikeStorage() (*BikeStorageProperties, error) {
+	if err := f.checkType(FacilityBikeStorage); err != nil {
+		return nil, err
+	}
+	p := &BikeStorageProperties{
+		Owner:    f.stringProperty("owner"),
+		Operator: f.stringProperty("operator"),
+	}
+	var err error
+	if p.Capacity, err = f.intProperty("capacity"); err != nil {
+		return nil, err
+	}
+	if p.Enclosed, err = f.flagProperty("enclosed"); err != nil {
+		return nil, err
+	}
+	if p.Secured, err = f.flagProperty("secured"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ElevatorProperties typed properties of a FacilityElevator or FacilityEscalator
+type ElevatorProperties struct {
+	AlternateServiceText string   // What to do when it's out of service
+	ExcludesStops        []string // Child stops of the station that it doesn't reach
+}
+
+// Elevator returns the typed properties of an elevator or escalator facility
+func (f *Facility) Elevator() (*ElevatorProperties, error) {
+	if err := f.checkType(FacilityElevator, FacilityEscalator); err != nil {
+		return nil, err
+	}
+	return &ElevatorProperties{
+		AlternateServiceText: f.stringProperty("alternate-service-text"),
+		ExcludesStops:        f.PropertyValues("excludes-stop"),
+	}, nil
+}
+
+func (f *Facility) checkType(types ...FacilityType) error {
+	for _, t := range types {
+		if f.Type == t {
+			return nil
+		}
+	}
+	return xerrors.Errorf("facility %s is a %s: %w", f.ID, f.Type, ErrWrongFacilityType)
+}
+
+func (f *Facility) stringProperty(name string) string {
+	value, _ := f.Property(name)
+	return value
+}
+
+// intProperty 0 when the property is missing
+func (f *Facility) intProperty(name string) (int, error) {
+	value, ok := f.Property(name)
+	if !ok || value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, xerrors.Errorf("facility %s property %s is %q, not a number: %w", f.ID, name, value, ErrInvalidFacilityProperty)
+	}
+	return i, nil
+}
+
+// flagProperty FacilityPropertyNoInfo when the property is missing
+func (f *Facility) flagProperty(name string) (FacilityPropertyFlag, error) {
+	i, err := f.intProperty(name)
+	if err != nil {
+		return FacilityPropertyNoInfo, err
+	}
+	if i < int(FacilityPropertyNoInfo) || i > int(FacilityPropertyNo) {
+		return FacilityPropertyNoInfo, xerrors.Errorf("facility %s property %s is %d, not 0, 1 or 2: %w", f.ID, name, i, ErrInvalidFacilityProperty)
+	}
+	return FacilityPropertyFlag(i), nil
+}
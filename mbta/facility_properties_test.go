@@ -0,0 +1,141 @@
+package mbta
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestFacilityProperty_UnmarshalJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"name":"capacity","value":35}`:               "35",
+		`{"name":"capacity","value":"35"}`:             "35",
+		`{"name":"contact","value":"Town of Needham"}`: "Town of Needham",
+		`{"name":"fee","value":2.5}`:                   "2.5",
+		`{"name":"enclosed","value":true}`:             "true",
+		`{"name":"note","value":null}`:                 "",
+		`{"name":"note"}`:                              "",
+		`{"name":"list","value":["a","b"]}`:            `["a","b"]`,
+	}
+	for input, expected := range tests {
+		var p FacilityProperty
+		ok(t, json.Unmarshal([]byte(input), &p))
+		equals(t, expected, p.Value)
+	}
+
+	var p FacilityProperty
+	assert(t, json.Unmarshal([]byte(`{"name":1}`), &p) != nil, "expected an error for a numeric name")
+}
+
+func TestFacilityProperty_MarshalJSON(t *testing.T) {
+	tests := map[string]string{
+		"35":              `{"name":"p","value":35}`,
+		"2.5":             `{"name":"p","value":2.5}`,
+		"true":            `{"name":"p","value":true}`,
+		`["a","b"]`:       `{"name":"p","value":["a","b"]}`,
+		"Town of Needham": `{"name":"p","value":"Town of Needham"}`,
+		"781-455-7500":    `{"name":"p","value":"781-455-7500"}`,
+		`"quoted"`:        `{"name":"p","value":"\"quoted\""}`,
+		"":                `{"name":"p","value":""}`,
+	}
+	for value, expected := range tests {
+		b, err := json.Marshal(&FacilityProperty{Name: "p", Value: value})
+		ok(t, err)
+		equals(t, expected, string(b))
+	}
+}
+
+func TestFacility_ParkingArea(t *testing.T) {
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", facilitiesAPIPath, "park-NB-0127")))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	facility, _, err := mbtaClient.Facilities.GetFacility("park-NB-0127", &GetFacilityRequestConfig{})
+	ok(t, err)
+	parking, err := facility.ParkingArea()
+	ok(t, err)
+	equals(t, &ParkingAreaProperties{
+		Capacity:     35,
+		Attended:     FacilityPropertyNo,
+		Contact:      "Town of Needham, Parking Clerk",
+		ContactPhone: "781-455-7500",
+	}, parking)
+
+	_, err = facility.BikeStorage()
+	equals(t, true, xerrors.Is(err, ErrWrongFacilityType))
+}
+
+func TestFacility_PropertyValues(t *testing.T) {
+	facility := &Facility{
+		ID:   "park-1",
+		Type: FacilityParkingArea,
+		Properties: []FacilityProperty{
+			{Name: "capacity", Value: "100"},
+			{Name: "payment-form-accepted", Value: "cash"},
+			{Name: "payment-app", Value: "ParkMobile"},
+			{Name: "payment-form-accepted", Value: "mobile-app"},
+			{Name: "payment-app-id", Value: "1234"},
+			{Name: "fee-daily", Value: "$9.00"},
+			{Name: "enclosed", Value: "1"},
+		},
+	}
+	value, found := facility.Property("payment-form-accepted")
+	equals(t, true, found)
+	equals(t, "cash", value)
+	_, found = facility.Property("owner")
+	equals(t, false, found)
+	equals(t, []string{"cash", "mobile-app"}, facility.PropertyValues("payment-form-accepted"))
+
+	parking, err := facility.ParkingArea()
+	ok(t, err)
+	equals(t, 100, parking.Capacity)
+	equals(t, FacilityPropertyYes, parking.Enclosed)
+	equals(t, FacilityPropertyNoInfo, parking.Attended)
+	equals(t, []string{"ParkMobile"}, parking.PaymentApps)
+	equals(t, []string{"1234"}, parking.PaymentAppIDs)
+	equals(t, "$9.00", parking.FeeDaily)
+
+	facility.Properties = append(facility.Properties, FacilityProperty{Name: "capacity-accessible", Value: "a few"})
+	_, err = facility.ParkingArea()
+	equals(t, true, xerrors.Is(err, ErrInvalidFacilityProperty))
+}
+
+func TestFacility_BikeStorage(t *testing.T) {
+	facility := &Facility{
+		ID:         "bike-1",
+		Type:       FacilityBikeStorage,
+		Properties: []FacilityProperty{{Name: "capacity", Value: "40"}, {Name: "enclosed", Value: "2"}, {Name: "secured", Value: "7"}},
+	}
+	_, err := facility.BikeStorage()
+	equals(t, true, xerrors.Is(err, ErrInvalidFacilityProperty))
+
+	facility.Properties[2].Value = "1"
+	bikes, err := facility.BikeStorage()
+	ok(t, err)
+	equals(t, &BikeStorageProperties{Capacity: 40, Enclosed: FacilityPropertyNo, Secured: FacilityPropertyYes}, bikes)
+}
+
+func TestFacility_Elevator(t *testing.T) {
+	server := httptest.NewServer(handlerForServer(t, facilitiesAPIPath))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	facilities, _, err := mbtaClient.Facilities.GetAllFacilities(&GetAllFacilitiesRequestConfig{})
+	ok(t, err)
+	elevator, err := facilities[0].Elevator()
+	ok(t, err)
+	equals(t, &ElevatorProperties{
+		AlternateServiceText: "See station personnel or use the call box to request assistance.",
+		ExcludesStops:        []string{"23151"},
+	}, elevator)
+
+	_, err = facilities[1].Elevator()
+	equals(t, true, xerrors.Is(err, ErrWrongFacilityType))
+}
@@ -0,0 +1,89 @@
+package mbta
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Field an attribute or relationship of a resource type, for a Fieldset. The <Model>Field constants, e.g. StopFieldName
+// or TripFieldHeadsign, are the only Fields
+type Field interface {
+	resourceType() string
+	fieldName() string
+}
+
+// Fieldset the fields the API returns for each resource type, for the primary data and included resources alike
+// (JSON:API sparse fieldsets, the fields[TYPE] parameters). Types without an entry get all of their fields.
+// The other fields of a decoded resource are nil or zero. The models' Has methods, e.g. Stop.Has, tell them apart
+// from fields the resource has that are null or zero
+type Fieldset map[string][]string
+
+// NewFieldset makes a Fieldset of the given fields, which can be of different resource types
+func NewFieldset(fields ...Field) Fieldset {
+	f := make(Fieldset)
+	for _, field := range fields {
+		f.Add(field)
+	}
+	return f
+}
+
+// Add adds a field to the fields of its resource type
+func (f Fieldset) Add(field Field) {
+	t := field.resourceType()
+	for _, name := range f[t] {
+		if name == field.fieldName() {
+			return
+		}
+	}
+	f[t] = append(f[t], field.fieldName())
+}
+
+// Requested whether the Fieldset asks for the field, i.e. whether the API sends it. A request's Fields option isn't
+// part of the Fieldset and isn't taken into account
+func (f Fieldset) Requested(field Field) bool {
+	names, ok := f[field.resourceType()]
+	if !ok {
+		return true
+	}
+	for _, name := range names {
+		if name == field.fieldName() {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldPresence which fields a model's resource had in the response the model was decoded from, for the models' Has
+// methods. The API leaves out the fields a Fieldset doesn't request, and all but the id of related resources that
+// weren't included
+type fieldPresence struct {
+	present     map[string]bool // nil when the resource had all of the model's fields, or the model wasn't decoded
+	withoutData map[string]bool // The to-one relationships it had without data, which decode like null ones
+}
+
+func (p *fieldPresence) has(name string) bool {
+	return p.present == nil || p.present[name]
+}
+
+// hasData whether the relationship had data, for MarshalPayload
+func (p *fieldPresence) hasData(name string) bool {
+	return !p.withoutData[name]
+}
+
+func (p *fieldPresence) setPresent(present, withoutData map[string]bool) {
+	p.present, p.withoutData = present, withoutData
+}
+
+// EncodeValues sets the fields[TYPE] parameter of every type in the Fieldset, so that it can be a request config field
+func (f Fieldset) EncodeValues(_ string, v *url.Values) error {
+	types := make([]string, 0, len(f))
+	for t := range f {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		v.Set("fields["+t+"]", strings.Join(f[t], ","))
+	}
+	return nil
+}
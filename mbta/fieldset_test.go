@@ -0,0 +1,50 @@
+package mbta
+
+import (
+	"testing"
+)
+
+func Test_NewFieldset(t *testing.T) {
+	fieldset := NewFieldset(VehicleFieldLatitude, VehicleFieldTrip, TripFieldHeadsign, VehicleFieldLatitude)
+	equals(t, Fieldset{"vehicle": {"latitude", "trip"}, "trip": {"headsign"}}, fieldset)
+
+	assert(t, fieldset.Requested(VehicleFieldLatitude), "latitude was requested")
+	assert(t, fieldset.Requested(TripFieldHeadsign), "headsign was requested")
+	assert(t, !fieldset.Requested(VehicleFieldBearing), "bearing wasn't requested")
+	assert(t, !fieldset.Requested(TripFieldRoute), "the trip's route wasn't requested")
+	assert(t, fieldset.Requested(StopFieldName), "stops have all their fields")
+
+	fieldset.Add(TripFieldRoute)
+	assert(t, fieldset.Requested(TripFieldRoute), "the trip's route was added")
+}
+
+func Test_ModelHas(t *testing.T) {
+	stop := &Stop{ID: "70067", ParentStation: &Stop{ID: "place-harsq", fieldPresence: presentFields()}}
+	assert(t, stop.Has(StopFieldName), "a model that wasn't decoded has all of its fields")
+	assert(t, !stop.ParentStation.Has(StopFieldName), "a related resource that wasn't included has only its id")
+
+	stop.fieldPresence = missingFields(StopFieldWheelchairBoarding)
+	assert(t, stop.Has(StopFieldParentStation), "the relationship was sent")
+	assert(t, !stop.Has(StopFieldWheelchairBoarding), "the attribute wasn't sent")
+}
+
+func Test_FieldsetQuery(t *testing.T) {
+	config := &GetAllVehiclesRequestConfig{
+		Fields:   []string{"label"},
+		Fieldset: NewFieldset(VehicleFieldLatitude, VehicleFieldTrip, TripFieldHeadsign),
+		Include:  []VehicleInclude{VehicleIncludeTrip},
+	}
+	path, err := addOptions(vehiclesAPIPath, config)
+	ok(t, err)
+	equals(t, vehiclesAPIPath+"?fields%5Btrip%5D=headsign&fields%5Bvehicle%5D=latitude%2Ctrip&include=trip", path)
+
+	// an empty entry leaves trips with just their ids, and without a vehicle entry Fields still applies
+	config.Fieldset = Fieldset{"trip": {}}
+	path, err = addOptions(vehiclesAPIPath, config)
+	ok(t, err)
+	equals(t, vehiclesAPIPath+"?fields%5Btrip%5D=&fields%5Bvehicle%5D=label&include=trip", path)
+
+	path, err = addOptions(vehiclesAPIPath, &GetVehicleRequestConfig{})
+	ok(t, err)
+	equals(t, vehiclesAPIPath, path)
+}
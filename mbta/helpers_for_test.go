@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 var testDataPath = "testdata"
@@ -68,3 +69,64 @@ func strPtr(s string) *string {
 func float64Ptr(f float64) *float64 {
 	return &f
 }
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func routeTypePtr(r RouteType) *RouteType {
+	return &r
+}
+
+func timeISO8601Ptr(t time.Time) *TimeISO8601 {
+	return &TimeISO8601{Time: t}
+}
+
+func scheduleRelationshipPtr(r PredictionScheduleRelationshipType) *PredictionScheduleRelationshipType {
+	return &r
+}
+
+func occupancyStatusPtr(o OccupancyStatus) *OccupancyStatus {
+	return &o
+}
+
+// presentFields the fieldPresence of a model decoded from a resource that had only the given fields, e.g. none for
+// a related resource that wasn't included
+func presentFields(fields ...Field) fieldPresence {
+	present := make(map[string]bool)
+	for _, field := range fields {
+		present[field.fieldName()] = true
+	}
+	return fieldPresence{present: present}
+}
+
+// missingFields the fieldPresence of a model decoded from a resource that had all but the given fields, which are of
+// one resource type
+func missingFields(fields ...Field) fieldPresence {
+	missing := make(map[string]bool)
+	for _, field := range fields {
+		missing[field.fieldName()] = true
+	}
+	present := make(map[string]bool)
+	t := payloadModelTypes[fields[0].resourceType()].Elem()
+	for i := 0; i < t.NumField(); i++ {
+		if kind, name := jsonapiTag(t.Field(i)); (kind == "attr" || kind == "relation") && !missing[name] {
+			present[name] = true
+		}
+	}
+	return fieldPresence{present: present}
+}
+
+// withoutData the fieldPresence of a model decoded from a resource that had all of its fields, and the given to-one
+// relationships without data
+func withoutData(fields ...Field) fieldPresence {
+	relationships := make(map[string]bool)
+	for _, field := range fields {
+		relationships[field.fieldName()] = true
+	}
+	return fieldPresence{withoutData: relationships}
+}
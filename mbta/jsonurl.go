@@ -1,6 +1,7 @@
 package mbta
 
 import (
+	"encoding/json"
 	"net/url"
 	"strings"
 )
@@ -19,3 +20,11 @@ func (j *JSONURL) UnmarshalJSON(b []byte) error {
 	}
 	return err
 }
+
+// MarshalJSON marshals the url.URL as a JSON string, or null if there is none
+func (j *JSONURL) MarshalJSON() ([]byte, error) {
+	if j.URL == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(j.URL.String())
+}
@@ -1,6 +1,7 @@
 package mbta
 
 import (
+	"encoding/json"
 	"net/url"
 	"testing"
 )
@@ -28,3 +29,14 @@ func TestJSONURL_Unmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONURL_Marshal(t *testing.T) {
+	googleURL, _ := url.Parse("http://www.google.com/search?q=mbta")
+	b, err := json.Marshal(&JSONURL{URL: googleURL})
+	ok(t, err)
+	equals(t, `"http://www.google.com/search?q=mbta"`, string(b))
+
+	b, err = json.Marshal(&JSONURL{})
+	ok(t, err)
+	equals(t, "null", string(b))
+}
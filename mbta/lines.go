@@ -8,10 +8,10 @@ import (
 
 const linesAPIPath = "/lines"
 
-// LineService handling all of the route related API calls
+// LineService handling all of the line related API calls
 type LineService service
 
-// Line holds all the info about a given MBTA Route
+// Line holds all the info about a given MBTA line
 type Line struct {
 	ID        string   `jsonapi:"primary,line"`
 	Color     string   `jsonapi:"attr,color"`
@@ -19,13 +19,33 @@ type Line struct {
 	ShortName string   `jsonapi:"attr,short_name"`
 	SortOrder int      `jsonapi:"attr,sort_order"`
 	TextColor string   `jsonapi:"attr,text_color"`
-	Routes    []*Route `jsonapi:"relation,route"`
+	Routes    []*Route `jsonapi:"relation,routes"`
+	fieldPresence
 }
 
+// LineField an attribute or relationship of a line, for a Fieldset
+type LineField string
+
+const (
+	LineFieldColor     LineField = "color"
+	LineFieldLongName  LineField = "long_name"
+	LineFieldShortName LineField = "short_name"
+	LineFieldSortOrder LineField = "sort_order"
+	LineFieldTextColor LineField = "text_color"
+	LineFieldRoutes    LineField = "routes"
+)
+
+func (f LineField) resourceType() string { return "line" }
+func (f LineField) fieldName() string    { return string(f) }
+
+// Has whether the line has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (l *Line) Has(field LineField) bool { return l.has(string(field)) }
+
+// LineInclude all of the includes for a line request
 type LineInclude string
 
 const (
-	LineIncludeRoutes LineInclude = includeRoutes
+	LineIncludeRoutes LineInclude = "routes"
 )
 
 // LinesSortByType all of the possible ways to sort by for a GetAllLines request
@@ -34,7 +54,7 @@ type LinesSortByType string
 const (
 	LinesSortByColorAscending      LinesSortByType = "color"
 	LinesSortByColorDescending     LinesSortByType = "-color"
-	LinesSortByLongNameDesending   LinesSortByType = "long_name"
+	LinesSortByLongNameAscending   LinesSortByType = "long_name"
 	LinesSortByLongNameDescending  LinesSortByType = "-long_name"
 	LinesSortByShortNameAscending  LinesSortByType = "short_name"
 	LinesSortByShortNameDescending LinesSortByType = "-short_name"
@@ -48,9 +68,10 @@ const (
 type GetAllLinesRequestConfig struct {
 	PageOffset string          `url:"page[offset],omitempty"`       // Offset (0-based) of first element in the page
 	PageLimit  string          `url:"page[limit],omitempty"`        // Max number of elements to return
-	Sort       LinesSortByType `url:"sort,omitempty"`               // Results can be sorted by the id or any RoutesSortByType
-	Fields     []string        `url:"fields[line],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include    []LineInclude   `url:"include,comma,omitempty"`      // Include extra data in response
+	Sort       LinesSortByType `url:"sort,omitempty"`               // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields     []string        `url:"fields[line],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset   Fieldset        `url:"fields,omitempty"`             // Fields to include for each resource type, included ones too. Replaces Fields for line if it has an entry for it
+	Include    []LineInclude   `url:"include,comma,omitempty"`      // Relationships to include
 	FilterIDs  []string        `url:"filter[id],comma,omitempty"`   // Filter by multiple IDs
 }
 
@@ -71,28 +92,32 @@ func (s *LineService) GetAllLinesWithContext(ctx context.Context, config *GetAll
 	}
 	req = req.WithContext(ctx)
 
-	untypedLines, resp, err := s.client.doManyPayload(req, &Line{})
-	lines := make([]*Line, len(untypedLines))
-	for i := 0; i < len(untypedLines); i++ {
-		lines[i] = untypedLines[i].(*Line)
+	untyped, resp, err := s.client.doManyPayload(req, &Line{})
+	lines := make([]*Line, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		lines[i] = untyped[i].(*Line)
 	}
-
 	return lines, resp, err
 }
 
 // GetLineRequestConfig extra options for the GetLine request
 type GetLineRequestConfig struct {
-	Fields  []string      `url:"fields[line],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []LineInclude `url:"include,comma,omitempty"`      // Include extra data in response
+	Fields   []string      `url:"fields[line],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset      `url:"fields,omitempty"`             // Fields to include for each resource type, included ones too. Replaces Fields for line if it has an entry for it
+	Include  []LineInclude `url:"include,comma,omitempty"`      // Relationships to include
 }
 
-// GetLine return a line from the mbta API
+// GetLine returns a line from the mbta API
 func (s *LineService) GetLine(id string, config *GetLineRequestConfig) (*Line, *http.Response, error) {
 	return s.GetLineWithContext(context.Background(), id, config)
 }
 
-// GetLineWithContext return a line from the mbta API given a context
+// GetLineWithContext returns a line from the mbta API given a context
 func (s *LineService) GetLineWithContext(ctx context.Context, id string, config *GetLineRequestConfig) (*Line, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
 	path := fmt.Sprintf("%s/%s", linesAPIPath, id)
 	u, err := addOptions(path, config)
 	if err != nil {
@@ -108,3 +133,27 @@ func (s *LineService) GetLineWithContext(ctx context.Context, id string, config
 	resp, err := s.client.doSinglePayload(req, &line)
 	return &line, resp, err
 }
+
+// GetLines returns the lines with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *LineService) GetLines(ids []string) ([]*Line, error) {
+	return s.GetLinesWithContext(context.Background(), ids)
+}
+
+// GetLinesWithContext returns the lines with the given IDs from the mbta API given a context
+func (s *LineService) GetLinesWithContext(ctx context.Context, ids []string) ([]*Line, error) {
+	untyped, err := s.client.batchGet(ctx, linesAPIPath, ids, &Line{}, func(ids []string) interface{} {
+		return &GetAllLinesRequestConfig{FilterIDs: ids}
+	})
+	lines := make([]*Line, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		lines[i], _ = untyped[i].(*Line)
+	}
+	return lines, err
+}
+
+// Names from before the sort and include constants were renamed consistently
+const (
+	// Deprecated: use LinesSortByLongNameAscending
+	LinesSortByLongNameDesending = LinesSortByLongNameAscending
+)
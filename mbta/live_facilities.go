@@ -0,0 +1,186 @@
+package mbta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+const liveFacilitiesAPIPath = "/live_facilities"
+
+// LiveFacilityService handling all of the live facility related API calls
+// Note: This spec is not yet finalized by the MBTA, so this may change/break depending on what the MBTA does.
+type LiveFacilityService service
+
+// LiveFacility holds all the info about a given MBTA live facility
+// Live data about a given facility. Its id is the id of the facility
+type LiveFacility struct {
+	ID         string             `jsonapi:"primary,live_facility"`
+	UpdatedAt  TimeISO8601        `jsonapi:"attr,updated_at"`   // Time of the last update
+	Properties []FacilityProperty `jsonapi:"attr,properties"`   // Name/value pairs of the live properties, e.g. capacity and utilization of a parking area
+	Facility   *Facility          `jsonapi:"relation,facility"` // The facility these properties are for. Only includes id by default, use Include config option to get all data
+	fieldPresence
+}
+
+// LiveFacilityField an attribute or relationship of a live facility, for a Fieldset
+type LiveFacilityField string
+
+const (
+	LiveFacilityFieldUpdatedAt  LiveFacilityField = "updated_at"
+	LiveFacilityFieldProperties LiveFacilityField = "properties"
+	LiveFacilityFieldFacility   LiveFacilityField = "facility"
+)
+
+func (f LiveFacilityField) resourceType() string { return "live_facility" }
+func (f LiveFacilityField) fieldName() string    { return string(f) }
+
+// Has whether the live facility has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (f *LiveFacility) Has(field LiveFacilityField) bool { return f.has(string(field)) }
+
+// LiveFacilityInclude all of the includes for a live facility request
+type LiveFacilityInclude string
+
+const (
+	LiveFacilityIncludeFacility LiveFacilityInclude = "facility"
+)
+
+// GetAllLiveFacilitiesRequestConfig extra options for the GetAllLiveFacilities request
+type GetAllLiveFacilitiesRequestConfig struct {
+	PageOffset string                `url:"page[offset],omitempty"`                // Offset (0-based) of first element in the page
+	PageLimit  string                `url:"page[limit],omitempty"`                 // Max number of elements to return
+	Fields     []string              `url:"fields[live_facility],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset   Fieldset              `url:"fields,omitempty"`                      // Fields to include for each resource type, included ones too. Replaces Fields for live_facility if it has an entry for it
+	Include    []LiveFacilityInclude `url:"include,comma,omitempty"`               // Relationships to include
+	FilterIDs  []string              `url:"filter[id],comma,omitempty"`            // Filter by multiple facility IDs
+}
+
+// GetAllLiveFacilities returns all live facilities from the mbta API
+func (s *LiveFacilityService) GetAllLiveFacilities(config *GetAllLiveFacilitiesRequestConfig) ([]*LiveFacility, *http.Response, error) {
+	return s.GetAllLiveFacilitiesWithContext(context.Background(), config)
+}
+
+// GetAllLiveFacilitiesWithContext returns all live facilities from the mbta API given a context
+func (s *LiveFacilityService) GetAllLiveFacilitiesWithContext(ctx context.Context, config *GetAllLiveFacilitiesRequestConfig) ([]*LiveFacility, *http.Response, error) {
+	u, err := addOptions(liveFacilitiesAPIPath, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	untyped, resp, err := s.client.doManyPayload(req, &LiveFacility{})
+	liveFacilities := make([]*LiveFacility, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		liveFacilities[i] = untyped[i].(*LiveFacility)
+	}
+	return liveFacilities, resp, err
+}
+
+// GetLiveFacilityRequestConfig extra options for the GetLiveFacility request
+type GetLiveFacilityRequestConfig struct {
+	Fields   []string              `url:"fields[live_facility],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset              `url:"fields,omitempty"`                      // Fields to include for each resource type, included ones too. Replaces Fields for live_facility if it has an entry for it
+	Include  []LiveFacilityInclude `url:"include,comma,omitempty"`               // Relationships to include
+}
+
+// GetLiveFacility returns a live facility from the mbta API
+func (s *LiveFacilityService) GetLiveFacility(id string, config *GetLiveFacilityRequestConfig) (*LiveFacility, *http.Response, error) {
+	return s.GetLiveFacilityWithContext(context.Background(), id, config)
+}
+
+// GetLiveFacilityWithContext returns a live facility from the mbta API given a context
+func (s *LiveFacilityService) GetLiveFacilityWithContext(ctx context.Context, id string, config *GetLiveFacilityRequestConfig) (*LiveFacility, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
+	path := fmt.Sprintf("%s/%s", liveFacilitiesAPIPath, id)
+	u, err := addOptions(path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := s.client.newGETRequest(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var liveFacility LiveFacility
+	resp, err := s.client.doSinglePayload(req, &liveFacility)
+	return &liveFacility, resp, err
+}
+
+// GetLiveFacilities returns the live facilities with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *LiveFacilityService) GetLiveFacilities(ids []string) ([]*LiveFacility, error) {
+	return s.GetLiveFacilitiesWithContext(context.Background(), ids)
+}
+
+// GetLiveFacilitiesWithContext returns the live facilities with the given IDs from the mbta API given a context
+func (s *LiveFacilityService) GetLiveFacilitiesWithContext(ctx context.Context, ids []string) ([]*LiveFacility, error) {
+	untyped, err := s.client.batchGet(ctx, liveFacilitiesAPIPath, ids, &LiveFacility{}, func(ids []string) interface{} {
+		return &GetAllLiveFacilitiesRequestConfig{FilterIDs: ids}
+	})
+	liveFacilities := make([]*LiveFacility, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		liveFacilities[i], _ = untyped[i].(*LiveFacility)
+	}
+	return liveFacilities, err
+}
+
+// ParkingOccupancy live occupancy of a FacilityParkingArea
+type ParkingOccupancy struct {
+	Capacity    int // Number of spaces
+	Utilization int // Number of spaces in use
+}
+
+// Available the number of open spaces. Never less than 0
+func (p ParkingOccupancy) Available() int {
+	if p.Utilization >= p.Capacity {
+		return 0
+	}
+	return p.Capacity - p.Utilization
+}
+
+// Property returns the first value of the live property with the given name
+func (f *LiveFacility) Property(name string) (string, bool) {
+	for _, p := range f.Properties {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ParkingOccupancy returns the live occupancy of a parking area. The error wraps ErrInvalidFacilityProperty when the
+// capacity or utilization is missing or isn't a number
+func (f *LiveFacility) ParkingOccupancy() (*ParkingOccupancy, error) {
+	capacity, err := f.intProperty("capacity")
+	if err != nil {
+		return nil, err
+	}
+	utilization, err := f.intProperty("utilization")
+	if err != nil {
+		return nil, err
+	}
+	return &ParkingOccupancy{Capacity: capacity, Utilization: utilization}, nil
+}
+
+func (f *LiveFacility) intProperty(name string) (int, error) {
+	value, ok := f.Property(name)
+	if !ok {
+		return 0, xerrors.Errorf("live facility %s has no %s property: %w", f.ID, name, ErrInvalidFacilityProperty)
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, xerrors.Errorf("live facility %s property %s is %q, not a number: %w", f.ID, name, value, ErrInvalidFacilityProperty)
+	}
+	return i, nil
+}
@@ -0,0 +1,73 @@
+package mbta
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestGetLiveFacility(t *testing.T) {
+	parsedTime, _ := parseISO8601Time("2019-06-11T08:05:29-04:00")
+	expected := &LiveFacility{
+		ID:        "park-NB-0127",
+		UpdatedAt: timeToTimeISO8601(parsedTime),
+		Properties: []FacilityProperty{
+			{Name: "capacity", Value: "35"},
+			{Name: "utilization", Value: "12"},
+		},
+		Facility: &Facility{
+			ID:         "park-NB-0127",
+			Latitude:   float64Ptr(42.28123),
+			Longitude:  float64Ptr(-71.237271),
+			Name:       "Needham Center Parking Lot",
+			ShortName:  "Parking Lot",
+			Type:       FacilityParkingArea,
+			Properties: []FacilityProperty{{Name: "capacity", Value: "35"}},
+			Stop:       &Stop{ID: "place-NB-0127", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(FacilityFieldLiveFacility),
+		},
+	}
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s?include=facility", liveFacilitiesAPIPath, "park-NB-0127")))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	actual, _, err := mbtaClient.LiveFacilities.GetLiveFacility("park-NB-0127", &GetLiveFacilityRequestConfig{Include: []LiveFacilityInclude{LiveFacilityIncludeFacility}})
+	ok(t, err)
+	equals(t, expected, actual)
+
+	occupancy, err := actual.ParkingOccupancy()
+	ok(t, err)
+	equals(t, &ParkingOccupancy{Capacity: 35, Utilization: 12}, occupancy)
+	equals(t, 23, occupancy.Available())
+}
+
+func TestGetAllLiveFacilities(t *testing.T) {
+	server := httptest.NewServer(handlerForServer(t, liveFacilitiesAPIPath+"?filter%5Bid%5D=park-NB-0127%2Cpark-ALFCL-garage"))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	actual, _, err := mbtaClient.LiveFacilities.GetAllLiveFacilities(&GetAllLiveFacilitiesRequestConfig{FilterIDs: []string{"park-NB-0127", "park-ALFCL-garage"}})
+	ok(t, err)
+	equals(t, 2, len(actual))
+	equals(t, "park-ALFCL-garage", actual[1].ID)
+	equals(t, &Facility{ID: "park-ALFCL-garage", fieldPresence: presentFields()}, actual[1].Facility)
+
+	occupancy, err := actual[1].ParkingOccupancy()
+	ok(t, err)
+	equals(t, 0, occupancy.Available())
+}
+
+func TestLiveFacilityParkingOccupancyInvalid(t *testing.T) {
+	_, err := (&LiveFacility{ID: "park-x", Properties: []FacilityProperty{{Name: "capacity", Value: "35"}}}).ParkingOccupancy()
+	assert(t, xerrors.Is(err, ErrInvalidFacilityProperty), "expected ErrInvalidFacilityProperty, got %v", err)
+
+	_, err = (&LiveFacility{ID: "park-x", Properties: []FacilityProperty{{Name: "capacity", Value: "35"}, {Name: "utilization", Value: "many"}}}).ParkingOccupancy()
+	assert(t, xerrors.Is(err, ErrInvalidFacilityProperty), "expected ErrInvalidFacilityProperty, got %v", err)
+}
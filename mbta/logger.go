@@ -0,0 +1,48 @@
+package mbta
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// RequestLog what a Logger is told about an API call
+type RequestLog struct {
+	Service      string        // The Client field the call was made through, e.g. "Vehicles"
+	Method       string        // The service method, e.g. "GetAllVehicles"
+	HTTPMethod   string        // e.g. "GET"
+	URL          string        // The fully encoded request URL, including the query string, with any api_key parameter redacted
+	StatusCode   int           // 0 when no response was received
+	Duration     time.Duration // From sending the request until the response was decoded
+	ResponseSize int64         // Bytes of the response body that were read
+	Err          error         // The error returned to the caller, if any. Bad requests are a BadRequestError with the API's details
+	Body         []byte        // The whole response body, only when the Logger's WantsBody returned true
+}
+
+// Logger gets a RequestLog for every API call a Client makes, e.g. to see the final query string a config was encoded to.
+// A log/slog adapter lives in its own module under contrib
+type Logger interface {
+	// WantsBody whether RequestLog.Body should be filled in for a call made with ctx, e.g. when debug logging is on.
+	// Keeping the body costs a copy of the response
+	WantsBody(ctx context.Context) bool
+	// LogRequest is called once the response has been decoded or the request has failed
+	LogRequest(ctx context.Context, entry RequestLog)
+}
+
+// redactURL the URL as a string with the api_key query parameter hidden. The Client sends its key as a header,
+// but a BaseURL can carry one in the query string
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	query := u.Query()
+	if _, ok := query["api_key"]; !ok {
+		return u.String()
+	}
+	query.Set("api_key", redacted)
+	redactedURL := *u
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
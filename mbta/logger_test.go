@@ -0,0 +1,71 @@
+package mbta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type recordingLogger struct {
+	wantsBody bool
+	entries   []RequestLog
+}
+
+func (l *recordingLogger) WantsBody(ctx context.Context) bool {
+	return l.wantsBody
+}
+
+func (l *recordingLogger) LogRequest(ctx context.Context, entry RequestLog) {
+	entry.Duration = 0
+	l.entries = append(l.entries, entry)
+}
+
+func TestLogger(t *testing.T) {
+	const badRequest = `{"errors": [{"status": "400", "source": {"parameter": "filter[route_type]"}, "title": "Bad Request", "code": "bad_request"}]}`
+	const vehicles = `{"data": []}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter[route_type]") != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, badRequest)
+			return
+		}
+		fmt.Fprint(w, vehicles)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, Logger: logger})
+	mbtaClient.client = server.Client()
+
+	_, _, err := mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRouteIDs: []string{"Red"}})
+	ok(t, err)
+	logger.wantsBody = true
+	_, _, err = mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRouteTypes: []RouteType{RouteTypeBus}})
+	assert(t, err != nil, "expected a bad request")
+
+	equals(t, 2, len(logger.entries))
+	equals(t, RequestLog{
+		Service:      "Vehicles",
+		Method:       "GetAllVehicles",
+		HTTPMethod:   http.MethodGet,
+		URL:          server.URL + "/vehicles?filter%5Broute%5D=Red",
+		StatusCode:   http.StatusOK,
+		ResponseSize: int64(len(vehicles)),
+	}, logger.entries[0])
+	equals(t, http.StatusBadRequest, logger.entries[1].StatusCode)
+	equals(t, BadRequestError{SourceParameter: "filter[route_type]", Detail: "Bad Request", Code: "bad_request"}, logger.entries[1].Err)
+	equals(t, badRequest, string(logger.entries[1].Body))
+	equals(t, int64(len(badRequest)), logger.entries[1].ResponseSize)
+}
+
+func TestRedactURL(t *testing.T) {
+	u, err := url.Parse("https://api-v3.mbta.com/stops?api_key=secret&filter%5Broute%5D=Red")
+	ok(t, err)
+	equals(t, "https://api-v3.mbta.com/stops?api_key=REDACTED&filter%5Broute%5D=Red", redactURL(u))
+	u, err = url.Parse("https://api-v3.mbta.com/stops?filter%5Broute%5D=Red")
+	ok(t, err)
+	equals(t, "https://api-v3.mbta.com/stops?filter%5Broute%5D=Red", redactURL(u))
+}
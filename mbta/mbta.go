@@ -1,17 +1,23 @@
 package mbta
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/google/go-querystring/query"
-	"github.com/google/jsonapi"
+	"golang.org/x/xerrors"
 )
 
 const (
 	defaultBaseURL   = "https://api-v3.mbta.com"
 	defaultUserAgent = "mbta-v3-go"
+
+	defaultMaxURLLength     = 2000
+	defaultBatchConcurrency = 4
 )
 
 // ClientConfig the options for creating a Client
@@ -19,6 +25,17 @@ type ClientConfig struct {
 	BaseURL   string
 	APIKey    string
 	UserAgent string
+	Observer  Observer // Told about every API call, e.g. for metrics or tracing. May be nil
+	Logger    Logger   // Gets a RequestLog for every API call. May be nil
+
+	MaxURLLength     int // Longest URL the batch methods (e.g. GetStops) build before splitting the IDs into another request. Defaults to 2000
+	BatchConcurrency int // Most requests one batch method call runs at once. Defaults to 4
+
+	// CoalesceRequests makes identical calls (same URL, e.g. GetAllPredictions with the same filters) made while one
+	// is in flight wait for it and get its result instead of making their own request. The decoded models are then
+	// shared between the callers and must not be modified. The Observer and Logger only see the requests made
+	CoalesceRequests bool
+	CoalesceTTL      time.Duration // How long after a successful coalesced call identical calls still get its result. 0 for only the ones made while it is in flight
 }
 
 type service struct {
@@ -27,34 +44,46 @@ type service struct {
 
 // Client the client for the MBTA API
 type Client struct {
-	client *http.Client
+	client   *http.Client
+	observer Observer
+	logger   Logger
+
+	maxURLLength     int
+	batchConcurrency int
+	coalescer        *coalescer // nil unless CoalesceRequests
 
 	APIKey string
 
 	BaseURL   *url.URL
 	UserAgent string
 
-	common        service // Reuse a single struct instead of allocating one for each service on the heap. (same as github.com/google/go-github)
-	Alerts        *AlertService
-	Facilities    *FacilityService
-	Lines         *LineService
-	Predictions   *PredictionService
-	Routes        *RouteService
-	RoutePatterns *RoutePatternsService
-	Schedules     *ScheduleService
-	Services      *ServicesService
-	Shapes        *ShapeService
-	Stops         *StopService
-	Trips         *TripService
-	Vehicles      *VehicleService
+	common         service // Reuse a single struct instead of allocating one for each service on the heap. (same as github.com/google/go-github)
+	Alerts         *AlertService
+	Facilities     *FacilityService
+	Lines          *LineService
+	LiveFacilities *LiveFacilityService
+	Predictions    *PredictionService
+	Routes         *RouteService
+	RoutePatterns  *RoutePatternsService
+	Schedules      *ScheduleService
+	Services       *ServicesService
+	Shapes         *ShapeService
+	Stops          *StopService
+	Trips          *TripService
+	Vehicles       *VehicleService
 }
 
 // NewClient creates a new Client using the given config options
 func NewClient(config ClientConfig) *Client {
 	c := &Client{
 		client:    http.DefaultClient,
+		observer:  config.Observer,
+		logger:    config.Logger,
 		APIKey:    config.APIKey,
 		UserAgent: config.UserAgent,
+
+		maxURLLength:     config.MaxURLLength,
+		batchConcurrency: config.BatchConcurrency,
 	}
 
 	if config.BaseURL == "" {
@@ -70,10 +99,20 @@ func NewClient(config ClientConfig) *Client {
 	if config.UserAgent == "" {
 		c.UserAgent = defaultUserAgent
 	}
+	if c.maxURLLength <= 0 {
+		c.maxURLLength = defaultMaxURLLength
+	}
+	if c.batchConcurrency <= 0 {
+		c.batchConcurrency = defaultBatchConcurrency
+	}
+	if config.CoalesceRequests {
+		c.coalescer = newCoalescer(config.CoalesceTTL)
+	}
 
 	c.common.client = c
 	c.Alerts = (*AlertService)(&c.common)
 	c.Lines = (*LineService)(&c.common)
+	c.LiveFacilities = (*LiveFacilityService)(&c.common)
 	c.Predictions = (*PredictionService)(&c.common)
 	c.Facilities = (*FacilityService)(&c.common)
 	c.Routes = (*RouteService)(&c.common)
@@ -88,12 +127,17 @@ func NewClient(config ClientConfig) *Client {
 	return c
 }
 
+// newGETRequest a request for path, an API path with its query string. It always goes to BaseURL's scheme and host,
+// so a path that names another host, e.g. "//example.com/x", is ErrInvalidPath rather than a request there
 func (c *Client) newGETRequest(path string) (*http.Request, error) {
 	rel, err := url.Parse(path)
 	if err != nil {
 		return nil, err
 	}
-	u := c.BaseURL.ResolveReference(rel)
+	if rel.Scheme != "" || rel.Opaque != "" || rel.User != nil || rel.Host != "" || !strings.HasPrefix(rel.Path, "/") {
+		return nil, xerrors.Errorf("%q: %w", path, ErrInvalidPath)
+	}
+	u := &url.URL{Scheme: c.BaseURL.Scheme, Host: c.BaseURL.Host, Path: rel.Path, RawPath: rel.RawPath, RawQuery: rel.RawQuery}
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
 		return nil, err
@@ -129,30 +173,88 @@ func addOptions(s string, opt interface{}) (string, error) {
 	return u.String(), nil
 }
 
-func (c *Client) doSinglePayload(req *http.Request, v interface{}) (*http.Response, error) {
+type ifModifiedSinceKey struct{}
+
+// WithIfModifiedSince makes the requests done with ctx conditional: if the resource hasn't changed since lastModified,
+// the Last-Modified header of an earlier response, the request returns ErrNotModified instead of the resource
+func WithIfModifiedSince(ctx context.Context, lastModified string) context.Context {
+	return context.WithValue(ctx, ifModifiedSinceKey{}, lastModified)
+}
+
+func (c *Client) do(req *http.Request, cl *call) (*http.Response, error) {
+	if lastModified, _ := req.Context().Value(ifModifiedSinceKey{}).(string); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+	cl.wrapBody(resp)
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return resp, ErrNotModified
+	}
+	return resp, nil
+}
+
+func (c *Client) doSinglePayload(req *http.Request, v interface{}) (*http.Response, error) {
+	if c.coalescer == nil {
+		return c.fetchSinglePayload(req, v)
+	}
+	shared, resp, err := c.coalescer.do(req, func() (interface{}, *http.Response, error) {
+		resp, err := c.fetchSinglePayload(req, v)
+		return v, resp, err
+	})
+	if shared != nil && shared != v {
+		// the model of an identical call: the caller gets its own copy of the struct, sharing what it points to
+		reflect.ValueOf(v).Elem().Set(reflect.ValueOf(shared).Elem())
+	}
+	return resp, err
+}
+
+func (c *Client) fetchSinglePayload(req *http.Request, v interface{}) (*http.Response, error) {
+	req, cl := c.startCall(req)
+	resp, err := c.do(req, cl)
+	if err != nil {
+		cl.done(resp, err, false)
+		return resp, err
+	}
 	defer resp.Body.Close()
 	if err = getSpecialError(resp, err); err != nil {
+		cl.done(resp, err, false)
 		return nil, err
 	}
 
-	err = jsonapi.UnmarshalPayload(resp.Body, v)
+	err = unmarshalPayload(resp.Body, v)
+	cl.done(resp, err, err != nil)
 	return resp, err
 }
 
 func (c *Client) doManyPayload(req *http.Request, v interface{}) ([]interface{}, *http.Response, error) {
-	resp, err := c.client.Do(req)
+	if c.coalescer == nil {
+		return c.fetchManyPayload(req, v)
+	}
+	shared, resp, err := c.coalescer.do(req, func() (interface{}, *http.Response, error) {
+		return c.fetchManyPayload(req, v)
+	})
+	vals, _ := shared.([]interface{})
+	return vals, resp, err
+}
+
+func (c *Client) fetchManyPayload(req *http.Request, v interface{}) ([]interface{}, *http.Response, error) {
+	req, cl := c.startCall(req)
+	resp, err := c.do(req, cl)
 	if err != nil {
-		return nil, nil, err
+		cl.done(resp, err, false)
+		return nil, resp, err
 	}
 	defer resp.Body.Close()
 	if err = getSpecialError(resp, err); err != nil {
+		cl.done(resp, err, false)
 		return nil, nil, err
 	}
 
-	vals, err := jsonapi.UnmarshalManyPayload(resp.Body, reflect.TypeOf(v))
+	vals, err := unmarshalManyPayload(resp.Body, reflect.TypeOf(v))
+	cl.done(resp, err, err != nil)
 	return vals, resp, err
 }
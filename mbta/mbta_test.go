@@ -0,0 +1,38 @@
+package mbta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithIfModifiedSince(t *testing.T) {
+	const lastModified = "Mon, 03 Jun 2019 12:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		handlerForServer(t, linesAPIPath).ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	lines, resp, err := mbtaClient.Lines.GetAllLines(&GetAllLinesRequestConfig{})
+	ok(t, err)
+	assert(t, len(lines) > 0, "expected lines")
+	equals(t, lastModified, resp.Header.Get("Last-Modified"))
+
+	ctx := WithIfModifiedSince(context.Background(), resp.Header.Get("Last-Modified"))
+	lines, resp, err = mbtaClient.Lines.GetAllLinesWithContext(ctx, &GetAllLinesRequestConfig{})
+	equals(t, ErrNotModified, err)
+	equals(t, http.StatusNotModified, resp.StatusCode)
+	equals(t, 0, len(lines))
+
+	_, _, err = mbtaClient.Lines.GetLineWithContext(ctx, "line-Green", &GetLineRequestConfig{})
+	equals(t, ErrNotModified, err)
+}
@@ -0,0 +1,68 @@
+package mbta
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestInfo identifies an API call for an Observer
+type RequestInfo struct {
+	Service string   // The Client field the call was made through, e.g. "Vehicles"
+	Method  string   // The service method, e.g. "GetAllVehicles"
+	URL     *url.URL // The full request URL, including the query string
+}
+
+// RequestResult how an API call went, for an Observer
+type RequestResult struct {
+	StatusCode         int           // 0 when no response was received
+	Duration           time.Duration // From sending the request until the response was decoded
+	RateLimitRemaining int           // The x-ratelimit-remaining header, -1 when the response didn't have one
+	CacheHit           bool          // The server answered 304 Not Modified to a request made with WithIfModifiedSince
+	DecodeError        bool          // The response was received but its body couldn't be decoded
+	Err                error         // The error returned to the caller, if any
+}
+
+// Observer is told about every API call a Client makes, e.g. to record metrics or traces.
+// Adapters for Prometheus and OpenTelemetry live in their own modules under contrib, so the client doesn't depend on them.
+// The client never retries a request, so there is no retry count: a caller that retries makes a new call each time
+type Observer interface {
+	// RequestStarted is called before the request is sent. The returned context is used for the request, e.g. to carry a span
+	RequestStarted(ctx context.Context, info RequestInfo) context.Context
+	// RequestDone is called once the response has been decoded or the request has failed, with the context RequestStarted returned
+	RequestDone(ctx context.Context, info RequestInfo, result RequestResult)
+}
+
+// serviceMethods the Client field and the list and get methods for each API path
+var serviceMethods = map[string][3]string{
+	alertsAPIPath:         {"Alerts", "GetAllAlerts", "GetAlert"},
+	facilitiesAPIPath:     {"Facilities", "GetAllFacilities", "GetFacility"},
+	linesAPIPath:          {"Lines", "GetAllLines", "GetLine"},
+	liveFacilitiesAPIPath: {"LiveFacilities", "GetAllLiveFacilities", "GetLiveFacility"},
+	predictionsAPIPath:    {"Predictions", "GetAllPredictions", ""},
+	routePatternsAPIPath:  {"RoutePatterns", "GetAllRoutePatterns", "GetRoutePattern"},
+	routesAPIPath:         {"Routes", "GetAllRoutes", "GetRoute"},
+	schedulesAPIPath:      {"Schedules", "GetAllSchedules", ""},
+	servicesAPIPath:       {"Services", "GetAllServices", "GetService"},
+	shapesAPIPath:         {"Shapes", "GetAllShapes", "GetShape"},
+	stopsAPIPath:          {"Stops", "GetAllStops", "GetStop"},
+	tripsAPIPath:          {"Trips", "GetAllTrips", "GetTrip"},
+	vehiclesAPIPath:       {"Vehicles", "GetAllVehicles", "GetVehicle"},
+}
+
+// requestInfo works out which service method made the request from its path
+func requestInfo(req *http.Request) RequestInfo {
+	info := RequestInfo{URL: req.URL}
+	parts := strings.SplitN(strings.Trim(req.URL.Path, "/"), "/", 2)
+	methods, ok := serviceMethods["/"+parts[0]]
+	if !ok {
+		return info
+	}
+	info.Service, info.Method = methods[0], methods[1]
+	if len(parts) == 2 && methods[2] != "" {
+		info.Method = methods[2]
+	}
+	return info
+}
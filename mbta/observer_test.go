@@ -0,0 +1,64 @@
+package mbta
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type observerKey struct{}
+
+type recordingObserver struct {
+	started []RequestInfo
+	done    []RequestResult
+	ctxOK   []bool
+}
+
+func (o *recordingObserver) RequestStarted(ctx context.Context, info RequestInfo) context.Context {
+	o.started = append(o.started, info)
+	return context.WithValue(ctx, observerKey{}, len(o.started))
+}
+
+func (o *recordingObserver) RequestDone(ctx context.Context, info RequestInfo, result RequestResult) {
+	o.ctxOK = append(o.ctxOK, ctx.Value(observerKey{}) == len(o.started))
+	result.Duration = 0
+	o.done = append(o.done, result)
+}
+
+func TestObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/vehicles/broken" {
+			w.Write([]byte("not json"))
+			return
+		}
+		w.Header().Set("x-ratelimit-remaining", "999")
+		body, err := ioutil.ReadFile(httpPathToTestData(r.URL.Path))
+		ok(t, err)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, Observer: observer})
+	mbtaClient.client = server.Client()
+
+	_, _, err := mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRouteIDs: []string{"Red"}})
+	ok(t, err)
+	_, _, err = mbtaClient.Vehicles.GetVehicle("broken", &GetVehicleRequestConfig{})
+	assert(t, err != nil, "expected a decode error")
+
+	equals(t, 2, len(observer.started))
+	equals(t, "Vehicles", observer.started[0].Service)
+	equals(t, "GetAllVehicles", observer.started[0].Method)
+	equals(t, "filter%5Broute%5D=Red", observer.started[0].URL.RawQuery)
+	equals(t, "GetVehicle", observer.started[1].Method)
+
+	equals(t, RequestResult{StatusCode: http.StatusOK, RateLimitRemaining: 999}, observer.done[0])
+	equals(t, http.StatusOK, observer.done[1].StatusCode)
+	equals(t, -1, observer.done[1].RateLimitRemaining)
+	assert(t, observer.done[1].DecodeError, "expected a decode error")
+	equals(t, err, observer.done[1].Err)
+	equals(t, []bool{true, true}, observer.ctxOK)
+}
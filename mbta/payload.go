@@ -0,0 +1,310 @@
+package mbta
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// MarshalPayload writes data, a model or a slice of models as the client returns them (e.g. a *Vehicle or a
+// []*Prediction), as a JSON:API document like the API's own responses. includes are the relationship paths to write
+// the related resources of to "included", like the Include config options, e.g. "trip" or "stop.parent_station".
+// They should be ones the data was requested with: the other relationships only have the related resource's id.
+// Resources that can be fetched by id get a self link, and relationships the API links to get a related link
+func MarshalPayload(w io.Writer, data interface{}, includes ...string) error {
+	return MarshalPayloadWithLinks(w, data, nil, includes...)
+}
+
+// MarshalPayloadWithLinks writes data like MarshalPayload, with the document's top-level links, e.g. the pagination
+// links "first", "last" and "next" of a page of a list. The client doesn't keep the ones of the API's responses, they
+// point at the API rather than at whoever re-serves the data
+func MarshalPayloadWithLinks(w io.Writer, data interface{}, links map[string]string, includes ...string) error {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return xerrors.Errorf("nil: %w", ErrNotModel)
+	}
+	isSlice := v.Kind() == reflect.Slice
+	t := v.Type()
+	if isSlice {
+		t = t.Elem()
+	}
+	if !isModelType(t) {
+		return xerrors.Errorf("%T: %w", data, ErrNotModel)
+	}
+	tree, err := buildIncludeTree(t.Elem(), includes)
+	if err != nil {
+		return err
+	}
+
+	e := &payloadEncoder{seen: make(map[resourceIdentifier]bool)}
+	var models []reflect.Value
+	if isSlice {
+		for i := 0; i < v.Len(); i++ {
+			if !v.Index(i).IsNil() {
+				models = append(models, v.Index(i))
+			}
+		}
+	} else if !v.IsNil() {
+		models = append(models, v)
+	}
+	resources := make([]*resourceObject, len(models))
+	for i, model := range models {
+		resources[i], err = encodeResource(model)
+		if err != nil {
+			return err
+		}
+		e.seen[resources[i].identifier()] = true
+	}
+	for _, model := range models {
+		if err := e.includeRelated(model, tree); err != nil {
+			return err
+		}
+	}
+
+	doc := payloadDocument{Included: e.included, Links: links, JSONAPI: map[string]string{"version": "1.0"}}
+	if isSlice {
+		doc.Data = resources
+	} else if len(resources) == 1 {
+		doc.Data = resources[0]
+	}
+	enc := json.NewEncoder(w)
+	// like the API, which doesn't escape the & of its links' query strings
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+type payloadDocument struct {
+	Data     interface{}       `json:"data"` // *resourceObject or []*resourceObject
+	Included []*resourceObject `json:"included,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+	JSONAPI  map[string]string `json:"jsonapi"`
+}
+
+type resourceObject struct {
+	Type          string                     `json:"type"`
+	ID            string                     `json:"id"`
+	Attributes    map[string]json.RawMessage `json:"attributes,omitempty"`
+	Relationships map[string]relationship    `json:"relationships,omitempty"`
+	Links         map[string]string          `json:"links,omitempty"`
+}
+
+func (r *resourceObject) identifier() resourceIdentifier {
+	return resourceIdentifier{Type: r.Type, ID: r.ID}
+}
+
+type relationship struct {
+	Data  interface{}       `json:"data,omitempty"` // *resourceIdentifier, null or []resourceIdentifier. nil leaves it out
+	Links map[string]string `json:"links,omitempty"`
+}
+
+type resourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// includeTree the relationships to include, each with the relationships to include of its resources
+type includeTree map[string]includeTree
+
+// buildIncludeTree checks the include paths against the model type t and turns them into an includeTree
+func buildIncludeTree(t reflect.Type, includes []string) (includeTree, error) {
+	tree := make(includeTree)
+	for _, include := range includes {
+		node, nodeType := tree, t
+		for _, name := range strings.Split(include, ".") {
+			field, ok := relationField(nodeType, name)
+			if !ok {
+				return nil, xerrors.Errorf("%s has no relationship %s: %w", nodeType.Name(), name, ErrInvalidInclude)
+			}
+			if node[name] == nil {
+				node[name] = make(includeTree)
+			}
+			node, nodeType = node[name], field.Type
+			if nodeType.Kind() == reflect.Slice {
+				nodeType = nodeType.Elem()
+			}
+			nodeType = nodeType.Elem()
+		}
+	}
+	return tree, nil
+}
+
+// payloadEncoder collects the included resources of a document, each once and none that are in its primary data
+type payloadEncoder struct {
+	seen     map[resourceIdentifier]bool
+	included []*resourceObject
+}
+
+// includeRelated adds the resources of model's relationships in tree to the included resources, and theirs in turn
+func (e *payloadEncoder) includeRelated(model reflect.Value, tree includeTree) error {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		subtree := tree[name]
+		field, _ := relationField(model.Type().Elem(), name)
+		related := model.Elem().FieldByIndex(field.Index)
+		if related.Kind() != reflect.Slice {
+			related = reflect.Append(reflect.MakeSlice(reflect.SliceOf(related.Type()), 0, 1), related)
+		}
+		for i := 0; i < related.Len(); i++ {
+			if related.Index(i).IsNil() {
+				continue
+			}
+			if err := e.include(related.Index(i), subtree); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *payloadEncoder) include(model reflect.Value, tree includeTree) error {
+	resource, err := encodeResource(model)
+	if err != nil {
+		return err
+	}
+	if !e.seen[resource.identifier()] {
+		e.seen[resource.identifier()] = true
+		e.included = append(e.included, resource)
+	}
+	return e.includeRelated(model, tree)
+}
+
+// encodeResource makes the resource object of a model, a pointer to one of the model structs. It leaves out the fields
+// the resource the model was decoded from didn't have
+func encodeResource(model reflect.Value) (*resourceObject, error) {
+	r := &resourceObject{
+		Attributes:    make(map[string]json.RawMessage),
+		Relationships: make(map[string]relationship),
+	}
+	presence, _ := model.Interface().(interface {
+		has(string) bool
+		hasData(string) bool
+	})
+	v := model.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		kind, name := jsonapiTag(v.Type().Field(i))
+		field := v.Field(i)
+		if presence != nil && (kind == "attr" || kind == "relation") && !presence.has(name) {
+			continue
+		}
+		switch kind {
+		case "primary":
+			r.Type, r.ID = name, field.String()
+		case "attr":
+			b, err := encodeAttribute(field)
+			if err != nil {
+				return nil, xerrors.Errorf("%s attribute %s: %w", v.Type().Name(), name, err)
+			}
+			r.Attributes[name] = b
+		case "relation":
+			var rel relationship
+			if path, ok := relatedLinkPaths[r.Type][name]; ok {
+				rel.Links = map[string]string{"related": path + r.ID}
+			}
+			if field.Kind() == reflect.Slice {
+				// the API leaves out the data of to-many relationships that weren't included
+				if field.Len() > 0 {
+					data := make([]resourceIdentifier, 0, field.Len())
+					for j := 0; j < field.Len(); j++ {
+						if !field.Index(j).IsNil() {
+							data = append(data, identifierOf(field.Index(j)))
+						}
+					}
+					rel.Data = data
+				}
+			} else if field.IsNil() {
+				if presence == nil || presence.hasData(name) {
+					rel.Data = json.RawMessage("null")
+				}
+			} else {
+				id := identifierOf(field)
+				rel.Data = &id
+			}
+			r.Relationships[name] = rel
+		}
+	}
+	if path, ok := selfLinkPaths[r.Type]; ok {
+		r.Links = map[string]string{"self": path + "/" + r.ID}
+	}
+	return r, nil
+}
+
+// encodeAttribute marshals an attribute the way the API sends it: empty lists are [] rather than null
+func encodeAttribute(field reflect.Value) (json.RawMessage, error) {
+	if field.Kind() == reflect.Slice && field.Len() == 0 {
+		return json.RawMessage("[]"), nil
+	}
+	// through a pointer, so that the MarshalJSON methods with pointer receivers, e.g. TimeISO8601's, apply
+	return json.Marshal(field.Addr().Interface())
+}
+
+func identifierOf(model reflect.Value) resourceIdentifier {
+	id := resourceIdentifier{}
+	v := model.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if kind, name := jsonapiTag(v.Type().Field(i)); kind == "primary" {
+			id.Type, id.ID = name, v.Field(i).String()
+		}
+	}
+	return id
+}
+
+// isModelType whether t is a pointer to a model struct, one with a jsonapi primary field
+func isModelType(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		if kind, _ := jsonapiTag(t.Elem().Field(i)); kind == "primary" {
+			return true
+		}
+	}
+	return false
+}
+
+// relationField the field of the model struct type t for the relationship name
+func relationField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if kind, n := jsonapiTag(t.Field(i)); kind == "relation" && n == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// jsonapiTag the kind (primary, attr or relation) and name of a model field's jsonapi tag
+func jsonapiTag(field reflect.StructField) (kind, name string) {
+	args := strings.Split(field.Tag.Get("jsonapi"), ",")
+	if len(args) < 2 {
+		return "", ""
+	}
+	return args[0], args[1]
+}
+
+// selfLinkPaths the collection path of each JSON:API type that can be fetched by id, for the self links of MarshalPayload
+var selfLinkPaths = map[string]string{
+	"alert":         alertsAPIPath,
+	"facility":      facilitiesAPIPath,
+	"line":          linesAPIPath,
+	"live_facility": liveFacilitiesAPIPath,
+	"route_pattern": routePatternsAPIPath,
+	"route":         routesAPIPath,
+	"service":       servicesAPIPath,
+	"shape":         shapesAPIPath,
+	"stop":          stopsAPIPath,
+	"trip":          tripsAPIPath,
+	"vehicle":       vehiclesAPIPath,
+}
+
+// relatedLinkPaths the related links the API gives relationships, by JSON:API type and relationship, without the id of
+// the resource at the end, for MarshalPayload
+var relatedLinkPaths = map[string]map[string]string{
+	"stop": {"facilities": facilitiesAPIPath + "/?filter[stop]="},
+}
@@ -0,0 +1,270 @@
+package mbta
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+var payloadModelTypes = map[string]reflect.Type{
+	"alert":         reflect.TypeOf(&Alert{}),
+	"facility":      reflect.TypeOf(&Facility{}),
+	"line":          reflect.TypeOf(&Line{}),
+	"live_facility": reflect.TypeOf(&LiveFacility{}),
+	"prediction":    reflect.TypeOf(&Prediction{}),
+	"route":         reflect.TypeOf(&Route{}),
+	"route_pattern": reflect.TypeOf(&RoutePattern{}),
+	"schedule":      reflect.TypeOf(&Schedule{}),
+	"service":       reflect.TypeOf(&Service{}),
+	"shape":         reflect.TypeOf(&Shape{}),
+	"stop":          reflect.TypeOf(&Stop{}),
+	"trip":          reflect.TypeOf(&Trip{}),
+	"vehicle":       reflect.TypeOf(&Vehicle{}),
+}
+
+var updateGolden = flag.Bool("update", false, "update the golden files of Test_MarshalPayloadFixtures")
+
+// Test_MarshalPayloadFixtures decodes every fixture like the client does, encodes the models again, with the fixture's
+// top-level links, and checks that nothing the models hold was lost: the encoded document decodes to the same models,
+// and it is the fixture apart from the members the models don't have. Members the models have but a fixture doesn't,
+// e.g. of sparse fieldsets or attributes added since, must come back empty. The encoded documents must also match
+// testdata/payload/<fixture>.golden, go test -run Test_MarshalPayloadFixtures -update rewrites those
+func Test_MarshalPayloadFixtures(t *testing.T) {
+	// the includes the fixtures were requested with
+	includes := map[string][]string{
+		"live_facilities_park-NB-0127.json": {"facility"},
+		"stops_place-harsq.json":            {"child_stops"},
+	}
+
+	files, err := filepath.Glob(filepath.Join(testDataPath, "*.json"))
+	ok(t, err)
+	assert(t, len(files) > 0, "no fixtures")
+	for _, file := range files {
+		name := filepath.Base(file)
+		t.Run(name, func(t *testing.T) {
+			fixture, err := ioutil.ReadFile(file)
+			ok(t, err)
+			var links struct {
+				Links map[string]string `json:"links"`
+			}
+			ok(t, json.Unmarshal(fixture, &links))
+
+			models := decodeFixture(t, fixture)
+			var b bytes.Buffer
+			ok(t, MarshalPayloadWithLinks(&b, models, links.Links, includes[name]...))
+			equals(t, models, decodeFixture(t, b.Bytes()))
+
+			var indented bytes.Buffer
+			ok(t, json.Indent(&indented, b.Bytes(), "", "    "))
+			goldenFile := filepath.Join(testDataPath, "payload", name+".golden")
+			if *updateGolden {
+				ok(t, ioutil.WriteFile(goldenFile, indented.Bytes(), 0644))
+			}
+			golden, err := ioutil.ReadFile(goldenFile)
+			ok(t, err)
+			assert(t, bytes.Equal(golden, indented.Bytes()), "doesn't match %s:\n%s", goldenFile, indented.Bytes())
+
+			expDoc, actDoc := normalizeDocument(t, fixture), normalizeDocument(t, b.Bytes())
+			equals(t, expDoc["links"], actDoc["links"])
+			exp, act := documentResources(expDoc), documentResources(actDoc)
+			equals(t, len(exp), len(act))
+			for i := range exp {
+				for _, member := range []string{"attributes", "relationships"} {
+					expValues, _ := exp[i][member].(map[string]interface{})
+					actValues, _ := act[i][member].(map[string]interface{})
+					for name, value := range actValues {
+						if _, found := expValues[name]; !found {
+							assert(t, isEmptyMember(value), "%s %s isn't in the fixture but is %v", resourceKey(act[i]), name, value)
+							delete(actValues, name)
+						}
+					}
+					if len(actValues) == 0 {
+						delete(act[i], member)
+					}
+				}
+				equals(t, exp[i], act[i])
+			}
+		})
+	}
+}
+
+func Test_MarshalPayloadIncludes(t *testing.T) {
+	route := &Route{ID: "Red", LongName: "Red Line"}
+	trip := &Trip{ID: "t1", Headsign: "Ashmont", Route: route}
+	predictions := []*Prediction{
+		{ID: "p1", Trip: trip, Route: route, Stop: &Stop{ID: "70061"}},
+		{ID: "p2", Trip: trip, Route: route},
+	}
+
+	var b bytes.Buffer
+	ok(t, MarshalPayload(&b, predictions, "trip.route"))
+	var doc struct {
+		Data []struct {
+			ID            string            `json:"id"`
+			Links         map[string]string `json:"links"`
+			Relationships map[string]struct {
+				Data *resourceIdentifier `json:"data"`
+			} `json:"relationships"`
+		} `json:"data"`
+		Included []struct {
+			Type       string                 `json:"type"`
+			ID         string                 `json:"id"`
+			Attributes map[string]interface{} `json:"attributes"`
+			Links      map[string]string      `json:"links"`
+		} `json:"included"`
+	}
+	ok(t, json.Unmarshal(b.Bytes(), &doc))
+
+	equals(t, 2, len(doc.Data))
+	equals(t, map[string]string(nil), doc.Data[0].Links)
+	equals(t, &resourceIdentifier{Type: "stop", ID: "70061"}, doc.Data[0].Relationships["stop"].Data)
+	assert(t, doc.Data[1].Relationships["stop"].Data == nil, "p2 has no stop")
+	equals(t, &resourceIdentifier{Type: "route", ID: "Red"}, doc.Data[1].Relationships["route"].Data)
+
+	// the trip and its route once each, and not the stop
+	equals(t, 2, len(doc.Included))
+	equals(t, "trip", doc.Included[0].Type)
+	equals(t, "Ashmont", doc.Included[0].Attributes["headsign"])
+	equals(t, "route", doc.Included[1].Type)
+	equals(t, "Red Line", doc.Included[1].Attributes["long_name"])
+	equals(t, map[string]string{"self": "/routes/Red"}, doc.Included[1].Links)
+
+	b.Reset()
+	ok(t, MarshalPayload(&b, (*Vehicle)(nil)))
+	equals(t, "{\"data\":null,\"jsonapi\":{\"version\":\"1.0\"}}\n", b.String())
+
+	b.Reset()
+	ok(t, MarshalPayload(&b, []*Vehicle{}))
+	equals(t, "{\"data\":[],\"jsonapi\":{\"version\":\"1.0\"}}\n", b.String())
+}
+
+func Test_MarshalPayloadErrors(t *testing.T) {
+	for _, data := range []interface{}{nil, Vehicle{}, []Vehicle{}, &FacilityProperty{}, "vehicle"} {
+		err := MarshalPayload(ioutil.Discard, data)
+		assert(t, xerrors.Is(err, ErrNotModel), "%#v: expected ErrNotModel, got %v", data, err)
+	}
+	for _, include := range []string{"trips", "trip.stop", "route."} {
+		err := MarshalPayload(ioutil.Discard, []*Vehicle{}, include)
+		assert(t, xerrors.Is(err, ErrInvalidInclude), "%s: expected ErrInvalidInclude, got %v", include, err)
+	}
+}
+
+// decodeFixture decodes a document into a model, or a slice of them, of the type of its data
+func decodeFixture(t *testing.T, b []byte) interface{} {
+	t.Helper()
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	ok(t, json.Unmarshal(b, &doc))
+	var first struct {
+		Type string `json:"type"`
+	}
+	many := doc.Data[0] == '['
+	if many {
+		var data []json.RawMessage
+		ok(t, json.Unmarshal(doc.Data, &data))
+		assert(t, len(data) > 0, "no data")
+		ok(t, json.Unmarshal(data[0], &first))
+	} else {
+		ok(t, json.Unmarshal(doc.Data, &first))
+	}
+	typ, found := payloadModelTypes[first.Type]
+	assert(t, found, "no model for %s", first.Type)
+
+	if !many {
+		model := reflect.New(typ.Elem())
+		ok(t, unmarshalPayload(bytes.NewReader(b), model.Interface()))
+		return model.Interface()
+	}
+	vals, err := unmarshalManyPayload(bytes.NewReader(b), typ)
+	ok(t, err)
+	models := reflect.MakeSlice(reflect.SliceOf(typ), 0, len(vals))
+	for _, val := range vals {
+		models = reflect.Append(models, reflect.ValueOf(val))
+	}
+	return models.Interface()
+}
+
+// normalizeDocument strips the members the models don't have out of a document's resources, and sorts the included
+// resources by type and id
+func normalizeDocument(t *testing.T, b []byte) map[string]interface{} {
+	t.Helper()
+	var doc map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	ok(t, d.Decode(&doc))
+
+	for _, resource := range documentResources(doc) {
+		typ := resource["type"].(string)
+		known := make(map[string]bool)
+		for i := 0; i < payloadModelTypes[typ].Elem().NumField(); i++ {
+			if kind, name := jsonapiTag(payloadModelTypes[typ].Elem().Field(i)); kind != "primary" {
+				known[name] = true
+			}
+		}
+		for _, member := range []string{"attributes", "relationships"} {
+			values, _ := resource[member].(map[string]interface{})
+			for name := range values {
+				if !known[name] {
+					delete(values, name)
+				}
+			}
+			if len(values) == 0 {
+				delete(resource, member)
+			}
+		}
+	}
+	included, _ := doc["included"].([]interface{})
+	sort.Slice(included, func(i, j int) bool {
+		return resourceKey(included[i].(map[string]interface{})) < resourceKey(included[j].(map[string]interface{}))
+	})
+	return doc
+}
+
+// documentResources the resource objects of a document's data and included resources, in order
+func documentResources(doc map[string]interface{}) []map[string]interface{} {
+	var resources []interface{}
+	if data, isList := doc["data"].([]interface{}); isList {
+		resources = append(resources, data...)
+	} else {
+		resources = append(resources, doc["data"])
+	}
+	included, _ := doc["included"].([]interface{})
+	resources = append(resources, included...)
+	objects := make([]map[string]interface{}, len(resources))
+	for i, r := range resources {
+		objects[i] = r.(map[string]interface{})
+	}
+	return objects
+}
+
+// isEmptyMember whether an attribute or relationship value is what a model has when the API didn't send it
+func isEmptyMember(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case json.Number:
+		return v.String() == "0"
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		data, hasData := v["data"]
+		return len(v) == 1 && hasData && data == nil
+	}
+	return false
+}
+
+func resourceKey(resource map[string]interface{}) string {
+	return strings.Join([]string{resource["type"].(string), fmt.Sprint(resource["id"])}, ",")
+}
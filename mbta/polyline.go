@@ -0,0 +1,153 @@
+package mbta
+
+import (
+	"errors"
+	"math"
+)
+
+const earthRadiusMeters = 6371008.8
+
+// ErrInvalidPolyline the polyline string is not a valid encoded polyline
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+// LatLng a point in the WGS-84 coordinate system
+type LatLng struct {
+	Latitude  float64 // Degrees North
+	Longitude float64 // Degrees East
+}
+
+// DecodePolyline decodes a polyline encoded with the Google encoded polyline algorithm (https://developers.google.com/maps/documentation/utilities/polylinealgorithm)
+func DecodePolyline(encoded string) ([]LatLng, error) {
+	var points []LatLng
+	var lat, lng int
+	for i := 0; i < len(encoded); {
+		var deltas [2]int
+		for j := range deltas {
+			var result, shift uint
+			for {
+				if i >= len(encoded) {
+					return nil, ErrInvalidPolyline
+				}
+				b := int(encoded[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, ErrInvalidPolyline
+				}
+				result |= uint(b&0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[j] = ^int(result >> 1)
+			} else {
+				deltas[j] = int(result >> 1)
+			}
+		}
+		lat += deltas[0]
+		lng += deltas[1]
+		points = append(points, LatLng{Latitude: float64(lat) / 1e5, Longitude: float64(lng) / 1e5})
+	}
+	return points, nil
+}
+
+// EncodePolyline encodes points with the Google encoded polyline algorithm, the inverse of DecodePolyline
+func EncodePolyline(points []LatLng) string {
+	var buf []byte
+	var prevLat, prevLng int
+	for _, p := range points {
+		lat := int(math.Round(p.Latitude * 1e5))
+		lng := int(math.Round(p.Longitude * 1e5))
+		for _, delta := range []int{lat - prevLat, lng - prevLng} {
+			v := uint(delta << 1)
+			if delta < 0 {
+				v = ^v
+			}
+			for v >= 0x20 {
+				buf = append(buf, byte((0x20|(v&0x1f))+63))
+				v >>= 5
+			}
+			buf = append(buf, byte(v+63))
+		}
+		prevLat, prevLng = lat, lng
+	}
+	return string(buf)
+}
+
+// Points decodes the shape's Polyline into its points
+func (s *Shape) Points() ([]LatLng, error) {
+	return DecodePolyline(s.Polyline)
+}
+
+// DistanceTo great-circle distance in meters to another point using the haversine formula
+func (a LatLng) DistanceTo(b LatLng) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// polylinePath a decoded polyline with the cumulative distance (in meters) to each of its points
+type polylinePath struct {
+	points  []LatLng
+	cumDist []float64
+}
+
+func newPolylinePath(points []LatLng) *polylinePath {
+	p := &polylinePath{points: points, cumDist: make([]float64, len(points))}
+	for i := 1; i < len(points); i++ {
+		p.cumDist[i] = p.cumDist[i-1] + points[i-1].DistanceTo(points[i])
+	}
+	return p
+}
+
+// length total length of the path in meters
+func (p *polylinePath) length() float64 {
+	if len(p.cumDist) == 0 {
+		return 0
+	}
+	return p.cumDist[len(p.cumDist)-1]
+}
+
+// project finds the closest point on the path to pt whose distance along the path is within [minAlong, maxAlong].
+// It returns the distance along the path of that point and how far pt is from it, both in meters
+func (p *polylinePath) project(pt LatLng, minAlong, maxAlong float64) (along float64, offset float64) {
+	if len(p.points) == 1 {
+		return 0, pt.DistanceTo(p.points[0])
+	}
+	offset = math.Inf(1)
+	for i := 1; i < len(p.points); i++ {
+		if p.cumDist[i] < minAlong || p.cumDist[i-1] > maxAlong {
+			continue
+		}
+		a, b := p.points[i-1], p.points[i]
+		// project onto the segment using an equirectangular approximation around a, fine for segments this short
+		cosLat := math.Cos(a.Latitude * math.Pi / 180)
+		bx, by := (b.Longitude-a.Longitude)*cosLat, b.Latitude-a.Latitude
+		px, py := (pt.Longitude-a.Longitude)*cosLat, pt.Latitude-a.Latitude
+		t := 0.0
+		if segLen := bx*bx + by*by; segLen > 0 {
+			t = math.Max(0, math.Min(1, (px*bx+py*by)/segLen))
+		}
+		segAlong := p.cumDist[i-1] + t*(p.cumDist[i]-p.cumDist[i-1])
+		if segAlong < minAlong || segAlong > maxAlong {
+			segAlong = math.Max(minAlong, math.Min(maxAlong, segAlong))
+			t = 0
+			if segDist := p.cumDist[i] - p.cumDist[i-1]; segDist > 0 {
+				t = (segAlong - p.cumDist[i-1]) / segDist
+			}
+		}
+		closest := LatLng{Latitude: a.Latitude + t*(b.Latitude-a.Latitude), Longitude: a.Longitude + t*(b.Longitude-a.Longitude)}
+		if d := pt.DistanceTo(closest); d < offset {
+			along, offset = segAlong, d
+		}
+	}
+	if math.IsInf(offset, 1) && (minAlong > 0 || maxAlong < p.length()) {
+		// nothing in the window, fall back to the whole path
+		return p.project(pt, 0, p.length())
+	}
+	return along, offset
+}
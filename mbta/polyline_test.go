@@ -0,0 +1,50 @@
+package mbta
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	expected := []LatLng{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	actual, err := DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	ok(t, err)
+	equals(t, len(expected), len(actual))
+	for i := range expected {
+		assert(t, math.Abs(expected[i].Latitude-actual[i].Latitude) < 1e-9, "latitude %d: exp %v got %v", i, expected[i], actual[i])
+		assert(t, math.Abs(expected[i].Longitude-actual[i].Longitude) < 1e-9, "longitude %d: exp %v got %v", i, expected[i], actual[i])
+	}
+
+	_, err = DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq")
+	equals(t, ErrInvalidPolyline, err)
+}
+
+func TestEncodePolyline(t *testing.T) {
+	points := []LatLng{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	equals(t, "_p~iF~ps|U_ulLnnqC_mqNvxq`@", EncodePolyline(points))
+}
+
+func Test_polylinePath_project(t *testing.T) {
+	path := newPolylinePath([]LatLng{
+		{Latitude: 42.0, Longitude: -71.0},
+		{Latitude: 42.01, Longitude: -71.0},
+		{Latitude: 42.01, Longitude: -71.01},
+	})
+	assert(t, math.Abs(path.length()-(1111.95+826.35)) < 1, "unexpected length %v", path.length())
+
+	along, offset := path.project(LatLng{Latitude: 42.005, Longitude: -70.9999}, 0, path.length())
+	assert(t, math.Abs(along-555.97) < 1, "unexpected along %v", along)
+	assert(t, math.Abs(offset-8.26) < 0.1, "unexpected offset %v", offset)
+
+	// restricted to the second segment the closest point is its start
+	along, _ = path.project(LatLng{Latitude: 42.005, Longitude: -70.9999}, 1200, path.length())
+	assert(t, math.Abs(along-1200) < 1, "unexpected along %v", along)
+}
@@ -9,27 +9,16 @@ import (
 
 const predictionsAPIPath = "/predictions"
 
-// PredictionScheduleRelationshipType possible values for the ScheduleRelationship field in a Prediction
-type PredictionScheduleRelationshipType string
-
-const (
-	ScheduleRelationshipAdded       PredictionScheduleRelationshipType = "ADDED"
-	ScheduleRelationshipCancelled   PredictionScheduleRelationshipType = "CANCELLED"
-	ScheduleRelationshipNoData      PredictionScheduleRelationshipType = "NO_DATA"
-	ScheduleRelationshipSkipped     PredictionScheduleRelationshipType = "SKIPPED"
-	ScheduleRelationshipUnscheduled PredictionScheduleRelationshipType = "UNSCHEDULED"
-)
-
-// PredictionService service handling all of the prediction related API calls
+// PredictionService handling all of the prediction related API calls
 type PredictionService service
 
-// Prediction holds all info about a given MBTA prediction
+// Prediction holds all the info about a given MBTA prediction
 type Prediction struct {
 	ID                   string                              `jsonapi:"primary,prediction"`
 	ArrivalTime          *TimeISO8601                        `jsonapi:"attr,arrival_time"`          // Time when the trip arrives at the given stop
 	DepartureTime        *TimeISO8601                        `jsonapi:"attr,departure_time"`        // Time when the trip departs the given stop
-	DirectionID          int                                 `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1.
-	ScheduleRelationship *PredictionScheduleRelationshipType `jsonapi:"attr,schedule_relationship"` // How the predicted stop relates to the Model.Schedule.t stops.
+	DirectionID          int                                 `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1
+	ScheduleRelationship *PredictionScheduleRelationshipType `jsonapi:"attr,schedule_relationship"` // How the predicted stop relates to the Model.Schedule.t stops
 	Status               *string                             `jsonapi:"attr,status"`                // Status of the schedule
 	StopSequence         int                                 `jsonapi:"attr,stop_sequence"`         // The sequence the stop_id is arrived at during the trip_id. The stop sequence is monotonically increasing along the trip, but the stop_sequence along the trip_id are not necessarily consecutive
 	Route                *Route                              `jsonapi:"relation,route"`             // Route that the prediction is linked with. Only includes id by default, use Include config option to get all data
@@ -38,18 +27,43 @@ type Prediction struct {
 	Trip                 *Trip                               `jsonapi:"relation,trip"`              // Trip that the prediction is linked with. Only includes id by default, use Include config option to get all data
 	Vehicle              *Vehicle                            `jsonapi:"relation,vehicle"`           // Vehicle that the prediction is linked with. Only includes id by default, use Include config option to get all data
 	Alerts               []*Alert                            `jsonapi:"relation,alerts"`
+	fieldPresence
 }
 
+// PredictionField an attribute or relationship of a prediction, for a Fieldset
+type PredictionField string
+
+const (
+	PredictionFieldArrivalTime          PredictionField = "arrival_time"
+	PredictionFieldDepartureTime        PredictionField = "departure_time"
+	PredictionFieldDirectionID          PredictionField = "direction_id"
+	PredictionFieldScheduleRelationship PredictionField = "schedule_relationship"
+	PredictionFieldStatus               PredictionField = "status"
+	PredictionFieldStopSequence         PredictionField = "stop_sequence"
+	PredictionFieldRoute                PredictionField = "route"
+	PredictionFieldSchedule             PredictionField = "schedule"
+	PredictionFieldStop                 PredictionField = "stop"
+	PredictionFieldTrip                 PredictionField = "trip"
+	PredictionFieldVehicle              PredictionField = "vehicle"
+	PredictionFieldAlerts               PredictionField = "alerts"
+)
+
+func (f PredictionField) resourceType() string { return "prediction" }
+func (f PredictionField) fieldName() string    { return string(f) }
+
+// Has whether the prediction has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (p *Prediction) Has(field PredictionField) bool { return p.has(string(field)) }
+
 // PredictionInclude all of the includes for a prediction request
 type PredictionInclude string
 
 const (
-	PredictionIncludeSchedule PredictionInclude = includeSchedule
-	PredictionIncludeStop     PredictionInclude = includeStop
-	PredictionIncludeRoute    PredictionInclude = includeRoute
-	PredictionIncludeTrip     PredictionInclude = includeTrip
-	PredictionIncludeVehicle  PredictionInclude = includeVehicle
-	PredictionIncludeAlerts   PredictionInclude = includeAlerts
+	PredictionIncludeSchedule PredictionInclude = "schedule"
+	PredictionIncludeStop     PredictionInclude = "stop"
+	PredictionIncludeRoute    PredictionInclude = "route"
+	PredictionIncludeTrip     PredictionInclude = "trip"
+	PredictionIncludeVehicle  PredictionInclude = "vehicle"
+	PredictionIncludeAlerts   PredictionInclude = "alerts"
 )
 
 // PredictionsSortByType all of the possible ways to sort by for a GetAllPredictions request
@@ -74,9 +88,10 @@ const (
 type GetAllPredictionsRequestConfig struct {
 	PageOffset        string                `url:"page[offset],omitempty"`             // Offset (0-based) of first element in the page
 	PageLimit         string                `url:"page[limit],omitempty"`              // Max number of elements to return
-	Sort              PredictionsSortByType `url:"sort,omitempty"`                     // Results can be sorted by the id or any PredictionsSortByType
-	Fields            []string              `url:"fields[prediction],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include           []PredictionInclude   `url:"include,comma,omitempty"`            // Include extra data in response
+	Sort              PredictionsSortByType `url:"sort,omitempty"`                     // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields            []string              `url:"fields[prediction],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset          Fieldset              `url:"fields,omitempty"`                   // Fields to include for each resource type, included ones too. Replaces Fields for prediction if it has an entry for it
+	Include           []PredictionInclude   `url:"include,comma,omitempty"`            // Relationships to include
 	FilterLatitude    string                `url:"filter[latitude],omitempty"`         // Latitude/Longitude must be both present or both absent
 	FilterLongitude   string                `url:"filter[longitude],omitempty"`        // Latitude/Longitude must be both present or both absent
 	FilterRadius      string                `url:"filter[radius],omitempty"`           // Radius accepts a floating point number, and the default is 0.01. For example, if you query for: latitude: 42, longitude: -71, radius: 0.05 then you will filter between latitudes 41.95 and 42.05, and longitudes -70.95 and -71.05
@@ -96,11 +111,8 @@ func (s *PredictionService) GetAllPredictions(config *GetAllPredictionsRequestCo
 // GetAllPredictionsWithContext returns all predictions from the mbta API given a context
 // NOTE: A filter MUST be present for any predictions to be returned.
 func (s *PredictionService) GetAllPredictionsWithContext(ctx context.Context, config *GetAllPredictionsRequestConfig) ([]*Prediction, *http.Response, error) {
-	isEmptyString := func(s string) bool { return s == "" }
-	isEmptySlice := func(s []string) bool { return len(s) == 0 }
-	// Ensure that a filter is set
-	if isEmptySlice(config.FilterRouteType) && isEmptySlice(config.FilterRouteIDs) && isEmptySlice(config.FilterStopIDs) && isEmptySlice(config.FilterTripIDs) && isEmptyString(config.FilterLatitude) && isEmptyString(config.FilterLongitude) && isEmptyString(config.FilterRadius) && isEmptyString(config.FilterDirectionID) {
-		return nil, nil, xerrors.Errorf("A filter must be present for any predictions to be returned: %w", ErrInvalidConfig)
+	if err := config.validate(); err != nil {
+		return nil, nil, err
 	}
 
 	u, err := addOptions(predictionsAPIPath, config)
@@ -113,10 +125,30 @@ func (s *PredictionService) GetAllPredictionsWithContext(ctx context.Context, co
 	}
 	req = req.WithContext(ctx)
 
-	untypedPredictions, resp, err := s.client.doManyPayload(req, &Prediction{})
-	predictions := make([]*Prediction, len(untypedPredictions))
-	for i := 0; i < len(untypedPredictions); i++ {
-		predictions[i] = untypedPredictions[i].(*Prediction)
+	untyped, resp, err := s.client.doManyPayload(req, &Prediction{})
+	predictions := make([]*Prediction, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		predictions[i] = untyped[i].(*Prediction)
 	}
 	return predictions, resp, err
 }
+
+// PredictionScheduleRelationshipType possible values for the ScheduleRelationship field in a Prediction
+type PredictionScheduleRelationshipType string
+
+const (
+	ScheduleRelationshipAdded       PredictionScheduleRelationshipType = "ADDED"
+	ScheduleRelationshipCancelled   PredictionScheduleRelationshipType = "CANCELLED"
+	ScheduleRelationshipNoData      PredictionScheduleRelationshipType = "NO_DATA"
+	ScheduleRelationshipSkipped     PredictionScheduleRelationshipType = "SKIPPED"
+	ScheduleRelationshipUnscheduled PredictionScheduleRelationshipType = "UNSCHEDULED"
+)
+
+// validate checks a filter is set, as the API returns no predictions without one
+func (config *GetAllPredictionsRequestConfig) validate() error {
+	if config == nil || (len(config.FilterRouteType) == 0 && len(config.FilterRouteIDs) == 0 && len(config.FilterStopIDs) == 0 && len(config.FilterTripIDs) == 0 &&
+		config.FilterLatitude == "" && config.FilterLongitude == "" && config.FilterRadius == "" && config.FilterDirectionID == "") {
+		return xerrors.Errorf("A filter must be present for any predictions to be returned: %w", ErrInvalidConfig)
+	}
+	return nil
+}
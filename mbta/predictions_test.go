@@ -1,6 +1,8 @@
 package mbta
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -22,11 +24,13 @@ func Test_GetAllPredictions(t *testing.T) {
 			ScheduleRelationship: nil,
 			Status:               nil,
 			StopSequence:         50,
-			Route:                &Route{ID: "Green-B"},
-			Stop:                 &Stop{ID: "70196"},
-			Trip:                 &Trip{ID: "39990839-20:30-NewtonHighlandsRiverside"},
+			Route:                &Route{ID: "Green-B", fieldPresence: presentFields()},
+			Stop:                 &Stop{ID: "70196", fieldPresence: presentFields()},
+			Trip:                 &Trip{ID: "39990839-20:30-NewtonHighlandsRiverside", fieldPresence: presentFields()},
 			Vehicle:              nil,
 			Schedule:             nil,
+
+			fieldPresence: missingFields(PredictionFieldSchedule, PredictionFieldAlerts),
 		},
 		&Prediction{
 			ID:                   "prediction-39990840-20:30-NewtonHighlandsRiverside-70196-50",
@@ -36,11 +40,13 @@ func Test_GetAllPredictions(t *testing.T) {
 			ScheduleRelationship: nil,
 			Status:               nil,
 			StopSequence:         50,
-			Route:                &Route{ID: "Green-B"},
-			Stop:                 &Stop{ID: "70196"},
-			Trip:                 &Trip{ID: "39990840-20:30-NewtonHighlandsRiverside"},
+			Route:                &Route{ID: "Green-B", fieldPresence: presentFields()},
+			Stop:                 &Stop{ID: "70196", fieldPresence: presentFields()},
+			Trip:                 &Trip{ID: "39990840-20:30-NewtonHighlandsRiverside", fieldPresence: presentFields()},
 			Vehicle:              nil,
 			Schedule:             nil,
+
+			fieldPresence: missingFields(PredictionFieldSchedule, PredictionFieldAlerts),
 		},
 	}
 	opts := &GetAllPredictionsRequestConfig{FilterRouteIDs: []string{"Green-B"}}
@@ -66,3 +72,44 @@ func Test_GetAllPredictionsFail(t *testing.T) {
 	_, _, err := mbtaClient.Predictions.GetAllPredictions(&GetAllPredictionsRequestConfig{})
 	equals(t, true, xerrors.Is(err, ErrInvalidConfig))
 }
+
+func Test_GetAllPredictionsScheduleRelationship(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"type": "prediction", "id": "skipped", "attributes": {"schedule_relationship": "SKIPPED", "status": null}},
+			{"type": "prediction", "id": "scheduled", "attributes": {"schedule_relationship": null, "status": "Boarding"},
+				"relationships": {"vehicle": {"data": {"type": "vehicle", "id": "y1772"}}}}
+		], "included": [
+			{"type": "vehicle", "id": "y1772", "attributes": {"occupancy_status": "FULL", "current_stop_sequence": 3}}
+		]}`)
+	}))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	predictions, _, err := mbtaClient.Predictions.GetAllPredictions(&GetAllPredictionsRequestConfig{FilterStopIDs: []string{"70196"}})
+	ok(t, err)
+	equals(t, []*Prediction{
+		{
+			ID:                   "skipped",
+			ScheduleRelationship: scheduleRelationshipPtr(ScheduleRelationshipSkipped),
+			fieldPresence:        presentFields(PredictionFieldScheduleRelationship, PredictionFieldStatus),
+		},
+		{
+			ID:     "scheduled",
+			Status: strPtr("Boarding"),
+			Vehicle: &Vehicle{
+				ID:                  "y1772",
+				OccupancyStatus:     occupancyStatusPtr(OccupancyFull),
+				CurrentStopSequence: intPtr(3),
+				fieldPresence:       presentFields(VehicleFieldOccupancyStatus, VehicleFieldCurrentStopSequence),
+			},
+			fieldPresence: presentFields(PredictionFieldScheduleRelationship, PredictionFieldStatus, PredictionFieldVehicle),
+		},
+	}, predictions)
+	// null and left out are both nil, Has tells them apart
+	assert(t, predictions[1].Has(PredictionFieldScheduleRelationship), "the schedule relationship was null")
+	assert(t, !predictions[1].Has(PredictionFieldArrivalTime), "the arrival time was left out")
+	assert(t, !predictions[1].Vehicle.Has(VehicleFieldBearing), "the vehicle's bearing was left out")
+}
@@ -0,0 +1,31 @@
+package mbta
+
+import (
+	"context"
+	"net/http"
+)
+
+// MediaTypeEventStream the Accept header of a GetRaw request for a stream of the resources' changes
+const MediaTypeEventStream = "text/event-stream"
+
+// GetRaw sends a GET request for path, any API path with its query string (e.g. "/predictions?filter[route]=Red"),
+// with the client's API key and User-Agent, and returns the response as is, whatever its status, for the caller to
+// read and close. accept is the Accept header, "" for JSON:API or MediaTypeEventStream for a stream. With a
+// WithIfModifiedSince context it returns ErrNotModified if the resource hasn't changed. The request always goes to
+// BaseURL's host: a path that isn't rooted or names a host of its own is ErrInvalidPath.
+// The Observer and Logger see the call once the response headers have been received, without its body
+func (c *Client) GetRaw(ctx context.Context, path string, accept string) (*http.Response, error) {
+	req, err := c.newGETRequest(path)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req, cl := c.startCall(req.WithContext(ctx))
+	// the body is the caller's, and a stream never ends
+	cl.captureBody = false
+	resp, err := c.do(req, cl)
+	cl.done(resp, err, false)
+	return resp, err
+}
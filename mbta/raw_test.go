@@ -0,0 +1,67 @@
+package mbta
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestGetRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		equals(t, "key", r.Header.Get("x-api-key"))
+		if r.Header.Get("If-Modified-Since") == "Tue, 14 May 2019 20:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", r.Header.Get("Accept"))
+		if r.URL.Path == "/nope" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprint(w, r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	logger := &recordingLogger{wantsBody: true}
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL, APIKey: "key", Observer: observer, Logger: logger})
+	mbtaClient.client = server.Client()
+
+	resp, err := mbtaClient.GetRaw(context.Background(), "/predictions?filter%5Broute%5D=Red", "")
+	ok(t, err)
+	equals(t, "application/vnd.api+json", resp.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(resp.Body)
+	ok(t, err)
+	resp.Body.Close()
+	equals(t, "filter%5Broute%5D=Red", string(b))
+	equals(t, RequestInfo{Service: "Predictions", Method: "GetAllPredictions", URL: resp.Request.URL}, observer.started[0])
+	equals(t, []byte(nil), logger.entries[0].Body)
+
+	resp, err = mbtaClient.GetRaw(context.Background(), "/vehicles", MediaTypeEventStream)
+	ok(t, err)
+	equals(t, MediaTypeEventStream, resp.Header.Get("Content-Type"))
+	resp.Body.Close()
+
+	// other statuses are the caller's to handle
+	resp, err = mbtaClient.GetRaw(context.Background(), "/nope", "")
+	ok(t, err)
+	equals(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	ctx := WithIfModifiedSince(context.Background(), "Tue, 14 May 2019 20:00:00 GMT")
+	_, err = mbtaClient.GetRaw(ctx, "/vehicles", "")
+	assert(t, xerrors.Is(err, ErrNotModified), "expected ErrNotModified, got %v", err)
+	equals(t, true, observer.done[3].CacheHit)
+	equals(t, 4, len(logger.entries))
+
+	// a path naming another host is never sent, to it or to BaseURL
+	for _, path := range []string{"//attacker.example/x", "http://attacker.example/x", "vehicles"} {
+		_, err = mbtaClient.GetRaw(context.Background(), path, "")
+		assert(t, xerrors.Is(err, ErrInvalidPath), "%s: expected ErrInvalidPath, got %v", path, err)
+	}
+	equals(t, 4, len(observer.started))
+}
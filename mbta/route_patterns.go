@@ -6,28 +6,12 @@ import (
 	"net/http"
 )
 
-const routesPatternsAPIPath = "/route-patterns"
+const routePatternsAPIPath = "/route_patterns"
 
-// RoutePatternsService handling all of the route-patterns related API calls
+// RoutePatternsService handling all of the route pattern related API calls
 type RoutePatternsService service
 
-// RoutePatternTypicalityType Explains how common the route pattern is. For the MBTA, this is within the context of the entire route
-type RoutePatternTypicalityType int
-
-const (
-	// RoutePatternTypicalityNotDefined Not defined
-	RoutePatternTypicalityNotDefined RoutePatternTypicalityType = iota
-	// RoutePatternTypicalityTypical Typical. Pattern is common for the route. Most routes will have only one such pattern per direction. A few routes may have more than 1, such as the Red Line (with one branch to Ashmont and another to Braintree); routes with more than 2 are rare
-	RoutePatternTypicalityTypical
-	// RoutePatternTypicalityDeviation Pattern is a deviation from the regular route
-	RoutePatternTypicalityDeviation
-	// RoutePatternTypicalityHighlyAtypical Pattern represents a highly atypical pattern for the route, such as a special routing which only runs a handful of times per day
-	RoutePatternTypicalityHighlyAtypical
-	// RoutePatternTypicalityDiversionFromNormal Diversions from normal service, such as planned detours, bus shuttles, or snow routes
-	RoutePatternTypicalityDiversionFromNormal
-)
-
-// RoutePattern holds all the info about a given MBTA route-pattern
+// RoutePattern holds all the info about a given MBTA route pattern
 type RoutePattern struct {
 	ID                 string                     `jsonapi:"primary,route_pattern"`
 	Typicality         RoutePatternTypicalityType `jsonapi:"attr,typicality"`              // Explains how common the route pattern is. For the MBTA, this is within the context of the entire route
@@ -37,14 +21,34 @@ type RoutePattern struct {
 	DirectionID        int                        `jsonapi:"attr,direction_id"`            // Direction in which trip is traveling: 0 or 1
 	RepresentativeTrip *Trip                      `jsonapi:"relation,representative_trip"` // A trip that can be considered a canonical trip for the route pattern. This trip can be used to deduce a pattern’s canonical set of stops and shape. Only includes id by default, use Include config option to get all data
 	Route              *Route                     `jsonapi:"relation,route"`               // The route that this pattern belongs to. Only includes id by default, use Include config option to get all data
+	fieldPresence
 }
 
-// RoutePatternInclude all of the includes for a route-pattern request
+// RoutePatternField an attribute or relationship of a route pattern, for a Fieldset
+type RoutePatternField string
+
+const (
+	RoutePatternFieldTypicality         RoutePatternField = "typicality"
+	RoutePatternFieldTimeDesc           RoutePatternField = "time_desc"
+	RoutePatternFieldSortOrder          RoutePatternField = "sort_order"
+	RoutePatternFieldName               RoutePatternField = "name"
+	RoutePatternFieldDirectionID        RoutePatternField = "direction_id"
+	RoutePatternFieldRepresentativeTrip RoutePatternField = "representative_trip"
+	RoutePatternFieldRoute              RoutePatternField = "route"
+)
+
+func (f RoutePatternField) resourceType() string { return "route_pattern" }
+func (f RoutePatternField) fieldName() string    { return string(f) }
+
+// Has whether the route pattern has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (r *RoutePattern) Has(field RoutePatternField) bool { return r.has(string(field)) }
+
+// RoutePatternInclude all of the includes for a route pattern request
 type RoutePatternInclude string
 
 const (
-	RoutePatternIncludeRoute              RoutePatternInclude = includeLine
-	RoutePatternIncludeRepresentativeTrip RoutePatternInclude = includeRepresentativeTrip
+	RoutePatternIncludeRoute              RoutePatternInclude = "route"
+	RoutePatternIncludeRepresentativeTrip RoutePatternInclude = "representative_trip"
 )
 
 // RoutePatternsSortByType all of the possible ways to sort by for a GetAllRoutePatterns request
@@ -67,21 +71,21 @@ const (
 type GetAllRoutePatternsRequestConfig struct {
 	PageOffset        string                  `url:"page[offset],omitempty"`         // Offset (0-based) of first element in the page
 	PageLimit         string                  `url:"page[limit],omitempty"`          // Max number of elements to return
-	Sort              RoutePatternsSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any RoutesSortByType
-	Include           []RoutePatternInclude   `url:"include,comma,omitempty"`        // Include extra data in response
+	Sort              RoutePatternsSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Include           []RoutePatternInclude   `url:"include,comma,omitempty"`        // Relationships to include
 	FilterDirectionID string                  `url:"filter[direction_id],omitempty"` // Filter by Direction ID (Either "0" or "1")
 	FilterIDs         []string                `url:"filter[id],comma,omitempty"`     // Filter by multiple IDs
-	FilterRouteIDs    []string                `url:"filter[route],comma,omitempty"`  // Filter by stops
+	FilterRouteIDs    []string                `url:"filter[route],comma,omitempty"`  // Filter by route IDs
 }
 
-// GetAllRoutePatterns returns all routes from the mbta API
+// GetAllRoutePatterns returns all route patterns from the mbta API
 func (s *RoutePatternsService) GetAllRoutePatterns(config *GetAllRoutePatternsRequestConfig) ([]*RoutePattern, *http.Response, error) {
 	return s.GetAllRoutePatternsWithContext(context.Background(), config)
 }
 
-// GetAllRoutePatternsWithContext returns all routes from the mbta API given a context
+// GetAllRoutePatternsWithContext returns all route patterns from the mbta API given a context
 func (s *RoutePatternsService) GetAllRoutePatternsWithContext(ctx context.Context, config *GetAllRoutePatternsRequestConfig) ([]*RoutePattern, *http.Response, error) {
-	u, err := addOptions(routesPatternsAPIPath, config)
+	u, err := addOptions(routePatternsAPIPath, config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -91,27 +95,31 @@ func (s *RoutePatternsService) GetAllRoutePatternsWithContext(ctx context.Contex
 	}
 	req = req.WithContext(ctx)
 
-	untypedRoutePatterns, resp, err := s.client.doManyPayload(req, &RoutePattern{})
-	routePatterns := make([]*RoutePattern, len(untypedRoutePatterns))
-	for i := 0; i < len(untypedRoutePatterns); i++ {
-		routePatterns[i] = untypedRoutePatterns[i].(*RoutePattern)
+	untyped, resp, err := s.client.doManyPayload(req, &RoutePattern{})
+	routePatterns := make([]*RoutePattern, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		routePatterns[i] = untyped[i].(*RoutePattern)
 	}
 	return routePatterns, resp, err
 }
 
-// GetRoutePatternRequestConfig extra options for GetRoutePattern request
+// GetRoutePatternRequestConfig extra options for the GetRoutePattern request
 type GetRoutePatternRequestConfig struct {
-	Include []RouteInclude `url:"include,comma,omitempty"` // Include extra data in response
+	Include []RoutePatternInclude `url:"include,comma,omitempty"` // Relationships to include
 }
 
-// GetRoutePattern return a route from the mbta API
+// GetRoutePattern returns a route pattern from the mbta API
 func (s *RoutePatternsService) GetRoutePattern(id string, config *GetRoutePatternRequestConfig) (*RoutePattern, *http.Response, error) {
 	return s.GetRoutePatternWithContext(context.Background(), id, config)
 }
 
-// GetRoutePatternWithContext return a route from the mbta API given a context
+// GetRoutePatternWithContext returns a route pattern from the mbta API given a context
 func (s *RoutePatternsService) GetRoutePatternWithContext(ctx context.Context, id string, config *GetRoutePatternRequestConfig) (*RoutePattern, *http.Response, error) {
-	path := fmt.Sprintf("%s/%s", routesPatternsAPIPath, id)
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
+	path := fmt.Sprintf("%s/%s", routePatternsAPIPath, id)
 	u, err := addOptions(path, config)
 	if err != nil {
 		return nil, nil, err
@@ -126,3 +134,37 @@ func (s *RoutePatternsService) GetRoutePatternWithContext(ctx context.Context, i
 	resp, err := s.client.doSinglePayload(req, &routePattern)
 	return &routePattern, resp, err
 }
+
+// GetRoutePatterns returns the route patterns with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *RoutePatternsService) GetRoutePatterns(ids []string) ([]*RoutePattern, error) {
+	return s.GetRoutePatternsWithContext(context.Background(), ids)
+}
+
+// GetRoutePatternsWithContext returns the route patterns with the given IDs from the mbta API given a context
+func (s *RoutePatternsService) GetRoutePatternsWithContext(ctx context.Context, ids []string) ([]*RoutePattern, error) {
+	untyped, err := s.client.batchGet(ctx, routePatternsAPIPath, ids, &RoutePattern{}, func(ids []string) interface{} {
+		return &GetAllRoutePatternsRequestConfig{FilterIDs: ids}
+	})
+	routePatterns := make([]*RoutePattern, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		routePatterns[i], _ = untyped[i].(*RoutePattern)
+	}
+	return routePatterns, err
+}
+
+// RoutePatternTypicalityType Explains how common the route pattern is. For the MBTA, this is within the context of the entire route
+type RoutePatternTypicalityType int
+
+const (
+	// RoutePatternTypicalityNotDefined Not defined
+	RoutePatternTypicalityNotDefined RoutePatternTypicalityType = iota
+	// RoutePatternTypicalityTypical Typical. Pattern is common for the route. Most routes will have only one such pattern per direction. A few routes may have more than 1, such as the Red Line (with one branch to Ashmont and another to Braintree); routes with more than 2 are rare
+	RoutePatternTypicalityTypical
+	// RoutePatternTypicalityDeviation Pattern is a deviation from the regular route
+	RoutePatternTypicalityDeviation
+	// RoutePatternTypicalityHighlyAtypical Pattern represents a highly atypical pattern for the route, such as a special routing which only runs a handful of times per day
+	RoutePatternTypicalityHighlyAtypical
+	// RoutePatternTypicalityDiversionFromNormal Diversions from normal service, such as planned detours, bus shuttles, or snow routes
+	RoutePatternTypicalityDiversionFromNormal
+)
@@ -15,13 +15,15 @@ func TestGetRoutePattern(t *testing.T) {
 		TimeDesc:    nil,
 		Typicality:  RoutePatternTypicalityTypical,
 		RepresentativeTrip: &Trip{
-			ID: "39923059",
+			ID:            "39923059",
+			fieldPresence: presentFields(),
 		},
 		Route: &Route{
-			ID: "Mattapan",
+			ID:            "Mattapan",
+			fieldPresence: presentFields(),
 		},
 	}
-	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", routesPatternsAPIPath, "Mattapan-_-0")))
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", routePatternsAPIPath, "Mattapan-_-0")))
 	defer server.Close()
 
 	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
@@ -42,10 +44,12 @@ func TestGetAllRoutePatterns(t *testing.T) {
 			TimeDesc:    nil,
 			Typicality:  RoutePatternTypicalityTypical,
 			RepresentativeTrip: &Trip{
-				ID: "40132582-L",
+				ID:            "40132582-L",
+				fieldPresence: presentFields(),
 			},
 			Route: &Route{
-				ID: "Red",
+				ID:            "Red",
+				fieldPresence: presentFields(),
 			},
 		},
 		&RoutePattern{
@@ -56,14 +60,16 @@ func TestGetAllRoutePatterns(t *testing.T) {
 			TimeDesc:    nil,
 			Typicality:  RoutePatternTypicalityTypical,
 			RepresentativeTrip: &Trip{
-				ID: "40132593-L",
+				ID:            "40132593-L",
+				fieldPresence: presentFields(),
 			},
 			Route: &Route{
-				ID: "Red",
+				ID:            "Red",
+				fieldPresence: presentFields(),
 			},
 		},
 	}
-	server := httptest.NewServer(handlerForServer(t, routesPatternsAPIPath))
+	server := httptest.NewServer(handlerForServer(t, routePatternsAPIPath))
 	defer server.Close()
 
 	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
@@ -11,23 +11,7 @@ const routesAPIPath = "/routes"
 // RouteService handling all of the route related API calls
 type RouteService service
 
-// RouteType enum for possible Route types (see https://github.com/google/transit/blob/master/gtfs/spec/en/reference.md#routestxt)
-type RouteType int
-
-const (
-	// RouteTypeLightRail ...
-	RouteTypeLightRail RouteType = iota
-	// RouteTypeHeavyRail ...
-	RouteTypeHeavyRail
-	// RouteTypeCommuterRail ...
-	RouteTypeCommuterRail
-	// RouteTypeBus ...
-	RouteTypeBus
-	// RouteTypeFerry ...
-	RouteTypeFerry
-)
-
-// Route holds all the info about a given MBTA Route
+// Route holds all the info about a given MBTA route
 type Route struct {
 	ID                    string    `jsonapi:"primary,route"`
 	Color                 string    `jsonapi:"attr,color"`
@@ -40,52 +24,76 @@ type Route struct {
 	Type                  RouteType `jsonapi:"attr,type"`
 	ShortName             string    `jsonapi:"attr,short_name"`
 	Line                  *Line     `jsonapi:"relation,line"`
+	fieldPresence
 }
 
+// RouteField an attribute or relationship of a route, for a Fieldset
+type RouteField string
+
+const (
+	RouteFieldColor                 RouteField = "color"
+	RouteFieldDescription           RouteField = "description"
+	RouteFieldDirectionDestinations RouteField = "direction_destinations"
+	RouteFieldDirectionNames        RouteField = "direction_names"
+	RouteFieldLongName              RouteField = "long_name"
+	RouteFieldSortOrder             RouteField = "sort_order"
+	RouteFieldTextColor             RouteField = "text_color"
+	RouteFieldType                  RouteField = "type"
+	RouteFieldShortName             RouteField = "short_name"
+	RouteFieldLine                  RouteField = "line"
+)
+
+func (f RouteField) resourceType() string { return "route" }
+func (f RouteField) fieldName() string    { return string(f) }
+
+// Has whether the route has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (r *Route) Has(field RouteField) bool { return r.has(string(field)) }
+
 // RouteInclude all of the includes for a route request
 type RouteInclude string
 
 const (
-	RouteIncludeLine          RouteInclude = includeLine
-	RouteIncludeStop          RouteInclude = includeStop
-	RouteIncludeRoutePatterns RouteInclude = includeRoutePatterns
+	RouteIncludeLine          RouteInclude = "line"
+	RouteIncludeStop          RouteInclude = "stop"
+	RouteIncludeRoutePatterns RouteInclude = "route_patterns"
 )
 
 // RoutesSortByType all of the possible ways to sort by for a GetAllRoutes request
 type RoutesSortByType string
 
 const (
-	RoutesSortByColorAscending                 RoutesSortByType = "color"
-	RoutesSortByColorDescending                RoutesSortByType = "-color"
-	RoutesSortByDescriptionAscending           RoutesSortByType = "description"
-	RoutesSortByDescriptionDescending          RoutesSortByType = "-description"
-	RoutesSortByDirectionDestinationAscending  RoutesSortByType = "direction_destinations"
-	RoutesSortByDirectionDestinationDescending RoutesSortByType = "-direction_destinations"
-	RoutesSortByDirectionNameAscending         RoutesSortByType = "direction_names"
-	RoutesSortByDirectionNameDescending        RoutesSortByType = "-direction_names"
-	RoutesSortByFareClassAscending             RoutesSortByType = "fare_class"
-	RoutesSortByFareClassDescending            RoutesSortByType = "-fare_class"
-	RoutesSortByLongNameDesending              RoutesSortByType = "long_name"
-	RoutesSortByLongNameDescending             RoutesSortByType = "-long_name"
-	RoutesSortByShortNameAscending             RoutesSortByType = "short_name"
-	RoutesSortByShortNameDescending            RoutesSortByType = "-short_name"
-	RoutesSortBySortOrderAscending             RoutesSortByType = "sort_order"
-	RoutesSortBySortOrderDescending            RoutesSortByType = "-sort_order"
-	RoutesSortByTextColorAscending             RoutesSortByType = "text_color"
-	RoutesSortByTextColorDescending            RoutesSortByType = "-text_color"
-	RoutesSortByTypeAscending                  RoutesSortByType = "type"
-	RoutesSortByTypeDescending                 RoutesSortByType = "-type"
+	RoutesSortByColorAscending                  RoutesSortByType = "color"
+	RoutesSortByColorDescending                 RoutesSortByType = "-color"
+	RoutesSortByDescriptionAscending            RoutesSortByType = "description"
+	RoutesSortByDescriptionDescending           RoutesSortByType = "-description"
+	RoutesSortByDirectionDestinationsAscending  RoutesSortByType = "direction_destinations"
+	RoutesSortByDirectionDestinationsDescending RoutesSortByType = "-direction_destinations"
+	RoutesSortByDirectionNamesAscending         RoutesSortByType = "direction_names"
+	RoutesSortByDirectionNamesDescending        RoutesSortByType = "-direction_names"
+	RoutesSortByFareClassAscending              RoutesSortByType = "fare_class"
+	RoutesSortByFareClassDescending             RoutesSortByType = "-fare_class"
+	RoutesSortByLongNameAscending               RoutesSortByType = "long_name"
+	RoutesSortByLongNameDescending              RoutesSortByType = "-long_name"
+	RoutesSortByShortNameAscending              RoutesSortByType = "short_name"
+	RoutesSortByShortNameDescending             RoutesSortByType = "-short_name"
+	RoutesSortBySortOrderAscending              RoutesSortByType = "sort_order"
+	RoutesSortBySortOrderDescending             RoutesSortByType = "-sort_order"
+	RoutesSortByTextColorAscending              RoutesSortByType = "text_color"
+	RoutesSortByTextColorDescending             RoutesSortByType = "-text_color"
+	RoutesSortByTypeAscending                   RoutesSortByType = "type"
+	RoutesSortByTypeDescending                  RoutesSortByType = "-type"
 )
 
 // GetAllRoutesRequestConfig extra options for the GetAllRoutes request
 type GetAllRoutesRequestConfig struct {
 	PageOffset        string           `url:"page[offset],omitempty"`         // Offset (0-based) of first element in the page
 	PageLimit         string           `url:"page[limit],omitempty"`          // Max number of elements to return
-	Sort              RoutesSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any RoutesSortByType
-	Include           []RouteInclude   `url:"include,comma,omitempty"`        // Include extra data in response
-	Fields            []string         `url:"fields[route],comma,omitempty"`  // Fields to include with the response. Note that fields can also be selected for included data types
+	Sort              RoutesSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Include           []RouteInclude   `url:"include,comma,omitempty"`        // Relationships to include
+	Fields            []string         `url:"fields[route],comma,omitempty"`  // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset          Fieldset         `url:"fields,omitempty"`               // Fields to include for each resource type, included ones too. Replaces Fields for route if it has an entry for it
 	FilterDirectionID string           `url:"filter[direction_id],omitempty"` // Filter by Direction ID (Either "0" or "1")
-	FilterDate        string           `url:"filter[data],omitempty"`         // Filter by date that route is active
+	FilterDate        string           `url:"filter[date],omitempty"`         // Filter by date that route is active
 	FilterIDs         []string         `url:"filter[id],comma,omitempty"`     // Filter by multiple IDs
 	FilterStop        string           `url:"filter[stop],omitempty"`         // Filter by stops
 	FilterRouteTypes  []RouteType      `url:"filter[type],comma,omitempty"`   // Filter by different route types
@@ -108,27 +116,32 @@ func (s *RouteService) GetAllRoutesWithContext(ctx context.Context, config *GetA
 	}
 	req = req.WithContext(ctx)
 
-	untypedRoutes, resp, err := s.client.doManyPayload(req, &Route{})
-	routes := make([]*Route, len(untypedRoutes))
-	for i := 0; i < len(untypedRoutes); i++ {
-		routes[i] = untypedRoutes[i].(*Route)
+	untyped, resp, err := s.client.doManyPayload(req, &Route{})
+	routes := make([]*Route, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		routes[i] = untyped[i].(*Route)
 	}
 	return routes, resp, err
 }
 
-// GetRouteRequestConfig extra options for GetRoute request
+// GetRouteRequestConfig extra options for the GetRoute request
 type GetRouteRequestConfig struct {
-	Fields  []string       `url:"fields[route],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []RouteInclude `url:"include,comma,omitempty"`       // Include extra data in response
+	Fields   []string       `url:"fields[route],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset       `url:"fields,omitempty"`              // Fields to include for each resource type, included ones too. Replaces Fields for route if it has an entry for it
+	Include  []RouteInclude `url:"include,comma,omitempty"`       // Relationships to include
 }
 
-// GetRoute return a route from the mbta API
+// GetRoute returns a route from the mbta API
 func (s *RouteService) GetRoute(id string, config *GetRouteRequestConfig) (*Route, *http.Response, error) {
 	return s.GetRouteWithContext(context.Background(), id, config)
 }
 
-// GetRouteWithContext return a route from the mbta API given a context
+// GetRouteWithContext returns a route from the mbta API given a context
 func (s *RouteService) GetRouteWithContext(ctx context.Context, id string, config *GetRouteRequestConfig) (*Route, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
 	path := fmt.Sprintf("%s/%s", routesAPIPath, id)
 	u, err := addOptions(path, config)
 	if err != nil {
@@ -144,3 +157,51 @@ func (s *RouteService) GetRouteWithContext(ctx context.Context, id string, confi
 	resp, err := s.client.doSinglePayload(req, &route)
 	return &route, resp, err
 }
+
+// GetRoutes returns the routes with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *RouteService) GetRoutes(ids []string) ([]*Route, error) {
+	return s.GetRoutesWithContext(context.Background(), ids)
+}
+
+// GetRoutesWithContext returns the routes with the given IDs from the mbta API given a context
+func (s *RouteService) GetRoutesWithContext(ctx context.Context, ids []string) ([]*Route, error) {
+	untyped, err := s.client.batchGet(ctx, routesAPIPath, ids, &Route{}, func(ids []string) interface{} {
+		return &GetAllRoutesRequestConfig{FilterIDs: ids}
+	})
+	routes := make([]*Route, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		routes[i], _ = untyped[i].(*Route)
+	}
+	return routes, err
+}
+
+// RouteType enum for possible Route types (see https://github.com/google/transit/blob/master/gtfs/spec/en/reference.md#routestxt)
+type RouteType int
+
+const (
+	// RouteTypeLightRail ...
+	RouteTypeLightRail RouteType = iota
+	// RouteTypeHeavyRail ...
+	RouteTypeHeavyRail
+	// RouteTypeCommuterRail ...
+	RouteTypeCommuterRail
+	// RouteTypeBus ...
+	RouteTypeBus
+	// RouteTypeFerry ...
+	RouteTypeFerry
+)
+
+// Names from before the sort and include constants were renamed consistently
+const (
+	// Deprecated: use RoutesSortByDirectionDestinationsAscending
+	RoutesSortByDirectionDestinationAscending = RoutesSortByDirectionDestinationsAscending
+	// Deprecated: use RoutesSortByDirectionDestinationsDescending
+	RoutesSortByDirectionDestinationDescending = RoutesSortByDirectionDestinationsDescending
+	// Deprecated: use RoutesSortByDirectionNamesAscending
+	RoutesSortByDirectionNameAscending = RoutesSortByDirectionNamesAscending
+	// Deprecated: use RoutesSortByDirectionNamesDescending
+	RoutesSortByDirectionNameDescending = RoutesSortByDirectionNamesDescending
+	// Deprecated: use RoutesSortByLongNameAscending
+	RoutesSortByLongNameDesending = RoutesSortByLongNameAscending
+)
@@ -19,7 +19,8 @@ func Test_GetRoute(t *testing.T) {
 		Type:                  RouteTypeBus,
 		ShortName:             "66",
 		Line: &Line{
-			ID: "line-66",
+			ID:            "line-66",
+			fieldPresence: presentFields(),
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", routesAPIPath, "66")))
@@ -47,7 +48,8 @@ func Test_GetAllRoutes(t *testing.T) {
 			Type:                  RouteTypeBus,
 			ShortName:             "66",
 			Line: &Line{
-				ID: "line-66",
+				ID:            "line-66",
+				fieldPresence: presentFields(),
 			},
 		},
 		&Route{
@@ -62,7 +64,8 @@ func Test_GetAllRoutes(t *testing.T) {
 			Type:                  RouteTypeBus,
 			ShortName:             "39",
 			Line: &Line{
-				ID: "line-39",
+				ID:            "line-39",
+				fieldPresence: presentFields(),
 			},
 		},
 	}
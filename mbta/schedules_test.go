@@ -1,6 +1,8 @@
 package mbta
 
 import (
+	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -16,29 +18,33 @@ func Test_GetAllSchedules(t *testing.T) {
 	expected := []*Schedule{
 		&Schedule{
 			ID:            "schedule-39988449-20:30-NewtonHighlandsRiverside-70238-180",
-			ArrivalTime:   timeToTimeISO8601(parsedArrivalTime1),
-			DepartureTime: timeToTimeISO8601(parsedDepartureTime1),
+			ArrivalTime:   timeISO8601Ptr(parsedArrivalTime1),
+			DepartureTime: timeISO8601Ptr(parsedDepartureTime1),
 			DirectionID:   1,
 			DropOffType:   SchedulePickupNotAvailable,
 			PickupType:    SchedulePickupRegular,
 			StopSequence:  180,
 			Timepoint:     ScheduleTimepointEstimates,
-			Route:         &Route{ID: "Green-C"},
-			Stop:          &Stop{ID: "70238"},
-			Trip:          &Trip{ID: "39988449-20:30-NewtonHighlandsRiverside"},
+			Route:         &Route{ID: "Green-C", fieldPresence: presentFields()},
+			Stop:          &Stop{ID: "70238", fieldPresence: presentFields()},
+			Trip:          &Trip{ID: "39988449-20:30-NewtonHighlandsRiverside", fieldPresence: presentFields()},
+
+			fieldPresence: withoutData(ScheduleFieldPrediction),
 		},
 		&Schedule{
 			ID:            "schedule-39988449-20:30-NewtonHighlandsRiverside-70236-190",
-			ArrivalTime:   timeToTimeISO8601(parsedArrivalTime2),
-			DepartureTime: timeToTimeISO8601(parsedDepartureTime2),
+			ArrivalTime:   timeISO8601Ptr(parsedArrivalTime2),
+			DepartureTime: timeISO8601Ptr(parsedDepartureTime2),
 			DirectionID:   1,
 			DropOffType:   SchedulePickupRegular,
 			PickupType:    SchedulePickupRegular,
 			StopSequence:  190,
 			Timepoint:     ScheduleTimepointEstimates,
-			Route:         &Route{ID: "Green-C"},
-			Stop:          &Stop{ID: "70236"},
-			Trip:          &Trip{ID: "39988449-20:30-NewtonHighlandsRiverside"},
+			Route:         &Route{ID: "Green-C", fieldPresence: presentFields()},
+			Stop:          &Stop{ID: "70236", fieldPresence: presentFields()},
+			Trip:          &Trip{ID: "39988449-20:30-NewtonHighlandsRiverside", fieldPresence: presentFields()},
+
+			fieldPresence: withoutData(ScheduleFieldPrediction),
 		},
 	}
 	opts := &GetAllSchedulesRequestConfig{FilterRouteIDs: []string{"Green-C"}}
@@ -64,3 +70,38 @@ func Test_GetAllSchedulesFail(t *testing.T) {
 	_, _, err := mbtaClient.Schedules.GetAllSchedules(&GetAllSchedulesRequestConfig{})
 	equals(t, true, xerrors.Is(err, ErrInvalidConfig))
 }
+
+func Test_GetAllSchedulesNullTimes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [
+			{"type": "schedule", "id": "first", "attributes": {"arrival_time": null, "departure_time": "2019-06-03T05:01:00-04:00", "stop_sequence": 1}},
+			{"type": "schedule", "id": "last", "attributes": {"arrival_time": "2019-06-03T05:30:00-04:00", "departure_time": null, "stop_sequence": 20}},
+			{"type": "schedule", "id": "sparse", "attributes": {"stop_sequence": 10}}
+		]}`)
+	}))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	fieldset := NewFieldset(ScheduleFieldStopSequence)
+	schedules, _, err := mbtaClient.Schedules.GetAllSchedules(&GetAllSchedulesRequestConfig{FilterTripIDs: []string{"t1"}, Fieldset: fieldset})
+	ok(t, err)
+	equals(t, 3, len(schedules))
+
+	first, _ := parseISO8601Time("2019-06-03T05:01:00-04:00")
+	equals(t, (*TimeISO8601)(nil), schedules[0].ArrivalTime)
+	equals(t, timeISO8601Ptr(first), schedules[0].DepartureTime)
+
+	last, _ := parseISO8601Time("2019-06-03T05:30:00-04:00")
+	equals(t, timeISO8601Ptr(last), schedules[1].ArrivalTime)
+	equals(t, (*TimeISO8601)(nil), schedules[1].DepartureTime)
+
+	// not sent at all rather than null, which Has tells apart
+	equals(t, (*TimeISO8601)(nil), schedules[2].ArrivalTime)
+	assert(t, schedules[0].Has(ScheduleFieldArrivalTime), "the first arrival time was null")
+	assert(t, !schedules[2].Has(ScheduleFieldArrivalTime), "the sparse arrival time wasn't sent")
+	assert(t, !fieldset.Requested(ScheduleFieldArrivalTime), "the arrival time wasn't requested")
+	equals(t, 10, schedules[2].StopSequence)
+	assert(t, schedules[2].Has(ScheduleFieldStopSequence), "the stop sequence was sent")
+}
@@ -8,85 +8,86 @@ import (
 
 const servicesAPIPath = "/services"
 
+// ServicesService handling all of the service related API calls
 type ServicesService service
 
-// Weekday used to represent `valid_dates` in response
-type Weekday int
-
-const (
-	Monday Weekday = iota + 1
-	Tuesday
-	Wednesday
-	Thursday
-	Friday
-	Saturday
-	Sunday
-)
-
-// ScheduleTypicality Describes how well this schedule represents typical service for the listed schedule_type
-type ScheduleTypicality int
-
-const (
-	Undefined ScheduleTypicality = iota
-	Typical
-	SupplementalExtra
-	ReducedHoliday
-	PlannedMajorChange
-	UnplannedMajorReduction
-)
-
-// Service holds all the info about a given MBTA Service
+// Service holds all the info about a given MBTA service
 type Service struct {
 	ID                 string             `jsonapi:"primary,service"`
 	AddedDates         []TimeISO8601      `jsonapi:"attr,added_dates"`
 	AddedDatesNotes    []*string          `jsonapi:"attr,added_dates_notes"`
-	Description        string             `jsonapi:"attr,description"`
+	Description        *string            `jsonapi:"attr,description"`
 	EndDate            TimeISO8601        `jsonapi:"attr,end_date"`
 	RemovedDates       []TimeISO8601      `jsonapi:"attr,removed_dates"`
-	RemovedDatesNotes  []string           `jsonapi:"attr,removed_dates_notes"`
-	ScheduleName       string             `jsonapi:"attr,schedule_name"`
-	ScheduleType       string             `jsonapi:"attr,schedule_type"`
+	RemovedDatesNotes  []*string          `jsonapi:"attr,removed_dates_notes"`
+	ScheduleName       *string            `jsonapi:"attr,schedule_name"`
+	ScheduleType       *string            `jsonapi:"attr,schedule_type"`
 	ScheduleTypicality ScheduleTypicality `jsonapi:"attr,schedule_typicality"`
 	StartDate          TimeISO8601        `jsonapi:"attr,start_date"`
 	ValidDays          []Weekday          `jsonapi:"attr,valid_days"`
+	fieldPresence
 }
 
-// ServicesSortByType all possible ways to sort /services request
+// ServiceField an attribute or relationship of a service, for a Fieldset
+type ServiceField string
+
+const (
+	ServiceFieldAddedDates         ServiceField = "added_dates"
+	ServiceFieldAddedDatesNotes    ServiceField = "added_dates_notes"
+	ServiceFieldDescription        ServiceField = "description"
+	ServiceFieldEndDate            ServiceField = "end_date"
+	ServiceFieldRemovedDates       ServiceField = "removed_dates"
+	ServiceFieldRemovedDatesNotes  ServiceField = "removed_dates_notes"
+	ServiceFieldScheduleName       ServiceField = "schedule_name"
+	ServiceFieldScheduleType       ServiceField = "schedule_type"
+	ServiceFieldScheduleTypicality ServiceField = "schedule_typicality"
+	ServiceFieldStartDate          ServiceField = "start_date"
+	ServiceFieldValidDays          ServiceField = "valid_days"
+)
+
+func (f ServiceField) resourceType() string { return "service" }
+func (f ServiceField) fieldName() string    { return string(f) }
+
+// Has whether the service has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (s *Service) Has(field ServiceField) bool { return s.has(string(field)) }
+
+// ServicesSortByType all of the possible ways to sort by for a GetAllServices request
 type ServicesSortByType string
 
 const (
-	ServicesSortAddedDatesByAscending          ServicesSortByType = "added_dates"
-	ServicesSortAddedDatesByDescending         ServicesSortByType = "-added_dates"
-	ServicesSortAddedDatesNotesByAscending     ServicesSortByType = "added_dates_notes"
-	ServicesSortAddedDatesNotesByDescending    ServicesSortByType = "-added_dates_notes"
-	ServicesSortDescriptionByAscending         ServicesSortByType = "description"
-	ServicesSortDescriptionByDescending        ServicesSortByType = "-description"
-	ServicesSortEndDateByAscending             ServicesSortByType = "end_date"
-	ServicesSortEndDateByDescending            ServicesSortByType = "-end_date"
-	ServicesSortRemovedDatesByAscending        ServicesSortByType = "removed_dates"
-	ServicesSortRemovedDatesByDescending       ServicesSortByType = "-removed_dates"
-	ServicesSortRemovedDatesNotesByAscending   ServicesSortByType = "removed_dates_notes"
-	ServicesSortRemovedDatesNotesByDescending  ServicesSortByType = "-removed_dates_notes"
-	ServicesSortScheduleNameByAscending        ServicesSortByType = "schedule_name"
-	ServicesSortScheduleNameByDescending       ServicesSortByType = "-schedule_name"
-	ServicesSortScheduleTypeByAscending        ServicesSortByType = "schedule_type"
-	ServicesSortScheduleTypeByDescending       ServicesSortByType = "-schedule_type"
-	ServicesSortScheduleTypicalityByAscending  ServicesSortByType = "schedule_typicality"
-	ServicesSortScheduleTypicalityByDescending ServicesSortByType = "-schedule_typicality"
-	ServicesSortStartDateByAscending           ServicesSortByType = "start_date"
-	ServicesSortStartDateByDescending          ServicesSortByType = "-start_date"
-	ServicesSortValidDaysByAscending           ServicesSortByType = "valid_days"
-	ServicesSortValidDaysByDescending          ServicesSortByType = "-valid_days"
+	ServicesSortByAddedDatesAscending          ServicesSortByType = "added_dates"
+	ServicesSortByAddedDatesDescending         ServicesSortByType = "-added_dates"
+	ServicesSortByAddedDatesNotesAscending     ServicesSortByType = "added_dates_notes"
+	ServicesSortByAddedDatesNotesDescending    ServicesSortByType = "-added_dates_notes"
+	ServicesSortByDescriptionAscending         ServicesSortByType = "description"
+	ServicesSortByDescriptionDescending        ServicesSortByType = "-description"
+	ServicesSortByEndDateAscending             ServicesSortByType = "end_date"
+	ServicesSortByEndDateDescending            ServicesSortByType = "-end_date"
+	ServicesSortByRemovedDatesAscending        ServicesSortByType = "removed_dates"
+	ServicesSortByRemovedDatesDescending       ServicesSortByType = "-removed_dates"
+	ServicesSortByRemovedDatesNotesAscending   ServicesSortByType = "removed_dates_notes"
+	ServicesSortByRemovedDatesNotesDescending  ServicesSortByType = "-removed_dates_notes"
+	ServicesSortByScheduleNameAscending        ServicesSortByType = "schedule_name"
+	ServicesSortByScheduleNameDescending       ServicesSortByType = "-schedule_name"
+	ServicesSortByScheduleTypeAscending        ServicesSortByType = "schedule_type"
+	ServicesSortByScheduleTypeDescending       ServicesSortByType = "-schedule_type"
+	ServicesSortByScheduleTypicalityAscending  ServicesSortByType = "schedule_typicality"
+	ServicesSortByScheduleTypicalityDescending ServicesSortByType = "-schedule_typicality"
+	ServicesSortByStartDateAscending           ServicesSortByType = "start_date"
+	ServicesSortByStartDateDescending          ServicesSortByType = "-start_date"
+	ServicesSortByValidDaysAscending           ServicesSortByType = "valid_days"
+	ServicesSortByValidDaysDescending          ServicesSortByType = "-valid_days"
 )
 
-// GetAllServicesRequestConfig extra options for GetAllServices Request
+// GetAllServicesRequestConfig extra options for the GetAllServices request
 type GetAllServicesRequestConfig struct {
 	PageOffset   string             `url:"page[offset],omitempty"`          // Offset (0-based) of first element in the page
 	PageLimit    string             `url:"page[limit],omitempty"`           // Max number of elements to return
-	Sort         ServicesSortByType `url:"sort,omitempty"`                  // Results can be sorted by the id or any RoutesSortByType
-	Fields       []string           `url:"fields[service],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
+	Sort         ServicesSortByType `url:"sort,omitempty"`                  // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields       []string           `url:"fields[service],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset     Fieldset           `url:"fields,omitempty"`                // Fields to include for each resource type, included ones too. Replaces Fields for service if it has an entry for it
 	FilterIDs    []string           `url:"filter[id],comma,omitempty"`      // Filter by multiple IDs
-	FilterRoutes []Route            `url:"filter[route],comma,omitempty"`   // Filter by Routes
+	FilterRoutes []string           `url:"filter[route],comma,omitempty"`   // Filter by Routes
 }
 
 // GetAllServices returns all services from the mbta API
@@ -106,17 +107,18 @@ func (s *ServicesService) GetAllServicesWithContext(ctx context.Context, config
 	}
 	req = req.WithContext(ctx)
 
-	untypedServices, resp, err := s.client.doManyPayload(req, &Service{})
-	services := make([]*Service, len(untypedServices))
-	for i := 0; i < len(untypedServices); i++ {
-		services[i] = untypedServices[i].(*Service)
+	untyped, resp, err := s.client.doManyPayload(req, &Service{})
+	services := make([]*Service, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		services[i] = untyped[i].(*Service)
 	}
 	return services, resp, err
 }
 
-// GetServiceRequestConfig extra options for GetService Request
+// GetServiceRequestConfig extra options for the GetService request
 type GetServiceRequestConfig struct {
-	Fields []string `url:"fields[service],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
+	Fields   []string `url:"fields[service],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset `url:"fields,omitempty"`                // Fields to include for each resource type, included ones too. Replaces Fields for service if it has an entry for it
 }
 
 // GetService returns a service from the mbta API
@@ -126,6 +128,10 @@ func (s *ServicesService) GetService(id string, config *GetServiceRequestConfig)
 
 // GetServiceWithContext returns a service from the mbta API given a context
 func (s *ServicesService) GetServiceWithContext(ctx context.Context, id string, config *GetServiceRequestConfig) (*Service, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
 	path := fmt.Sprintf("%s/%s", servicesAPIPath, id)
 	u, err := addOptions(path, config)
 	if err != nil {
@@ -141,3 +147,94 @@ func (s *ServicesService) GetServiceWithContext(ctx context.Context, id string,
 	resp, err := s.client.doSinglePayload(req, &service)
 	return &service, resp, err
 }
+
+// GetServices returns the services with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *ServicesService) GetServices(ids []string) ([]*Service, error) {
+	return s.GetServicesWithContext(context.Background(), ids)
+}
+
+// GetServicesWithContext returns the services with the given IDs from the mbta API given a context
+func (s *ServicesService) GetServicesWithContext(ctx context.Context, ids []string) ([]*Service, error) {
+	untyped, err := s.client.batchGet(ctx, servicesAPIPath, ids, &Service{}, func(ids []string) interface{} {
+		return &GetAllServicesRequestConfig{FilterIDs: ids}
+	})
+	services := make([]*Service, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		services[i], _ = untyped[i].(*Service)
+	}
+	return services, err
+}
+
+// Weekday used to represent `valid_dates` in response
+type Weekday int
+
+const (
+	Monday Weekday = iota + 1
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+	Sunday
+)
+
+// ScheduleTypicality Describes how well this schedule represents typical service for the listed schedule_type
+type ScheduleTypicality int
+
+const (
+	Undefined ScheduleTypicality = iota
+	Typical
+	SupplementalExtra
+	ReducedHoliday
+	PlannedMajorChange
+	UnplannedMajorReduction
+)
+
+// Names from before the sort and include constants were renamed consistently
+const (
+	// Deprecated: use ServicesSortByAddedDatesAscending
+	ServicesSortAddedDatesByAscending = ServicesSortByAddedDatesAscending
+	// Deprecated: use ServicesSortByAddedDatesDescending
+	ServicesSortAddedDatesByDescending = ServicesSortByAddedDatesDescending
+	// Deprecated: use ServicesSortByAddedDatesNotesAscending
+	ServicesSortAddedDatesNotesByAscending = ServicesSortByAddedDatesNotesAscending
+	// Deprecated: use ServicesSortByAddedDatesNotesDescending
+	ServicesSortAddedDatesNotesByDescending = ServicesSortByAddedDatesNotesDescending
+	// Deprecated: use ServicesSortByDescriptionAscending
+	ServicesSortDescriptionByAscending = ServicesSortByDescriptionAscending
+	// Deprecated: use ServicesSortByDescriptionDescending
+	ServicesSortDescriptionByDescending = ServicesSortByDescriptionDescending
+	// Deprecated: use ServicesSortByEndDateAscending
+	ServicesSortEndDateByAscending = ServicesSortByEndDateAscending
+	// Deprecated: use ServicesSortByEndDateDescending
+	ServicesSortEndDateByDescending = ServicesSortByEndDateDescending
+	// Deprecated: use ServicesSortByRemovedDatesAscending
+	ServicesSortRemovedDatesByAscending = ServicesSortByRemovedDatesAscending
+	// Deprecated: use ServicesSortByRemovedDatesDescending
+	ServicesSortRemovedDatesByDescending = ServicesSortByRemovedDatesDescending
+	// Deprecated: use ServicesSortByRemovedDatesNotesAscending
+	ServicesSortRemovedDatesNotesByAscending = ServicesSortByRemovedDatesNotesAscending
+	// Deprecated: use ServicesSortByRemovedDatesNotesDescending
+	ServicesSortRemovedDatesNotesByDescending = ServicesSortByRemovedDatesNotesDescending
+	// Deprecated: use ServicesSortByScheduleNameAscending
+	ServicesSortScheduleNameByAscending = ServicesSortByScheduleNameAscending
+	// Deprecated: use ServicesSortByScheduleNameDescending
+	ServicesSortScheduleNameByDescending = ServicesSortByScheduleNameDescending
+	// Deprecated: use ServicesSortByScheduleTypeAscending
+	ServicesSortScheduleTypeByAscending = ServicesSortByScheduleTypeAscending
+	// Deprecated: use ServicesSortByScheduleTypeDescending
+	ServicesSortScheduleTypeByDescending = ServicesSortByScheduleTypeDescending
+	// Deprecated: use ServicesSortByScheduleTypicalityAscending
+	ServicesSortScheduleTypicalityByAscending = ServicesSortByScheduleTypicalityAscending
+	// Deprecated: use ServicesSortByScheduleTypicalityDescending
+	ServicesSortScheduleTypicalityByDescending = ServicesSortByScheduleTypicalityDescending
+	// Deprecated: use ServicesSortByStartDateAscending
+	ServicesSortStartDateByAscending = ServicesSortByStartDateAscending
+	// Deprecated: use ServicesSortByStartDateDescending
+	ServicesSortStartDateByDescending = ServicesSortByStartDateDescending
+	// Deprecated: use ServicesSortByValidDaysAscending
+	ServicesSortValidDaysByAscending = ServicesSortByValidDaysAscending
+	// Deprecated: use ServicesSortByValidDaysDescending
+	ServicesSortValidDaysByDescending = ServicesSortByValidDaysDescending
+)
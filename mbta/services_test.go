@@ -13,16 +13,16 @@ func Test_GetService(t *testing.T) {
 	removedDates, _ := parseISO8601TimeDateOnlySlice([]string{"2019-05-27"})
 	expected := &Service{
 		ID:                 "BUS22019-hbb29011-Weekday-02",
-		AddedDates:         timeSliceToTimeISO8601Slice(addedDates),
+		AddedDates:         dateSliceToTimeISO8601Slice(addedDates),
 		AddedDatesNotes:    []*string{nil, nil, nil, nil},
-		Description:        "Weekday schedule",
-		EndDate:            timeToTimeISO8601(endDate),
-		RemovedDates:       timeSliceToTimeISO8601Slice(removedDates),
-		RemovedDatesNotes:  []string{"Memorial Day"},
-		ScheduleName:       "Weekday",
-		ScheduleType:       "Weekday",
+		Description:        strPtr("Weekday schedule"),
+		EndDate:            dateToTimeISO8601(endDate),
+		RemovedDates:       dateSliceToTimeISO8601Slice(removedDates),
+		RemovedDatesNotes:  []*string{strPtr("Memorial Day")},
+		ScheduleName:       strPtr("Weekday"),
+		ScheduleType:       strPtr("Weekday"),
 		ScheduleTypicality: 1,
-		StartDate:          timeToTimeISO8601(startDate),
+		StartDate:          dateToTimeISO8601(startDate),
 		ValidDays:          []Weekday{Monday, Tuesday, Wednesday, Thursday, Friday},
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", servicesAPIPath, "BUS22019-hbb29011-Weekday-02")))
@@ -48,30 +48,30 @@ func Test_GetAllServices(t *testing.T) {
 	expected := []*Service{
 		&Service{
 			ID:                 "BUS22019-hbb29011-Weekday-02",
-			AddedDates:         timeSliceToTimeISO8601Slice(addedDates1),
+			AddedDates:         dateSliceToTimeISO8601Slice(addedDates1),
 			AddedDatesNotes:    []*string{nil, nil, nil, nil},
-			Description:        "Weekday schedule",
-			EndDate:            timeToTimeISO8601(endDate1),
-			RemovedDates:       timeSliceToTimeISO8601Slice(removedDates1),
-			RemovedDatesNotes:  []string{"Memorial Day"},
-			ScheduleName:       "Weekday",
-			ScheduleType:       "Weekday",
+			Description:        strPtr("Weekday schedule"),
+			EndDate:            dateToTimeISO8601(endDate1),
+			RemovedDates:       dateSliceToTimeISO8601Slice(removedDates1),
+			RemovedDatesNotes:  []*string{strPtr("Memorial Day")},
+			ScheduleName:       strPtr("Weekday"),
+			ScheduleType:       strPtr("Weekday"),
 			ScheduleTypicality: 1,
-			StartDate:          timeToTimeISO8601(startDate1),
+			StartDate:          dateToTimeISO8601(startDate1),
 			ValidDays:          []Weekday{Monday, Tuesday, Wednesday, Thursday, Friday},
 		},
 		&Service{
 			ID:                 "BUS22019-hbb29016-Saturday-02",
-			AddedDates:         timeSliceToTimeISO8601Slice(addedDates2),
+			AddedDates:         dateSliceToTimeISO8601Slice(addedDates2),
 			AddedDatesNotes:    []*string{nil},
-			Description:        "Saturday schedule",
-			EndDate:            timeToTimeISO8601(endDate2),
+			Description:        strPtr("Saturday schedule"),
+			EndDate:            dateToTimeISO8601(endDate2),
 			RemovedDates:       nil,
-			RemovedDatesNotes:  []string{},
-			ScheduleName:       "Saturday",
-			ScheduleType:       "Saturday",
+			RemovedDatesNotes:  []*string{},
+			ScheduleName:       strPtr("Saturday"),
+			ScheduleType:       strPtr("Saturday"),
 			ScheduleTypicality: 1,
-			StartDate:          timeToTimeISO8601(startDate2),
+			StartDate:          dateToTimeISO8601(startDate2),
 			ValidDays:          []Weekday{Saturday},
 		},
 	}
@@ -8,10 +8,10 @@ import (
 
 const shapesAPIPath = "/shapes"
 
-// ShapeService handles all of the shape related API calls
+// ShapeService handling all of the shape related API calls
 type ShapeService service
 
-// Shape holds all the info about an MBTA shape
+// Shape holds all the info about a given MBTA shape
 type Shape struct {
 	ID          string  `jsonapi:"primary,shape"`
 	Priority    int     `jsonapi:"attr,priority"`
@@ -20,47 +20,67 @@ type Shape struct {
 	DirectionID int     `jsonapi:"attr,direction_id"`
 	Stops       []*Stop `jsonapi:"relation,stops"`
 	Route       *Route  `jsonapi:"relation,route"`
+	fieldPresence
 }
 
-// ShapesSortByType is an enumerable for all the ways you can sort shapes
-type ShapesSortByType string
+// ShapeField an attribute or relationship of a shape, for a Fieldset
+type ShapeField string
 
 const (
-	ShapesSortByDirectionIDAscending ShapesSortByType = "direction_id"
-	ShapesSortByDirectionIDDecending ShapesSortByType = "-direction_id"
-	ShapesSortByNameAscending        ShapesSortByType = "name"
-	ShapesSortByNameDecending        ShapesSortByType = "-name"
-	ShapesSortByPolylineAscending    ShapesSortByType = "polyline"
-	ShapesSortByPolylineDecending    ShapesSortByType = "-polyline"
-	ShapesSortByPriorityAscending    ShapesSortByType = "priority"
-	ShapesSortByPriorityDecending    ShapesSortByType = "-priority"
+	ShapeFieldPriority    ShapeField = "priority"
+	ShapeFieldPolyline    ShapeField = "polyline"
+	ShapeFieldName        ShapeField = "name"
+	ShapeFieldDirectionID ShapeField = "direction_id"
+	ShapeFieldStops       ShapeField = "stops"
+	ShapeFieldRoute       ShapeField = "route"
 )
 
-// ShapeInclude is an enumerable for all the includes for shape
+func (f ShapeField) resourceType() string { return "shape" }
+func (f ShapeField) fieldName() string    { return string(f) }
+
+// Has whether the shape has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (s *Shape) Has(field ShapeField) bool { return s.has(string(field)) }
+
+// ShapeInclude all of the includes for a shape request
 type ShapeInclude string
 
 const (
-	ShapeIncludeRoutes ShapeInclude = includeRoute
-	ShapeIncludeStops  ShapeInclude = includeStop
+	ShapeIncludeRoute ShapeInclude = "route"
+	ShapeIncludeStops ShapeInclude = "stops"
+)
+
+// ShapesSortByType all of the possible ways to sort by for a GetAllShapes request
+type ShapesSortByType string
+
+const (
+	ShapesSortByDirectionIDAscending  ShapesSortByType = "direction_id"
+	ShapesSortByDirectionIDDescending ShapesSortByType = "-direction_id"
+	ShapesSortByNameAscending         ShapesSortByType = "name"
+	ShapesSortByNameDescending        ShapesSortByType = "-name"
+	ShapesSortByPolylineAscending     ShapesSortByType = "polyline"
+	ShapesSortByPolylineDescending    ShapesSortByType = "-polyline"
+	ShapesSortByPriorityAscending     ShapesSortByType = "priority"
+	ShapesSortByPriorityDescending    ShapesSortByType = "-priority"
 )
 
-// GetAllShapesRequestConfig holds the request info for the GetAllShapes function
+// GetAllShapesRequestConfig extra options for the GetAllShapes request
 type GetAllShapesRequestConfig struct {
 	PageOffset        int              `url:"page[offset],omitempty"`         // Offset (0-based) of first element in the page
 	PageLimit         int              `url:"page[limit],omitempty"`          // Max number of elements to return
-	Sort              ShapesSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any /data/{index}/attributes key. Assumes ascending; may be prefixed with '-' for descending
-	Fields            []string         `url:"fields[shape],comma,omitempty"`  // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, “,”) list. Note that fields can also be selected for included data types: see the V3 API Best Practices (https://www.mbta.com/developers/v3-api/best-practices) for an example.
-	Include           []ShapeInclude   `url:"include,comma,omitempty"`        // Can include choose to include route and stop.
-	FilterRoute       []string         `url:"filter[route],comma,omitempty"`  // Filter by /data/{index}/relationships/route/data/id.
-	FilterDirectionID string           `url:"filter[direction_id],omitempty"` // Filter by direction of travel along the route.
+	Sort              ShapesSortByType `url:"sort,omitempty"`                 // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields            []string         `url:"fields[shape],comma,omitempty"`  // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset          Fieldset         `url:"fields,omitempty"`               // Fields to include for each resource type, included ones too. Replaces Fields for shape if it has an entry for it
+	Include           []ShapeInclude   `url:"include,comma,omitempty"`        // Relationships to include
+	FilterRoute       []string         `url:"filter[route],comma,omitempty"`  // Filter by /data/{index}/relationships/route/data/id
+	FilterDirectionID string           `url:"filter[direction_id],omitempty"` // Filter by direction of travel along the route
 }
 
-// GetAllShapes gets all the shapes based on the config info
+// GetAllShapes returns all shapes from the mbta API
 func (s *ShapeService) GetAllShapes(config *GetAllShapesRequestConfig) ([]*Shape, *http.Response, error) {
 	return s.GetAllShapesWithContext(context.Background(), config)
 }
 
-// GetAllShapesWithContext gets all the shapes based on the config info and accepts a context
+// GetAllShapesWithContext returns all shapes from the mbta API given a context
 func (s *ShapeService) GetAllShapesWithContext(ctx context.Context, config *GetAllShapesRequestConfig) ([]*Shape, *http.Response, error) {
 	u, err := addOptions(shapesAPIPath, config)
 	if err != nil {
@@ -72,28 +92,32 @@ func (s *ShapeService) GetAllShapesWithContext(ctx context.Context, config *GetA
 	}
 	req = req.WithContext(ctx)
 
-	untypedShapes, resp, err := s.client.doManyPayload(req, &Shape{})
-	shapes := make([]*Shape, len(untypedShapes))
-	for i := 0; i < len(untypedShapes); i++ {
-		shapes[i] = untypedShapes[i].(*Shape)
+	untyped, resp, err := s.client.doManyPayload(req, &Shape{})
+	shapes := make([]*Shape, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		shapes[i] = untyped[i].(*Shape)
 	}
 	return shapes, resp, err
 }
 
-// GetShapeRequestConfig holds the request info for the GetShape function
+// GetShapeRequestConfig extra options for the GetShape request
 type GetShapeRequestConfig struct {
-	Fields  []string       `url:"fields[shape],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, “,”) list. Note that fields can also be selected for included data types: see the V3 API Best Practices for an example.
-	Include []ShapeInclude `url:"include,comma,omitempty"`       // Can include choose to include route and stop.
+	Fields   []string       `url:"fields[shape],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset       `url:"fields,omitempty"`              // Fields to include for each resource type, included ones too. Replaces Fields for shape if it has an entry for it
+	Include  []ShapeInclude `url:"include,comma,omitempty"`       // Relationships to include
 }
 
-// GetShape gets the shape with the specified ID
+// GetShape returns a shape from the mbta API
 func (s *ShapeService) GetShape(id string, config *GetShapeRequestConfig) (*Shape, *http.Response, error) {
 	return s.GetShapeWithContext(context.Background(), id, config)
-
 }
 
-// GetShapeWithContext gets the shape with the specified ID and accepts context
+// GetShapeWithContext returns a shape from the mbta API given a context
 func (s *ShapeService) GetShapeWithContext(ctx context.Context, id string, config *GetShapeRequestConfig) (*Shape, *http.Response, error) {
+	if id == "" {
+		return nil, nil, ErrMustSpecifyID
+	}
+
 	path := fmt.Sprintf("%s/%s", shapesAPIPath, id)
 	u, err := addOptions(path, config)
 	if err != nil {
@@ -109,3 +133,17 @@ func (s *ShapeService) GetShapeWithContext(ctx context.Context, id string, confi
 	resp, err := s.client.doSinglePayload(req, &shape)
 	return &shape, resp, err
 }
+
+// Names from before the sort and include constants were renamed consistently
+const (
+	// Deprecated: use ShapeIncludeRoute
+	ShapeIncludeRoutes = ShapeIncludeRoute
+	// Deprecated: use ShapesSortByDirectionIDDescending
+	ShapesSortByDirectionIDDecending = ShapesSortByDirectionIDDescending
+	// Deprecated: use ShapesSortByNameDescending
+	ShapesSortByNameDecending = ShapesSortByNameDescending
+	// Deprecated: use ShapesSortByPolylineDescending
+	ShapesSortByPolylineDecending = ShapesSortByPolylineDescending
+	// Deprecated: use ShapesSortByPriorityDescending
+	ShapesSortByPriorityDecending = ShapesSortByPriorityDescending
+)
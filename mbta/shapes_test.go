@@ -13,10 +13,12 @@ func TestGetShape(t *testing.T) {
 		Name:        "Dudley Station via Allston",
 		DirectionID: 1,
 		Stops: []*Stop{
-			&Stop{ID: "22549"},
-			&Stop{ID: "32549"},
+			&Stop{ID: "22549", fieldPresence: presentFields()},
+			&Stop{ID: "32549", fieldPresence: presentFields()},
 		},
-		Route: &Route{ID: "66"},
+		Route: &Route{ID: "66", fieldPresence: presentFields()},
+
+		fieldPresence: missingFields(ShapeFieldPolyline),
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", shapesAPIPath, "660085")))
 	defer server.Close()
@@ -37,10 +39,12 @@ func TestGetAllShapes(t *testing.T) {
 			Name:        "Dudley Station via Allston",
 			DirectionID: 1,
 			Stops: []*Stop{
-				&Stop{ID: "22549"},
-				&Stop{ID: "32549"},
+				&Stop{ID: "22549", fieldPresence: presentFields()},
+				&Stop{ID: "32549", fieldPresence: presentFields()},
 			},
-			Route: &Route{ID: "66"},
+			Route: &Route{ID: "66", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(ShapeFieldPolyline),
 		},
 		&Shape{
 			ID:          "660113-2",
@@ -48,10 +52,12 @@ func TestGetAllShapes(t *testing.T) {
 			Name:        "Franklin Park via Dudley",
 			DirectionID: 1,
 			Stops: []*Stop{
-				&Stop{ID: "925"},
-				&Stop{ID: "926"},
+				&Stop{ID: "925", fieldPresence: presentFields()},
+				&Stop{ID: "926", fieldPresence: presentFields()},
 			},
-			Route: &Route{ID: "66"},
+			Route: &Route{ID: "66", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(ShapeFieldPolyline),
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, shapesAPIPath))
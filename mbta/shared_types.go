@@ -23,25 +23,3 @@ const (
 	// BikesAllowedNo No bicycles are allowed on this trip
 	BikesAllowedNo
 )
-
-const (
-	includeAlerts             = "alerts"
-	includeLine               = "line"
-	includeStop               = "stop"
-	includeStops              = "stops"
-	includeTrip               = "trip"
-	includeTrips              = "trips"
-	includeRoute              = "route"
-	includeRoutes             = "routes"
-	includeParentStation      = "parent_station"
-	includeVehicle            = "vehicle"
-	includeService            = "service"
-	includeShape              = "shape"
-	includePrediction         = "prediction"
-	includePredictions        = "predictions"
-	includeRepresentativeTrip = "representative_trip"
-	includeRoutePattern       = "route_pattern"
-	includeRoutePatterns      = "route_patterns"
-	includeFacilities         = "facilities"
-	includeSchedule           = "schedule"
-)
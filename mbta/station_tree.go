@@ -0,0 +1,46 @@
+package mbta
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/xerrors"
+)
+
+// StationTree a station with the stops inside it, split up by location type. Boarding areas belong to their platform
+// rather than the station, so they aren't part of it
+type StationTree struct {
+	Station   *Stop   // The station itself, with ChildStops set
+	Platforms []*Stop // Where vehicles are boarded (StopLocationStop)
+	Entrances []*Stop // Where the station is entered from the street (StopLocationStationEntranceExit)
+	Nodes     []*Stop // Points linking the station's pathways, e.g. mezzanines and stairs (StopLocationGenericNode)
+}
+
+// StationTree returns a station and the platforms, entrances and nodes inside it from the mbta API
+func (s *StopService) StationTree(stationID string) (*StationTree, *http.Response, error) {
+	return s.StationTreeWithContext(context.Background(), stationID)
+}
+
+// StationTreeWithContext returns a station and the platforms, entrances and nodes inside it from the mbta API given a context
+func (s *StopService) StationTreeWithContext(ctx context.Context, stationID string) (*StationTree, *http.Response, error) {
+	station, resp, err := s.GetStopWithContext(ctx, stationID, &GetStopRequestConfig{Include: []StopInclude{StopIncludeChildStops}})
+	if err != nil {
+		return nil, resp, err
+	}
+	if station.LocationType != StopLocationStation {
+		return nil, resp, xerrors.Errorf("stop %s has location type %d: %w", stationID, station.LocationType, ErrNotStation)
+	}
+
+	tree := &StationTree{Station: station}
+	for _, child := range station.ChildStops {
+		switch child.LocationType {
+		case StopLocationStop:
+			tree.Platforms = append(tree.Platforms, child)
+		case StopLocationStationEntranceExit:
+			tree.Entrances = append(tree.Entrances, child)
+		case StopLocationGenericNode:
+			tree.Nodes = append(tree.Nodes, child)
+		}
+	}
+	return tree, resp, nil
+}
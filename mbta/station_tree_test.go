@@ -0,0 +1,57 @@
+package mbta
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/xerrors"
+)
+
+func TestStationTree(t *testing.T) {
+	id := "place-harsq"
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s?include=child_stops", stopsAPIPath, id)))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	tree, _, err := mbtaClient.Stops.StationTree(id)
+	ok(t, err)
+	equals(t, id, tree.Station.ID)
+	equals(t, strPtr("Cambridge"), tree.Station.Municipality)
+	equals(t, &Zone{ID: "RapidTransit"}, tree.Station.Zone)
+	equals(t, 3, len(tree.Station.ChildStops))
+
+	equals(t, 1, len(tree.Platforms))
+	equals(t, &Stop{
+		ID:                 "70067",
+		Description:        strPtr("Harvard - Red Line - Ashmont/Braintree"),
+		Latitude:           42.373362,
+		LocationType:       StopLocationStop,
+		Longitude:          -71.118956,
+		Municipality:       strPtr("Cambridge"),
+		Name:               "Harvard",
+		PlatformName:       strPtr("Ashmont/Braintree"),
+		VehicleType:        routeTypePtr(RouteTypeHeavyRail),
+		WheelchairBoarding: WheelchairBoardingAccessible,
+		ParentStation:      &Stop{ID: id, fieldPresence: presentFields()},
+
+		fieldPresence: missingFields(StopFieldChildStops, StopFieldConnectingStops, StopFieldFacilities, StopFieldRecommendedTransfers, StopFieldZone),
+	}, tree.Platforms[0])
+	equals(t, 1, len(tree.Entrances))
+	equals(t, "door-harsq-brattle", tree.Entrances[0].ID)
+	equals(t, 1, len(tree.Nodes))
+	equals(t, StopLocationGenericNode, tree.Nodes[0].LocationType)
+}
+
+func TestStationTreeNotStation(t *testing.T) {
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s?include=child_stops", stopsAPIPath, "55")))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	_, _, err := mbtaClient.Stops.StationTree("55")
+	assert(t, xerrors.Is(err, ErrNotStation), "expected ErrNotStation, got %v", err)
+}
@@ -8,41 +8,74 @@ import (
 
 const stopsAPIPath = "/stops"
 
-// StopService service handling all of the stop related API calls
+// StopService handling all of the stop related API calls
 type StopService service
 
-// StopLocationType enum for the possible stop location types
-type StopLocationType int
+// Stop holds all the info about a given MBTA stop
+type Stop struct {
+	ID                   string                 `jsonapi:"primary,stop"`
+	Address              *string                `jsonapi:"attr,address"`                   // A street address for the station
+	AtStreet             *string                `jsonapi:"attr,at_street"`                 // The cross street at which the stop is located, e.g. "Massachusetts Ave"
+	Description          *string                `jsonapi:"attr,description"`               // Description of the stop
+	Latitude             float64                `jsonapi:"attr,latitude"`                  // Degrees North, in the WGS-84 coordinate system
+	LocationType         StopLocationType       `jsonapi:"attr,location_type"`             // The type of the stop
+	Longitude            float64                `jsonapi:"attr,longitude"`                 // Degrees East, in the WGS-84 coordinate system
+	Municipality         *string                `jsonapi:"attr,municipality"`              // The municipality in which the stop is located
+	Name                 string                 `jsonapi:"attr,name"`                      // Name of a stop or station in the local and tourist vernacular
+	OnStreet             *string                `jsonapi:"attr,on_street"`                 // The street on which the stop is located, e.g. "Washington St"
+	PlatformCode         *string                `jsonapi:"attr,platform_code"`             // A short code representing the platform/track (like a number or letter)
+	PlatformName         *string                `jsonapi:"attr,platform_name"`             // A textual description of the platform or track
+	VehicleType          *RouteType             `jsonapi:"attr,vehicle_type"`              // The type of vehicle serving the stop. nil for stations and stops served by more than one type
+	WheelchairBoarding   WheelchairBoardingType `jsonapi:"attr,wheelchair_boarding"`       // Whether there are any vehicles with wheelchair boarding or paths to stops that are wheelchair acessible
+	ParentStation        *Stop                  `jsonapi:"relation,parent_station"`        // The link to the parent station. Only includes id by default, use IncludeParentStation config option to get all data
+	ChildStops           []*Stop                `jsonapi:"relation,child_stops"`           // The platforms, entrances and nodes of a station. Only set when included with StopIncludeChildStops
+	ConnectingStops      []*Stop                `jsonapi:"relation,connecting_stops"`      // Nearby stops that can be transferred to, e.g. bus stops outside a station. Only set when included with StopIncludeConnectingStops
+	Facilities           []*Facility            `jsonapi:"relation,facilities"`            // The facilities at the stop. Only set when included with StopIncludeFacilities
+	RecommendedTransfers []*Stop                `jsonapi:"relation,recommended_transfers"` // Stops recommended for transfers from this one. Only set when included with StopIncludeRecommendedTransfers
+	Zone                 *Zone                  `jsonapi:"relation,zone"`                  // The fare zone of the stop, e.g. for commuter rail. nil for stops not in a zone
+	fieldPresence
+}
+
+// StopField an attribute or relationship of a stop, for a Fieldset
+type StopField string
 
 const (
-	// StopLocationStop A location where passengers board or disembark from a transit vehicle
-	StopLocationStop StopLocationType = iota
-	// StopLocationStation A physical structure or area that contains one or more stops
-	StopLocationStation
-	// StopLocationStationEntranceExit A location where passengers can enter or exit a station from the street
-	StopLocationStationEntranceExit
+	StopFieldAddress              StopField = "address"
+	StopFieldAtStreet             StopField = "at_street"
+	StopFieldDescription          StopField = "description"
+	StopFieldLatitude             StopField = "latitude"
+	StopFieldLocationType         StopField = "location_type"
+	StopFieldLongitude            StopField = "longitude"
+	StopFieldMunicipality         StopField = "municipality"
+	StopFieldName                 StopField = "name"
+	StopFieldOnStreet             StopField = "on_street"
+	StopFieldPlatformCode         StopField = "platform_code"
+	StopFieldPlatformName         StopField = "platform_name"
+	StopFieldVehicleType          StopField = "vehicle_type"
+	StopFieldWheelchairBoarding   StopField = "wheelchair_boarding"
+	StopFieldParentStation        StopField = "parent_station"
+	StopFieldChildStops           StopField = "child_stops"
+	StopFieldConnectingStops      StopField = "connecting_stops"
+	StopFieldFacilities           StopField = "facilities"
+	StopFieldRecommendedTransfers StopField = "recommended_transfers"
+	StopFieldZone                 StopField = "zone"
 )
 
-// Stop holds all info about a given MBTA Stop
-type Stop struct {
-	ID                 string                 `jsonapi:"primary,stop"`
-	Address            *string                `jsonapi:"attr,address"`             // A street address for the station
-	Description        *string                `jsonapi:"attr,description"`         // Description of the stop
-	Latitude           float64                `jsonapi:"attr,latitude"`            // Degrees North, in the WGS-84 coordinate system
-	LocationType       StopLocationType       `jsonapi:"attr,location_type"`       // The type of the stop
-	Longitude          float64                `jsonapi:"attr,longitude"`           // Degrees East, in the WGS-84 coordinate system
-	Name               string                 `jsonapi:"attr,name"`                // Name of a stop or station in the local and tourist vernacular
-	PlatformCode       *string                `jsonapi:"attr,platform_code"`       // A short code representing the platform/track (like a number or letter)
-	PlatformName       *string                `jsonapi:"attr,platform_name"`       // A textual description of the platform or track
-	WheelchairBoarding WheelchairBoardingType `jsonapi:"attr,wheelchair_boarding"` // Whether there are any vehicles with wheelchair boarding or paths to stops that are wheelchair acessible
-	ParentStation      *Stop                  `jsonapi:"relation,parent_station"`  // The link to the parent station. Only includes id by default, use IncludeParentStation config option to get all data
-}
+func (f StopField) resourceType() string { return "stop" }
+func (f StopField) fieldName() string    { return string(f) }
+
+// Has whether the stop has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (s *Stop) Has(field StopField) bool { return s.has(string(field)) }
 
 // StopInclude all of the includes for a stop request
 type StopInclude string
 
 const (
-	StopIncludeParentStation StopInclude = includeParentStation
+	StopIncludeParentStation        StopInclude = "parent_station"
+	StopIncludeChildStops           StopInclude = "child_stops"
+	StopIncludeConnectingStops      StopInclude = "connecting_stops"
+	StopIncludeFacilities           StopInclude = "facilities"
+	StopIncludeRecommendedTransfers StopInclude = "recommended_transfers"
 )
 
 // StopsSortByType all of the possible ways to sort by for a GetAllStops request
@@ -51,40 +84,49 @@ type StopsSortByType string
 const (
 	StopsSortByAddressAscending             StopsSortByType = "address"
 	StopsSortByAddressDescending            StopsSortByType = "-address"
+	StopsSortByAtStreetAscending            StopsSortByType = "at_street"
+	StopsSortByAtStreetDescending           StopsSortByType = "-at_street"
 	StopsSortByDescriptionAscending         StopsSortByType = "description"
 	StopsSortByDescriptionDescending        StopsSortByType = "-description"
+	StopsSortByDistanceAscending            StopsSortByType = "distance"
+	StopsSortByDistanceDescending           StopsSortByType = "-distance"
 	StopsSortByLatitudeAscending            StopsSortByType = "latitude"
 	StopsSortByLatitudeDescending           StopsSortByType = "-latitude"
 	StopsSortByLocationTypeAscending        StopsSortByType = "location_type"
 	StopsSortByLocationTypeDescending       StopsSortByType = "-location_type"
 	StopsSortByLongitudeAscending           StopsSortByType = "longitude"
 	StopsSortByLongitudeDescending          StopsSortByType = "-longitude"
+	StopsSortByMunicipalityAscending        StopsSortByType = "municipality"
+	StopsSortByMunicipalityDescending       StopsSortByType = "-municipality"
 	StopsSortByNameAscending                StopsSortByType = "name"
 	StopsSortByNameDescending               StopsSortByType = "-name"
+	StopsSortByOnStreetAscending            StopsSortByType = "on_street"
+	StopsSortByOnStreetDescending           StopsSortByType = "-on_street"
 	StopsSortByPlatformCodeAscending        StopsSortByType = "platform_code"
 	StopsSortByPlatformCodeDescending       StopsSortByType = "-platform_code"
 	StopsSortByPlatformNameAscending        StopsSortByType = "platform_name"
 	StopsSortByPlatformNameDescending       StopsSortByType = "-platform_name"
+	StopsSortByVehicleTypeAscending         StopsSortByType = "vehicle_type"
+	StopsSortByVehicleTypeDescending        StopsSortByType = "-vehicle_type"
 	StopsSortByWheelchairBoardingAscending  StopsSortByType = "wheelchair_boarding"
 	StopsSortByWheelchairBoardingDescending StopsSortByType = "-wheelchair_boarding"
-	StopsSortByDistanceAscending            StopsSortByType = "distance"
-	StopsSortByDistanceDescending           StopsSortByType = "-distance"
 )
 
 // GetAllStopsRequestConfig extra options for the GetAllStops request
 type GetAllStopsRequestConfig struct {
 	PageOffset         string          `url:"page[offset],omitempty"`                // Offset (0-based) of first element in the page
 	PageLimit          string          `url:"page[limit],omitempty"`                 // Max number of elements to return
-	Sort               StopsSortByType `url:"sort,omitempty"`                        // Results can be sorted by the id or any StopsSortByType
-	Fields             []string        `url:"fields[stop],comma,omitempty"`          // Fields to include with the response. Note that fields can also be selected for included data types
-	Include            []StopInclude   `url:"include,comma,omitempty"`               // Include extra data in response (parentstation)
+	Sort               StopsSortByType `url:"sort,omitempty"`                        // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields             []string        `url:"fields[stop],comma,omitempty"`          // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset           Fieldset        `url:"fields,omitempty"`                      // Fields to include for each resource type, included ones too. Replaces Fields for stop if it has an entry for it
+	Include            []StopInclude   `url:"include,comma,omitempty"`               // Relationships to include
 	FilterDirectionID  string          `url:"filter[direction_id],omitempty"`        // Filter by Direction ID (Either "0" or "1")
 	FilterLatitude     string          `url:"filter[latitude],omitempty"`            // Latitude in degrees North in the WGS-84 coordinate system to search filter[radius] degrees around with filter[longitude]
 	FilterLongitude    string          `url:"filter[longitude],omitempty"`           // Longitude in degrees East in the WGS-84 coordinate system to search filter[radius] degrees around with filter[latitude]
 	FilterRadius       string          `url:"filter[radius],omitempty"`              // The distance is in degrees as if latitude and longitude were on a flat 2D plane and normal Pythagorean distance was calculated. Over the region MBTA serves, 0.02 degrees is approximately 1 mile. Defaults to 0.01 degrees (approximately a half mile)
 	FilterIDs          []string        `url:"filter[id],comma,omitempty"`            // Filter by multiple IDs
 	FilterRouteTypes   []RouteType     `url:"filter[route_type],comma,omitempty"`    // Filter by route type(s)
-	FilterRouteIDs     []string        `url:"filter[route],comma,omitempty"`         // Filter by route IDs. If the vehicle is on a multi-route trip, it will be returned for any of the routes
+	FilterRouteIDs     []string        `url:"filter[route],comma,omitempty"`         // Filter by route IDs
 	FilterLocationType []string        `url:"filter[location_type],comma,omitempty"` // Filter by location type
 }
 
@@ -105,18 +147,19 @@ func (s *StopService) GetAllStopsWithContext(ctx context.Context, config *GetAll
 	}
 	req = req.WithContext(ctx)
 
-	untypedStops, resp, err := s.client.doManyPayload(req, &Stop{})
-	stops := make([]*Stop, len(untypedStops))
-	for i := 0; i < len(untypedStops); i++ {
-		stops[i] = untypedStops[i].(*Stop)
+	untyped, resp, err := s.client.doManyPayload(req, &Stop{})
+	stops := make([]*Stop, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		stops[i] = untyped[i].(*Stop)
 	}
 	return stops, resp, err
 }
 
 // GetStopRequestConfig extra options for the GetStop request
 type GetStopRequestConfig struct {
-	Fields  []string      `url:"fields[stop],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []StopInclude `url:"include,comma,omitempty"`      // Include extra data in response (parentstation)
+	Fields   []string      `url:"fields[stop],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset      `url:"fields,omitempty"`             // Fields to include for each resource type, included ones too. Replaces Fields for stop if it has an entry for it
+	Include  []StopInclude `url:"include,comma,omitempty"`      // Relationships to include
 }
 
 // GetStop returns a stop from the mbta API
@@ -145,3 +188,42 @@ func (s *StopService) GetStopWithContext(ctx context.Context, id string, config
 	resp, err := s.client.doSinglePayload(req, &stop)
 	return &stop, resp, err
 }
+
+// GetStops returns the stops with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *StopService) GetStops(ids []string) ([]*Stop, error) {
+	return s.GetStopsWithContext(context.Background(), ids)
+}
+
+// GetStopsWithContext returns the stops with the given IDs from the mbta API given a context
+func (s *StopService) GetStopsWithContext(ctx context.Context, ids []string) ([]*Stop, error) {
+	untyped, err := s.client.batchGet(ctx, stopsAPIPath, ids, &Stop{}, func(ids []string) interface{} {
+		return &GetAllStopsRequestConfig{FilterIDs: ids}
+	})
+	stops := make([]*Stop, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		stops[i], _ = untyped[i].(*Stop)
+	}
+	return stops, err
+}
+
+// StopLocationType enum for the possible stop location types
+type StopLocationType int
+
+const (
+	// StopLocationStop A location where passengers board or disembark from a transit vehicle
+	StopLocationStop StopLocationType = iota
+	// StopLocationStation A physical structure or area that contains one or more stops
+	StopLocationStation
+	// StopLocationStationEntranceExit A location where passengers can enter or exit a station from the street
+	StopLocationStationEntranceExit
+	// StopLocationGenericNode A location within a station, e.g. a mezzanine or the top of a staircase, used to link its pathways
+	StopLocationGenericNode
+	// StopLocationBoardingArea A specific location on a platform, where passengers can board and/or alight vehicles
+	StopLocationBoardingArea
+)
+
+// Zone a fare zone, e.g. "CR-zone-1A". The API only gives its id
+type Zone struct {
+	ID string `jsonapi:"primary,zone"`
+}
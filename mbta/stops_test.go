@@ -12,15 +12,21 @@ func Test_GetStop(t *testing.T) {
 	expected := &Stop{
 		ID:                 id,
 		Address:            nil,
+		AtStreet:           strPtr("Massachusetts Ave"),
 		Description:        strPtr("Washington St @ Massachusetts Ave - Silver Line - Dudley"),
 		Latitude:           42.336361,
 		LocationType:       StopLocationStop,
 		Longitude:          -71.077214,
+		Municipality:       strPtr("Boston"),
 		Name:               "Washington St @ Massachusetts Ave",
+		OnStreet:           strPtr("Washington St"),
 		PlatformCode:       nil,
 		PlatformName:       strPtr("Dudley"),
+		VehicleType:        routeTypePtr(RouteTypeBus),
 		WheelchairBoarding: WheelchairBoardingAccessible,
 		ParentStation:      nil,
+
+		fieldPresence: missingFields(StopFieldConnectingStops),
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", stopsAPIPath, id)))
 	defer server.Close()
@@ -47,6 +53,8 @@ func Test_GetAllStops(t *testing.T) {
 			PlatformName:       nil,
 			WheelchairBoarding: WheelchairBoardingNoInfo,
 			ParentStation:      nil,
+
+			fieldPresence: missingFields(StopFieldAtStreet, StopFieldMunicipality, StopFieldOnStreet, StopFieldVehicleType, StopFieldConnectingStops),
 		},
 		&Stop{
 			ID:                 "9172",
@@ -60,6 +68,8 @@ func Test_GetAllStops(t *testing.T) {
 			PlatformName:       nil,
 			WheelchairBoarding: WheelchairBoardingNoInfo,
 			ParentStation:      nil,
+
+			fieldPresence: missingFields(StopFieldAtStreet, StopFieldMunicipality, StopFieldOnStreet, StopFieldVehicleType, StopFieldConnectingStops),
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, stopsAPIPath))
@@ -14,8 +14,9 @@ const (
 
 // TimeISO8601 wrapper for a time.Time struct so that the Unmarshal works
 type TimeISO8601 struct {
-	Time time.Time
-	Now  bool // Used for when "NOW" is an option in filters
+	Time     time.Time
+	Now      bool // Used for when "NOW" is an option in filters
+	DateOnly bool // The value is a date without a time, e.g. a service's start_date. Set when unmarshalling a date
 }
 
 // Format the time as ISO8601
@@ -28,24 +29,38 @@ func (t *TimeISO8601) FormatOnlyDate() string {
 	return t.Time.Format(iso8601FormatDateOnly)
 }
 
-// MarshalJSON marshal time.Time as ISO8601
+// MarshalJSON marshal time.Time as ISO8601, or as just the date if DateOnly is set. The zero time is null
 func (t *TimeISO8601) MarshalJSON() ([]byte, error) {
+	if t.Time.IsZero() {
+		return []byte("null"), nil
+	}
+	if t.DateOnly {
+		return []byte(fmt.Sprintf("\"%s\"", t.FormatOnlyDate())), nil
+	}
 	strTime := fmt.Sprintf("\"%s\"", t.Format())
 	return []byte(strTime), nil
 }
 
-// UnmarshalJSON unmarshal time.Time as ISO8601
+// UnmarshalJSON unmarshal time.Time as ISO8601, setting DateOnly for a date without a time. null is the zero time
 func (t *TimeISO8601) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		t.Time = time.Time{}
+		t.DateOnly = false
+		return nil
+	}
 	strTime := strings.Trim(string(b), "\"")
 	parsed, err := parseISO8601Time(strTime)
+	dateOnly := false
 	if err != nil {
 		parsed, err = parseISO8601TimeDateOnly(strTime)
 		if err != nil {
 			return err
 		}
+		dateOnly = true
 	}
 
 	t.Time = parsed
+	t.DateOnly = dateOnly
 	return nil
 }
 
@@ -79,10 +94,14 @@ func timeToTimeISO8601(t time.Time) TimeISO8601 {
 	return TimeISO8601{Time: t}
 }
 
-func timeSliceToTimeISO8601Slice(timeSlice []time.Time) []TimeISO8601 {
+func dateToTimeISO8601(t time.Time) TimeISO8601 {
+	return TimeISO8601{Time: t, DateOnly: true}
+}
+
+func dateSliceToTimeISO8601Slice(timeSlice []time.Time) []TimeISO8601 {
 	var timeISO8601Slice = make([]TimeISO8601, len(timeSlice))
 	for i, t := range timeSlice {
-		timeISO8601Slice[i] = TimeISO8601{Time: t}
+		timeISO8601Slice[i] = dateToTimeISO8601(t)
 	}
 	return timeISO8601Slice
 }
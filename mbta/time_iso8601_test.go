@@ -33,6 +33,32 @@ func Test_TimeISO8601_MarshalJSON(t *testing.T) {
 	actual, err := testTime.MarshalJSON()
 	ok(t, err)
 	equals(t, expected, string(actual))
+
+	// dates round-trip as dates, while midnight is still a time, in UTC too
+	date := TimeISO8601{}
+	ok(t, date.UnmarshalJSON([]byte("\"2019-05-27\"")))
+	assert(t, date.DateOnly, "a date should unmarshal as DateOnly")
+	actual, err = date.MarshalJSON()
+	ok(t, err)
+	equals(t, "\"2019-05-27\"", string(actual))
+
+	midnight := TimeISO8601{Time: time.Date(2019, time.May, 27, 0, 0, 0, 0, time.FixedZone("", -4*60*60))}
+	actual, err = midnight.MarshalJSON()
+	ok(t, err)
+	equals(t, "\"2019-05-27T00:00:00-04:00\"", string(actual))
+
+	midnight = TimeISO8601{Time: time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)}
+	actual, err = midnight.MarshalJSON()
+	ok(t, err)
+	equals(t, "\"2019-05-01T00:00:00+00:00\"", string(actual))
+	ok(t, midnight.UnmarshalJSON(actual))
+	assert(t, !midnight.DateOnly, "a time at midnight shouldn't unmarshal as DateOnly")
+
+	actual, err = (&TimeISO8601{}).MarshalJSON()
+	ok(t, err)
+	equals(t, "null", string(actual))
+	ok(t, date.UnmarshalJSON(actual))
+	assert(t, date.Time.IsZero(), "null should unmarshal to the zero time")
 }
 
 func Test_TimeISO8601_UnmarshalJSON(t *testing.T) {
@@ -8,34 +8,57 @@ import (
 
 const tripsAPIPath = "/trips"
 
-// TripService service handling all of the trip related API calls
+// TripService handling all of the trip related API calls
 type TripService service
 
-// Trip holds all info about a given MBTA Trip
+// Trip holds all the info about a given MBTA trip
 type Trip struct {
 	ID                   string                 `jsonapi:"primary,trip"`
 	WheelchairAccessible WheelchairBoardingType `jsonapi:"attr,wheelchair_accessible"` // Indicator of wheelchair accessibility
 	Name                 string                 `jsonapi:"attr,name"`                  // The text that appears in schedules and sign boards to identify the trip to passengers
 	Headsign             string                 `jsonapi:"attr,headsign"`              // The text that appears on a sign that identifies the trip’s destination to passengers
-	DirectionID          int                    `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1.
+	DirectionID          int                    `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1
 	BlockID              string                 `jsonapi:"attr,block_id"`              // ID used to group sequential trips with the same vehicle for a given service_id
 	BikesAllowed         BikesAllowedType       `jsonapi:"attr,bikes_allowed"`         // Indicator of whether or not bikes are allowed on this trip
-	Route                *Route                 `jsonapi:"relation,route"`             // Trip that the current trip is linked with. Only includes id by default, use Include config option to get all data
+	Route                *Route                 `jsonapi:"relation,route"`             // Route that the current trip is linked with. Only includes id by default, use Include config option to get all data
 	RoutePattern         *RoutePattern          `jsonapi:"relation,route_pattern"`
 	Service              *Service               `jsonapi:"relation,service"`
 	Shape                *Shape                 `jsonapi:"relation,shape"`
+	fieldPresence
 }
 
+// TripField an attribute or relationship of a trip, for a Fieldset
+type TripField string
+
+const (
+	TripFieldWheelchairAccessible TripField = "wheelchair_accessible"
+	TripFieldName                 TripField = "name"
+	TripFieldHeadsign             TripField = "headsign"
+	TripFieldDirectionID          TripField = "direction_id"
+	TripFieldBlockID              TripField = "block_id"
+	TripFieldBikesAllowed         TripField = "bikes_allowed"
+	TripFieldRoute                TripField = "route"
+	TripFieldRoutePattern         TripField = "route_pattern"
+	TripFieldService              TripField = "service"
+	TripFieldShape                TripField = "shape"
+)
+
+func (f TripField) resourceType() string { return "trip" }
+func (f TripField) fieldName() string    { return string(f) }
+
+// Has whether the trip has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (t *Trip) Has(field TripField) bool { return t.has(string(field)) }
+
 // TripInclude all of the includes for a trip request
 type TripInclude string
 
 const (
-	TripIncludeRoute        TripInclude = includeRoute
-	TripIncludeVehicle      TripInclude = includeVehicle
-	TripIncludeService      TripInclude = includeService
-	TripIncludeShape        TripInclude = includeShape
-	TripIncludeRoutePattern TripInclude = includeRoutePattern
-	TripIncludePredictions  TripInclude = includePredictions
+	TripIncludeRoute        TripInclude = "route"
+	TripIncludeVehicle      TripInclude = "vehicle"
+	TripIncludeService      TripInclude = "service"
+	TripIncludeShape        TripInclude = "shape"
+	TripIncludeRoutePattern TripInclude = "route_pattern"
+	TripIncludePredictions  TripInclude = "predictions"
 )
 
 // TripsSortByType all of the possible ways to sort by for a GetAllTrips request
@@ -60,9 +83,10 @@ const (
 type GetAllTripsRequestConfig struct {
 	PageOffset            string          `url:"page[offset],omitempty"`                // Offset (0-based) of first element in the page
 	PageLimit             string          `url:"page[limit],omitempty"`                 // Max number of elements to return
-	Sort                  TripsSortByType `url:"sort,omitempty"`                        // Results can be sorted by the id or any TripsSortByType
-	Fields                []string        `url:"fields[trip],comma,omitempty"`          // Fields to include with the response. Note that fields can also be selected for included data types
-	Include               []TripInclude   `url:"include,comma,omitempty"`               // Include extra data in response (route, vehicle, service, shape, route_pattern, predictions)
+	Sort                  TripsSortByType `url:"sort,omitempty"`                        // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields                []string        `url:"fields[trip],comma,omitempty"`          // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset              Fieldset        `url:"fields,omitempty"`                      // Fields to include for each resource type, included ones too. Replaces Fields for trip if it has an entry for it
+	Include               []TripInclude   `url:"include,comma,omitempty"`               // Relationships to include
 	FilterDate            *TimeISO8601    `url:"filter[date],omitempty"`                // Filter by trips on a particular date The active date is the service date. Trips that begin between midnight and 3am are considered part of the previous service day
 	FilterDirectionID     string          `url:"filter[direction_id],omitempty"`        // Filter by direction of travel along the route
 	FilterRouteIDs        []string        `url:"filter[route],comma,omitempty"`         // Filter by route id(s)
@@ -71,12 +95,12 @@ type GetAllTripsRequestConfig struct {
 	FilterNames           []string        `url:"filter[name],comma,omitempty"`          // Filter by names
 }
 
-// GetAllTrips returns all vehicles from the mbta API
+// GetAllTrips returns all trips from the mbta API
 func (s *TripService) GetAllTrips(config GetAllTripsRequestConfig) ([]*Trip, *http.Response, error) {
 	return s.GetAllTripsWithContext(context.Background(), config)
 }
 
-// GetAllTripsWithContext returns all vehicles from the mbta API given a context
+// GetAllTripsWithContext returns all trips from the mbta API given a context
 func (s *TripService) GetAllTripsWithContext(ctx context.Context, config GetAllTripsRequestConfig) ([]*Trip, *http.Response, error) {
 	u, err := addOptions(tripsAPIPath, config)
 	if err != nil {
@@ -88,26 +112,27 @@ func (s *TripService) GetAllTripsWithContext(ctx context.Context, config GetAllT
 	}
 	req = req.WithContext(ctx)
 
-	untypedTrips, resp, err := s.client.doManyPayload(req, &Trip{})
-	trips := make([]*Trip, len(untypedTrips))
-	for i := 0; i < len(untypedTrips); i++ {
-		trips[i] = untypedTrips[i].(*Trip)
+	untyped, resp, err := s.client.doManyPayload(req, &Trip{})
+	trips := make([]*Trip, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		trips[i] = untyped[i].(*Trip)
 	}
 	return trips, resp, err
 }
 
 // GetTripRequestConfig extra options for the GetTrip request
 type GetTripRequestConfig struct {
-	Fields  []string      `url:"fields[trip],comma,omitempty"` // Fields to include with the response. Note that fields can also be selected for included data types
-	Include []TripInclude `url:"include,comma,omitempty"`      // Include extra data in response (route, vehicle, service, shape, route_pattern, predictions)
+	Fields   []string      `url:"fields[trip],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset      `url:"fields,omitempty"`             // Fields to include for each resource type, included ones too. Replaces Fields for trip if it has an entry for it
+	Include  []TripInclude `url:"include,comma,omitempty"`      // Relationships to include
 }
 
-// GetTrip returns a vehicle from the mbta API
+// GetTrip returns a trip from the mbta API
 func (s *TripService) GetTrip(id string, config GetTripRequestConfig) (*Trip, *http.Response, error) {
 	return s.GetTripWithContext(context.Background(), id, config)
 }
 
-// GetTripWithContext returns a vehicle from the mbta API given a context
+// GetTripWithContext returns a trip from the mbta API given a context
 func (s *TripService) GetTripWithContext(ctx context.Context, id string, config GetTripRequestConfig) (*Trip, *http.Response, error) {
 	if id == "" {
 		return nil, nil, ErrMustSpecifyID
@@ -128,3 +153,21 @@ func (s *TripService) GetTripWithContext(ctx context.Context, id string, config
 	resp, err := s.client.doSinglePayload(req, &trip)
 	return &trip, resp, err
 }
+
+// GetTrips returns the trips with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *TripService) GetTrips(ids []string) ([]*Trip, error) {
+	return s.GetTripsWithContext(context.Background(), ids)
+}
+
+// GetTripsWithContext returns the trips with the given IDs from the mbta API given a context
+func (s *TripService) GetTripsWithContext(ctx context.Context, ids []string) ([]*Trip, error) {
+	untyped, err := s.client.batchGet(ctx, tripsAPIPath, ids, &Trip{}, func(ids []string) interface{} {
+		return &GetAllTripsRequestConfig{FilterIDs: ids}
+	})
+	trips := make([]*Trip, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		trips[i], _ = untyped[i].(*Trip)
+	}
+	return trips, err
+}
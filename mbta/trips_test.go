@@ -17,10 +17,10 @@ func Test_GetTrip(t *testing.T) {
 		DirectionID:          0,
 		BikesAllowed:         BikesAllowedNoInfo,
 		BlockID:              "S931_-5-0-L-0-BraintreeQuincyCenter",
-		Route:                &Route{ID: "Red"},
-		RoutePattern:         &RoutePattern{ID: "Red-1-0"},
-		Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-BraintreeQuincyCenterL"},
-		Shape:                &Shape{ID: "931_0009"},
+		Route:                &Route{ID: "Red", fieldPresence: presentFields()},
+		RoutePattern:         &RoutePattern{ID: "Red-1-0", fieldPresence: presentFields()},
+		Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-BraintreeQuincyCenterL", fieldPresence: presentFields()},
+		Shape:                &Shape{ID: "931_0009", fieldPresence: presentFields()},
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", tripsAPIPath, id)))
 	defer server.Close()
@@ -43,10 +43,10 @@ func Test_GetAllTrips(t *testing.T) {
 			DirectionID:          0,
 			BikesAllowed:         BikesAllowedNoInfo,
 			BlockID:              "S931_-4-0-L-0-BraintreeQuincyCenter",
-			Route:                &Route{ID: "Red"},
-			RoutePattern:         &RoutePattern{ID: "Red-1-0"},
-			Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-BraintreeQuincyCenterL"},
-			Shape:                &Shape{ID: "931_0009"},
+			Route:                &Route{ID: "Red", fieldPresence: presentFields()},
+			RoutePattern:         &RoutePattern{ID: "Red-1-0", fieldPresence: presentFields()},
+			Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-BraintreeQuincyCenterL", fieldPresence: presentFields()},
+			Shape:                &Shape{ID: "931_0009", fieldPresence: presentFields()},
 		},
 		&Trip{
 			ID:                   "40119998-L",
@@ -56,10 +56,10 @@ func Test_GetAllTrips(t *testing.T) {
 			DirectionID:          0,
 			BikesAllowed:         BikesAllowedNoInfo,
 			BlockID:              "S931_-4-0-L",
-			Route:                &Route{ID: "Red"},
-			RoutePattern:         &RoutePattern{ID: "Red-1-0"},
-			Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-L"},
-			Shape:                &Shape{ID: "931_0009"},
+			Route:                &Route{ID: "Red", fieldPresence: presentFields()},
+			RoutePattern:         &RoutePattern{ID: "Red-1-0", fieldPresence: presentFields()},
+			Service:              &Service{ID: "RTL22019-hms29016-Saturday-01-L", fieldPresence: presentFields()},
+			Shape:                &Shape{ID: "931_0009", fieldPresence: presentFields()},
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, tripsAPIPath))
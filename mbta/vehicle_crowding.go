@@ -0,0 +1,96 @@
+package mbta
+
+// CrowdingLevel how crowded a vehicle or carriage is, in the three levels the MBTA shows riders
+type CrowdingLevel int
+
+const (
+	// CrowdingUnknown No occupancy information
+	CrowdingUnknown CrowdingLevel = iota
+	// NotCrowded Many seats available
+	NotCrowded
+	// SomeCrowding A few seats available
+	SomeCrowding
+	// Crowded Standing room only or worse
+	Crowded
+)
+
+func (l CrowdingLevel) String() string {
+	switch l {
+	case NotCrowded:
+		return "Not crowded"
+	case SomeCrowding:
+		return "Some crowding"
+	case Crowded:
+		return "Crowded"
+	default:
+		return "Unknown"
+	}
+}
+
+// CrowdingLevel the CrowdingLevel the status is shown as
+func (o OccupancyStatus) CrowdingLevel() CrowdingLevel {
+	switch o {
+	case OccupancyEmpty, OccupancyManySeatsAvailable:
+		return NotCrowded
+	case OccupancyFewSeatsAvailable:
+		return SomeCrowding
+	case OccupancyStandingRoomOnly, OccupancyCrushedStandingRoomOnly, OccupancyFull, OccupancyNotAcceptingPassengers:
+		return Crowded
+	default:
+		return CrowdingUnknown
+	}
+}
+
+// VehicleCrowding a summary of how crowded a vehicle is across its carriages, for display
+type VehicleCrowding struct {
+	Level                CrowdingLevel   // The vehicle's own level when it has one, otherwise the average over the carriages that report one
+	Carriages            []CrowdingLevel // The level of each carriage, in the order of Vehicle.Carriages
+	AveragePercentage    *int            // The average occupancy percentage of the carriages that report one. nil when none do
+	LeastCrowdedCarriage string          // Label of the least crowded carriage that reports its occupancy, to point riders at. Empty when none do
+}
+
+// Crowding summarises how crowded the vehicle is across its carriages
+func (v *Vehicle) Crowding() VehicleCrowding {
+	crowding := VehicleCrowding{Carriages: make([]CrowdingLevel, len(v.Carriages))}
+	if v.OccupancyStatus != nil {
+		crowding.Level = v.OccupancyStatus.CrowdingLevel()
+	}
+	var levelSum, levelCount, percentageSum, percentageCount int
+	leastCrowded := -1
+	for i, carriage := range v.Carriages {
+		level := carriage.OccupancyStatus.CrowdingLevel()
+		crowding.Carriages[i] = level
+		if level != CrowdingUnknown {
+			levelSum += int(level)
+			levelCount++
+		}
+		if carriage.OccupancyPercentage != nil {
+			percentageSum += *carriage.OccupancyPercentage
+			percentageCount++
+		}
+		if level != CrowdingUnknown && (leastCrowded < 0 || lessCrowded(carriage, v.Carriages[leastCrowded])) {
+			leastCrowded = i
+		}
+	}
+	if crowding.Level == CrowdingUnknown && levelCount > 0 {
+		// round to the nearest level
+		crowding.Level = CrowdingLevel((2*levelSum + levelCount) / (2 * levelCount))
+	}
+	if percentageCount > 0 {
+		average := (percentageSum + percentageCount/2) / percentageCount
+		crowding.AveragePercentage = &average
+	}
+	if leastCrowded >= 0 {
+		crowding.LeastCrowdedCarriage = v.Carriages[leastCrowded].Label
+	}
+	return crowding
+}
+
+// lessCrowded whether a is less crowded than b, by level and then by percentage when both have one
+func lessCrowded(a, b Carriage) bool {
+	aLevel, bLevel := a.OccupancyStatus.CrowdingLevel(), b.OccupancyStatus.CrowdingLevel()
+	if aLevel != bLevel {
+		return aLevel < bLevel
+	}
+	return a.OccupancyPercentage != nil && b.OccupancyPercentage != nil && *a.OccupancyPercentage < *b.OccupancyPercentage
+}
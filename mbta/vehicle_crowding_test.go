@@ -0,0 +1,53 @@
+package mbta
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVehicleCarriages(t *testing.T) {
+	id := "R-5482B1F4"
+	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", vehiclesAPIPath, id)))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	vehicle, _, err := mbtaClient.Vehicles.GetVehicle(id, &GetVehicleRequestConfig{})
+	ok(t, err)
+	equals(t, (*OccupancyStatus)(nil), vehicle.OccupancyStatus)
+	equals(t, Revenue, vehicle.Revenue)
+	equals(t, []Carriage{
+		{Label: "1712", OccupancyStatus: OccupancyManySeatsAvailable, OccupancyPercentage: intPtr(18)},
+		{Label: "1713", OccupancyStatus: OccupancyFewSeatsAvailable, OccupancyPercentage: intPtr(54)},
+		{Label: "1508", OccupancyStatus: OccupancyStandingRoomOnly, OccupancyPercentage: intPtr(91)},
+		{Label: "1509", OccupancyStatus: OccupancyNoDataAvailable},
+	}, vehicle.Carriages)
+
+	equals(t, VehicleCrowding{
+		Level:                SomeCrowding,
+		Carriages:            []CrowdingLevel{NotCrowded, SomeCrowding, Crowded, CrowdingUnknown},
+		AveragePercentage:    intPtr(54),
+		LeastCrowdedCarriage: "1712",
+	}, vehicle.Crowding())
+}
+
+func TestVehicleCrowding(t *testing.T) {
+	bus := &Vehicle{OccupancyStatus: occupancyStatusPtr(OccupancyFull)}
+	equals(t, VehicleCrowding{Level: Crowded, Carriages: []CrowdingLevel{}}, bus.Crowding())
+	equals(t, "Crowded", bus.Crowding().Level.String())
+
+	unknown := &Vehicle{Carriages: []Carriage{{Label: "1", OccupancyStatus: OccupancyNoDataAvailable}}}
+	equals(t, VehicleCrowding{Level: CrowdingUnknown, Carriages: []CrowdingLevel{CrowdingUnknown}}, unknown.Crowding())
+
+	// same level, the lower percentage wins
+	train := &Vehicle{Carriages: []Carriage{
+		{Label: "1", OccupancyStatus: OccupancyEmpty, OccupancyPercentage: intPtr(20)},
+		{Label: "2", OccupancyStatus: OccupancyManySeatsAvailable, OccupancyPercentage: intPtr(5)},
+	}}
+	crowding := train.Crowding()
+	equals(t, NotCrowded, crowding.Level)
+	equals(t, "2", crowding.LeastCrowdedCarriage)
+	equals(t, intPtr(13), crowding.AveragePercentage)
+}
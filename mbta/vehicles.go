@@ -8,45 +8,64 @@ import (
 
 const vehiclesAPIPath = "/vehicles"
 
-// VehicleService service handling all of the vehicle related API calls
+// VehicleService handling all of the vehicle related API calls
 type VehicleService service
 
-// VehicleStatus enum for the possible vehicle statuses
-type VehicleStatus string
+// Vehicle holds all the info about a given MBTA vehicle
+type Vehicle struct {
+	ID                  string           `jsonapi:"primary,vehicle"`
+	Bearing             *float32         `jsonapi:"attr,bearing"`               // Bearing, in degrees, clockwise from True North, i.e., 0 is North and 90 is East. nil when unknown
+	CurrentStatus       VehicleStatus    `jsonapi:"attr,current_status"`        // Status of vehicle relative to the stops
+	CurrentStopSequence *int             `jsonapi:"attr,current_stop_sequence"` // The stop_sequence of the stop the vehicle is at or on its way to, see current_status. nil when it isn't on a trip
+	DirectionID         int              `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1
+	Label               string           `jsonapi:"attr,label"`                 // User visible label, such as the one of on the signage on the vehicle
+	Latitude            float64          `jsonapi:"attr,latitude"`              // Degrees North, in the WGS-84 coordinate system
+	Longitude           float64          `jsonapi:"attr,longitude"`             // Degrees East, in the WGS-84 coordinate system
+	Speed               *float32         `jsonapi:"attr,speed"`                 // meters per second
+	OccupancyStatus     *OccupancyStatus `jsonapi:"attr,occupancy_status"`      // How full the vehicle is as a whole. nil when unknown, see Carriages for trains
+	Carriages           []Carriage       `jsonapi:"attr,carriages"`             // The individual cars of the vehicle, with their own occupancy. Empty for single-car vehicles like buses
+	Revenue             RevenueStatus    `jsonapi:"attr,revenue"`               // Whether the vehicle is carrying passengers
+	UpdatedAt           TimeISO8601      `jsonapi:"attr,updated_at"`            // Time at which vehicle information was last updated. Format is ISO8601
+	Route               *Route           `jsonapi:"relation,route"`             // Route that the current vehicle is on. Only includes id by default, use Include config option to get all data
+	Stop                *Stop            `jsonapi:"relation,stop"`              // Stop that the vehicle is at. Only includes id by default, use Include config option to get all data
+	Trip                *Trip            `jsonapi:"relation,trip"`              // Trip that the current vehicle is on. Only includes id by default, use Include config option to get all data
+	fieldPresence
+}
+
+// VehicleField an attribute or relationship of a vehicle, for a Fieldset
+type VehicleField string
 
 const (
-	// InTransitTo vehicleStatus for a vehicle in transit
-	InTransitTo VehicleStatus = "IN_TRANSIT_TO"
-	// StoppedAt vehicleStatus for a vehicle stopped at a station
-	StoppedAt VehicleStatus = "STOPPED_AT"
-	// IncomingAt vehicleStatus for a vehicle getting into a station
-	IncomingAt VehicleStatus = "INCOMING_AT"
+	VehicleFieldBearing             VehicleField = "bearing"
+	VehicleFieldCurrentStatus       VehicleField = "current_status"
+	VehicleFieldCurrentStopSequence VehicleField = "current_stop_sequence"
+	VehicleFieldDirectionID         VehicleField = "direction_id"
+	VehicleFieldLabel               VehicleField = "label"
+	VehicleFieldLatitude            VehicleField = "latitude"
+	VehicleFieldLongitude           VehicleField = "longitude"
+	VehicleFieldSpeed               VehicleField = "speed"
+	VehicleFieldOccupancyStatus     VehicleField = "occupancy_status"
+	VehicleFieldCarriages           VehicleField = "carriages"
+	VehicleFieldRevenue             VehicleField = "revenue"
+	VehicleFieldUpdatedAt           VehicleField = "updated_at"
+	VehicleFieldRoute               VehicleField = "route"
+	VehicleFieldStop                VehicleField = "stop"
+	VehicleFieldTrip                VehicleField = "trip"
 )
 
-// Vehicle holds all info about a given MBTA vehicle
-type Vehicle struct {
-	ID                  string        `jsonapi:"primary,vehicle"`
-	Bearing             float32       `jsonapi:"attr,bearing"`               // Bearing, in degrees, clockwise from True North, i.e., 0 is North and 90 is East
-	CurrentStatus       VehicleStatus `jsonapi:"attr,current_status"`        // Status of vehicle relative to the stops
-	CurrentStopSequence int           `jsonapi:"attr,current_stop_sequence"` // not sure on this one yet
-	DirectionID         int           `jsonapi:"attr,direction_id"`          // Direction in which trip is traveling: 0 or 1.
-	Label               string        `jsonapi:"attr,label"`                 // User visible label, such as the one of on the signage on the vehicle
-	Latitude            float64       `jsonapi:"attr,latitude"`              // Degrees North, in the WGS-84 coordinate system
-	Longitude           float64       `jsonapi:"attr,longitude"`             // Degrees East, in the WGS-84 coordinate system
-	Speed               *float32      `jsonapi:"attr,speed"`                 // meters per second
-	UpdatedAt           TimeISO8601   `jsonapi:"attr,updated_at"`            // Time at which vehicle information was last updated. Format is ISO8601
-	Route               *Route        `jsonapi:"relation,route"`             // Route that the current vehicle is on. Only includes id by default, use Include config option to get all data
-	Stop                *Stop         `jsonapi:"relation,stop"`              // Stop that the vehicle is at. Only includes id by default, use Include config option to get all data
-	Trip                *Trip         `jsonapi:"relation,trip"`              // Trip that the current vehicle is on. Only includes id by default, use Include config option to get all data
-}
+func (f VehicleField) resourceType() string { return "vehicle" }
+func (f VehicleField) fieldName() string    { return string(f) }
+
+// Has whether the vehicle has the field, i.e. whether the response it was decoded from had it, see Fieldset
+func (v *Vehicle) Has(field VehicleField) bool { return v.has(string(field)) }
 
 // VehicleInclude all of the includes for a vehicle request
 type VehicleInclude string
 
 const (
-	VehicleIncludeTrip  VehicleInclude = includeTrip
-	VehicleIncludeStop  VehicleInclude = includeStop
-	VehicleIncludeRoute VehicleInclude = includeRoute
+	VehicleIncludeTrip  VehicleInclude = "trip"
+	VehicleIncludeStop  VehicleInclude = "stop"
+	VehicleIncludeRoute VehicleInclude = "route"
 )
 
 // VehiclesSortByType all of the possible ways to sort by for a GetAllVehicles request
@@ -77,15 +96,17 @@ const (
 type GetAllVehiclesRequestConfig struct {
 	PageOffset        string             `url:"page[offset],omitempty"`             // Offset (0-based) of first element in the page
 	PageLimit         string             `url:"page[limit],omitempty"`              // Max number of elements to return
-	Sort              VehiclesSortByType `url:"sort,omitempty"`                     // Results can be sorted by the id or any VehiclesSortByType
-	Fields            []string           `url:"fields[vehicle],comma,omitempty"`    // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, “,”) list. Note that fields can also be selected for included data types
-	Include           []VehicleInclude   `url:"include,comma,omitempty"`            // Include extra data in response (trip, stop, or route)
+	Sort              VehiclesSortByType `url:"sort,omitempty"`                     // Results can be sorted by the id or any `/data/{index}/attributes` key. Assumes ascending; may be prefixed with '-' for descending
+	Fields            []string           `url:"fields[vehicle],comma,omitempty"`    // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset          Fieldset           `url:"fields,omitempty"`                   // Fields to include for each resource type, included ones too. Replaces Fields for vehicle if it has an entry for it
+	Include           []VehicleInclude   `url:"include,comma,omitempty"`            // Relationships to include
 	FilterIDs         []string           `url:"filter[id],comma,omitempty"`         // Filter by multiple IDs
 	FilterTripIDs     []string           `url:"filter[trip],comma,omitempty"`       // Filter by trip IDs
 	FilterLabels      []string           `url:"filter[label],comma,omitempty"`      // Filter by label
 	FilterRouteIDs    []string           `url:"filter[route],comma,omitempty"`      // Filter by route IDs. If the vehicle is on a multi-route trip, it will be returned for any of the routes
 	FilterDirectionID string             `url:"filter[direction_id],omitempty"`     // Filter by Direction ID (Either "0" or "1")
 	FilterRouteTypes  []RouteType        `url:"filter[route_type],comma,omitempty"` // Filter by route type(s)
+	FilterRevenue     []RevenueStatus    `url:"filter[revenue],comma,omitempty"`    // Filter by whether vehicles are in passenger service. The API defaults to Revenue only
 }
 
 // GetAllVehicles returns all vehicles from the mbta API
@@ -105,18 +126,19 @@ func (s *VehicleService) GetAllVehiclesWithContext(ctx context.Context, config *
 	}
 	req = req.WithContext(ctx)
 
-	untypedVehicles, resp, err := s.client.doManyPayload(req, &Vehicle{})
-	vehicles := make([]*Vehicle, len(untypedVehicles))
-	for i := 0; i < len(untypedVehicles); i++ {
-		vehicles[i] = untypedVehicles[i].(*Vehicle)
+	untyped, resp, err := s.client.doManyPayload(req, &Vehicle{})
+	vehicles := make([]*Vehicle, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		vehicles[i] = untyped[i].(*Vehicle)
 	}
 	return vehicles, resp, err
 }
 
 // GetVehicleRequestConfig extra options for the GetVehicle request
 type GetVehicleRequestConfig struct {
-	Fields  []string         `url:"fields[vehicle],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, “,”) list. Note that fields can also be selected for included data types
-	Include []VehicleInclude `url:"include,comma,omitempty"`         // Include extra data in response (trip, stop, or route)
+	Fields   []string         `url:"fields[vehicle],comma,omitempty"` // Fields to include with the response. Multiple fields MUST be a comma-separated (U+002C COMMA, ",") list
+	Fieldset Fieldset         `url:"fields,omitempty"`                // Fields to include for each resource type, included ones too. Replaces Fields for vehicle if it has an entry for it
+	Include  []VehicleInclude `url:"include,comma,omitempty"`         // Relationships to include
 }
 
 // GetVehicle returns a vehicle from the mbta API
@@ -145,3 +167,65 @@ func (s *VehicleService) GetVehicleWithContext(ctx context.Context, id string, c
 	resp, err := s.client.doSinglePayload(req, &vehicle)
 	return &vehicle, resp, err
 }
+
+// GetVehicles returns the vehicles with the given IDs from the mbta API, in the same order as ids. The IDs are split across as many
+// requests as it takes to keep the URLs short. Where an ID isn't found the result is nil and the error is a MissingIDsError
+func (s *VehicleService) GetVehicles(ids []string) ([]*Vehicle, error) {
+	return s.GetVehiclesWithContext(context.Background(), ids)
+}
+
+// GetVehiclesWithContext returns the vehicles with the given IDs from the mbta API given a context
+func (s *VehicleService) GetVehiclesWithContext(ctx context.Context, ids []string) ([]*Vehicle, error) {
+	untyped, err := s.client.batchGet(ctx, vehiclesAPIPath, ids, &Vehicle{}, func(ids []string) interface{} {
+		return &GetAllVehiclesRequestConfig{FilterIDs: ids}
+	})
+	vehicles := make([]*Vehicle, len(untyped))
+	for i := 0; i < len(untyped); i++ {
+		vehicles[i], _ = untyped[i].(*Vehicle)
+	}
+	return vehicles, err
+}
+
+// VehicleStatus enum for the possible vehicle statuses
+type VehicleStatus string
+
+const (
+	// InTransitTo vehicleStatus for a vehicle in transit
+	InTransitTo VehicleStatus = "IN_TRANSIT_TO"
+	// StoppedAt vehicleStatus for a vehicle stopped at a station
+	StoppedAt VehicleStatus = "STOPPED_AT"
+	// IncomingAt vehicleStatus for a vehicle getting into a station
+	IncomingAt VehicleStatus = "INCOMING_AT"
+)
+
+// OccupancyStatus enum for how full a vehicle or carriage is, from the GTFS-realtime OccupancyStatus
+type OccupancyStatus string
+
+const (
+	OccupancyEmpty                   OccupancyStatus = "EMPTY"
+	OccupancyManySeatsAvailable      OccupancyStatus = "MANY_SEATS_AVAILABLE"
+	OccupancyFewSeatsAvailable       OccupancyStatus = "FEW_SEATS_AVAILABLE"
+	OccupancyStandingRoomOnly        OccupancyStatus = "STANDING_ROOM_ONLY"
+	OccupancyCrushedStandingRoomOnly OccupancyStatus = "CRUSHED_STANDING_ROOM_ONLY"
+	OccupancyFull                    OccupancyStatus = "FULL"
+	OccupancyNotAcceptingPassengers  OccupancyStatus = "NOT_ACCEPTING_PASSENGERS"
+	OccupancyNoDataAvailable         OccupancyStatus = "NO_DATA_AVAILABLE"
+	OccupancyNotBoardable            OccupancyStatus = "NOT_BOARDABLE"
+)
+
+// RevenueStatus enum for whether a vehicle is carrying passengers
+type RevenueStatus string
+
+const (
+	// Revenue the vehicle is in passenger service
+	Revenue RevenueStatus = "REVENUE"
+	// NonRevenue the vehicle isn't carrying passengers, e.g. it's going to or from a yard
+	NonRevenue RevenueStatus = "NON_REVENUE"
+)
+
+// Carriage a single car of a vehicle, e.g. of a subway train
+type Carriage struct {
+	Label               string          `json:"label"`                // Carriage-specific label, used as an identifier
+	OccupancyStatus     OccupancyStatus `json:"occupancy_status"`     // How full the carriage is
+	OccupancyPercentage *int            `json:"occupancy_percentage"` // Percentage of the carriage's capacity in use. nil when unknown
+}
@@ -12,18 +12,20 @@ func Test_GetVehicle(t *testing.T) {
 	parsedTime, _ := parseISO8601Time("2019-05-14T16:05:53-04:00")
 	expected := &Vehicle{
 		ID:                  id,
-		Bearing:             270.0,
+		Bearing:             float32Ptr(270.0),
 		CurrentStatus:       InTransitTo,
-		CurrentStopSequence: 1,
+		CurrentStopSequence: intPtr(1),
 		DirectionID:         1,
 		Label:               "1772",
 		Latitude:            42.349491119384766,
 		Longitude:           -71.07652282714844,
 		Speed:               nil,
+		OccupancyStatus:     occupancyStatusPtr(OccupancyManySeatsAvailable),
+		Revenue:             Revenue,
 		UpdatedAt:           timeToTimeISO8601(parsedTime),
-		Route:               &Route{ID: "10"},
-		Stop:                &Stop{ID: "178"},
-		Trip:                &Trip{ID: "39915343"},
+		Route:               &Route{ID: "10", fieldPresence: presentFields()},
+		Stop:                &Stop{ID: "178", fieldPresence: presentFields()},
+		Trip:                &Trip{ID: "39915343", fieldPresence: presentFields()},
 	}
 	server := httptest.NewServer(handlerForServer(t, fmt.Sprintf("%s/%s", vehiclesAPIPath, id)))
 	defer server.Close()
@@ -42,33 +44,37 @@ func Test_GetAllVehicles(t *testing.T) {
 	expected := []*Vehicle{
 		&Vehicle{
 			ID:                  "y1772",
-			Bearing:             194.0,
+			Bearing:             float32Ptr(194.0),
 			CurrentStatus:       InTransitTo,
-			CurrentStopSequence: 12,
+			CurrentStopSequence: intPtr(12),
 			DirectionID:         1,
 			Label:               "1772",
 			Latitude:            42.335472106933594,
 			Longitude:           -71.0453109741211,
 			Speed:               nil,
 			UpdatedAt:           timeToTimeISO8601(parsedTime1),
-			Route:               &Route{ID: "10"},
-			Stop:                &Stop{ID: "46"},
-			Trip:                &Trip{ID: "39915358"},
+			Route:               &Route{ID: "10", fieldPresence: presentFields()},
+			Stop:                &Stop{ID: "46", fieldPresence: presentFields()},
+			Trip:                &Trip{ID: "39915358", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(VehicleFieldOccupancyStatus, VehicleFieldCarriages, VehicleFieldRevenue),
 		},
 		&Vehicle{
 			ID:                  "y1869",
-			Bearing:             231.0,
+			Bearing:             float32Ptr(231.0),
 			CurrentStatus:       InTransitTo,
-			CurrentStopSequence: 24,
+			CurrentStopSequence: intPtr(24),
 			DirectionID:         1,
 			Label:               "1869",
 			Latitude:            42.331825256347656,
 			Longitude:           -71.07601165771484,
 			Speed:               nil,
 			UpdatedAt:           timeToTimeISO8601(parsedTime2),
-			Route:               &Route{ID: "1"},
-			Stop:                &Stop{ID: "10100"},
-			Trip:                &Trip{ID: "39914092"},
+			Route:               &Route{ID: "1", fieldPresence: presentFields()},
+			Stop:                &Stop{ID: "10100", fieldPresence: presentFields()},
+			Trip:                &Trip{ID: "39914092", fieldPresence: presentFields()},
+
+			fieldPresence: missingFields(VehicleFieldOccupancyStatus, VehicleFieldCarriages, VehicleFieldRevenue),
 		},
 	}
 	server := httptest.NewServer(handlerForServer(t, vehiclesAPIPath))
@@ -81,3 +87,14 @@ func Test_GetAllVehicles(t *testing.T) {
 	ok(t, err)
 	equals(t, expected, actual)
 }
+
+func Test_GetAllVehiclesFilterRevenue(t *testing.T) {
+	server := httptest.NewServer(handlerForServer(t, vehiclesAPIPath+"?filter%5Brevenue%5D=REVENUE%2CNON_REVENUE"))
+	defer server.Close()
+
+	mbtaClient := NewClient(ClientConfig{BaseURL: server.URL})
+	mbtaClient.client = server.Client()
+
+	_, _, err := mbtaClient.Vehicles.GetAllVehicles(&GetAllVehiclesRequestConfig{FilterRevenue: []RevenueStatus{Revenue, NonRevenue}})
+	ok(t, err)
+}
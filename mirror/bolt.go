@@ -0,0 +1,61 @@
+package mirror
+
+import (
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("mbta-mirror")
+
+// BoltStore keeps records in a bbolt database file, so a restarted process can answer queries before its first sync
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBoltStore opens (or creates) the database file at path
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Load reads a record from the database
+func (s *BoltStore) Load(resource Resource) (*Record, error) {
+	var record *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltBucket).Get([]byte(resource))
+		if b == nil {
+			return nil
+		}
+		record = &Record{}
+		return json.Unmarshal(b, record)
+	})
+	return record, err
+}
+
+// Save writes a record to the database
+func (s *BoltStore) Save(resource Resource, record *Record) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(resource), b)
+	})
+}
+
+// Close closes the database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
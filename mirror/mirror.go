@@ -0,0 +1,323 @@
+// Package mirror keeps a local copy of the MBTA's static resources (routes, lines, stops, shapes, route patterns and services)
+// and answers queries about them without going to the network.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+// Resource a kind of static data the Mirror keeps
+type Resource string
+
+const (
+	Routes        Resource = "routes"
+	Lines         Resource = "lines"
+	Stops         Resource = "stops"
+	RoutePatterns Resource = "route_patterns"
+	Services      Resource = "services"
+	Shapes        Resource = "shapes"
+)
+
+// AllResources every resource, in the order they are synced. Shapes can only be fetched by route, so they come after routes
+var AllResources = []Resource{Routes, Lines, Stops, RoutePatterns, Services, Shapes}
+
+// Mirror syncs static resources into a Store and answers queries about them from memory.
+// Resources returned by queries are shared and must not be modified
+type Mirror struct {
+	client    *mbta.Client
+	store     Store
+	resources []Resource
+
+	mu            sync.RWMutex
+	meta          map[Resource]Record // without Data
+	routes        []*mbta.Route
+	routeIndex    map[string]*mbta.Route
+	lines         []*mbta.Line
+	lineIndex     map[string]*mbta.Line
+	stops         []*mbta.Stop
+	stopIndex     map[string]*mbta.Stop
+	patterns      []*mbta.RoutePattern
+	patternIndex  map[string]*mbta.RoutePattern
+	services      []*mbta.Service
+	serviceIndex  map[string]*mbta.Service
+	shapes        []*mbta.Shape
+	shapeIndex    map[string]*mbta.Shape
+	shapeRouteIDs []string // the routes the shapes were fetched for
+}
+
+// New creates a Mirror of the given resources (all of them if none are given) that keeps its data in store. A nil store keeps it in memory only
+func New(client *mbta.Client, store Store, resources ...Resource) *Mirror {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	if len(resources) == 0 {
+		resources = AllResources
+	}
+	return &Mirror{
+		client:    client,
+		store:     store,
+		resources: resources,
+		meta:      make(map[Resource]Record),
+	}
+}
+
+// Load reads the resources from the store, so that queries can be answered before the first Sync
+func (m *Mirror) Load() error {
+	for _, r := range m.resources {
+		record, err := m.store.Load(r)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			continue
+		}
+		if err := m.decode(r, record); err != nil {
+			return xerrors.Errorf("loading %s: %w", r, err)
+		}
+	}
+	return nil
+}
+
+// Sync fetches every resource that changed since the last sync and saves it to the store
+func (m *Mirror) Sync() error {
+	return m.SyncWithContext(context.Background())
+}
+
+// SyncWithContext fetches every resource that changed since the last sync given a context and saves it to the store.
+// A failing resource doesn't stop the others from syncing; the first error is returned
+func (m *Mirror) SyncWithContext(ctx context.Context) error {
+	var firstErr error
+	for _, r := range m.resources {
+		if err := m.sync(ctx, r); err != nil && firstErr == nil {
+			firstErr = xerrors.Errorf("syncing %s: %w", r, err)
+		}
+	}
+	return firstErr
+}
+
+// Run loads the store, then syncs every interval until ctx is done. Errors are passed to onError, which may be nil, and don't stop the mirror
+func (m *Mirror) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	if err := m.Load(); err != nil && onError != nil {
+		onError(err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.SyncWithContext(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// LastModified the Last-Modified header of the response the resource was last fetched from, "" if it was never fetched
+func (m *Mirror) LastModified(r Resource) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.meta[r].LastModified
+}
+
+// FetchedAt when the resource was last fetched, which is the zero time if it was never fetched
+func (m *Mirror) FetchedAt(r Resource) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.meta[r].FetchedAt
+}
+
+func (m *Mirror) sync(ctx context.Context, r Resource) error {
+	m.mu.RLock()
+	lastModified := m.meta[r].LastModified
+	m.mu.RUnlock()
+
+	var routeIDs []string
+	if r == Shapes {
+		var err error
+		if routeIDs, err = m.shapeRoutes(ctx); err != nil {
+			return err
+		}
+		// the Last-Modified of a different set of routes says nothing about this one
+		if !sameStrings(routeIDs, m.currentShapeRoutes()) {
+			lastModified = ""
+		}
+	}
+	reqCtx := ctx
+	if lastModified != "" {
+		reqCtx = mbta.WithIfModifiedSince(ctx, lastModified)
+	}
+
+	value, resp, err := m.fetch(reqCtx, r, routeIDs)
+	if err == mbta.ErrNotModified {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	record := &Record{Data: data, LastModified: resp.Header.Get("Last-Modified"), FetchedAt: time.Now(), RouteIDs: routeIDs}
+	if err := m.store.Save(r, record); err != nil {
+		return err
+	}
+	m.set(r, value, *record)
+	return nil
+}
+
+// shapeRoutes the routes to fetch shapes for: the mirrored routes, or all routes when routes aren't mirrored
+func (m *Mirror) shapeRoutes(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	routes := m.routes
+	m.mu.RUnlock()
+	if len(routes) == 0 {
+		var err error
+		routes, _, err = m.client.Routes.GetAllRoutesWithContext(ctx, &mbta.GetAllRoutesRequestConfig{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]string, len(routes))
+	for i, route := range routes {
+		ids[i] = route.ID
+	}
+	return ids, nil
+}
+
+func (m *Mirror) currentShapeRoutes() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.shapeRouteIDs
+}
+
+func (m *Mirror) fetch(ctx context.Context, r Resource, routeIDs []string) (interface{}, *http.Response, error) {
+	switch r {
+	case Routes:
+		return m.client.Routes.GetAllRoutesWithContext(ctx, &mbta.GetAllRoutesRequestConfig{})
+	case Lines:
+		return m.client.Lines.GetAllLinesWithContext(ctx, &mbta.GetAllLinesRequestConfig{})
+	case Stops:
+		return m.client.Stops.GetAllStopsWithContext(ctx, &mbta.GetAllStopsRequestConfig{})
+	case RoutePatterns:
+		return m.client.RoutePatterns.GetAllRoutePatternsWithContext(ctx, &mbta.GetAllRoutePatternsRequestConfig{})
+	case Services:
+		return m.client.Services.GetAllServicesWithContext(ctx, &mbta.GetAllServicesRequestConfig{})
+	case Shapes:
+		return m.client.Shapes.GetAllShapesWithContext(ctx, &mbta.GetAllShapesRequestConfig{FilterRoute: routeIDs})
+	}
+	return nil, nil, xerrors.Errorf("unknown resource %q", r)
+}
+
+func (m *Mirror) decode(r Resource, record *Record) error {
+	var value interface{}
+	switch r {
+	case Routes:
+		var routes []*mbta.Route
+		if err := json.Unmarshal(record.Data, &routes); err != nil {
+			return err
+		}
+		value = routes
+	case Lines:
+		var lines []*mbta.Line
+		if err := json.Unmarshal(record.Data, &lines); err != nil {
+			return err
+		}
+		value = lines
+	case Stops:
+		var stops []*mbta.Stop
+		if err := json.Unmarshal(record.Data, &stops); err != nil {
+			return err
+		}
+		value = stops
+	case RoutePatterns:
+		var patterns []*mbta.RoutePattern
+		if err := json.Unmarshal(record.Data, &patterns); err != nil {
+			return err
+		}
+		value = patterns
+	case Services:
+		var services []*mbta.Service
+		if err := json.Unmarshal(record.Data, &services); err != nil {
+			return err
+		}
+		value = services
+	case Shapes:
+		var shapes []*mbta.Shape
+		if err := json.Unmarshal(record.Data, &shapes); err != nil {
+			return err
+		}
+		value = shapes
+	default:
+		return xerrors.Errorf("unknown resource %q", r)
+	}
+	m.set(r, value, *record)
+	return nil
+}
+
+// set replaces a resource in memory
+func (m *Mirror) set(r Resource, value interface{}, record Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record.Data = nil
+	m.meta[r] = record
+	switch v := value.(type) {
+	case []*mbta.Route:
+		m.routes, m.routeIndex = v, make(map[string]*mbta.Route, len(v))
+		for _, x := range v {
+			m.routeIndex[x.ID] = x
+		}
+	case []*mbta.Line:
+		m.lines, m.lineIndex = v, make(map[string]*mbta.Line, len(v))
+		for _, x := range v {
+			m.lineIndex[x.ID] = x
+		}
+	case []*mbta.Stop:
+		m.stops, m.stopIndex = v, make(map[string]*mbta.Stop, len(v))
+		for _, x := range v {
+			m.stopIndex[x.ID] = x
+		}
+	case []*mbta.RoutePattern:
+		m.patterns, m.patternIndex = v, make(map[string]*mbta.RoutePattern, len(v))
+		for _, x := range v {
+			m.patternIndex[x.ID] = x
+		}
+	case []*mbta.Service:
+		m.services, m.serviceIndex = v, make(map[string]*mbta.Service, len(v))
+		for _, x := range v {
+			m.serviceIndex[x.ID] = x
+		}
+	case []*mbta.Shape:
+		m.shapes, m.shapeIndex = v, make(map[string]*mbta.Shape, len(v))
+		for _, x := range v {
+			m.shapeIndex[x.ID] = x
+		}
+		m.shapeRouteIDs = record.RouteIDs
+	}
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+const testLastModified = "Mon, 03 Jun 2019 08:00:00 GMT"
+
+var testBodies = map[string]string{
+	"/routes": `{"data": [
+		{"type": "route", "id": "Red", "attributes": {"long_name": "Red Line", "type": 1, "sort_order": 10010}, "relationships": {"line": {"data": {"type": "line", "id": "line-Red"}}}},
+		{"type": "route", "id": "Green-B", "attributes": {"long_name": "Green Line B", "type": 0, "sort_order": 10032}, "relationships": {"line": {"data": {"type": "line", "id": "line-Green"}}}},
+		{"type": "route", "id": "1", "attributes": {"long_name": "Harvard - Nubian", "type": 3, "sort_order": 50010}, "relationships": {"line": {"data": {"type": "line", "id": "line-1"}}}}
+	]}`,
+	"/lines": `{"data": [
+		{"type": "line", "id": "line-Red", "attributes": {"long_name": "Red Line", "color": "DA291C"}},
+		{"type": "line", "id": "line-Green", "attributes": {"long_name": "Green Line", "color": "00843D"}},
+		{"type": "line", "id": "line-1", "attributes": {"long_name": "Harvard - Nubian"}}
+	]}`,
+	"/stops": `{"data": [
+		{"type": "stop", "id": "place-harsq", "attributes": {"name": "Harvard", "latitude": 42.373362, "longitude": -71.118956, "location_type": 1}},
+		{"type": "stop", "id": "70068", "attributes": {"name": "Harvard", "latitude": 42.373362, "longitude": -71.118956, "location_type": 0}, "relationships": {"parent_station": {"data": {"type": "stop", "id": "place-harsq"}}}},
+		{"type": "stop", "id": "place-pktrm", "attributes": {"name": "Park Street", "latitude": 42.35639457, "longitude": -71.0624242, "location_type": 1}}
+	]}`,
+	"/route_patterns": `{"data": [
+		{"type": "route_pattern", "id": "Red-1-0", "attributes": {"name": "Ashmont", "direction_id": 0}, "relationships": {"route": {"data": {"type": "route", "id": "Red"}}}},
+		{"type": "route_pattern", "id": "Red-1-1", "attributes": {"name": "Alewife", "direction_id": 1}, "relationships": {"route": {"data": {"type": "route", "id": "Red"}}}},
+		{"type": "route_pattern", "id": "1-_-0", "attributes": {"name": "Nubian", "direction_id": 0}, "relationships": {"route": {"data": {"type": "route", "id": "1"}}}}
+	]}`,
+	"/services": `{"data": [
+		{"type": "service", "id": "weekday", "attributes": {"description": "Weekday schedule"}},
+		{"type": "service", "id": "saturday", "attributes": {"description": "Saturday schedule"}}
+	]}`,
+	"/shapes": `{"data": [
+		{"type": "shape", "id": "931_0009", "attributes": {"name": "Ashmont", "direction_id": 0}, "relationships": {"route": {"data": {"type": "route", "id": "Red"}}}},
+		{"type": "shape", "id": "931_0010", "attributes": {"name": "Alewife", "direction_id": 1}, "relationships": {"route": {"data": {"type": "route", "id": "Red"}}}}
+	]}`,
+}
+
+// testServer serves testBodies, answering 304 when If-Modified-Since matches testLastModified
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]int
+	notMod   map[string]int
+	shapes   []string // filter[route] of the last shapes request
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{requests: make(map[string]int), notMod: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := testBodies[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		if r.URL.Path == "/shapes" {
+			s.shapes = []string{r.URL.Query().Get("filter[route]")}
+		}
+		s.mu.Unlock()
+		if r.Header.Get("If-Modified-Since") == testLastModified {
+			s.mu.Lock()
+			s.notMod[r.URL.Path]++
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", testLastModified)
+		fmt.Fprint(w, body)
+	}))
+	return s
+}
+
+func (s *testServer) counts(path string) (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path], s.notMod[path]
+}
+
+func routeIDs(routes []*mbta.Route) []string {
+	ids := make([]string, len(routes))
+	for i, r := range routes {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func stopIDs(stops []*mbta.Stop) []string {
+	ids := make([]string, len(stops))
+	for i, s := range stops {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+func TestMirror_Sync(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	m := New(mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL}), nil)
+
+	_, err := m.GetAllRoutes(nil)
+	assert(t, xerrors.Is(err, ErrNotMirrored), "expected ErrNotMirrored before the first sync, got %v", err)
+
+	ok(t, m.Sync())
+	equals(t, testLastModified, m.LastModified(Routes))
+	assert(t, !m.FetchedAt(Shapes).IsZero(), "expected shapes to be fetched")
+	equals(t, []string{"Red,Green-B,1"}, server.shapes)
+
+	ok(t, m.Sync())
+	for path := range testBodies {
+		requests, notModified := server.counts(path)
+		equals(t, 2, requests)
+		equals(t, 1, notModified)
+	}
+
+	routes, err := m.GetAllRoutes(nil)
+	ok(t, err)
+	equals(t, []string{"Red", "Green-B", "1"}, routeIDs(routes))
+}
+
+func TestMirror_BoltStore(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "mirror")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mirror.db")
+
+	store, err := OpenBoltStore(path)
+	ok(t, err)
+	client := mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL})
+	ok(t, New(client, store).Sync())
+	ok(t, store.Close())
+
+	// a restarted process answers from the file, then only revalidates
+	store, err = OpenBoltStore(path)
+	ok(t, err)
+	defer store.Close()
+	m := New(client, store)
+	ok(t, m.Load())
+	stop, err := m.GetStop("70068", &mbta.GetStopRequestConfig{Include: []mbta.StopInclude{mbta.StopIncludeParentStation}})
+	ok(t, err)
+	equals(t, "Harvard", stop.ParentStation.Name)
+	equals(t, testLastModified, m.LastModified(Shapes))
+
+	ok(t, m.Sync())
+	for path := range testBodies {
+		_, notModified := server.counts(path)
+		equals(t, 1, notModified)
+	}
+}
+
+func TestMirror_Run(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+	m := New(mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL}), nil, Services)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- m.Run(ctx, time.Hour, func(err error) { t.Error(err) }) }()
+	for m.FetchedAt(Services).IsZero() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	equals(t, context.Canceled, <-done)
+
+	_, err := m.GetAllRoutes(nil)
+	assert(t, xerrors.Is(err, ErrNotMirrored), "expected routes not to be mirrored, got %v", err)
+}
+
+func syncedMirror(t *testing.T) *Mirror {
+	server := newTestServer(t)
+	defer server.Close()
+	m := New(mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL}), nil)
+	ok(t, m.Sync())
+	return m
+}
+
+func TestMirror_GetAllRoutes(t *testing.T) {
+	m := syncedMirror(t)
+
+	routes, err := m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{FilterRouteTypes: []mbta.RouteType{mbta.RouteTypeLightRail, mbta.RouteTypeHeavyRail}})
+	ok(t, err)
+	equals(t, []string{"Red", "Green-B"}, routeIDs(routes))
+
+	routes, err = m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{PageOffset: "1", PageLimit: "1"})
+	ok(t, err)
+	equals(t, []string{"Green-B"}, routeIDs(routes))
+
+	routes, err = m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{FilterIDs: []string{"1"}, Include: []mbta.RouteInclude{mbta.RouteIncludeLine}})
+	ok(t, err)
+	equals(t, "Harvard - Nubian", routes[0].Line.LongName)
+
+	route, err := m.GetRoute("Red", nil)
+	ok(t, err)
+	equals(t, "", route.Line.LongName)
+
+	_, err = m.GetRoute("Orange", nil)
+	assert(t, xerrors.Is(err, ErrNotFound), "expected ErrNotFound, got %v", err)
+	_, err = m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{FilterStop: "place-harsq"})
+	equals(t, ErrUnsupportedQuery, err)
+	_, err = m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{PageLimit: "many"})
+	assert(t, xerrors.Is(err, mbta.ErrInvalidConfig), "expected ErrInvalidConfig, got %v", err)
+}
+
+func TestMirror_UnsupportedFieldset(t *testing.T) {
+	m := syncedMirror(t)
+	// the mirror keeps whole resources, it can't leave fields out
+	fieldset := mbta.NewFieldset(mbta.StopFieldName)
+	unsupported := func(method string, err error) {
+		assert(t, err == ErrUnsupportedQuery, "%s: expected ErrUnsupportedQuery, got %v", method, err)
+	}
+	_, err := m.GetAllRoutes(&mbta.GetAllRoutesRequestConfig{Fieldset: fieldset})
+	unsupported("GetAllRoutes", err)
+	_, err = m.GetRoute("Red", &mbta.GetRouteRequestConfig{Fieldset: fieldset})
+	unsupported("GetRoute", err)
+	_, err = m.GetAllLines(&mbta.GetAllLinesRequestConfig{Fieldset: fieldset})
+	unsupported("GetAllLines", err)
+	_, err = m.GetLine("line-Red", &mbta.GetLineRequestConfig{Fieldset: fieldset})
+	unsupported("GetLine", err)
+	_, err = m.GetAllStops(&mbta.GetAllStopsRequestConfig{Fieldset: fieldset})
+	unsupported("GetAllStops", err)
+	_, err = m.GetStop("place-harsq", &mbta.GetStopRequestConfig{Fieldset: fieldset})
+	unsupported("GetStop", err)
+	_, err = m.GetAllShapes(&mbta.GetAllShapesRequestConfig{Fieldset: fieldset})
+	unsupported("GetAllShapes", err)
+	_, err = m.GetShape("931_0009", &mbta.GetShapeRequestConfig{Fieldset: fieldset})
+	unsupported("GetShape", err)
+	_, err = m.GetAllServices(&mbta.GetAllServicesRequestConfig{Fieldset: fieldset})
+	unsupported("GetAllServices", err)
+	_, err = m.GetService("BUS", &mbta.GetServiceRequestConfig{Fieldset: fieldset})
+	unsupported("GetService", err)
+}
+
+func TestMirror_GetAllStops(t *testing.T) {
+	m := syncedMirror(t)
+
+	stops, err := m.GetAllStops(&mbta.GetAllStopsRequestConfig{FilterLocationType: []string{"1"}})
+	ok(t, err)
+	equals(t, []string{"place-harsq", "place-pktrm"}, stopIDs(stops))
+
+	stops, err = m.GetAllStops(&mbta.GetAllStopsRequestConfig{FilterLatitude: "42.3736", FilterLongitude: "-71.1190"})
+	ok(t, err)
+	equals(t, []string{"place-harsq", "70068"}, stopIDs(stops))
+
+	stops, err = m.GetAllStops(&mbta.GetAllStopsRequestConfig{FilterLatitude: "42.3736", FilterLongitude: "-71.1190", FilterRadius: "0.1"})
+	ok(t, err)
+	equals(t, 3, len(stops))
+
+	_, err = m.GetAllStops(&mbta.GetAllStopsRequestConfig{FilterLatitude: "42.3736"})
+	assert(t, xerrors.Is(err, mbta.ErrInvalidConfig), "expected ErrInvalidConfig, got %v", err)
+}
+
+func TestMirror_GetAllRoutePatterns(t *testing.T) {
+	m := syncedMirror(t)
+
+	patterns, err := m.GetAllRoutePatterns(&mbta.GetAllRoutePatternsRequestConfig{FilterRouteIDs: []string{"Red"}, FilterDirectionID: "1"})
+	ok(t, err)
+	equals(t, 1, len(patterns))
+	equals(t, "Red-1-1", patterns[0].ID)
+
+	shapes, err := m.GetAllShapes(&mbta.GetAllShapesRequestConfig{FilterRoute: []string{"Red"}, FilterDirectionID: "0"})
+	ok(t, err)
+	equals(t, 1, len(shapes))
+	equals(t, "931_0009", shapes[0].ID)
+
+	services, err := m.GetAllServices(&mbta.GetAllServicesRequestConfig{FilterIDs: []string{"saturday"}})
+	ok(t, err)
+	equals(t, 1, len(services))
+	service, err := m.GetService("weekday", nil)
+	ok(t, err)
+	equals(t, "Weekday schedule", *service.Description)
+
+	lines, err := m.GetAllLines(nil)
+	ok(t, err)
+	equals(t, 3, len(lines))
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("exp: %#v\n\ngot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
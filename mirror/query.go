@@ -0,0 +1,522 @@
+package mirror
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ErrNotMirrored the resource isn't part of the Mirror, or it hasn't been loaded or synced yet
+	ErrNotMirrored = errors.New("resource is not mirrored")
+	// ErrNotFound no mirrored resource has the given id
+	ErrNotFound = errors.New("not found in the mirror")
+	// ErrUnsupportedQuery the config uses an option the Mirror can't answer locally, e.g. sorting or sparse fields
+	ErrUnsupportedQuery = errors.New("query is not supported by the mirror")
+)
+
+// defaultStopRadius the radius the API uses when FilterRadius isn't given
+const defaultStopRadius = 0.01
+
+// GetAllRoutes returns the mirrored routes matching the config. Supports paging, FilterIDs, FilterRouteTypes and including the line
+func (m *Mirror) GetAllRoutes(config *mbta.GetAllRoutesRequestConfig) ([]*mbta.Route, error) {
+	if config == nil {
+		config = &mbta.GetAllRoutesRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Fields) > 0 || len(config.Fieldset) > 0 || config.FilterDirectionID != "" || config.FilterDate != "" || config.FilterStop != "" {
+		return nil, ErrUnsupportedQuery
+	}
+	includeLine, err := routeIncludesLine(config.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Routes); err != nil {
+		return nil, err
+	}
+	if includeLine {
+		if err := m.loaded(Lines); err != nil {
+			return nil, err
+		}
+	}
+	ids := stringSet(config.FilterIDs)
+	var routes []*mbta.Route
+	for _, route := range m.routes {
+		if ids != nil && !ids[route.ID] {
+			continue
+		}
+		if len(config.FilterRouteTypes) > 0 && !hasRouteType(config.FilterRouteTypes, route.Type) {
+			continue
+		}
+		routes = append(routes, m.route(route, includeLine))
+	}
+	start, end, err := page(len(routes), config.PageOffset, config.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return routes[start:end], nil
+}
+
+// GetRoute returns the mirrored route with the given id
+func (m *Mirror) GetRoute(id string, config *mbta.GetRouteRequestConfig) (*mbta.Route, error) {
+	if config == nil {
+		config = &mbta.GetRouteRequestConfig{}
+	}
+	if len(config.Fields) > 0 || len(config.Fieldset) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+	includeLine, err := routeIncludesLine(config.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Routes); err != nil {
+		return nil, err
+	}
+	if includeLine {
+		if err := m.loaded(Lines); err != nil {
+			return nil, err
+		}
+	}
+	route, ok := m.routeIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("route %s: %w", id, ErrNotFound)
+	}
+	return m.route(route, includeLine), nil
+}
+
+// GetAllLines returns the mirrored lines matching the config. Supports paging and FilterIDs
+func (m *Mirror) GetAllLines(config *mbta.GetAllLinesRequestConfig) ([]*mbta.Line, error) {
+	if config == nil {
+		config = &mbta.GetAllLinesRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Fields) > 0 || len(config.Fieldset) > 0 || len(config.Include) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Lines); err != nil {
+		return nil, err
+	}
+	ids := stringSet(config.FilterIDs)
+	var lines []*mbta.Line
+	for _, line := range m.lines {
+		if ids == nil || ids[line.ID] {
+			lines = append(lines, line)
+		}
+	}
+	start, end, err := page(len(lines), config.PageOffset, config.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return lines[start:end], nil
+}
+
+// GetLine returns the mirrored line with the given id
+func (m *Mirror) GetLine(id string, config *mbta.GetLineRequestConfig) (*mbta.Line, error) {
+	if config != nil && (len(config.Fields) > 0 || len(config.Fieldset) > 0 || len(config.Include) > 0) {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Lines); err != nil {
+		return nil, err
+	}
+	line, ok := m.lineIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("line %s: %w", id, ErrNotFound)
+	}
+	return line, nil
+}
+
+// GetAllStops returns the mirrored stops matching the config. Supports paging, FilterIDs, FilterLocationType,
+// the latitude/longitude/radius filter and including the parent station
+func (m *Mirror) GetAllStops(config *mbta.GetAllStopsRequestConfig) ([]*mbta.Stop, error) {
+	if config == nil {
+		config = &mbta.GetAllStopsRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Fields) > 0 || len(config.Fieldset) > 0 || config.FilterDirectionID != "" || len(config.FilterRouteTypes) > 0 || len(config.FilterRouteIDs) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+	includeParent, err := stopIncludesParent(config.Include)
+	if err != nil {
+		return nil, err
+	}
+	near, err := parseNear(config.FilterLatitude, config.FilterLongitude, config.FilterRadius)
+	if err != nil {
+		return nil, err
+	}
+	locationTypes := make(map[mbta.StopLocationType]bool)
+	for _, s := range config.FilterLocationType {
+		t, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, xerrors.Errorf("location type %q: %w", s, mbta.ErrInvalidConfig)
+		}
+		locationTypes[mbta.StopLocationType(t)] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Stops); err != nil {
+		return nil, err
+	}
+	ids := stringSet(config.FilterIDs)
+	var stops []*mbta.Stop
+	for _, stop := range m.stops {
+		if ids != nil && !ids[stop.ID] {
+			continue
+		}
+		if len(locationTypes) > 0 && !locationTypes[stop.LocationType] {
+			continue
+		}
+		if near != nil && !near.contains(stop) {
+			continue
+		}
+		stops = append(stops, m.stop(stop, includeParent))
+	}
+	start, end, err := page(len(stops), config.PageOffset, config.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return stops[start:end], nil
+}
+
+// GetStop returns the mirrored stop with the given id
+func (m *Mirror) GetStop(id string, config *mbta.GetStopRequestConfig) (*mbta.Stop, error) {
+	if config == nil {
+		config = &mbta.GetStopRequestConfig{}
+	}
+	if len(config.Fields) > 0 || len(config.Fieldset) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+	includeParent, err := stopIncludesParent(config.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Stops); err != nil {
+		return nil, err
+	}
+	stop, ok := m.stopIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("stop %s: %w", id, ErrNotFound)
+	}
+	return m.stop(stop, includeParent), nil
+}
+
+// GetAllShapes returns the mirrored shapes matching the config. Supports paging, FilterRoute and FilterDirectionID
+func (m *Mirror) GetAllShapes(config *mbta.GetAllShapesRequestConfig) ([]*mbta.Shape, error) {
+	if config == nil {
+		config = &mbta.GetAllShapesRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Fields) > 0 || len(config.Fieldset) > 0 || len(config.Include) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+	direction, err := parseDirection(config.FilterDirectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Shapes); err != nil {
+		return nil, err
+	}
+	routeIDs := stringSet(config.FilterRoute)
+	var shapes []*mbta.Shape
+	for _, shape := range m.shapes {
+		if routeIDs != nil && (shape.Route == nil || !routeIDs[shape.Route.ID]) {
+			continue
+		}
+		if direction != nil && shape.DirectionID != *direction {
+			continue
+		}
+		shapes = append(shapes, shape)
+	}
+	start, end, err := page(len(shapes), strconv.Itoa(config.PageOffset), strconv.Itoa(config.PageLimit))
+	if err != nil {
+		return nil, err
+	}
+	return shapes[start:end], nil
+}
+
+// GetShape returns the mirrored shape with the given id
+func (m *Mirror) GetShape(id string, config *mbta.GetShapeRequestConfig) (*mbta.Shape, error) {
+	if config != nil && (len(config.Fields) > 0 || len(config.Fieldset) > 0 || len(config.Include) > 0) {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Shapes); err != nil {
+		return nil, err
+	}
+	shape, ok := m.shapeIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("shape %s: %w", id, ErrNotFound)
+	}
+	return shape, nil
+}
+
+// GetAllRoutePatterns returns the mirrored route patterns matching the config. Supports paging, FilterIDs, FilterRouteIDs and FilterDirectionID
+func (m *Mirror) GetAllRoutePatterns(config *mbta.GetAllRoutePatternsRequestConfig) ([]*mbta.RoutePattern, error) {
+	if config == nil {
+		config = &mbta.GetAllRoutePatternsRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Include) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+	direction, err := parseDirection(config.FilterDirectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(RoutePatterns); err != nil {
+		return nil, err
+	}
+	ids := stringSet(config.FilterIDs)
+	routeIDs := stringSet(config.FilterRouteIDs)
+	var patterns []*mbta.RoutePattern
+	for _, pattern := range m.patterns {
+		if ids != nil && !ids[pattern.ID] {
+			continue
+		}
+		if routeIDs != nil && (pattern.Route == nil || !routeIDs[pattern.Route.ID]) {
+			continue
+		}
+		if direction != nil && pattern.DirectionID != *direction {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	start, end, err := page(len(patterns), config.PageOffset, config.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return patterns[start:end], nil
+}
+
+// GetRoutePattern returns the mirrored route pattern with the given id
+func (m *Mirror) GetRoutePattern(id string, config *mbta.GetRoutePatternRequestConfig) (*mbta.RoutePattern, error) {
+	if config != nil && len(config.Include) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(RoutePatterns); err != nil {
+		return nil, err
+	}
+	pattern, ok := m.patternIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("route pattern %s: %w", id, ErrNotFound)
+	}
+	return pattern, nil
+}
+
+// GetAllServices returns the mirrored services matching the config. Supports paging and FilterIDs
+func (m *Mirror) GetAllServices(config *mbta.GetAllServicesRequestConfig) ([]*mbta.Service, error) {
+	if config == nil {
+		config = &mbta.GetAllServicesRequestConfig{}
+	}
+	if config.Sort != "" || len(config.Fields) > 0 || len(config.Fieldset) > 0 || len(config.FilterRoutes) > 0 {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Services); err != nil {
+		return nil, err
+	}
+	ids := stringSet(config.FilterIDs)
+	var services []*mbta.Service
+	for _, service := range m.services {
+		if ids == nil || ids[service.ID] {
+			services = append(services, service)
+		}
+	}
+	start, end, err := page(len(services), config.PageOffset, config.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	return services[start:end], nil
+}
+
+// GetService returns the mirrored service with the given id
+func (m *Mirror) GetService(id string, config *mbta.GetServiceRequestConfig) (*mbta.Service, error) {
+	if config != nil && (len(config.Fields) > 0 || len(config.Fieldset) > 0) {
+		return nil, ErrUnsupportedQuery
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.loaded(Services); err != nil {
+		return nil, err
+	}
+	service, ok := m.serviceIndex[id]
+	if !ok {
+		return nil, xerrors.Errorf("service %s: %w", id, ErrNotFound)
+	}
+	return service, nil
+}
+
+// loaded must be called with m.mu held
+func (m *Mirror) loaded(r Resource) error {
+	if _, ok := m.meta[r]; !ok {
+		return xerrors.Errorf("%s: %w", r, ErrNotMirrored)
+	}
+	return nil
+}
+
+// route a copy of the route with its full line when includeLine is set, the shared route otherwise
+func (m *Mirror) route(route *mbta.Route, includeLine bool) *mbta.Route {
+	if !includeLine || route.Line == nil {
+		return route
+	}
+	if line, ok := m.lineIndex[route.Line.ID]; ok {
+		withLine := *route
+		withLine.Line = line
+		return &withLine
+	}
+	return route
+}
+
+// stop a copy of the stop with its full parent station when includeParent is set, the shared stop otherwise
+func (m *Mirror) stop(stop *mbta.Stop, includeParent bool) *mbta.Stop {
+	if !includeParent || stop.ParentStation == nil {
+		return stop
+	}
+	if parent, ok := m.stopIndex[stop.ParentStation.ID]; ok {
+		withParent := *stop
+		withParent.ParentStation = parent
+		return &withParent
+	}
+	return stop
+}
+
+func routeIncludesLine(include []mbta.RouteInclude) (bool, error) {
+	includeLine := false
+	for _, i := range include {
+		if i != mbta.RouteIncludeLine {
+			return false, ErrUnsupportedQuery
+		}
+		includeLine = true
+	}
+	return includeLine, nil
+}
+
+func stopIncludesParent(include []mbta.StopInclude) (bool, error) {
+	includeParent := false
+	for _, i := range include {
+		if i != mbta.StopIncludeParentStation {
+			return false, ErrUnsupportedQuery
+		}
+		includeParent = true
+	}
+	return includeParent, nil
+}
+
+// nearFilter the API's latitude/longitude filter: a flat distance in degrees
+type nearFilter struct {
+	latitude, longitude, radius float64
+}
+
+func (f *nearFilter) contains(stop *mbta.Stop) bool {
+	return math.Hypot(stop.Latitude-f.latitude, stop.Longitude-f.longitude) <= f.radius
+}
+
+// parseNear nil when no latitude and longitude are given
+func parseNear(latitude, longitude, radius string) (*nearFilter, error) {
+	if latitude == "" && longitude == "" {
+		return nil, nil
+	}
+	if latitude == "" || longitude == "" {
+		return nil, xerrors.Errorf("latitude and longitude must be given together: %w", mbta.ErrInvalidConfig)
+	}
+	f := &nearFilter{radius: defaultStopRadius}
+	var err error
+	if f.latitude, err = strconv.ParseFloat(latitude, 64); err != nil {
+		return nil, xerrors.Errorf("latitude %q: %w", latitude, mbta.ErrInvalidConfig)
+	}
+	if f.longitude, err = strconv.ParseFloat(longitude, 64); err != nil {
+		return nil, xerrors.Errorf("longitude %q: %w", longitude, mbta.ErrInvalidConfig)
+	}
+	if radius != "" {
+		if f.radius, err = strconv.ParseFloat(radius, 64); err != nil {
+			return nil, xerrors.Errorf("radius %q: %w", radius, mbta.ErrInvalidConfig)
+		}
+	}
+	return f, nil
+}
+
+// parseDirection nil when no direction is given
+func parseDirection(direction string) (*int, error) {
+	if direction == "" {
+		return nil, nil
+	}
+	if direction != "0" && direction != "1" {
+		return nil, xerrors.Errorf("direction id %q: %w", direction, mbta.ErrInvalidConfig)
+	}
+	d := int(direction[0] - '0')
+	return &d, nil
+}
+
+// page the bounds of the page of n results. A limit of 0 or "" means no limit
+func page(n int, offset, limit string) (int, int, error) {
+	start, end := 0, n
+	if offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil || o < 0 {
+			return 0, 0, xerrors.Errorf("page offset %q: %w", offset, mbta.ErrInvalidConfig)
+		}
+		if o < n {
+			start = o
+		} else {
+			start = n
+		}
+	}
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 0 {
+			return 0, 0, xerrors.Errorf("page limit %q: %w", limit, mbta.ErrInvalidConfig)
+		}
+		if l > 0 && start+l < n {
+			end = start + l
+		}
+	}
+	return start, end, nil
+}
+
+// stringSet nil when ids is empty, so that callers can tell no filter from an empty one
+func stringSet(ids []string) map[string]bool {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func hasRouteType(types []mbta.RouteType, t mbta.RouteType) bool {
+	for _, want := range types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package mirror
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Record a mirrored resource as it's kept in a Store
+type Record struct {
+	Data         json.RawMessage `json:"data"`          // The resources, JSON encoded
+	LastModified string          `json:"last_modified"` // Last-Modified header of the response the data came from
+	FetchedAt    time.Time       `json:"fetched_at"`    // When the data was fetched
+	RouteIDs     []string        `json:"route_ids"`     // Shapes only: the routes the shapes were fetched for
+}
+
+// Store persists mirrored resources
+type Store interface {
+	Load(resource Resource) (*Record, error) // nil with no error when the resource was never saved
+	Save(resource Resource, record *Record) error
+}
+
+// MemoryStore keeps records in memory, which is useful for tests and for processes that only want the periodic refresh
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[Resource]Record
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[Resource]Record)}
+}
+
+// Load returns a copy of the saved record
+func (s *MemoryStore) Load(resource Resource) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[resource]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Save replaces the saved record
+func (s *MemoryStore) Save(resource Resource, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[resource] = *record
+	return nil
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Sink receives alert events
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SinkFunc a callback used as a Sink
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Send calls f
+func (f SinkFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// ChannelSink sends events on a channel, blocking until they are received or the context is done
+type ChannelSink chan<- Event
+
+// Send sends the event on the channel
+func (c ChannelSink) Send(ctx context.Context, event Event) error {
+	select {
+	case c <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WebhookSink POSTs every event as JSON to a URL
+type WebhookSink struct {
+	URL    string
+	Header http.Header  // Extra headers for every request, e.g. for authentication
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+// NewWebhookSink creates a WebhookSink posting to url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Header: make(http.Header)}
+}
+
+// Send POSTs the event. Responses other than 2xx are errors
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for key, values := range s.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %s for alert %s", s.URL, resp.Status, event.AlertID)
+	}
+	return nil
+}
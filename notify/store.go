@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateStore persists the alerts an AlertWatcher knows about so that a restart doesn't report them all as created again
+type StateStore interface {
+	Load() (map[string]AlertState, error) // nil with no error when nothing was saved yet
+	Save(state map[string]AlertState) error
+}
+
+// MemoryStore keeps the state in memory only
+type MemoryStore struct {
+	mu    sync.Mutex
+	state map[string]AlertState
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns the last saved state
+func (s *MemoryStore) Load() (map[string]AlertState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyState(s.state), nil
+}
+
+// Save replaces the saved state
+func (s *MemoryStore) Save(state map[string]AlertState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = copyState(state)
+	return nil
+}
+
+func copyState(state map[string]AlertState) map[string]AlertState {
+	if state == nil {
+		return nil
+	}
+	c := make(map[string]AlertState, len(state))
+	for id, s := range state {
+		c[id] = s
+	}
+	return c
+}
+
+// FileStore keeps the state in a JSON file
+type FileStore struct {
+	Path string
+}
+
+// NewFileStore creates a FileStore using the file at path, which is created on the first Save
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// Load reads the state from the file. A missing file is an empty state
+func (s *FileStore) Load() (map[string]AlertState, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state map[string]AlertState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save writes the state to a temporary file and renames it over the file, so a crash never leaves a partial file behind
+func (s *FileStore) Save(state map[string]AlertState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
@@ -0,0 +1,299 @@
+// Package notify detects new, updated and resolved MBTA alerts between polls and sends them to pluggable sinks.
+package notify
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+// EventType the kind of change to an alert
+type EventType string
+
+const (
+	// AlertCreated an alert that wasn't known before
+	AlertCreated EventType = "ALERT_CREATED"
+	// AlertUpdated a known alert whose UpdatedAt, Lifecycle or ActivePeriod changed
+	AlertUpdated EventType = "ALERT_UPDATED"
+	// AlertResolved a known alert that is no longer returned by the API
+	AlertResolved EventType = "ALERT_RESOLVED"
+)
+
+// FieldChange one field of an alert that changed
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Event a change to an alert
+type Event struct {
+	Type    EventType     `json:"type"`
+	AlertID string        `json:"alert_id"`
+	Time    time.Time     `json:"time"`              // When the change was detected
+	Alert   AlertState    `json:"alert"`             // The alert after the change, or the last known version for AlertResolved
+	Changes []FieldChange `json:"changes,omitempty"` // AlertUpdated only
+}
+
+// AlertState what the watcher remembers about an alert between polls
+type AlertState struct {
+	ID             string                     `json:"id"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	Lifecycle      mbta.AlertLifecycleType    `json:"lifecycle"`
+	ActivePeriod   []mbta.AlertActivePeriod   `json:"active_period"`
+	Effect         mbta.AlertEffectType       `json:"effect"`
+	Cause          mbta.AlertCauseType        `json:"cause"`
+	Severity       int                        `json:"severity"`
+	Header         string                     `json:"header"`
+	ShortHeader    string                     `json:"short_header"`
+	Description    string                     `json:"description"`
+	InformedEntity []mbta.AlertInformedEntity `json:"informed_entity"`
+}
+
+// NewAlertState the state kept for an alert
+func NewAlertState(alert *mbta.Alert) AlertState {
+	state := AlertState{
+		ID:             alert.ID,
+		UpdatedAt:      alert.UpdatedAt.Time,
+		Lifecycle:      alert.Lifecycle,
+		ActivePeriod:   alert.ActivePeriod,
+		Effect:         alert.Effect,
+		Cause:          alert.Cause,
+		Severity:       alert.Severity,
+		Header:         alert.Header,
+		ShortHeader:    alert.ShortHeader,
+		InformedEntity: alert.InformedEntity,
+	}
+	if alert.Description != nil {
+		state.Description = *alert.Description
+	}
+	return state
+}
+
+// changed whether the fields that mark an alert as updated differ
+func (s AlertState) changed(other AlertState) bool {
+	return !s.UpdatedAt.Equal(other.UpdatedAt) || s.Lifecycle != other.Lifecycle || !samePeriods(s.ActivePeriod, other.ActivePeriod)
+}
+
+// Diff the fields that differ between an older state s and a newer state
+func (s AlertState) Diff(newer AlertState) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, same bool, old, new interface{}) {
+		if !same {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	add("updated_at", s.UpdatedAt.Equal(newer.UpdatedAt), s.UpdatedAt, newer.UpdatedAt)
+	add("lifecycle", s.Lifecycle == newer.Lifecycle, s.Lifecycle, newer.Lifecycle)
+	add("active_period", samePeriods(s.ActivePeriod, newer.ActivePeriod), s.ActivePeriod, newer.ActivePeriod)
+	add("effect", s.Effect == newer.Effect, s.Effect, newer.Effect)
+	add("cause", s.Cause == newer.Cause, s.Cause, newer.Cause)
+	add("severity", s.Severity == newer.Severity, s.Severity, newer.Severity)
+	add("header", s.Header == newer.Header, s.Header, newer.Header)
+	add("short_header", s.ShortHeader == newer.ShortHeader, s.ShortHeader, newer.ShortHeader)
+	add("description", s.Description == newer.Description, s.Description, newer.Description)
+	add("informed_entity", reflect.DeepEqual(s.InformedEntity, newer.InformedEntity), s.InformedEntity, newer.InformedEntity)
+	return changes
+}
+
+func samePeriods(a, b []mbta.AlertActivePeriod) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Time.Equal(b[i].Start.Time) {
+			return false
+		}
+		if (a[i].End == nil) != (b[i].End == nil) {
+			return false
+		}
+		if a[i].End != nil && !a[i].End.Time.Equal(b[i].End.Time) {
+			return false
+		}
+	}
+	return true
+}
+
+// AlertWatcher polls alerts, works out what changed since the last poll and sends the changes to its sinks
+type AlertWatcher struct {
+	client *mbta.Client
+	store  StateStore
+	sinks  []Sink
+
+	Config mbta.GetAllAlertsRequestConfig // Filters used for every poll. Alerts that stop matching them are reported as resolved
+
+	mu      sync.Mutex
+	state   map[string]AlertState
+	loaded  bool
+	pending [][]Event // The events each sink, by index in sinks, hasn't taken yet, in order
+
+	sendMu sync.Mutex // Held while sending, so that each sink gets its events in order
+}
+
+// NewAlertWatcher creates an AlertWatcher that remembers alerts in store, which may be nil to only remember them in memory
+func NewAlertWatcher(client *mbta.Client, store StateStore, sinks ...Sink) *AlertWatcher {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &AlertWatcher{
+		client:  client,
+		store:   store,
+		sinks:   sinks,
+		pending: make([][]Event, len(sinks)),
+	}
+}
+
+// AddSink sends future events to sink as well
+func (w *AlertWatcher) AddSink(sink Sink) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sinks = append(w.sinks, sink)
+	w.pending = append(w.pending, nil)
+}
+
+// Poll fetches alerts and sends the changes since the last poll to the sinks
+func (w *AlertWatcher) Poll() ([]Event, error) {
+	return w.PollWithContext(context.Background())
+}
+
+// PollWithContext fetches alerts given a context and sends the changes since the last poll to the sinks
+func (w *AlertWatcher) PollWithContext(ctx context.Context) ([]Event, error) {
+	config := w.Config
+	alerts, _, err := w.client.Alerts.GetAllAlertsWithContext(ctx, &config)
+	if err != nil {
+		return nil, err
+	}
+	return w.Update(ctx, time.Now(), alerts)
+}
+
+// Run polls every interval until ctx is done. Errors are passed to onError, which may be nil, and don't stop the watcher
+func (w *AlertWatcher) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.PollWithContext(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update compares already fetched alerts with the last known state, saves the new state and sends the changes to the sinks.
+// It returns the changes it found. A sink that fails to take an event gets it again, and the ones after it, on the next
+// Update, before that Update's own, while the other sinks only get each event once. The first error is returned. The
+// events a sink hasn't taken yet are only kept in memory
+func (w *AlertWatcher) Update(ctx context.Context, now time.Time, alerts []*mbta.Alert) ([]Event, error) {
+	events, err := w.detect(now, alerts)
+	if err != nil {
+		return nil, err
+	}
+	err = w.send(ctx)
+	if len(events) > 0 {
+		if saveErr := w.save(); saveErr != nil && err == nil {
+			err = saveErr
+		}
+	}
+	return events, err
+}
+
+// detect finds the changes since the last known state, makes alerts the known state and queues the changes for every
+// sink
+func (w *AlertWatcher) detect(now time.Time, alerts []*mbta.Alert) ([]Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.loaded {
+		state, err := w.store.Load()
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			state = make(map[string]AlertState)
+		}
+		w.state, w.loaded = state, true
+	}
+
+	current := make(map[string]AlertState, len(alerts))
+	for _, alert := range alerts {
+		current[alert.ID] = NewAlertState(alert)
+	}
+	events := diffStates(now, w.state, current)
+	w.state = current
+	for i := range w.pending {
+		w.pending[i] = append(w.pending[i], events...)
+	}
+	return events, nil
+}
+
+// send sends each sink its pending events without holding mu, keeping the ones from the first it fails to take on
+func (w *AlertWatcher) send(ctx context.Context) error {
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+
+	w.mu.Lock()
+	sinks, pending := w.sinks, w.pending
+	w.pending = make([][]Event, len(sinks))
+	w.mu.Unlock()
+
+	var firstErr error
+	for i, sink := range sinks {
+		for j, event := range pending[i] {
+			if err := sink.Send(ctx, event); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				w.mu.Lock()
+				w.pending[i] = append(pending[i][j:len(pending[i]):len(pending[i])], w.pending[i]...)
+				w.mu.Unlock()
+				break
+			}
+		}
+	}
+	return firstErr
+}
+
+// save saves the known state to the store
+func (w *AlertWatcher) save() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.store.Save(w.state)
+}
+
+// diffStates events for every alert created, updated or resolved between previous and current, ordered by alert id
+func diffStates(now time.Time, previous, current map[string]AlertState) []Event {
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var events []Event
+	for _, id := range ids {
+		state := current[id]
+		old, known := previous[id]
+		switch {
+		case !known:
+			events = append(events, Event{Type: AlertCreated, AlertID: id, Time: now, Alert: state})
+		case old.changed(state):
+			events = append(events, Event{Type: AlertUpdated, AlertID: id, Time: now, Alert: state, Changes: old.Diff(state)})
+		}
+	}
+
+	var resolved []string
+	for id := range previous {
+		if _, ok := current[id]; !ok {
+			resolved = append(resolved, id)
+		}
+	}
+	sort.Strings(resolved)
+	for _, id := range resolved {
+		events = append(events, Event{Type: AlertResolved, AlertID: id, Time: now, Alert: previous[id]})
+	}
+	return events
+}
@@ -0,0 +1,237 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+var testNow = time.Date(2019, time.June, 3, 8, 0, 0, 0, time.UTC)
+
+func testAlert(id string, updatedAt time.Time, lifecycle mbta.AlertLifecycleType, header string) *mbta.Alert {
+	return &mbta.Alert{
+		ID:           id,
+		UpdatedAt:    mbta.TimeISO8601{Time: updatedAt},
+		Lifecycle:    lifecycle,
+		Header:       header,
+		Effect:       mbta.AlertEffectDelay,
+		ActivePeriod: []mbta.AlertActivePeriod{{Start: mbta.TimeISO8601{Time: testNow.Add(-time.Hour)}}},
+	}
+}
+
+func eventTypes(events []Event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, string(e.Type)+" "+e.AlertID)
+	}
+	return types
+}
+
+func TestAlertWatcher_Update(t *testing.T) {
+	var received []Event
+	watcher := NewAlertWatcher(nil, nil, SinkFunc(func(ctx context.Context, event Event) error {
+		received = append(received, event)
+		return nil
+	}))
+	ctx := context.Background()
+
+	events, err := watcher.Update(ctx, testNow, []*mbta.Alert{
+		testAlert("1", testNow, mbta.AlertLifecycleNew, "Red line delays"),
+		testAlert("2", testNow, mbta.AlertLifecycleNew, "Bus 1 detour"),
+	})
+	ok(t, err)
+	equals(t, []string{"ALERT_CREATED 1", "ALERT_CREATED 2"}, eventTypes(events))
+	equals(t, events, received)
+
+	// nothing that marks an update changed
+	events, err = watcher.Update(ctx, testNow, []*mbta.Alert{
+		testAlert("1", testNow, mbta.AlertLifecycleNew, "Red line delays"),
+		testAlert("2", testNow, mbta.AlertLifecycleNew, "Bus 1 detour"),
+	})
+	ok(t, err)
+	equals(t, 0, len(events))
+
+	later := testNow.Add(10 * time.Minute)
+	events, err = watcher.Update(ctx, later, []*mbta.Alert{
+		testAlert("1", later, mbta.AlertLifecycleOngoing, "Red line delays of about 20 minutes"),
+	})
+	ok(t, err)
+	equals(t, []string{"ALERT_UPDATED 1", "ALERT_RESOLVED 2"}, eventTypes(events))
+	equals(t, []FieldChange{
+		{Field: "updated_at", Old: testNow, New: later},
+		{Field: "lifecycle", Old: mbta.AlertLifecycleNew, New: mbta.AlertLifecycleOngoing},
+		{Field: "header", Old: "Red line delays", New: "Red line delays of about 20 minutes"},
+	}, events[0].Changes)
+	equals(t, "Bus 1 detour", events[1].Alert.Header)
+	equals(t, 4, len(received))
+}
+
+func TestAlertWatcher_UpdateSinkError(t *testing.T) {
+	failing := errors.New("sink failed")
+	calls := 0
+	watcher := NewAlertWatcher(nil, nil,
+		SinkFunc(func(ctx context.Context, event Event) error { return failing }),
+		SinkFunc(func(ctx context.Context, event Event) error {
+			calls++
+			return nil
+		}),
+	)
+	events, err := watcher.Update(context.Background(), testNow, []*mbta.Alert{testAlert("1", testNow, mbta.AlertLifecycleNew, "")})
+	equals(t, failing, err)
+	equals(t, 1, len(events))
+	equals(t, 1, calls)
+}
+
+func TestAlertWatcher_UpdateRetriesFailedEvents(t *testing.T) {
+	fail := true
+	var received, others []string
+	watcher := NewAlertWatcher(nil, nil,
+		SinkFunc(func(ctx context.Context, event Event) error {
+			if fail {
+				return errors.New("webhook down")
+			}
+			received = append(received, string(event.Type)+" "+event.AlertID)
+			return nil
+		}),
+		SinkFunc(func(ctx context.Context, event Event) error {
+			others = append(others, string(event.Type)+" "+event.AlertID)
+			return nil
+		}),
+	)
+	ctx := context.Background()
+	alerts := []*mbta.Alert{testAlert("1", testNow, mbta.AlertLifecycleNew, "Red line delays")}
+
+	events, err := watcher.Update(ctx, testNow, alerts)
+	assert(t, err != nil, "expected the sink's error")
+	equals(t, []string{"ALERT_CREATED 1"}, eventTypes(events))
+	fail = false
+	events, err = watcher.Update(ctx, testNow, alerts)
+	ok(t, err)
+	equals(t, 0, len(events))
+	equals(t, []string{"ALERT_CREATED 1"}, received)
+
+	// a failed update or resolve is sent again too, in order with the events found since
+	later := testNow.Add(10 * time.Minute)
+	fail = true
+	_, err = watcher.Update(ctx, later, []*mbta.Alert{testAlert("1", later, mbta.AlertLifecycleOngoing, "Red line delays")})
+	assert(t, err != nil, "expected the sink's error")
+	_, err = watcher.Update(ctx, later, nil)
+	assert(t, err != nil, "expected the sink's error")
+	fail = false
+	events, err = watcher.Update(ctx, later, nil)
+	ok(t, err)
+	equals(t, 0, len(events))
+
+	events, err = watcher.Update(ctx, later, nil)
+	ok(t, err)
+	equals(t, 0, len(events))
+	equals(t, []string{"ALERT_CREATED 1", "ALERT_UPDATED 1", "ALERT_RESOLVED 1"}, received)
+	// the sink that took every event the first time got each of them once
+	equals(t, []string{"ALERT_CREATED 1", "ALERT_UPDATED 1", "ALERT_RESOLVED 1"}, others)
+}
+
+func TestAlertWatcher_UpdateSendsWithoutLock(t *testing.T) {
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	watcher := NewAlertWatcher(nil, nil, SinkFunc(func(ctx context.Context, event Event) error {
+		close(sending)
+		<-release
+		return nil
+	}))
+	done := make(chan error, 1)
+	go func() {
+		_, err := watcher.Update(context.Background(), testNow, []*mbta.Alert{testAlert("1", testNow, mbta.AlertLifecycleNew, "")})
+		done <- err
+	}()
+	<-sending
+	// a slow sink doesn't hold up the watcher
+	watcher.AddSink(SinkFunc(func(ctx context.Context, event Event) error { return nil }))
+	close(release)
+	ok(t, <-done)
+}
+
+func TestAlertWatcher_FileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alerts.json")
+	alerts := []*mbta.Alert{testAlert("1", testNow, mbta.AlertLifecycleNew, "Red line delays")}
+	end := mbta.TimeISO8601{Time: testNow.Add(time.Hour)}
+	alerts[0].ActivePeriod[0].End = &end
+
+	events, err := NewAlertWatcher(nil, NewFileStore(path)).Update(context.Background(), testNow, alerts)
+	ok(t, err)
+	equals(t, 1, len(events))
+
+	// a restarted watcher doesn't report the alert again
+	events, err = NewAlertWatcher(nil, NewFileStore(path)).Update(context.Background(), testNow, alerts)
+	ok(t, err)
+	equals(t, 0, len(events))
+
+	events, err = NewAlertWatcher(nil, NewFileStore(path)).Update(context.Background(), testNow, nil)
+	ok(t, err)
+	equals(t, []string{"ALERT_RESOLVED 1"}, eventTypes(events))
+}
+
+func TestChannelSink(t *testing.T) {
+	ch := make(chan Event, 1)
+	ok(t, ChannelSink(ch).Send(context.Background(), Event{AlertID: "1"}))
+	equals(t, "1", (<-ch).AlertID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	equals(t, context.Canceled, ChannelSink(make(chan Event)).Send(ctx, Event{AlertID: "2"}))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got Event
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		equals(t, http.MethodPost, r.Method)
+		equals(t, "application/json", r.Header.Get("Content-Type"))
+		equals(t, "secret", r.Header.Get("X-Token"))
+		ok(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	sink.Header.Set("X-Token", "secret")
+	ok(t, sink.Send(context.Background(), Event{Type: AlertCreated, AlertID: "1", Time: testNow, Alert: NewAlertState(testAlert("1", testNow, mbta.AlertLifecycleNew, "Red line delays"))}))
+	equals(t, AlertCreated, got.Type)
+	equals(t, "Red line delays", got.Alert.Header)
+
+	status = http.StatusInternalServerError
+	assert(t, sink.Send(context.Background(), Event{AlertID: "1"}) != nil, "expected an error for a 500")
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("\n\n\texp: %#v\n\n\tgot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,486 @@
+package planner
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+// ItineraryLeg one ride of a stored itinerary
+type ItineraryLeg struct {
+	TripID       string
+	BoardStopID  string
+	AlightStopID string
+	RouteID      string        // Optional, learned from predictions when empty
+	Departure    time.Time     // Planned departure from BoardStopID, used until there is a prediction
+	Arrival      time.Time     // Planned arrival at AlightStopID, used until there is a prediction
+	TransferTime time.Duration // Time needed to get from the previous leg to this one. 0 uses the monitor's MinTransferTime
+}
+
+// Itinerary a rider's planned rides, in order
+type Itinerary struct {
+	ID         string
+	Legs       []ItineraryLeg
+	Wheelchair bool // Whether elevator closures and other wheelchair-only alerts affect this itinerary
+}
+
+// ItineraryFromJourney turns the transit legs of a planned journey into an itinerary
+func ItineraryFromJourney(id string, journey *Journey) Itinerary {
+	itinerary := Itinerary{ID: id}
+	var walked time.Duration
+	for _, leg := range journey.Legs {
+		if leg.Mode != LegModeTransit {
+			walked += leg.Arrival.Sub(leg.Departure)
+			continue
+		}
+		l := ItineraryLeg{
+			TripID:       leg.Trip.ID,
+			BoardStopID:  leg.From.ID,
+			AlightStopID: leg.To.ID,
+			Departure:    leg.Departure,
+			Arrival:      leg.Arrival,
+		}
+		if leg.Route != nil {
+			l.RouteID = leg.Route.ID
+		}
+		if len(itinerary.Legs) > 0 {
+			l.TransferTime = walked
+		}
+		itinerary.Legs = append(itinerary.Legs, l)
+		walked = 0
+	}
+	return itinerary
+}
+
+// ProblemType what is wrong with an itinerary
+type ProblemType string
+
+const (
+	// ProblemStopCancelled the trip no longer serves the boarding or alighting stop because it was cancelled
+	ProblemStopCancelled ProblemType = "STOP_CANCELLED"
+	// ProblemStopSkipped the trip will skip the boarding or alighting stop
+	ProblemStopSkipped ProblemType = "STOP_SKIPPED"
+	// ProblemMissedTransfer the previous leg is predicted to arrive too late to make this leg
+	ProblemMissedTransfer ProblemType = "MISSED_TRANSFER"
+	// ProblemAlert an alert with a disruptive effect affects the leg
+	ProblemAlert ProblemType = "ALERT"
+)
+
+// Problem something that breaks an itinerary
+type Problem struct {
+	Type    ProblemType
+	Leg     int                  // Index of the affected leg
+	StopID  string               // The stop the problem is at, if it's at a single stop
+	AlertID string               // ProblemAlert only
+	Effect  mbta.AlertEffectType // ProblemAlert only
+	Slack   time.Duration        // ProblemMissedTransfer only: time left to transfer, which is less than needed and may be negative
+}
+
+type problemKey struct {
+	problemType ProblemType
+	leg         int
+	stopID      string
+	alertID     string
+}
+
+func (p Problem) key() problemKey {
+	return problemKey{problemType: p.Type, leg: p.Leg, stopID: p.StopID, alertID: p.AlertID}
+}
+
+// LegStatus the latest known times of a leg
+type LegStatus struct {
+	Departure          time.Time
+	Arrival            time.Time
+	DeparturePredicted bool
+	ArrivalPredicted   bool
+}
+
+// ItineraryStatus the outcome of checking an itinerary
+type ItineraryStatus struct {
+	ItineraryID string
+	CheckedAt   time.Time
+	Legs        []LegStatus
+	Problems    []Problem
+}
+
+// Broken whether the itinerary can't be completed as planned
+func (s ItineraryStatus) Broken() bool {
+	return len(s.Problems) > 0
+}
+
+// ItineraryEventType the kind of change in an itinerary's outcome
+type ItineraryEventType string
+
+const (
+	// ItineraryEventProblemDetected a new problem affects the itinerary
+	ItineraryEventProblemDetected ItineraryEventType = "PROBLEM_DETECTED"
+	// ItineraryEventProblemResolved a problem no longer affects the itinerary
+	ItineraryEventProblemResolved ItineraryEventType = "PROBLEM_RESOLVED"
+)
+
+// ItineraryEvent a change in an itinerary's outcome
+type ItineraryEvent struct {
+	Type        ItineraryEventType
+	ItineraryID string
+	Problem     Problem
+	Status      ItineraryStatus // The status after the change
+}
+
+// DefaultDisruptiveEffects alert effects that break an itinerary
+var DefaultDisruptiveEffects = []mbta.AlertEffectType{
+	mbta.AlertEffectCancellation,
+	mbta.AlertEffectElevatorClosure,
+	mbta.AlertEffectNoService,
+	mbta.AlertEffectShuttle,
+	mbta.AlertEffectStationClosure,
+	mbta.AlertEffectStopClosure,
+	mbta.AlertEffectSuspension,
+}
+
+// ItineraryMonitor re-checks itineraries against predictions and alerts and reports when their outcome changes
+type ItineraryMonitor struct {
+	client *mbta.Client
+
+	MinTransferTime time.Duration          // Time needed between legs when a leg has no TransferTime. Defaults to 2 minutes
+	Effects         []mbta.AlertEffectType // Alert effects that break an itinerary. Defaults to DefaultDisruptiveEffects
+
+	mu          sync.Mutex
+	itineraries map[string]Itinerary
+	statuses    map[string]ItineraryStatus
+}
+
+// NewItineraryMonitor creates an ItineraryMonitor that fetches predictions and alerts with client
+func NewItineraryMonitor(client *mbta.Client) *ItineraryMonitor {
+	return &ItineraryMonitor{
+		client:          client,
+		MinTransferTime: defaultMinTransferTime,
+		Effects:         DefaultDisruptiveEffects,
+		itineraries:     make(map[string]Itinerary),
+		statuses:        make(map[string]ItineraryStatus),
+	}
+}
+
+// Watch starts monitoring an itinerary, replacing any itinerary with the same id
+func (m *ItineraryMonitor) Watch(itinerary Itinerary) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.itineraries[itinerary.ID] = itinerary
+	delete(m.statuses, itinerary.ID)
+}
+
+// Unwatch stops monitoring an itinerary
+func (m *ItineraryMonitor) Unwatch(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.itineraries, id)
+	delete(m.statuses, id)
+}
+
+// Status the result of the last check of an itinerary
+func (m *ItineraryMonitor) Status(id string) (ItineraryStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.statuses[id]
+	return status, ok
+}
+
+// Check fetches predictions and alerts for every watched itinerary and returns the changes since the last check
+func (m *ItineraryMonitor) Check() ([]ItineraryEvent, error) {
+	return m.CheckWithContext(context.Background())
+}
+
+// CheckWithContext fetches predictions and alerts for every watched itinerary given a context and returns the changes since the last check
+func (m *ItineraryMonitor) CheckWithContext(ctx context.Context) ([]ItineraryEvent, error) {
+	m.mu.Lock()
+	tripSet := make(map[string]bool)
+	for _, itinerary := range m.itineraries {
+		for _, leg := range itinerary.Legs {
+			tripSet[leg.TripID] = true
+		}
+	}
+	m.mu.Unlock()
+	if len(tripSet) == 0 {
+		return nil, nil
+	}
+	tripIDs := make([]string, 0, len(tripSet))
+	for id := range tripSet {
+		tripIDs = append(tripIDs, id)
+	}
+	sort.Strings(tripIDs)
+
+	predictions, _, err := m.client.Predictions.GetAllPredictionsWithContext(ctx, &mbta.GetAllPredictionsRequestConfig{
+		Include:       []mbta.PredictionInclude{mbta.PredictionIncludeStop, mbta.PredictionIncludeRoute},
+		FilterTripIDs: tripIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	alerts, _, err := m.client.Alerts.GetAllAlertsWithContext(ctx, &mbta.GetAllAlertsRequestConfig{
+		FilterActivity: []mbta.AlertActivityType{mbta.AlertActivityFilterAll},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.Update(time.Now(), predictions, alerts), nil
+}
+
+// Update checks every watched itinerary against already fetched predictions and alerts and returns the changes since the last check.
+// predictions should include their stop so that parent stations can be matched
+func (m *ItineraryMonitor) Update(now time.Time, predictions []*mbta.Prediction, alerts []*mbta.Alert) []ItineraryEvent {
+	byTrip := make(map[string][]*mbta.Prediction)
+	parents := make(map[string]string)
+	for _, p := range predictions {
+		if p.Trip == nil || p.Stop == nil {
+			continue
+		}
+		byTrip[p.Trip.ID] = append(byTrip[p.Trip.ID], p)
+		if p.Stop.ParentStation != nil {
+			parents[p.Stop.ID] = p.Stop.ParentStation.ID
+		}
+	}
+	for _, trip := range byTrip {
+		sort.Slice(trip, func(i, j int) bool { return trip[i].StopSequence < trip[j].StopSequence })
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.itineraries))
+	for id := range m.itineraries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var events []ItineraryEvent
+	for _, id := range ids {
+		status := m.evaluate(now, m.itineraries[id], byTrip, parents, alerts)
+		events = append(events, diffStatus(m.statuses[id], status)...)
+		m.statuses[id] = status
+	}
+	return events
+}
+
+// legContext a leg together with what the predictions say about it
+type legContext struct {
+	leg         ItineraryLeg
+	board       *mbta.Prediction
+	alight      *mbta.Prediction
+	through     []string // stops ridden through without getting off
+	routeID     string
+	routeType   *mbta.RouteType
+	directionID *int
+}
+
+func (m *ItineraryMonitor) evaluate(now time.Time, itinerary Itinerary, byTrip map[string][]*mbta.Prediction, parents map[string]string, alerts []*mbta.Alert) ItineraryStatus {
+	status := ItineraryStatus{ItineraryID: itinerary.ID, CheckedAt: now}
+	sameStop := func(a, b string) bool {
+		return a == b || parents[a] == b || parents[b] == a
+	}
+
+	legs := make([]legContext, len(itinerary.Legs))
+	for i, leg := range itinerary.Legs {
+		lc := legContext{leg: leg, routeID: leg.RouteID}
+		for _, p := range byTrip[leg.TripID] {
+			switch {
+			case lc.board == nil && sameStop(p.Stop.ID, leg.BoardStopID):
+				lc.board = p
+			case lc.board != nil && lc.alight == nil && sameStop(p.Stop.ID, leg.AlightStopID):
+				lc.alight = p
+			case lc.board != nil && lc.alight == nil:
+				lc.through = append(lc.through, p.Stop.ID)
+			}
+			if lc.routeID == "" && p.Route != nil {
+				lc.routeID = p.Route.ID
+			}
+			// an included route always has a name; a route with only an id says nothing about its type
+			if p.Route != nil && p.Route.ID == lc.routeID && (p.Route.LongName != "" || p.Route.ShortName != "") {
+				routeType := p.Route.Type
+				lc.routeType = &routeType
+			}
+			directionID := p.DirectionID
+			lc.directionID = &directionID
+		}
+		legs[i] = lc
+
+		ls := LegStatus{Departure: leg.Departure, Arrival: leg.Arrival}
+		if t := predictedTime(lc.board, true); !t.IsZero() {
+			ls.Departure, ls.DeparturePredicted = t, true
+		}
+		if t := predictedTime(lc.alight, false); !t.IsZero() {
+			ls.Arrival, ls.ArrivalPredicted = t, true
+		}
+		status.Legs = append(status.Legs, ls)
+
+		for _, stop := range []struct {
+			id         string
+			prediction *mbta.Prediction
+		}{{leg.BoardStopID, lc.board}, {leg.AlightStopID, lc.alight}} {
+			if stop.prediction == nil || stop.prediction.ScheduleRelationship == nil {
+				continue
+			}
+			switch *stop.prediction.ScheduleRelationship {
+			case mbta.ScheduleRelationshipCancelled:
+				status.Problems = append(status.Problems, Problem{Type: ProblemStopCancelled, Leg: i, StopID: stop.id})
+			case mbta.ScheduleRelationshipSkipped:
+				status.Problems = append(status.Problems, Problem{Type: ProblemStopSkipped, Leg: i, StopID: stop.id})
+			}
+		}
+
+		if i > 0 {
+			needed := leg.TransferTime
+			if needed == 0 {
+				needed = m.MinTransferTime
+			}
+			previous := status.Legs[i-1]
+			if !previous.Arrival.IsZero() && !ls.Departure.IsZero() {
+				if slack := ls.Departure.Sub(previous.Arrival); slack < needed {
+					status.Problems = append(status.Problems, Problem{Type: ProblemMissedTransfer, Leg: i, StopID: leg.BoardStopID, Slack: slack})
+				}
+			}
+		}
+	}
+
+	var disruptive []*mbta.Alert
+	for _, alert := range alerts {
+		if m.disruptive(alert.Effect) {
+			disruptive = append(disruptive, alert)
+		}
+	}
+	index := mbta.NewAlertIndex(disruptive)
+	for i, lc := range legs {
+		at := status.Legs[i].Departure
+		if at.IsZero() {
+			at = now
+		}
+		for _, hit := range lc.alertsAffecting(index, at, itinerary.Wheelchair, parents) {
+			status.Problems = append(status.Problems, Problem{Type: ProblemAlert, Leg: i, StopID: hit.stopID, AlertID: hit.alert.ID, Effect: hit.alert.Effect})
+		}
+	}
+
+	sort.SliceStable(status.Problems, func(i, j int) bool { return status.Problems[i].Leg < status.Problems[j].Leg })
+	return status
+}
+
+func (m *ItineraryMonitor) disruptive(effect mbta.AlertEffectType) bool {
+	for _, e := range m.Effects {
+		if e == effect {
+			return true
+		}
+	}
+	return false
+}
+
+type alertHit struct {
+	alert  *mbta.Alert
+	stopID string
+}
+
+// alertsAffecting the alerts affecting boarding, getting off or riding through the stops of the leg at the given time,
+// with the first stop each alert affects
+func (lc legContext) alertsAffecting(index *mbta.AlertIndex, at time.Time, wheelchair bool, parents map[string]string) []alertHit {
+	boarding := []mbta.AlertActivityType{mbta.AlertActivityBoard}
+	exiting := []mbta.AlertActivityType{mbta.AlertActivityExit}
+	if wheelchair {
+		boarding = append(boarding, mbta.AlertActivityUsingWheelchair)
+		exiting = append(exiting, mbta.AlertActivityUsingWheelchair)
+	}
+	type check struct {
+		stopID     string
+		platforms  []string
+		activities []mbta.AlertActivityType
+	}
+	checks := []check{
+		{lc.leg.BoardStopID, stopAliases(lc.leg.BoardStopID, lc.board, parents), boarding},
+		{lc.leg.AlightStopID, stopAliases(lc.leg.AlightStopID, lc.alight, parents), exiting},
+	}
+	for _, stopID := range lc.through {
+		checks = append(checks, check{stopID, stopAliases(stopID, nil, parents), []mbta.AlertActivityType{mbta.AlertActivityRide}})
+	}
+
+	var hits []alertHit
+	seen := make(map[string]bool)
+	for _, c := range checks {
+		for _, stopID := range c.platforms {
+			q := mbta.EntityQuery{
+				RouteID:     lc.routeID,
+				RouteType:   lc.routeType,
+				DirectionID: lc.directionID,
+				StopID:      stopID,
+				TripID:      lc.leg.TripID,
+				Activities:  c.activities,
+				Time:        at,
+			}
+			for _, alert := range index.Affecting(q) {
+				if !seen[alert.ID] {
+					seen[alert.ID] = true
+					hits = append(hits, alertHit{alert: alert, stopID: c.stopID})
+				}
+			}
+		}
+	}
+	return hits
+}
+
+// stopAliases the ids an alert could use for a stop: the stop itself, the stop that was predicted for it and their parent stations
+func stopAliases(stopID string, prediction *mbta.Prediction, parents map[string]string) []string {
+	aliases := []string{stopID}
+	add := func(id string) {
+		if id == "" {
+			return
+		}
+		for _, a := range aliases {
+			if a == id {
+				return
+			}
+		}
+		aliases = append(aliases, id)
+	}
+	if prediction != nil {
+		add(prediction.Stop.ID)
+	}
+	for _, id := range aliases {
+		add(parents[id])
+	}
+	return aliases
+}
+
+func predictedTime(p *mbta.Prediction, departure bool) time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	first, second := p.ArrivalTime, p.DepartureTime
+	if departure {
+		first, second = second, first
+	}
+	if first != nil {
+		return first.Time
+	}
+	if second != nil {
+		return second.Time
+	}
+	return time.Time{}
+}
+
+// diffStatus events for the problems that were added or removed between two checks
+func diffStatus(previous, current ItineraryStatus) []ItineraryEvent {
+	var events []ItineraryEvent
+	before := make(map[problemKey]bool)
+	for _, p := range previous.Problems {
+		before[p.key()] = true
+	}
+	after := make(map[problemKey]bool)
+	for _, p := range current.Problems {
+		after[p.key()] = true
+		if !before[p.key()] {
+			events = append(events, ItineraryEvent{Type: ItineraryEventProblemDetected, ItineraryID: current.ItineraryID, Problem: p, Status: current})
+		}
+	}
+	for _, p := range previous.Problems {
+		if !after[p.key()] {
+			events = append(events, ItineraryEvent{Type: ItineraryEventProblemResolved, ItineraryID: current.ItineraryID, Problem: p, Status: current})
+		}
+	}
+	return events
+}
@@ -0,0 +1,152 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+func testPrediction(trip, route, stop, parent string, seq int, at time.Time, relationship mbta.PredictionScheduleRelationshipType) *mbta.Prediction {
+	t := mbta.TimeISO8601{Time: at}
+	p := &mbta.Prediction{
+		ArrivalTime:   &t,
+		DepartureTime: &t,
+		StopSequence:  seq,
+		Trip:          &mbta.Trip{ID: trip},
+		Route:         &mbta.Route{ID: route},
+		Stop:          &mbta.Stop{ID: stop},
+	}
+	if relationship != "" {
+		p.ScheduleRelationship = &relationship
+	}
+	if parent != "" {
+		p.Stop.ParentStation = &mbta.Stop{ID: parent}
+	}
+	return p
+}
+
+func testItinerary() Itinerary {
+	return Itinerary{
+		ID: "commute",
+		Legs: []ItineraryLeg{
+			{TripID: "A1", BoardStopID: "a1", AlightStopID: "place-x", Departure: testStart, Arrival: testStart.Add(5 * time.Minute)},
+			{TripID: "B1", BoardStopID: "place-x", AlightStopID: "b3", Departure: testStart.Add(8 * time.Minute), Arrival: testStart.Add(20 * time.Minute)},
+		},
+	}
+}
+
+func eventSummary(events []ItineraryEvent) []string {
+	var summary []string
+	for _, e := range events {
+		summary = append(summary, string(e.Type)+" "+string(e.Problem.Type)+" "+e.Problem.StopID+" "+e.Problem.AlertID)
+	}
+	return summary
+}
+
+func TestItineraryMonitor_Update(t *testing.T) {
+	monitor := NewItineraryMonitor(nil)
+	monitor.Watch(testItinerary())
+
+	predictions := func(delay time.Duration, b1Relationship mbta.PredictionScheduleRelationshipType) []*mbta.Prediction {
+		return []*mbta.Prediction{
+			testPrediction("A1", "A", "a1", "", 1, testStart.Add(delay), ""),
+			testPrediction("A1", "A", "x1", "place-x", 2, testStart.Add(5*time.Minute+delay), ""),
+			testPrediction("B1", "B", "x2", "place-x", 1, testStart.Add(8*time.Minute), b1Relationship),
+			testPrediction("B1", "B", "b2", "", 2, testStart.Add(15*time.Minute), ""),
+			testPrediction("B1", "B", "b3", "", 3, testStart.Add(20*time.Minute), ""),
+		}
+	}
+
+	events := monitor.Update(testStart, predictions(0, ""), nil)
+	equals(t, 0, len(events))
+	status, found := monitor.Status("commute")
+	assert(t, found, "expected a status")
+	assert(t, !status.Broken(), "expected the itinerary to work")
+	assert(t, status.Legs[0].ArrivalPredicted, "expected a predicted arrival")
+
+	// two minutes late leaves one minute to transfer
+	events = monitor.Update(testStart, predictions(2*time.Minute, ""), nil)
+	equals(t, []string{"PROBLEM_DETECTED MISSED_TRANSFER place-x "}, eventSummary(events))
+	equals(t, time.Minute, events[0].Problem.Slack)
+
+	// still missed, nothing changed
+	events = monitor.Update(testStart, predictions(150*time.Second, ""), nil)
+	equals(t, 0, len(events))
+
+	events = monitor.Update(testStart, predictions(0, mbta.ScheduleRelationshipSkipped), nil)
+	equals(t, []string{"PROBLEM_DETECTED STOP_SKIPPED place-x ", "PROBLEM_RESOLVED MISSED_TRANSFER place-x "}, eventSummary(events))
+
+	events = monitor.Update(testStart, predictions(0, ""), nil)
+	equals(t, []string{"PROBLEM_RESOLVED STOP_SKIPPED place-x "}, eventSummary(events))
+}
+
+func TestItineraryMonitor_UpdateAlerts(t *testing.T) {
+	monitor := NewItineraryMonitor(nil)
+	itinerary := testItinerary()
+	monitor.Watch(itinerary)
+	predictions := []*mbta.Prediction{
+		testPrediction("B1", "B", "x2", "place-x", 1, testStart.Add(8*time.Minute), ""),
+		testPrediction("B1", "B", "b2", "", 2, testStart.Add(15*time.Minute), ""),
+		testPrediction("B1", "B", "b3", "", 3, testStart.Add(20*time.Minute), ""),
+	}
+	suspension := &mbta.Alert{
+		ID:     "suspension",
+		Effect: mbta.AlertEffectSuspension,
+		InformedEntity: []mbta.AlertInformedEntity{
+			{RouteID: strPtr("B"), StopID: strPtr("b2"), Activities: []mbta.AlertActivityType{mbta.AlertActivityBoard, mbta.AlertActivityExit, mbta.AlertActivityRide}},
+		},
+	}
+	elevator := &mbta.Alert{
+		ID:     "elevator",
+		Effect: mbta.AlertEffectElevatorClosure,
+		InformedEntity: []mbta.AlertInformedEntity{
+			{StopID: strPtr("place-x"), FacilityID: strPtr("elevator-1"), Activities: []mbta.AlertActivityType{mbta.AlertActivityUsingWheelchair}},
+		},
+	}
+	upcoming := &mbta.Alert{
+		ID:             "shuttle-tomorrow",
+		Effect:         mbta.AlertEffectShuttle,
+		InformedEntity: []mbta.AlertInformedEntity{{RouteID: strPtr("A")}},
+		ActivePeriod:   []mbta.AlertActivePeriod{{Start: mbta.TimeISO8601{Time: testStart.Add(24 * time.Hour)}}},
+	}
+	delay := &mbta.Alert{
+		ID:             "delay",
+		Effect:         mbta.AlertEffectDelay,
+		InformedEntity: []mbta.AlertInformedEntity{{RouteID: strPtr("B")}},
+	}
+	alerts := []*mbta.Alert{suspension, elevator, upcoming, delay}
+
+	events := monitor.Update(testStart, predictions, alerts)
+	equals(t, []string{"PROBLEM_DETECTED ALERT b2 suspension"}, eventSummary(events))
+	equals(t, 1, events[0].Problem.Leg)
+
+	itinerary.Wheelchair = true
+	monitor.Watch(itinerary)
+	events = monitor.Update(testStart, predictions, alerts)
+	equals(t, []string{"PROBLEM_DETECTED ALERT place-x elevator", "PROBLEM_DETECTED ALERT place-x elevator", "PROBLEM_DETECTED ALERT b2 suspension"}, eventSummary(events))
+
+	events = monitor.Update(testStart, predictions, nil)
+	equals(t, 3, len(events))
+	for _, e := range events {
+		equals(t, ItineraryEventProblemResolved, e.Type)
+	}
+}
+
+func TestItineraryFromJourney(t *testing.T) {
+	network := testNetwork(t)
+	journey, err := network.EarliestArrival("a1", "b3", testStart, DefaultOptions())
+	ok(t, err)
+
+	itinerary := ItineraryFromJourney("commute", journey)
+	equals(t, 2, len(itinerary.Legs))
+	leg := itinerary.Legs[0]
+	equals(t, []string{"A1", "a1", "x1", "A"}, []string{leg.TripID, leg.BoardStopID, leg.AlightStopID, leg.RouteID})
+	assert(t, leg.Departure.Equal(testStart) && leg.Arrival.Equal(testStart.Add(5*time.Minute)), "unexpected times %v - %v", leg.Departure, leg.Arrival)
+	equals(t, 2*time.Minute, itinerary.Legs[1].TransferTime)
+	equals(t, "B1", itinerary.Legs[1].TripID)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,367 @@
+// Package planner answers earliest-arrival trip planning queries over the MBTA's scheduled network using the Connection Scan Algorithm.
+package planner
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const (
+	defaultWalkingSpeed    = 1.3 // meters per second
+	defaultMinTransferTime = 2 * time.Minute
+	walkingGridDegrees     = 0.01 // about 1km of latitude
+)
+
+// ErrNoSchedules the network data has no usable schedules
+var ErrNoSchedules = errors.New("no schedules with trips and stops to build a network from")
+
+// NetworkData everything a Network is built from. Schedules must have their Stop and Trip relationships,
+// which only need the id unless the stop or trip is missing from Stops/Trips
+type NetworkData struct {
+	Routes        []*mbta.Route
+	RoutePatterns []*mbta.RoutePattern
+	Trips         []*mbta.Trip
+	Schedules     []*mbta.Schedule
+	Stops         []*mbta.Stop // Stops and parent stations. Stops referenced by schedules are added automatically
+}
+
+// NetworkConfig options used while building a Network
+type NetworkConfig struct {
+	MinTransferTime time.Duration // Time needed to change platforms within a parent station. Defaults to 2 minutes
+}
+
+// connection a vehicle going from one stop to the next without stopping in between
+type connection struct {
+	trip      int
+	from, to  int
+	departure int64 // unix seconds
+	arrival   int64 // unix seconds
+	pickup    bool  // passengers can board at from
+	dropOff   bool  // passengers can get off at to
+}
+
+// footpath a transfer between two stops within a parent station
+type footpath struct {
+	to       int
+	duration int64 // seconds
+}
+
+// Network a time-dependent graph of the scheduled network
+type Network struct {
+	stops       []*mbta.Stop
+	stopIndex   map[string]int
+	children    map[string][]int // parent station id -> child stops
+	trips       []*mbta.Trip
+	tripRoutes  []string // route id of each trip, which may only be known from its schedules
+	routes      map[string]*mbta.Route
+	patterns    map[string]*mbta.RoutePattern
+	conns       []connection // sorted by departure
+	transfers   [][]footpath // transfers within parent stations, by stop
+	walkGrid    map[[2]int][]int
+	minTransfer time.Duration
+}
+
+// NewNetwork builds a Network from already fetched data
+func NewNetwork(data NetworkData, config NetworkConfig) (*Network, error) {
+	if config.MinTransferTime == 0 {
+		config.MinTransferTime = defaultMinTransferTime
+	}
+	n := &Network{
+		stopIndex:   make(map[string]int),
+		children:    make(map[string][]int),
+		routes:      make(map[string]*mbta.Route),
+		patterns:    make(map[string]*mbta.RoutePattern),
+		walkGrid:    make(map[[2]int][]int),
+		minTransfer: config.MinTransferTime,
+	}
+	for _, route := range data.Routes {
+		n.routes[route.ID] = route
+	}
+	for _, pattern := range data.RoutePatterns {
+		n.patterns[pattern.ID] = pattern
+	}
+	for _, stop := range data.Stops {
+		n.addStop(stop)
+	}
+
+	tripIndex := make(map[string]int)
+	addTrip := func(trip *mbta.Trip) int {
+		tripIndex[trip.ID] = len(n.trips)
+		n.trips = append(n.trips, trip)
+		routeID := ""
+		if trip.Route != nil {
+			routeID = trip.Route.ID
+		}
+		n.tripRoutes = append(n.tripRoutes, routeID)
+		return len(n.trips) - 1
+	}
+	for _, trip := range data.Trips {
+		addTrip(trip)
+	}
+
+	byTrip := make(map[int][]*mbta.Schedule)
+	for _, schedule := range data.Schedules {
+		if schedule.Trip == nil || schedule.Stop == nil {
+			continue
+		}
+		t, ok := tripIndex[schedule.Trip.ID]
+		if !ok {
+			t = addTrip(schedule.Trip)
+		}
+		if n.tripRoutes[t] == "" && schedule.Route != nil {
+			n.tripRoutes[t] = schedule.Route.ID
+		}
+		n.addStop(schedule.Stop)
+		byTrip[t] = append(byTrip[t], schedule)
+	}
+	if len(byTrip) == 0 {
+		return nil, ErrNoSchedules
+	}
+
+	for t, schedules := range byTrip {
+		sort.Slice(schedules, func(i, j int) bool { return schedules[i].StopSequence < schedules[j].StopSequence })
+		for i := 1; i < len(schedules); i++ {
+			from, to := schedules[i-1], schedules[i]
+			departure := departureTime(from)
+			arrival := arrivalTime(to)
+			if departure.IsZero() || arrival.IsZero() || arrival.Before(departure) {
+				continue
+			}
+			n.conns = append(n.conns, connection{
+				trip:      t,
+				from:      n.stopIndex[from.Stop.ID],
+				to:        n.stopIndex[to.Stop.ID],
+				departure: departure.Unix(),
+				arrival:   arrival.Unix(),
+				pickup:    from.PickupType != mbta.SchedulePickupNotAvailable,
+				dropOff:   to.DropOffType != mbta.SchedulePickupNotAvailable,
+			})
+		}
+	}
+	sort.Slice(n.conns, func(i, j int) bool {
+		if n.conns[i].departure != n.conns[j].departure {
+			return n.conns[i].departure < n.conns[j].departure
+		}
+		return n.conns[i].arrival < n.conns[j].arrival
+	})
+
+	n.buildTransfers()
+	return n, nil
+}
+
+// LoadConfig selects what part of the network Load fetches
+type LoadConfig struct {
+	NetworkConfig
+	Date       time.Time        // Service date to load the schedules for
+	RouteIDs   []string         // Routes to load. Leave empty to load all routes of RouteTypes
+	RouteTypes []mbta.RouteType // Route types to load when RouteIDs is empty. Leave both empty to load every route
+}
+
+// Load fetches the routes, route patterns, trips, schedules and stops for a service date and builds a Network from them
+func Load(ctx context.Context, client *mbta.Client, config LoadConfig) (*Network, error) {
+	routes, _, err := client.Routes.GetAllRoutesWithContext(ctx, &mbta.GetAllRoutesRequestConfig{
+		FilterIDs:        config.RouteIDs,
+		FilterRouteTypes: config.RouteTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	routeIDs := make([]string, len(routes))
+	for i, route := range routes {
+		routeIDs[i] = route.ID
+	}
+
+	patterns, _, err := client.RoutePatterns.GetAllRoutePatternsWithContext(ctx, &mbta.GetAllRoutePatternsRequestConfig{FilterRouteIDs: routeIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	date := mbta.TimeISO8601{Time: config.Date}
+	trips, _, err := client.Trips.GetAllTripsWithContext(ctx, mbta.GetAllTripsRequestConfig{
+		FilterDate:     &date,
+		FilterRouteIDs: routeIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, _, err := client.Schedules.GetAllSchedulesWithContext(ctx, &mbta.GetAllSchedulesRequestConfig{
+		Include:        []mbta.ScheduleInclude{mbta.ScheduleIncludeStop},
+		FilterDates:    []mbta.TimeISO8601{date},
+		FilterRouteIDs: routeIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stops, _, err := client.Stops.GetAllStopsWithContext(ctx, &mbta.GetAllStopsRequestConfig{
+		Include:        []mbta.StopInclude{mbta.StopIncludeParentStation},
+		FilterRouteIDs: routeIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNetwork(NetworkData{
+		Routes:        routes,
+		RoutePatterns: patterns,
+		Trips:         trips,
+		Schedules:     schedules,
+		Stops:         stops,
+	}, config.NetworkConfig)
+}
+
+// addStop adds the stop (and its parent station) if it's new, or fills in the details of a stop that was only known by id
+func (n *Network) addStop(stop *mbta.Stop) int {
+	if i, ok := n.stopIndex[stop.ID]; ok {
+		if !hasLocation(n.stops[i]) && hasLocation(stop) && (n.stops[i].ParentStation == nil || stop.ParentStation != nil) {
+			n.stops[i] = stop
+			n.indexWalking(i)
+		}
+		n.addParent(i)
+		return i
+	}
+	i := len(n.stops)
+	n.stopIndex[stop.ID] = i
+	n.stops = append(n.stops, stop)
+	n.indexWalking(i)
+	n.addParent(i)
+	return i
+}
+
+func (n *Network) addParent(i int) {
+	parent := n.stops[i].ParentStation
+	if parent == nil || parent.ID == "" {
+		return
+	}
+	for _, child := range n.children[parent.ID] {
+		if child == i {
+			return
+		}
+	}
+	n.children[parent.ID] = append(n.children[parent.ID], i)
+	if _, ok := n.stopIndex[parent.ID]; !ok {
+		n.addStop(parent)
+	}
+}
+
+func (n *Network) indexWalking(i int) {
+	if !hasLocation(n.stops[i]) {
+		return
+	}
+	cell := gridCell(location(n.stops[i]))
+	n.walkGrid[cell] = append(n.walkGrid[cell], i)
+}
+
+// buildTransfers connects every pair of stops that share a parent station
+func (n *Network) buildTransfers() {
+	n.transfers = make([][]footpath, len(n.stops))
+	duration := int64(n.minTransfer / time.Second)
+	for _, children := range n.children {
+		for _, a := range children {
+			for _, b := range children {
+				if a != b {
+					n.transfers[a] = append(n.transfers[a], footpath{to: b, duration: duration})
+				}
+			}
+		}
+	}
+}
+
+// nearby stops within radius meters of pt, with their distance
+func (n *Network) nearby(pt mbta.LatLng, radius float64) map[int]float64 {
+	found := make(map[int]float64)
+	if radius <= 0 {
+		return found
+	}
+	center := gridCell(pt)
+	// a cell is walkingGridDegrees of latitude and longitude, which is further than it is wide at these latitudes
+	cellsLat := int(math.Ceil(radius/111000/walkingGridDegrees)) + 1
+	cellsLng := int(math.Ceil(radius/(111000*math.Cos(pt.Latitude*math.Pi/180))/walkingGridDegrees)) + 1
+	for dLat := -cellsLat; dLat <= cellsLat; dLat++ {
+		for dLng := -cellsLng; dLng <= cellsLng; dLng++ {
+			for _, i := range n.walkGrid[[2]int{center[0] + dLat, center[1] + dLng}] {
+				if d := pt.DistanceTo(location(n.stops[i])); d <= radius {
+					found[i] = d
+				}
+			}
+		}
+	}
+	return found
+}
+
+// Stop returns the stop with the given id
+func (n *Network) Stop(id string) (*mbta.Stop, bool) {
+	i, ok := n.stopIndex[id]
+	if !ok {
+		return nil, false
+	}
+	return n.stops[i], true
+}
+
+// expand a stop id into the stops that can be used for it: the stop itself and, for parent stations, its child stops
+func (n *Network) expand(id string) []int {
+	var stops []int
+	if i, ok := n.stopIndex[id]; ok {
+		stops = append(stops, i)
+	}
+	return append(stops, n.children[id]...)
+}
+
+// accessible whether a stop can be used by someone in a wheelchair, using the parent station's value when the stop has no information
+func (n *Network) accessible(i int) bool {
+	stop := n.stops[i]
+	switch stop.WheelchairBoarding {
+	case mbta.WheelchairBoardingAccessible:
+		return true
+	case mbta.WheelchairBoardingInaccessible:
+		return false
+	}
+	if stop.ParentStation != nil {
+		if p, ok := n.stopIndex[stop.ParentStation.ID]; ok && p != i {
+			return n.stops[p].WheelchairBoarding == mbta.WheelchairBoardingAccessible
+		}
+	}
+	return false
+}
+
+func departureTime(schedule *mbta.Schedule) time.Time {
+	return scheduledTime(schedule, true)
+}
+
+func arrivalTime(schedule *mbta.Schedule) time.Time {
+	return scheduledTime(schedule, false)
+}
+
+// scheduledTime the departure or arrival time, falling back to the other at the first and last stops of a trip where one
+// of them is nil
+func scheduledTime(schedule *mbta.Schedule, departure bool) time.Time {
+	first, second := schedule.ArrivalTime, schedule.DepartureTime
+	if departure {
+		first, second = second, first
+	}
+	if first != nil {
+		return first.Time
+	}
+	if second != nil {
+		return second.Time
+	}
+	return time.Time{}
+}
+
+func hasLocation(stop *mbta.Stop) bool {
+	return stop.Latitude != 0 || stop.Longitude != 0
+}
+
+func location(stop *mbta.Stop) mbta.LatLng {
+	return mbta.LatLng{Latitude: stop.Latitude, Longitude: stop.Longitude}
+}
+
+func gridCell(pt mbta.LatLng) [2]int {
+	return [2]int{int(math.Floor(pt.Latitude / walkingGridDegrees)), int(math.Floor(pt.Longitude / walkingGridDegrees))}
+}
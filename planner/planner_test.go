@@ -0,0 +1,150 @@
+package planner
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+var testStart = time.Date(2019, time.June, 3, 8, 0, 0, 0, time.UTC)
+
+func testStop(id string, parent string, lat, lng float64) *mbta.Stop {
+	stop := &mbta.Stop{ID: id, Latitude: lat, Longitude: lng, WheelchairBoarding: mbta.WheelchairBoardingAccessible}
+	if parent != "" {
+		stop.ParentStation = &mbta.Stop{ID: parent}
+	}
+	return stop
+}
+
+func testTrip(id string, route string, accessible bool, stops []string, minutes []int) []*mbta.Schedule {
+	trip := &mbta.Trip{ID: id, Route: &mbta.Route{ID: route}, WheelchairAccessible: mbta.WheelchairBoardingInaccessible}
+	if accessible {
+		trip.WheelchairAccessible = mbta.WheelchairBoardingAccessible
+	}
+	schedules := make([]*mbta.Schedule, len(stops))
+	for i, stop := range stops {
+		t := mbta.TimeISO8601{Time: testStart.Add(time.Duration(minutes[i]) * time.Minute)}
+		schedules[i] = &mbta.Schedule{ArrivalTime: &t, DepartureTime: &t, StopSequence: i + 1, Stop: &mbta.Stop{ID: stop}, Trip: trip}
+	}
+	return schedules
+}
+
+// Two lines that meet at place-x, on different platforms
+//
+//	A: a1 -> x1 -> a3
+//	B: x2 -> b2 -> b3
+func testNetwork(t *testing.T) *Network {
+	var schedules []*mbta.Schedule
+	schedules = append(schedules, testTrip("A1", "A", true, []string{"a1", "x1", "a3"}, []int{0, 5, 10})...)
+	schedules = append(schedules, testTrip("B1", "B", false, []string{"x2", "b2", "b3"}, []int{8, 15, 20})...)
+	schedules = append(schedules, testTrip("B2", "B", true, []string{"x2", "b2", "b3"}, []int{30, 37, 42})...)
+
+	network, err := NewNetwork(NetworkData{
+		Routes: []*mbta.Route{&mbta.Route{ID: "A", LongName: "A Line"}, &mbta.Route{ID: "B", LongName: "B Line"}},
+		Stops: []*mbta.Stop{
+			testStop("place-x", "", 42.01, -71.0),
+			testStop("a1", "", 42.0, -71.0),
+			testStop("x1", "place-x", 42.01, -71.0),
+			testStop("a3", "", 42.02, -71.0),
+			testStop("x2", "place-x", 42.01, -71.0001),
+			testStop("b2", "", 42.01, -70.99),
+			testStop("b3", "", 42.01, -70.98),
+		},
+		Schedules: schedules,
+	}, NetworkConfig{})
+	ok(t, err)
+	return network
+}
+
+func legSummary(journey *Journey) []string {
+	var summary []string
+	for _, leg := range journey.Legs {
+		s := string(leg.Mode)
+		if leg.From != nil {
+			s += " " + leg.From.ID
+		}
+		s += " ->"
+		if leg.To != nil {
+			s += " " + leg.To.ID
+		}
+		if leg.Trip != nil {
+			s += " on " + leg.Trip.ID + " (" + leg.Route.LongName + ")"
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestNetwork_EarliestArrival(t *testing.T) {
+	network := testNetwork(t)
+
+	journey, err := network.EarliestArrival("a1", "b3", testStart.Add(-5*time.Minute), DefaultOptions())
+	ok(t, err)
+	equals(t, []string{"TRANSIT a1 -> x1 on A1 (A Line)", "WALK x1 -> x2", "TRANSIT x2 -> b3 on B1 (B Line)"}, legSummary(journey))
+	equals(t, 1, journey.Transfers)
+	assert(t, journey.Departure.Equal(testStart), "unexpected departure %v", journey.Departure)
+	assert(t, journey.Arrival.Equal(testStart.Add(20*time.Minute)), "unexpected arrival %v", journey.Arrival)
+
+	// parent station as the origin
+	journey, err = network.EarliestArrival("place-x", "b2", testStart, DefaultOptions())
+	ok(t, err)
+	equals(t, []string{"TRANSIT x2 -> b2 on B1 (B Line)"}, legSummary(journey))
+
+	opts := DefaultOptions()
+	opts.MaxTransfers = 0
+	_, err = network.EarliestArrival("a1", "b3", testStart, opts)
+	equals(t, ErrNoJourney, err)
+
+	_, err = network.EarliestArrival("a1", "nowhere", testStart, opts)
+	equals(t, ErrUnknownStop, err)
+}
+
+func TestNetwork_EarliestArrivalWheelchair(t *testing.T) {
+	network := testNetwork(t)
+	opts := DefaultOptions()
+	opts.WheelchairAccessible = true
+
+	journey, err := network.EarliestArrival("a1", "b3", testStart, opts)
+	ok(t, err)
+	equals(t, []string{"TRANSIT a1 -> x1 on A1 (A Line)", "WALK x1 -> x2", "TRANSIT x2 -> b3 on B2 (B Line)"}, legSummary(journey))
+	assert(t, journey.Arrival.Equal(testStart.Add(42*time.Minute)), "unexpected arrival %v", journey.Arrival)
+}
+
+func TestNetwork_EarliestArrivalFromCoordinates(t *testing.T) {
+	network := testNetwork(t)
+
+	from := mbta.LatLng{Latitude: 41.999, Longitude: -71.0} // ~111m south of a1
+	to := mbta.LatLng{Latitude: 42.011, Longitude: -70.98}  // ~111m north of b3
+	journey, err := network.EarliestArrivalFromCoordinates(from, to, testStart.Add(-5*time.Minute), DefaultOptions())
+	ok(t, err)
+	equals(t, []string{"WALK -> a1", "TRANSIT a1 -> x1 on A1 (A Line)", "WALK x1 -> x2", "TRANSIT x2 -> b3 on B1 (B Line)", "WALK b3 ->"}, legSummary(journey))
+	assert(t, journey.Arrival.Equal(testStart.Add(20*time.Minute).Add(86*time.Second)), "unexpected arrival %v", journey.Arrival)
+
+	// close enough to walk
+	journey, err = network.EarliestArrivalFromCoordinates(from, mbta.LatLng{Latitude: 42.0, Longitude: -71.0}, testStart, DefaultOptions())
+	ok(t, err)
+	equals(t, []string{"WALK ->"}, legSummary(journey))
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("\n\n\texp: %#v\n\n\tgot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
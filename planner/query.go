@@ -0,0 +1,417 @@
+package planner
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const unreachable = math.MaxInt64
+
+var (
+	// ErrUnknownStop a stop in the query is not part of the network
+	ErrUnknownStop = errors.New("stop is not part of the network")
+	// ErrNoJourney there is no way to get to the destination with the given options
+	ErrNoJourney = errors.New("no journey found")
+)
+
+// Options options for a query
+type Options struct {
+	MaxTransfers         int           // Maximum number of times to change vehicles. 0 only allows direct trips
+	WalkingRadius        float64       // Maximum distance in meters to walk to, from, or between stops. 0 disables walking other than transfers within a station
+	WalkingSpeed         float64       // Meters per second. Defaults to 1.3
+	WheelchairAccessible bool          // Only use accessible trips and board or alight at accessible stops
+	ExcludeAtypical      bool          // Skip trips whose route pattern is a deviation, highly atypical or a diversion
+	MaxDuration          time.Duration // Ignore connections departing later than this after the departure time. 0 means no limit
+}
+
+// DefaultOptions options suitable for most queries: up to 3 transfers and walks of up to 400 meters
+func DefaultOptions() Options {
+	return Options{
+		MaxTransfers:  3,
+		WalkingRadius: 400,
+		WalkingSpeed:  defaultWalkingSpeed,
+	}
+}
+
+// LegMode how a leg of a journey is travelled
+type LegMode string
+
+const (
+	// LegModeTransit riding a vehicle
+	LegModeTransit LegMode = "TRANSIT"
+	// LegModeWalk walking, including transfers within a station
+	LegModeWalk LegMode = "WALK"
+)
+
+// Leg one part of a journey
+type Leg struct {
+	Mode         LegMode
+	From         *mbta.Stop // nil when walking from a coordinate
+	To           *mbta.Stop // nil when walking to a coordinate
+	Departure    time.Time
+	Arrival      time.Time
+	Trip         *mbta.Trip         // Transit legs only
+	Route        *mbta.Route        // Transit legs only, may only include the id if the route wasn't part of the NetworkData
+	RoutePattern *mbta.RoutePattern // Transit legs only, if known
+	Distance     float64            // Walking legs only, in meters
+}
+
+// Journey the legs of the earliest arriving way to get somewhere
+type Journey struct {
+	Legs      []Leg
+	Departure time.Time
+	Arrival   time.Time
+	Transfers int
+}
+
+type labelKind int
+
+const (
+	labelNone labelKind = iota
+	labelOrigin
+	labelInherited
+	labelTransit
+	labelWalk
+)
+
+// label how a stop was reached in a round
+type label struct {
+	kind      labelKind
+	boardConn int // transit: connection the trip was boarded at
+	exitConn  int // transit: connection the trip was left after
+	from      int // walk: stop walked from, -1 from the origin coordinate
+	distance  float64
+}
+
+// query state of a single earliest arrival query
+type query struct {
+	n        *Network
+	opts     Options
+	departAt int64
+	rounds   [][]int64 // arrival time per round and stop
+	labels   [][]label
+	walks    map[int]map[int]float64 // cached nearby stops for walking transfers
+}
+
+// EarliestArrival finds the earliest arriving journey between two stops leaving no earlier than departAt.
+// Parent station ids can be used for either stop, in which case any of its child stops can be used
+func (n *Network) EarliestArrival(fromStopID, toStopID string, departAt time.Time, opts Options) (*Journey, error) {
+	origins := n.expand(fromStopID)
+	targets := n.expand(toStopID)
+	if len(origins) == 0 || len(targets) == 0 {
+		return nil, ErrUnknownStop
+	}
+	q := n.newQuery(departAt, opts)
+	for _, s := range origins {
+		q.rounds[0][s] = q.departAt
+		q.labels[0][s] = label{kind: labelOrigin}
+	}
+	targetTime := make(map[int]int64)
+	for _, s := range targets {
+		targetTime[s] = 0
+	}
+	return q.run(targetTime, nil, nil)
+}
+
+// EarliestArrivalFromCoordinates finds the earliest arriving journey between two points, walking up to opts.WalkingRadius to and from stops
+func (n *Network) EarliestArrivalFromCoordinates(from, to mbta.LatLng, departAt time.Time, opts Options) (*Journey, error) {
+	q := n.newQuery(departAt, opts)
+	for s, d := range n.nearby(from, opts.WalkingRadius) {
+		if opts.WheelchairAccessible && !n.accessible(s) {
+			continue
+		}
+		q.rounds[0][s] = q.departAt + q.walkSeconds(d)
+		q.labels[0][s] = label{kind: labelWalk, from: -1, distance: d}
+	}
+	targetTime := make(map[int]int64)
+	targetDist := make(map[int]float64)
+	for s, d := range n.nearby(to, opts.WalkingRadius) {
+		if opts.WheelchairAccessible && !n.accessible(s) {
+			continue
+		}
+		targetTime[s] = q.walkSeconds(d)
+		targetDist[s] = d
+	}
+	if len(targetTime) == 0 {
+		return nil, ErrNoJourney
+	}
+
+	direct := from.DistanceTo(to)
+	if direct <= opts.WalkingRadius {
+		// walking the whole way might be fastest
+		return q.run(targetTime, targetDist, &Leg{
+			Mode:      LegModeWalk,
+			Departure: departAt,
+			Arrival:   departAt.Add(time.Duration(q.walkSeconds(direct)) * time.Second),
+			Distance:  direct,
+		})
+	}
+	return q.run(targetTime, targetDist, nil)
+}
+
+func (n *Network) newQuery(departAt time.Time, opts Options) *query {
+	if opts.WalkingSpeed <= 0 {
+		opts.WalkingSpeed = defaultWalkingSpeed
+	}
+	if opts.MaxTransfers < 0 {
+		opts.MaxTransfers = 0
+	}
+	// round 0 is the origin, round k has k transit legs
+	q := &query{
+		n:        n,
+		opts:     opts,
+		departAt: departAt.Unix(),
+		rounds:   make([][]int64, opts.MaxTransfers+2),
+		labels:   make([][]label, opts.MaxTransfers+2),
+		walks:    make(map[int]map[int]float64),
+	}
+	q.rounds[0] = make([]int64, len(n.stops))
+	q.labels[0] = make([]label, len(n.stops))
+	for i := range q.rounds[0] {
+		q.rounds[0][i] = unreachable
+	}
+	return q
+}
+
+func (q *query) walkSeconds(meters float64) int64 {
+	return int64(math.Ceil(meters / q.opts.WalkingSpeed))
+}
+
+// run scans the connections once per round, each round allowing one more vehicle, then picks the best arrival at a target.
+// egress is the extra time needed from each target stop to the destination. walkOnly is a journey that doesn't use the network at all
+func (q *query) run(egress map[int]int64, egressDist map[int]float64, walkOnly *Leg) (*Journey, error) {
+	n := q.n
+	var maxDeparture int64 = unreachable
+	if q.opts.MaxDuration > 0 {
+		maxDeparture = q.departAt + int64(q.opts.MaxDuration/time.Second)
+	}
+	q.relaxFootpaths(0, q.reachedStops(0))
+	first := sort.Search(len(n.conns), func(i int) bool { return n.conns[i].departure >= q.departAt })
+
+	for k := 1; k < len(q.rounds); k++ {
+		prev := q.rounds[k-1]
+		arr := make([]int64, len(n.stops))
+		copy(arr, prev)
+		labels := make([]label, len(n.stops))
+		for i := range labels {
+			if prev[i] != unreachable {
+				labels[i] = label{kind: labelInherited}
+			}
+		}
+		q.rounds[k], q.labels[k] = arr, labels
+
+		boarded := make(map[int]int) // trip -> connection it was boarded at
+		var improved []int
+		for ci := first; ci < len(n.conns); ci++ {
+			c := &n.conns[ci]
+			if c.departure > maxDeparture {
+				break
+			}
+			if q.opts.WheelchairAccessible && n.trips[c.trip].WheelchairAccessible != mbta.WheelchairBoardingAccessible {
+				continue
+			}
+			if q.opts.ExcludeAtypical && q.atypical(c.trip) {
+				continue
+			}
+			board, onBoard := boarded[c.trip]
+			if !onBoard && prev[c.from] <= c.departure && c.pickup && (!q.opts.WheelchairAccessible || n.accessible(c.from)) {
+				board, onBoard = ci, true
+				boarded[c.trip] = ci
+			}
+			if !onBoard || !c.dropOff || c.arrival >= arr[c.to] {
+				continue
+			}
+			if q.opts.WheelchairAccessible && !n.accessible(c.to) {
+				continue
+			}
+			arr[c.to] = c.arrival
+			labels[c.to] = label{kind: labelTransit, boardConn: board, exitConn: ci}
+			improved = append(improved, c.to)
+		}
+		if len(improved) == 0 {
+			q.rounds, q.labels = q.rounds[:k+1], q.labels[:k+1]
+			break
+		}
+		q.relaxFootpaths(k, improved)
+	}
+
+	bestRound, bestStop, bestTime := -1, -1, int64(unreachable)
+	for k := range q.rounds {
+		for s, extra := range egress {
+			if t := q.rounds[k][s]; t != unreachable && t+extra < bestTime {
+				bestRound, bestStop, bestTime = k, s, t+extra
+			}
+		}
+	}
+	if walkOnly != nil && (bestRound < 0 || walkOnly.Arrival.Unix() <= bestTime) {
+		return &Journey{Legs: []Leg{*walkOnly}, Departure: walkOnly.Departure, Arrival: walkOnly.Arrival}, nil
+	}
+	if bestRound < 0 {
+		return nil, ErrNoJourney
+	}
+
+	journey := q.reconstruct(bestRound, bestStop)
+	if egressDist != nil && egressDist[bestStop] > 0 {
+		journey.Legs = append(journey.Legs, Leg{
+			Mode:      LegModeWalk,
+			From:      n.stops[bestStop],
+			Departure: time.Unix(q.rounds[bestRound][bestStop], 0),
+			Arrival:   time.Unix(bestTime, 0),
+			Distance:  egressDist[bestStop],
+		})
+	}
+	if len(journey.Legs) > 0 {
+		journey.Departure = journey.Legs[0].Departure
+		journey.Arrival = journey.Legs[len(journey.Legs)-1].Arrival
+	}
+	return journey, nil
+}
+
+// relaxFootpaths lets riders transfer within stations and walk to nearby stops from the stops reached in round k
+func (q *query) relaxFootpaths(k int, reached []int) {
+	n := q.n
+	arr, labels := q.rounds[k], q.labels[k]
+	// changing vehicles takes at least the minimum transfer time, however close the stops are
+	minWalk := int64(0)
+	if k > 0 {
+		minWalk = int64(n.minTransfer / time.Second)
+	}
+	base := make(map[int]int64, len(reached))
+	for _, s := range reached {
+		base[s] = arr[s]
+	}
+	for s, t := range base {
+		for _, f := range n.transfers[s] {
+			if _, isBase := base[f.to]; isBase || (q.opts.WheelchairAccessible && !n.accessible(f.to)) {
+				continue
+			}
+			if t+f.duration < arr[f.to] {
+				arr[f.to] = t + f.duration
+				labels[f.to] = label{kind: labelWalk, from: s}
+			}
+		}
+		if q.opts.WalkingRadius <= 0 || !hasLocation(n.stops[s]) {
+			continue
+		}
+		walks, ok := q.walks[s]
+		if !ok {
+			walks = n.nearby(location(n.stops[s]), q.opts.WalkingRadius)
+			q.walks[s] = walks
+		}
+		for to, d := range walks {
+			if _, isBase := base[to]; isBase || (q.opts.WheelchairAccessible && !n.accessible(to)) {
+				continue
+			}
+			duration := q.walkSeconds(d)
+			if duration < minWalk {
+				duration = minWalk
+			}
+			if walked := t + duration; walked < arr[to] {
+				arr[to] = walked
+				labels[to] = label{kind: labelWalk, from: s, distance: d}
+			}
+		}
+	}
+}
+
+func (q *query) reachedStops(k int) []int {
+	var reached []int
+	for s, t := range q.rounds[k] {
+		if t != unreachable {
+			reached = append(reached, s)
+		}
+	}
+	return reached
+}
+
+func (q *query) atypical(trip int) bool {
+	pattern := q.n.trips[trip].RoutePattern
+	if pattern == nil {
+		return false
+	}
+	if full, ok := q.n.patterns[pattern.ID]; ok {
+		pattern = full
+	}
+	return pattern.Typicality >= mbta.RoutePatternTypicalityDeviation
+}
+
+// reconstruct follows the labels back from a stop to the origin
+func (q *query) reconstruct(k, s int) *Journey {
+	n := q.n
+	var legs []Leg
+	for s >= 0 && k >= 0 {
+		l := q.labels[k][s]
+		switch l.kind {
+		case labelInherited:
+			k--
+			continue
+		case labelTransit:
+			board, exit := n.conns[l.boardConn], n.conns[l.exitConn]
+			legs = append(legs, q.transitLeg(board, exit))
+			s = board.from
+			k--
+			continue
+		case labelWalk:
+			leg := Leg{
+				Mode:     LegModeWalk,
+				To:       n.stops[s],
+				Arrival:  time.Unix(q.rounds[k][s], 0),
+				Distance: l.distance,
+			}
+			if l.from >= 0 {
+				leg.From = n.stops[l.from]
+				leg.Departure = time.Unix(q.rounds[k][l.from], 0)
+			} else {
+				leg.Departure = time.Unix(q.departAt, 0)
+			}
+			legs = append(legs, leg)
+			s = l.from
+			continue
+		}
+		break
+	}
+
+	journey := &Journey{Legs: make([]Leg, 0, len(legs))}
+	transit := 0
+	for i := len(legs) - 1; i >= 0; i-- {
+		if legs[i].Mode == LegModeTransit {
+			transit++
+		}
+		journey.Legs = append(journey.Legs, legs[i])
+	}
+	if transit > 0 {
+		journey.Transfers = transit - 1
+	}
+	return journey
+}
+
+func (q *query) transitLeg(board, exit connection) Leg {
+	n := q.n
+	trip := n.trips[board.trip]
+	leg := Leg{
+		Mode:      LegModeTransit,
+		From:      n.stops[board.from],
+		To:        n.stops[exit.to],
+		Departure: time.Unix(board.departure, 0),
+		Arrival:   time.Unix(exit.arrival, 0),
+		Trip:      trip,
+	}
+	if routeID := n.tripRoutes[board.trip]; routeID != "" {
+		if route, ok := n.routes[routeID]; ok {
+			leg.Route = route
+		} else {
+			leg.Route = &mbta.Route{ID: routeID}
+		}
+	}
+	if trip.RoutePattern != nil {
+		leg.RoutePattern = trip.RoutePattern
+		if full, ok := n.patterns[trip.RoutePattern.ID]; ok {
+			leg.RoutePattern = full
+		}
+	}
+	return leg
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+// forwardedHeaders the upstream response headers consumers get
+var forwardedHeaders = []string{"Content-Type", "Last-Modified"}
+
+// response an upstream response, shared by every request for its path and never modified
+type response struct {
+	status  int
+	header  http.Header
+	body    []byte
+	fetched time.Time // When it was last fetched or revalidated
+}
+
+// flight an upstream request in progress, that the requests for the same path wait for instead of making their own
+type flight struct {
+	done chan struct{}
+	resp *response
+	err  error
+}
+
+func (s *Server) serveCached(w http.ResponseWriter, r *http.Request, path string) {
+	resp, err := s.fetch(r.Context(), path)
+	if err != nil {
+		if r.Context().Err() == nil {
+			writeError(w, http.StatusBadGateway, "bad_gateway", err.Error())
+		}
+		return
+	}
+	for name, values := range resp.header {
+		w.Header()[name] = values
+	}
+	lastModified := resp.header.Get("Last-Modified")
+	if resp.status == http.StatusOK && lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.body)))
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// fetch returns the cached response for path if it is fresh. Otherwise it gets it from upstream, revalidating the
+// cached one if it has a Last-Modified, or waits for the request already in flight for it
+func (s *Server) fetch(ctx context.Context, path string) (*response, error) {
+	s.mu.Lock()
+	cached := s.cache.get(path)
+	if cached != nil && s.now().Sub(cached.fetched) < s.ttl {
+		s.mu.Unlock()
+		return cached, nil
+	}
+	f, inFlight := s.flights[path]
+	if !inFlight {
+		f = &flight{done: make(chan struct{})}
+		s.flights[path] = f
+		go s.fly(f, path, cached)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fly makes the upstream request of a flight, caching a successful response. While upstream fails or is rate
+// limited, the cached response is served even though it is stale
+func (s *Server) fly(f *flight, path string, cached *response) {
+	f.resp, f.err = s.fetchUpstream(path, cached)
+	if cached != nil && (f.err != nil || f.resp.status == http.StatusTooManyRequests || f.resp.status >= 500) {
+		f.resp, f.err = cached, nil
+	}
+
+	s.mu.Lock()
+	delete(s.flights, path)
+	if f.err == nil && f.resp.status == http.StatusOK {
+		s.cache.put(path, f.resp)
+	}
+	s.mu.Unlock()
+	close(f.done)
+}
+
+func (s *Server) fetchUpstream(path string, cached *response) (*response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+	if cached != nil && cached.header.Get("Last-Modified") != "" {
+		ctx = mbta.WithIfModifiedSince(ctx, cached.header.Get("Last-Modified"))
+	}
+	resp, err := s.client.GetRaw(ctx, path, "")
+	if xerrors.Is(err, mbta.ErrNotModified) && cached != nil {
+		revalidated := *cached
+		revalidated.fetched = s.now()
+		return &revalidated, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	for _, name := range forwardedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return &response{status: resp.StatusCode, header: header, body: body, fetched: s.now()}, nil
+}
+
+// cache the responses by path, dropping the least recently used when full. Guarded by the Server's mutex
+type cache struct {
+	maxEntries int
+	order      *list.List // of *cacheEntry, most recently used first
+	entries    map[string]*list.Element
+}
+
+type cacheEntry struct {
+	path string
+	resp *response
+}
+
+func newCache(maxEntries int) *cache {
+	return &cache{maxEntries: maxEntries, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *cache) get(path string) *response {
+	e, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).resp
+}
+
+func (c *cache) put(path string, resp *response) {
+	if e, ok := c.entries[path]; ok {
+		e.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[path] = c.order.PushFront(&cacheEntry{path: path, resp: resp})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).path)
+	}
+}
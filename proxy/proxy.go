@@ -0,0 +1,209 @@
+// Package proxy serves the MBTA v3 API to many internal consumers through one Client and its API key. Identical
+// requests in flight go upstream once, responses are cached and revalidated with Last-Modified, every stream of a
+// path is read from upstream once whatever the number of subscribers, and each consumer has its own quota.
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const (
+	defaultCacheTTL        = 5 * time.Second
+	defaultMaxCacheEntries = 1000
+
+	// upstreamTimeout bounds a shared upstream request, which no single consumer's request can cancel
+	upstreamTimeout = 30 * time.Second
+
+	quotaWindow = time.Minute
+
+	mediaTypeJSONAPI = "application/vnd.api+json"
+)
+
+// apiResources the first segment of the v3 API's paths, the only ones the proxy sends upstream
+var apiResources = map[string]bool{
+	"alerts":          true,
+	"facilities":      true,
+	"lines":           true,
+	"live_facilities": true,
+	"predictions":     true,
+	"route_patterns":  true,
+	"routes":          true,
+	"schedules":       true,
+	"services":        true,
+	"shapes":          true,
+	"stops":           true,
+	"trips":           true,
+	"vehicles":        true,
+}
+
+// Consumer a service allowed to use the proxy
+type Consumer struct {
+	Name              string `json:"name"`                // Who the consumer is, e.g. for logs
+	Key               string `json:"key"`                 // Sent like an API key, in the x-api-key header or the api_key parameter
+	RequestsPerMinute int    `json:"requests_per_minute"` // 0 for no limit
+}
+
+// Config the options for creating a Server
+type Config struct {
+	Client          *mbta.Client  // Makes the upstream requests, with its API key
+	Consumers       []Consumer    // Without any, requests don't need a key and have no quota
+	CacheTTL        time.Duration // How long a response is served from the cache before it is revalidated. Defaults to 5 seconds
+	MaxCacheEntries int           // Most responses cached, the least recently used are dropped first. Defaults to 1000
+}
+
+// Server the proxy's http.Handler. It answers GET requests for any v3 API path like the API does, including
+// streams when the request accepts text/event-stream, and 404 for any other path, which never goes upstream
+type Server struct {
+	client    *mbta.Client
+	consumers map[string]*quota
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	cache   *cache
+	flights map[string]*flight
+	streams map[string]*stream
+}
+
+// New creates a Server with the given config
+func New(config Config) *Server {
+	s := &Server{
+		client:    config.Client,
+		consumers: make(map[string]*quota, len(config.Consumers)),
+		ttl:       config.CacheTTL,
+		now:       time.Now,
+		flights:   make(map[string]*flight),
+		streams:   make(map[string]*stream),
+	}
+	for _, c := range config.Consumers {
+		s.consumers[c.Key] = &quota{consumer: c}
+	}
+	if s.ttl <= 0 {
+		s.ttl = defaultCacheTTL
+	}
+	maxEntries := config.MaxCacheEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxCacheEntries
+	}
+	s.cache = newCache(maxEntries)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET requests are supported")
+		return
+	}
+	if !s.allow(w, r) {
+		return
+	}
+	path, ok := upstreamPath(r.URL)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "Not a v3 API path")
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), mbta.MediaTypeEventStream) {
+		s.serveStream(w, r, path)
+		return
+	}
+	s.serveCached(w, r, path)
+}
+
+// allow checks the request's key and takes one request from its consumer's quota, writing the error response if
+// it can't be served
+func (s *Server) allow(w http.ResponseWriter, r *http.Request) bool {
+	if len(s.consumers) == 0 {
+		return true
+	}
+	key := r.Header.Get("x-api-key")
+	if key == "" {
+		key = r.URL.Query().Get("api_key")
+	}
+	q, ok := s.consumers[key]
+	if !ok {
+		writeError(w, http.StatusForbidden, "forbidden", "Unknown API key")
+		return false
+	}
+	if q.consumer.RequestsPerMinute <= 0 {
+		return true
+	}
+	remaining, reset, ok := q.take(s.now())
+	w.Header().Set("x-ratelimit-limit", strconv.Itoa(q.consumer.RequestsPerMinute))
+	w.Header().Set("x-ratelimit-remaining", strconv.Itoa(remaining))
+	w.Header().Set("x-ratelimit-reset", strconv.FormatInt(reset.Unix(), 10))
+	if !ok {
+		writeError(w, http.StatusTooManyRequests, "rate_limited", fmt.Sprintf("%s has used its %d requests per minute", q.consumer.Name, q.consumer.RequestsPerMinute))
+	}
+	return ok
+}
+
+// quota the requests a consumer has made in the current window
+type quota struct {
+	consumer Consumer
+
+	mu          sync.Mutex
+	windowStart time.Time
+	used        int
+}
+
+// take counts a request if the consumer has any left, returning how many are left and when the window resets
+func (q *quota) take(now time.Time) (remaining int, reset time.Time, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !now.Before(q.windowStart.Add(quotaWindow)) {
+		q.windowStart = now
+		q.used = 0
+	}
+	reset = q.windowStart.Add(quotaWindow)
+	if q.used >= q.consumer.RequestsPerMinute {
+		return 0, reset, false
+	}
+	q.used++
+	return q.consumer.RequestsPerMinute - q.used, reset, true
+}
+
+// upstreamPath the API path and query of a request, without the consumer's key. It is the same for requests with the
+// same parameters in any order, so it keys the cache, the requests in flight and the streams. It is false unless the
+// path starts with a single "/" and, once cleaned, is under one of the apiResources
+func upstreamPath(u *url.URL) (string, bool) {
+	if !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") {
+		return "", false
+	}
+	cleaned := path.Clean(u.Path)
+	if !apiResources[strings.SplitN(cleaned[1:], "/", 2)[0]] {
+		return "", false
+	}
+	escaped := (&url.URL{Path: cleaned}).EscapedPath()
+	query := u.Query()
+	query.Del("api_key")
+	if len(query) == 0 {
+		return escaped, true
+	}
+	return escaped + "?" + query.Encode(), true
+}
+
+// writeError writes a JSON:API error document like the API's
+func writeError(w http.ResponseWriter, status int, code, detail string) {
+	type apiError struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	w.Header().Set("Content-Type", mediaTypeJSONAPI)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Errors []apiError `json:"errors"`
+	}{[]apiError{{Status: strconv.Itoa(status), Code: code, Title: http.StatusText(status), Detail: detail}}})
+}
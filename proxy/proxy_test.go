@@ -0,0 +1,439 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const testLastModified = "Mon, 03 Jun 2019 08:00:00 GMT"
+
+// fakeUpstream serves body for /vehicles, answering 304 when If-Modified-Since matches lastModified, and streams
+// the events sent to events to every stream request for /predictions
+type fakeUpstream struct {
+	*httptest.Server
+	mu           sync.Mutex
+	status       int
+	body         string
+	lastModified string
+	requests     []*http.Request
+	notModified  int
+	release      chan struct{} // When not nil, responses wait for it to be closed
+
+	events       chan string
+	streamClosed chan struct{}
+}
+
+func newFakeUpstream(t *testing.T) *fakeUpstream {
+	u := &fakeUpstream{
+		status:       http.StatusOK,
+		body:         `{"data": []}`,
+		lastModified: testLastModified,
+		events:       make(chan string),
+		streamClosed: make(chan struct{}, 1),
+	}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		equals(t, "upstream-key", r.Header.Get("x-api-key"))
+		u.mu.Lock()
+		u.requests = append(u.requests, r)
+		release := u.release
+		status, body, lastModified := u.status, u.body, u.lastModified
+		u.mu.Unlock()
+		if release != nil {
+			<-release
+		}
+
+		if r.Header.Get("Accept") == mbta.MediaTypeEventStream {
+			if r.URL.Path != "/predictions" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"errors": [{"status": "400", "code": "bad_request"}]}`)
+				return
+			}
+			w.Header().Set("Content-Type", mbta.MediaTypeEventStream)
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case event := <-u.events:
+					fmt.Fprint(w, event)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					u.streamClosed <- struct{}{}
+					return
+				}
+			}
+		}
+
+		if lastModified != "" && r.Header.Get("If-Modified-Since") == lastModified {
+			u.mu.Lock()
+			u.notModified++
+			u.mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	return u
+}
+
+func (u *fakeUpstream) set(status int, body, lastModified string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.status, u.body, u.lastModified = status, body, lastModified
+}
+
+func (u *fakeUpstream) counts() (requests, notModified int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.requests), u.notModified
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestProxy(t *testing.T, consumers ...Consumer) (*fakeUpstream, *Server, *fakeClock, *httptest.Server) {
+	upstream := newFakeUpstream(t)
+	s := New(Config{
+		Client:    mbta.NewClient(mbta.ClientConfig{BaseURL: upstream.URL, APIKey: "upstream-key"}),
+		Consumers: consumers,
+		CacheTTL:  time.Minute,
+	})
+	clock := &fakeClock{now: time.Date(2019, time.June, 3, 12, 0, 0, 0, time.UTC)}
+	s.now = clock.Now
+	return upstream, s, clock, httptest.NewServer(s)
+}
+
+func get(t *testing.T, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	ok(t, err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	ok(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	ok(t, err)
+	return resp, string(b)
+}
+
+func TestServer_Coalesce(t *testing.T) {
+	upstream, s, _, proxy := newTestProxy(t, Consumer{Name: "web", Key: "web-key", RequestsPerMinute: 100})
+	defer upstream.Close()
+	defer proxy.Close()
+	upstream.release = make(chan struct{})
+
+	const n = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// the same parameters in another order, and the consumer's key is left out upstream
+			url := proxy.URL + "/vehicles?filter%5Broute%5D=Red&api_key=web-key&include=trip"
+			if i%2 == 1 {
+				url = proxy.URL + "/vehicles?include=trip&filter%5Broute%5D=Red&api_key=web-key"
+			}
+			_, bodies[i] = get(t, url, nil)
+		}(i)
+	}
+	// hold the upstream response until every request has been let through
+	for used := 0; used < n; {
+		time.Sleep(time.Millisecond)
+		q := s.consumers["web-key"]
+		q.mu.Lock()
+		used = q.used
+		q.mu.Unlock()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(upstream.release)
+	wg.Wait()
+
+	requests, _ := upstream.counts()
+	equals(t, 1, requests)
+	equals(t, "/vehicles?filter%5Broute%5D=Red&include=trip", upstream.requests[0].URL.RequestURI())
+	for _, body := range bodies {
+		equals(t, `{"data": []}`, body)
+	}
+}
+
+// getRaw sends a GET request for target exactly as given, without the client resolving or cleaning it
+func getRaw(t *testing.T, server *httptest.Server, target string) *http.Response {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	ok(t, err)
+	defer conn.Close()
+	_, err = fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target, server.Listener.Addr())
+	ok(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	ok(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	ok(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestServer_Paths(t *testing.T) {
+	upstream, _, _, proxy := newTestProxy(t)
+	defer upstream.Close()
+	defer proxy.Close()
+	var attackerRequests int32
+	attacker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attackerRequests, 1)
+	}))
+	defer attacker.Close()
+
+	// paths that would take the upstream request, and its API key, to another host, or that aren't the API's
+	for _, target := range []string{
+		"//" + attacker.Listener.Addr().String() + "/vehicles",
+		"///" + attacker.Listener.Addr().String() + "/vehicles",
+		"/vehicles/../../" + attacker.Listener.Addr().String() + "/vehicles",
+		"/nope",
+		"/",
+	} {
+		resp := getRaw(t, proxy, target)
+		equals(t, http.StatusNotFound, resp.StatusCode)
+	}
+	equals(t, int32(0), atomic.LoadInt32(&attackerRequests))
+	requests, _ := upstream.counts()
+	equals(t, 0, requests)
+
+	// the path that goes upstream is the cleaned one
+	resp := getRaw(t, proxy, "/stops/../vehicles/?include=trip")
+	equals(t, http.StatusOK, resp.StatusCode)
+	requests, _ = upstream.counts()
+	equals(t, 1, requests)
+	equals(t, "/vehicles?include=trip", upstream.requests[0].URL.RequestURI())
+}
+
+func TestServer_Cache(t *testing.T) {
+	upstream, _, clock, proxy := newTestProxy(t)
+	defer upstream.Close()
+	defer proxy.Close()
+
+	resp, body := get(t, proxy.URL+"/vehicles", nil)
+	equals(t, http.StatusOK, resp.StatusCode)
+	equals(t, `{"data": []}`, body)
+	equals(t, testLastModified, resp.Header.Get("Last-Modified"))
+
+	// fresh: no upstream request, and a consumer's conditional request is answered by the proxy
+	resp, _ = get(t, proxy.URL+"/vehicles", http.Header{"If-Modified-Since": {testLastModified}})
+	equals(t, http.StatusNotModified, resp.StatusCode)
+	requests, notModified := upstream.counts()
+	equals(t, 1, requests)
+
+	// stale: revalidated upstream, which hasn't changed
+	clock.Add(2 * time.Minute)
+	resp, body = get(t, proxy.URL+"/vehicles", nil)
+	equals(t, http.StatusOK, resp.StatusCode)
+	equals(t, `{"data": []}`, body)
+	requests, notModified = upstream.counts()
+	equals(t, 2, requests)
+	equals(t, 1, notModified)
+	equals(t, testLastModified, upstream.requests[1].Header.Get("If-Modified-Since"))
+
+	// changed upstream
+	clock.Add(2 * time.Minute)
+	upstream.set(http.StatusOK, `{"data": [{"type": "vehicle", "id": "y1234"}]}`, "Mon, 03 Jun 2019 12:03:00 GMT")
+	resp, body = get(t, proxy.URL+"/vehicles", nil)
+	equals(t, `{"data": [{"type": "vehicle", "id": "y1234"}]}`, body)
+	equals(t, "Mon, 03 Jun 2019 12:03:00 GMT", resp.Header.Get("Last-Modified"))
+
+	// rate limited upstream: the stale response is better than none
+	clock.Add(2 * time.Minute)
+	upstream.set(http.StatusTooManyRequests, `{"errors": []}`, "")
+	resp, body = get(t, proxy.URL+"/vehicles", nil)
+	equals(t, http.StatusOK, resp.StatusCode)
+	equals(t, `{"data": [{"type": "vehicle", "id": "y1234"}]}`, body)
+
+	// errors without a cached response are passed on, and not cached
+	upstream.set(http.StatusNotFound, `{"errors": [{"status": "404"}]}`, "")
+	resp, body = get(t, proxy.URL+"/vehicles/nope", nil)
+	equals(t, http.StatusNotFound, resp.StatusCode)
+	equals(t, `{"errors": [{"status": "404"}]}`, body)
+	get(t, proxy.URL+"/vehicles/nope", nil)
+	requests, _ = upstream.counts()
+	equals(t, 6, requests)
+
+	req, err := http.NewRequest(http.MethodPost, proxy.URL+"/vehicles", nil)
+	ok(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	ok(t, err)
+	resp.Body.Close()
+	equals(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestServer_CacheEviction(t *testing.T) {
+	c := newCache(2)
+	a, b, d := &response{status: 1}, &response{status: 2}, &response{status: 3}
+	c.put("/a", a)
+	c.put("/b", b)
+	equals(t, a, c.get("/a"))
+	c.put("/d", d)
+	equals(t, a, c.get("/a"))
+	assert(t, c.get("/b") == nil, "/b was the least recently used")
+	equals(t, d, c.get("/d"))
+}
+
+func TestServer_Quota(t *testing.T) {
+	upstream, _, clock, proxy := newTestProxy(t,
+		Consumer{Name: "web", Key: "web-key", RequestsPerMinute: 2},
+		Consumer{Name: "batch", Key: "batch-key"},
+	)
+	defer upstream.Close()
+	defer proxy.Close()
+	webKey := http.Header{"X-Api-Key": {"web-key"}}
+
+	resp, _ := get(t, proxy.URL+"/vehicles", webKey)
+	equals(t, http.StatusOK, resp.StatusCode)
+	equals(t, "2", resp.Header.Get("x-ratelimit-limit"))
+	equals(t, "1", resp.Header.Get("x-ratelimit-remaining"))
+	resp, _ = get(t, proxy.URL+"/vehicles?api_key=web-key", nil)
+	equals(t, http.StatusOK, resp.StatusCode)
+	equals(t, "0", resp.Header.Get("x-ratelimit-remaining"))
+
+	resp, body := get(t, proxy.URL+"/vehicles", webKey)
+	equals(t, http.StatusTooManyRequests, resp.StatusCode)
+	equals(t, fmt.Sprint(clock.Now().Add(time.Minute).Unix()), resp.Header.Get("x-ratelimit-reset"))
+	assert(t, strings.Contains(body, `"code":"rate_limited"`), "unexpected body %s", body)
+
+	// the client sees the proxy's errors like the API's
+	client := mbta.NewClient(mbta.ClientConfig{BaseURL: proxy.URL, APIKey: "web-key"})
+	_, _, err := client.Vehicles.GetAllVehicles(nil)
+	equals(t, mbta.ErrRateLimitExceeded, err)
+	client.APIKey = "nope"
+	_, _, err = client.Vehicles.GetAllVehicles(nil)
+	equals(t, mbta.ErrForbidden, err)
+
+	// other consumers have their own quotas, and the window resets
+	for i := 0; i < 5; i++ {
+		resp, _ = get(t, proxy.URL+"/vehicles", http.Header{"X-Api-Key": {"batch-key"}})
+		equals(t, http.StatusOK, resp.StatusCode)
+	}
+	clock.Add(time.Minute)
+	resp, _ = get(t, proxy.URL+"/vehicles", webKey)
+	equals(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_Stream(t *testing.T) {
+	upstream, _, _, proxy := newTestProxy(t)
+	defer upstream.Close()
+	defer proxy.Close()
+
+	subscribe := func() (*http.Response, *bufio.Reader) {
+		req, err := http.NewRequest(http.MethodGet, proxy.URL+"/predictions?filter%5Broute%5D=Red", nil)
+		ok(t, err)
+		req.Header.Set("Accept", mbta.MediaTypeEventStream)
+		resp, err := http.DefaultClient.Do(req)
+		ok(t, err)
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	// the response starts with the first event
+	go func() {
+		upstream.events <- "event: reset\ndata: [{\"type\":\"prediction\",\"id\":\"1\"},{\"type\":\"prediction\",\"id\":\"2\"}]\n\n"
+	}()
+	a, aEvents := subscribe()
+	equals(t, http.StatusOK, a.StatusCode)
+	equals(t, mbta.MediaTypeEventStream, a.Header.Get("Content-Type"))
+	equals(t, []string{"reset", `[{"type":"prediction","id":"1"},{"type":"prediction","id":"2"}]`}, readEvent(t, aEvents))
+	upstream.events <- ": keep-alive\n\nevent: update\ndata: {\"type\":\"prediction\",\"id\":\"1\",\n"
+	upstream.events <- "data: \"attributes\":{\"status\":\"Boarding\"}}\n\n"
+	equals(t, []string{"", ": keep-alive"}, readEvent(t, aEvents))
+	equals(t, []string{"update", "{\"type\":\"prediction\",\"id\":\"1\",\n\"attributes\":{\"status\":\"Boarding\"}}"}, readEvent(t, aEvents))
+
+	// a later subscriber starts from the current resources, off the same upstream stream
+	b, bEvents := subscribe()
+	equals(t, []string{"reset", "[{\"type\":\"prediction\",\"id\":\"1\",\n\"attributes\":{\"status\":\"Boarding\"}},{\"type\":\"prediction\",\"id\":\"2\"}]"}, readEvent(t, bEvents))
+	upstream.events <- "event: remove\ndata: {\"type\":\"prediction\",\"id\":\"2\"}\n\n"
+	equals(t, []string{"remove", `{"type":"prediction","id":"2"}`}, readEvent(t, aEvents))
+	equals(t, []string{"remove", `{"type":"prediction","id":"2"}`}, readEvent(t, bEvents))
+	requests, _ := upstream.counts()
+	equals(t, 1, requests)
+
+	// upstream is closed once the last subscriber is gone
+	a.Body.Close()
+	b.Body.Close()
+	select {
+	case <-upstream.streamClosed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the upstream stream wasn't closed")
+	}
+
+	// upstream refusing the stream is passed on
+	resp, body := get(t, proxy.URL+"/vehicles", http.Header{"Accept": {mbta.MediaTypeEventStream}})
+	equals(t, http.StatusBadRequest, resp.StatusCode)
+	equals(t, `{"errors": [{"status": "400", "code": "bad_request"}]}`, body)
+}
+
+// readEvent the event name and data of the next event, or "" and the comment
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var event string
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		ok(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return []string{event, strings.Join(data, "\n")}
+		case strings.HasPrefix(line, ":"):
+			data = append(data, line)
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("exp: %#v\n\ngot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+// subscriberBuffer how many events a subscriber can fall behind by before it is dropped. It then reconnects and
+// starts over with a reset, like after any dropped stream
+const subscriberBuffer = 64
+
+// stream one upstream event stream shared by its subscribers. It keeps track of the resources the events are about,
+// so that a subscriber joining later starts with a reset to them like the first one did
+type stream struct {
+	path   string
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+	resources   map[resourceIdentifier]string // the JSON of each resource
+	order       []resourceIdentifier
+	reset       bool      // Whether upstream has sent its first reset
+	failure     *response // Upstream's answer when it didn't open the stream
+}
+
+type subscriber struct {
+	events chan []byte // Formatted events, closed when the subscriber is dropped or the stream ends
+}
+
+type resourceIdentifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, path string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming_unsupported", "The server can't stream responses")
+		return
+	}
+	st, sub := s.subscribe(path)
+	defer s.unsubscribe(st, sub)
+
+	started := false
+	for {
+		select {
+		case event, open := <-sub.events:
+			if !open {
+				if !started {
+					st.writeFailure(w)
+				}
+				return
+			}
+			if !started {
+				w.Header().Set("Content-Type", mbta.MediaTypeEventStream)
+				w.Header().Set("Cache-Control", "no-cache")
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+			w.Write(event)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// subscribe adds a subscriber to the stream of path, opening the stream if it isn't open yet
+func (s *Server) subscribe(path string) (*stream, *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.streams[path]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		st = &stream{
+			path:        path,
+			cancel:      cancel,
+			subscribers: make(map[*subscriber]bool),
+			resources:   make(map[resourceIdentifier]string),
+		}
+		s.streams[path] = st
+		go s.runStream(ctx, st)
+	}
+
+	sub := &subscriber{events: make(chan []byte, subscriberBuffer)}
+	st.mu.Lock()
+	st.subscribers[sub] = true
+	if st.reset {
+		sub.events <- st.resetEvent()
+	}
+	st.mu.Unlock()
+	return st, sub
+}
+
+// unsubscribe removes a subscriber, closing the upstream stream if it was the last one
+func (s *Server) unsubscribe(st *stream, sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.mu.Lock()
+	st.drop(sub)
+	last := len(st.subscribers) == 0
+	st.mu.Unlock()
+	if last && s.streams[st.path] == st {
+		delete(s.streams, st.path)
+		st.cancel()
+	}
+}
+
+// runStream reads the upstream stream until it ends or its last subscriber leaves, then ends the subscriptions
+func (s *Server) runStream(ctx context.Context, st *stream) {
+	defer s.endStream(st)
+	resp, err := s.client.GetRaw(ctx, st.path, mbta.MediaTypeEventStream)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		st.mu.Lock()
+		st.failure = &response{status: resp.StatusCode, header: http.Header{"Content-Type": {resp.Header.Get("Content-Type")}}, body: body}
+		st.mu.Unlock()
+		return
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var event string
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if event != "" || len(data) > 0 {
+				st.publish(event, strings.Join(data, "\n"))
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comments keep idle connections open, so they go to the subscribers too
+			st.broadcast([]byte(line + "\n\n"))
+			continue
+		}
+		name, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			name, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch name {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
+
+func (s *Server) endStream(st *stream) {
+	s.mu.Lock()
+	if s.streams[st.path] == st {
+		delete(s.streams, st.path)
+	}
+	s.mu.Unlock()
+	st.cancel()
+
+	st.mu.Lock()
+	for sub := range st.subscribers {
+		st.drop(sub)
+	}
+	st.mu.Unlock()
+}
+
+// publish applies an upstream event to the resources and sends it to the subscribers
+func (st *stream) publish(event, data string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	switch event {
+	case "reset":
+		var resources []json.RawMessage
+		if json.Unmarshal([]byte(data), &resources) == nil {
+			st.resources = make(map[resourceIdentifier]string, len(resources))
+			st.order = st.order[:0]
+			for _, r := range resources {
+				st.set(string(r))
+			}
+			st.reset = true
+		}
+	case "add", "update":
+		st.set(data)
+	case "remove":
+		var id resourceIdentifier
+		if json.Unmarshal([]byte(data), &id) == nil {
+			if _, ok := st.resources[id]; ok {
+				delete(st.resources, id)
+				for i := range st.order {
+					if st.order[i] == id {
+						st.order = append(st.order[:i], st.order[i+1:]...)
+						break
+					}
+				}
+			}
+		}
+	}
+	st.send(formatEvent(event, data))
+}
+
+func (st *stream) broadcast(chunk []byte) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.send(chunk)
+}
+
+// send gives every subscriber a chunk, dropping the ones that have fallen too far behind. st.mu must be held
+func (st *stream) send(chunk []byte) {
+	for sub := range st.subscribers {
+		select {
+		case sub.events <- chunk:
+		default:
+			st.drop(sub)
+		}
+	}
+}
+
+// drop removes a subscriber and closes its events, if it is still subscribed. st.mu must be held
+func (st *stream) drop(sub *subscriber) {
+	if st.subscribers[sub] {
+		delete(st.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// set adds or replaces a resource. st.mu must be held
+func (st *stream) set(resource string) {
+	var id resourceIdentifier
+	if json.Unmarshal([]byte(resource), &id) != nil {
+		return
+	}
+	if _, ok := st.resources[id]; !ok {
+		st.order = append(st.order, id)
+	}
+	st.resources[id] = resource
+}
+
+// resetEvent a reset to the current resources. st.mu must be held
+func (st *stream) resetEvent() []byte {
+	resources := make([]string, len(st.order))
+	for i, id := range st.order {
+		resources[i] = st.resources[id]
+	}
+	return formatEvent("reset", "["+strings.Join(resources, ",")+"]")
+}
+
+// writeFailure answers a subscriber whose stream ended before it got any event
+func (st *stream) writeFailure(w http.ResponseWriter) {
+	st.mu.Lock()
+	failure := st.failure
+	st.mu.Unlock()
+	if failure == nil {
+		writeError(w, http.StatusBadGateway, "bad_gateway", "The upstream stream ended")
+		return
+	}
+	for name, values := range failure.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(failure.status)
+	w.Write(failure.body)
+}
+
+func formatEvent(event, data string) []byte {
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return []byte(b.String())
+}
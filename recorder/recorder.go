@@ -0,0 +1,276 @@
+// Package recorder keeps a history of vehicle positions on disk and replays it as vehicle snapshots at any time,
+// e.g. to investigate an incident after the fact or to feed the analytics package deterministic input.
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+)
+
+const (
+	defaultRollEvery = time.Hour
+	filePrefix       = "vehicles-"
+	fileSuffix       = ".ndjson"
+	fileTimeFormat   = "20060102T150405Z"
+)
+
+// Position a vehicle's position and status at a point in time, as it's written to disk
+type Position struct {
+	VehicleID    string             `json:"v"`
+	Time         int64              `json:"t"` // Unix seconds, from the vehicle's UpdatedAt
+	Latitude     float64            `json:"lat"`
+	Longitude    float64            `json:"lon"`
+	Bearing      *float32           `json:"b,omitempty"`
+	Speed        *float32           `json:"sp,omitempty"`
+	Status       mbta.VehicleStatus `json:"st,omitempty"`
+	StopSequence *int               `json:"seq,omitempty"`
+	DirectionID  int                `json:"d"`
+	Label        string             `json:"l,omitempty"`
+	RouteID      string             `json:"r,omitempty"`
+	StopID       string             `json:"s,omitempty"`
+	TripID       string             `json:"tr,omitempty"`
+}
+
+// NewPosition the position of vehicle. now is used when the vehicle has no UpdatedAt
+func NewPosition(vehicle *mbta.Vehicle, now time.Time) Position {
+	t := vehicle.UpdatedAt.Time
+	if t.IsZero() {
+		t = now
+	}
+	p := Position{
+		VehicleID:    vehicle.ID,
+		Time:         t.Unix(),
+		Latitude:     vehicle.Latitude,
+		Longitude:    vehicle.Longitude,
+		Bearing:      vehicle.Bearing,
+		Speed:        vehicle.Speed,
+		Status:       vehicle.CurrentStatus,
+		StopSequence: vehicle.CurrentStopSequence,
+		DirectionID:  vehicle.DirectionID,
+		Label:        vehicle.Label,
+	}
+	if vehicle.Route != nil {
+		p.RouteID = vehicle.Route.ID
+	}
+	if vehicle.Stop != nil {
+		p.StopID = vehicle.Stop.ID
+	}
+	if vehicle.Trip != nil {
+		p.TripID = vehicle.Trip.ID
+	}
+	return p
+}
+
+// At the time of the position
+func (p Position) At() time.Time {
+	return time.Unix(p.Time, 0).UTC()
+}
+
+// Vehicle the position as a Vehicle. Related resources only have their ids
+func (p Position) Vehicle() *mbta.Vehicle {
+	v := &mbta.Vehicle{
+		ID:            p.VehicleID,
+		CurrentStatus: p.Status,
+		DirectionID:   p.DirectionID,
+		Label:         p.Label,
+		Latitude:      p.Latitude,
+		Longitude:     p.Longitude,
+		UpdatedAt:     mbta.TimeISO8601{Time: p.At()},
+	}
+	if p.Bearing != nil {
+		bearing := *p.Bearing
+		v.Bearing = &bearing
+	}
+	if p.Speed != nil {
+		speed := *p.Speed
+		v.Speed = &speed
+	}
+	if p.StopSequence != nil {
+		sequence := *p.StopSequence
+		v.CurrentStopSequence = &sequence
+	}
+	if p.RouteID != "" {
+		v.Route = &mbta.Route{ID: p.RouteID}
+	}
+	if p.StopID != "" {
+		v.Stop = &mbta.Stop{ID: p.StopID}
+	}
+	if p.TripID != "" {
+		v.Trip = &mbta.Trip{ID: p.TripID}
+	}
+	return v
+}
+
+// Recorder snapshots vehicles and appends the positions that changed since the last snapshot to files in a directory.
+// A new file is started every RollEvery, named after the UTC time its period starts
+type Recorder struct {
+	client *mbta.Client
+	dir    string
+
+	Config    mbta.GetAllVehiclesRequestConfig // Filters used for every snapshot
+	RollEvery time.Duration                    // How much time each file covers. Defaults to an hour
+
+	mu          sync.Mutex
+	last        map[string]int64 // vehicle id -> time of the last position written
+	file        *os.File
+	writer      *bufio.Writer
+	fileStarted time.Time
+}
+
+// NewRecorder creates a Recorder that writes to dir, creating it if needed
+func NewRecorder(client *mbta.Client, dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		client: client,
+		dir:    dir,
+		last:   make(map[string]int64),
+	}, nil
+}
+
+// Snapshot fetches vehicles and records their new positions, returning how many were written
+func (r *Recorder) Snapshot() (int, error) {
+	return r.SnapshotWithContext(context.Background())
+}
+
+// SnapshotWithContext fetches vehicles given a context and records their new positions, returning how many were written
+func (r *Recorder) SnapshotWithContext(ctx context.Context) (int, error) {
+	config := r.Config
+	vehicles, _, err := r.client.Vehicles.GetAllVehiclesWithContext(ctx, &config)
+	if err != nil {
+		return 0, err
+	}
+	return r.Record(time.Now(), vehicles)
+}
+
+// Run snapshots every interval until ctx is done, then closes the recorder. Errors are passed to onError, which may be nil, and don't stop the recorder
+func (r *Recorder) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	defer r.Close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.SnapshotWithContext(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Record writes the positions of already fetched vehicles that changed since they were last recorded, returning how many were written.
+// A vehicle whose UpdatedAt hasn't moved since the last snapshot has nothing new to say, so it's skipped
+func (r *Recorder) Record(now time.Time, vehicles []*mbta.Vehicle) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	written := 0
+	for _, vehicle := range vehicles {
+		p := NewPosition(vehicle, now)
+		if last, ok := r.last[p.VehicleID]; ok && last >= p.Time {
+			continue
+		}
+		if err := r.write(p); err != nil {
+			return written, err
+		}
+		r.last[p.VehicleID] = p.Time
+		written++
+	}
+	if r.writer != nil {
+		if err := r.writer.Flush(); err != nil {
+			r.dropFile()
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Close flushes and closes the current file. Recording again opens a new one
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeFile()
+}
+
+func (r *Recorder) write(p Position) error {
+	started := p.At().Truncate(r.rollEvery())
+	if r.file == nil || !started.Equal(r.fileStarted) {
+		if err := r.closeFile(); err != nil {
+			return err
+		}
+		name := filepath.Join(r.dir, filePrefix+started.Format(fileTimeFormat)+fileSuffix)
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		if err := endLine(f); err != nil {
+			f.Close()
+			return err
+		}
+		r.file, r.writer, r.fileStarted = f, bufio.NewWriter(f), started
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if _, err := r.writer.Write(append(b, '\n')); err != nil {
+		r.dropFile()
+		return err
+	}
+	return nil
+}
+
+// endLine ends the last line of f if a failed write left it unfinished, so that only that line is broken and the
+// positions appended after it can still be read
+func endLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = f.Write([]byte{'\n'})
+	return err
+}
+
+// dropFile closes the current file after a failed write, which may have left part of a line in it. The next write
+// opens it again and ends that line first
+func (r *Recorder) dropFile() {
+	if r.file != nil {
+		r.file.Close()
+		r.file, r.writer = nil, nil
+	}
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.writer.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file, r.writer = nil, nil
+	return err
+}
+
+func (r *Recorder) rollEvery() time.Duration {
+	if r.RollEvery > 0 {
+		return r.RollEvery
+	}
+	return defaultRollEvery
+}
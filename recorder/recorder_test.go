@@ -0,0 +1,244 @@
+package recorder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+var testStart = time.Date(2019, time.June, 3, 8, 58, 0, 0, time.UTC)
+
+func float32Ptr(f float32) *float32 { return &f }
+
+func testVehicle(id, tripID string, t time.Time, lat, lon float64, bearing float32) *mbta.Vehicle {
+	return &mbta.Vehicle{
+		ID:            id,
+		Latitude:      lat,
+		Longitude:     lon,
+		Bearing:       float32Ptr(bearing),
+		Speed:         float32Ptr(10),
+		CurrentStatus: mbta.InTransitTo,
+		UpdatedAt:     mbta.TimeISO8601{Time: t},
+		Route:         &mbta.Route{ID: "Red"},
+		Trip:          &mbta.Trip{ID: tripID},
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "recorder")
+	ok(t, err)
+	return dir
+}
+
+func TestRecorder_Record(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	r, err := NewRecorder(nil, dir)
+	ok(t, err)
+
+	written, err := r.Record(testStart, []*mbta.Vehicle{
+		testVehicle("a", "t1", testStart, 42.0, -71.0, 0),
+		testVehicle("b", "t2", testStart, 42.5, -71.5, 90),
+	})
+	ok(t, err)
+	equals(t, 2, written)
+
+	// b hasn't reported since the last snapshot
+	written, err = r.Record(testStart.Add(time.Minute), []*mbta.Vehicle{
+		testVehicle("a", "t1", testStart.Add(time.Minute), 42.1, -71.0, 0),
+		testVehicle("b", "t2", testStart, 42.5, -71.5, 90),
+	})
+	ok(t, err)
+	equals(t, 1, written)
+
+	// the next hour goes to a new file
+	written, err = r.Record(testStart.Add(2*time.Minute), []*mbta.Vehicle{
+		testVehicle("a", "t1", testStart.Add(2*time.Minute), 42.2, -71.0, 0),
+	})
+	ok(t, err)
+	equals(t, 1, written)
+	ok(t, r.Close())
+
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	ok(t, err)
+	equals(t, []string{
+		filepath.Join(dir, "vehicles-20190603T080000Z.ndjson"),
+		filepath.Join(dir, "vehicles-20190603T090000Z.ndjson"),
+	}, names)
+
+	f, err := os.Open(names[0])
+	ok(t, err)
+	defer f.Close()
+	positions, err := ReadPositions(f)
+	ok(t, err)
+	equals(t, 3, len(positions))
+	equals(t, Position{VehicleID: "a", Time: testStart.Unix(), Latitude: 42, Longitude: -71, Bearing: float32Ptr(0),
+		Speed: float32Ptr(10), Status: mbta.InTransitTo, RouteID: "Red", TripID: "t1"}, positions[0])
+}
+
+func TestRecorder_RecordAfterPartialLine(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "vehicles-20190603T080000Z.ndjson")
+	// what a write that failed halfway leaves behind
+	ok(t, ioutil.WriteFile(name, []byte(`{"v":"a","t":1559548800}`+"\n"+`{"v":"b","t":155954`), 0644))
+
+	r, err := NewRecorder(nil, dir)
+	ok(t, err)
+	_, err = r.Record(testStart, []*mbta.Vehicle{testVehicle("c", "t3", testStart, 42.0, -71.0, 0)})
+	ok(t, err)
+	ok(t, r.Close())
+
+	f, err := os.Open(name)
+	ok(t, err)
+	defer f.Close()
+	_, err = ReadPositions(f)
+	assert(t, err != nil && strings.HasPrefix(err.Error(), "line 2: "), "expected an error for line 2, got %v", err)
+
+	// only the partial line is lost
+	b, err := ioutil.ReadFile(name)
+	ok(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	equals(t, 3, len(lines))
+	positions, err := ReadPositions(strings.NewReader(lines[2]))
+	ok(t, err)
+	equals(t, "c", positions[0].VehicleID)
+}
+
+func TestRecorder_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": [{"type": "vehicle", "id": "y1", "attributes": {"latitude": 42.1, "longitude": -71.1, "updated_at": "2019-06-03T04:58:00-04:00"}}]}`)
+	}))
+	defer server.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	r, err := NewRecorder(mbta.NewClient(mbta.ClientConfig{BaseURL: server.URL}), dir)
+	ok(t, err)
+
+	written, err := r.Snapshot()
+	ok(t, err)
+	equals(t, 1, written)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	equals(t, context.Canceled, r.Run(ctx, time.Hour, func(err error) { t.Error(err) }))
+
+	replay, err := OpenReplay(dir, testStart, testStart, 0, 0)
+	ok(t, err)
+	vehicles := replay.At(testStart)
+	equals(t, 1, len(vehicles))
+	equals(t, "y1", vehicles[0].ID)
+}
+
+func TestReplay_At(t *testing.T) {
+	var positions []Position
+	for _, v := range []*mbta.Vehicle{
+		testVehicle("a", "t1", testStart, 42.0, -71.0, 350),
+		testVehicle("a", "t1", testStart.Add(time.Minute), 42.2, -71.2, 10),
+		testVehicle("a", "t1", testStart.Add(time.Minute), 42.2, -71.2, 10),
+		testVehicle("b", "t2", testStart, 42.5, -71.5, 90),
+		testVehicle("b", "t2", testStart.Add(10*time.Minute), 42.6, -71.5, 90),
+		testVehicle("c", "t3", testStart, 43.0, -71.0, 0),
+		testVehicle("c", "t4", testStart.Add(time.Minute), 43.5, -71.0, 0),
+	} {
+		positions = append(positions, NewPosition(v, time.Time{}))
+	}
+	replay := NewReplay(positions)
+	equals(t, testStart, replay.Start())
+	equals(t, testStart.Add(10*time.Minute), replay.End())
+
+	equals(t, 0, len(replay.At(testStart.Add(-time.Second))))
+
+	vehicles := replay.At(testStart.Add(30 * time.Second))
+	equals(t, 3, len(vehicles))
+	a := vehicles[0]
+	assert(t, math.Abs(a.Latitude-42.1) < 1e-9, "expected a halfway between its positions, got %v", a.Latitude)
+	assert(t, math.Abs(a.Longitude+71.1) < 1e-9, "expected a halfway between its positions, got %v", a.Longitude)
+	assert(t, math.Abs(float64(*a.Bearing)) < 1e-3, "expected a to turn through north, got %v", *a.Bearing)
+	equals(t, testStart.Add(30*time.Second), a.UpdatedAt.Time)
+	equals(t, "t1", a.Trip.ID)
+	// b's positions are too far apart to interpolate, so it stays put
+	equals(t, 42.5, vehicles[1].Latitude)
+	// c changed trips, so it isn't interpolated either
+	equals(t, 43.0, vehicles[2].Latitude)
+
+	// b is gone once its last position is older than MaxAge and there's no later one close enough to interpolate to
+	vehicles = replay.At(testStart.Add(3 * time.Minute))
+	equals(t, 2, len(vehicles))
+	equals(t, "a", vehicles[0].ID)
+	equals(t, "c", vehicles[1].ID)
+	equals(t, 0, len(replay.At(testStart.Add(4*time.Minute))))
+
+	snapshots, err := replay.Snapshots(testStart, testStart.Add(time.Minute), 30*time.Second)
+	ok(t, err)
+	equals(t, 3, len(snapshots))
+	equals(t, testStart.Add(30*time.Second), snapshots[1].Time)
+	equals(t, 3, len(snapshots[1].Vehicles))
+	for _, step := range []time.Duration{0, -time.Second} {
+		_, err = replay.Snapshots(testStart, testStart.Add(time.Minute), step)
+		assert(t, xerrors.Is(err, ErrInvalidStep), "%v: expected ErrInvalidStep, got %v", step, err)
+	}
+}
+
+func TestOpenReplay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	r, err := NewRecorder(nil, dir)
+	ok(t, err)
+	r.RollEvery = 10 * time.Minute
+	for i := 0; i < 60; i++ {
+		at := testStart.Add(time.Duration(i) * time.Minute)
+		_, err := r.Record(at, []*mbta.Vehicle{testVehicle("a", "t1", at, 42+float64(i)/100, -71, 0)})
+		ok(t, err)
+	}
+	ok(t, r.Close())
+
+	replay, err := OpenReplay(dir, testStart.Add(30*time.Minute), testStart.Add(31*time.Minute), 0, 0)
+	ok(t, err)
+	// only the files around the range were read
+	assert(t, replay.Start().After(testStart), "expected the first file to be skipped, got %v", replay.Start())
+	assert(t, replay.End().Before(testStart.Add(59*time.Minute)), "expected the last file to be skipped, got %v", replay.End())
+	vehicles := replay.At(testStart.Add(30*time.Minute + 30*time.Second))
+	equals(t, 1, len(vehicles))
+	assert(t, math.Abs(vehicles[0].Latitude-42.305) < 1e-9, "expected an interpolated latitude, got %v", vehicles[0].Latitude)
+
+	// a longer MaxGap reads the files that far around the range too, and is the Replay's
+	replay, err = OpenReplay(dir, testStart.Add(30*time.Minute), testStart.Add(31*time.Minute), 15*time.Minute, 0)
+	ok(t, err)
+	equals(t, 15*time.Minute, replay.MaxGap)
+	assert(t, !replay.Start().After(testStart.Add(15*time.Minute)), "expected the files from 15 minutes before to be read, got %v", replay.Start())
+	assert(t, !replay.End().Before(testStart.Add(46*time.Minute)), "expected the files to 15 minutes after to be read, got %v", replay.End())
+}
+
+func ok(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func equals(tb testing.TB, exp, act interface{}) {
+	tb.Helper()
+	if !reflect.DeepEqual(exp, act) {
+		tb.Fatalf("exp: %#v\n\ngot: %#v", exp, act)
+	}
+}
+
+func assert(tb testing.TB, condition bool, msg string, v ...interface{}) {
+	tb.Helper()
+	if !condition {
+		tb.Fatalf(msg, v...)
+	}
+}
@@ -0,0 +1,238 @@
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mellena1/mbta-v3-go/analytics"
+	"github.com/mellena1/mbta-v3-go/mbta"
+	"golang.org/x/xerrors"
+)
+
+const (
+	defaultMaxGap = 5 * time.Minute
+	defaultMaxAge = 2 * time.Minute
+)
+
+// ErrInvalidStep the step between snapshots isn't positive
+var ErrInvalidStep = errors.New("step between snapshots must be positive")
+
+// Replay turns recorded positions back into vehicle snapshots
+type Replay struct {
+	MaxGap time.Duration // Positions further apart than this aren't interpolated between. Defaults to 5 minutes
+	MaxAge time.Duration // A vehicle whose last position is older than this and that has no later one is gone. Defaults to 2 minutes
+
+	positions map[string][]Position // vehicle id -> positions ordered by time
+	ids       []string
+	start     time.Time
+	end       time.Time
+}
+
+// NewReplay creates a Replay of positions, which can be in any order. Repeated positions are dropped
+func NewReplay(positions []Position) *Replay {
+	r := &Replay{positions: make(map[string][]Position)}
+	for _, p := range positions {
+		r.positions[p.VehicleID] = append(r.positions[p.VehicleID], p)
+	}
+	for id, ps := range r.positions {
+		sort.SliceStable(ps, func(i, j int) bool { return ps[i].Time < ps[j].Time })
+		deduped := ps[:1]
+		for _, p := range ps[1:] {
+			if p.Time != deduped[len(deduped)-1].Time {
+				deduped = append(deduped, p)
+			}
+		}
+		r.positions[id] = deduped
+		r.ids = append(r.ids, id)
+
+		if first := deduped[0].At(); r.start.IsZero() || first.Before(r.start) {
+			r.start = first
+		}
+		if last := deduped[len(deduped)-1].At(); last.After(r.end) {
+			r.end = last
+		}
+	}
+	sort.Strings(r.ids)
+	return r
+}
+
+// OpenReplay creates a Replay from the files a Recorder wrote to dir that cover from through to. maxGap and maxAge are
+// the Replay's MaxGap and MaxAge, 0 for the defaults. The files are read that far around the range, so that the
+// vehicles at its ends are interpolated or kept like the ones in it
+func OpenReplay(dir string, from, to time.Time, maxGap, maxAge time.Duration) (*Replay, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type recording struct {
+		name    string
+		started time.Time
+	}
+	var recordings []recording
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		started, err := time.Parse(fileTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recording{name: name, started: started})
+	}
+	sort.Slice(recordings, func(i, j int) bool { return recordings[i].started.Before(recordings[j].started) })
+
+	// a file covers the time until the next one starts. Read the positions before the range that can be interpolated
+	// from or kept, and the ones after it that can be interpolated to
+	replay := &Replay{MaxGap: maxGap, MaxAge: maxAge}
+	before := replay.maxGap()
+	if replay.maxAge() > before {
+		before = replay.maxAge()
+	}
+	from, to = from.Add(-before), to.Add(replay.maxGap())
+	var positions []Position
+	for i, rec := range recordings {
+		if rec.started.After(to) {
+			break
+		}
+		if i+1 < len(recordings) && !recordings[i+1].started.After(from) {
+			continue
+		}
+		ps, err := readFile(filepath.Join(dir, rec.name))
+		if err != nil {
+			return nil, xerrors.Errorf("reading %s: %w", rec.name, err)
+		}
+		positions = append(positions, ps...)
+	}
+	replay = NewReplay(positions)
+	replay.MaxGap, replay.MaxAge = maxGap, maxAge
+	return replay, nil
+}
+
+func readFile(name string) ([]Position, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPositions(f)
+}
+
+// ReadPositions reads positions in the format a Recorder writes: one JSON object per line. The error for a line that
+// isn't a position says which line it is, counting from 1
+func ReadPositions(r io.Reader) ([]Position, error) {
+	var positions []Position
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var p Position
+		if err := json.Unmarshal(line, &p); err != nil {
+			return nil, xerrors.Errorf("line %d: %w", n, err)
+		}
+		positions = append(positions, p)
+	}
+	return positions, scanner.Err()
+}
+
+// Start the time of the earliest position, the zero time if there are none
+func (r *Replay) Start() time.Time {
+	return r.start
+}
+
+// End the time of the latest position, the zero time if there are none
+func (r *Replay) End() time.Time {
+	return r.end
+}
+
+// At the vehicles as they were at t, ordered by id. Between two positions of the same trip the location, bearing and speed are
+// interpolated and the rest comes from the earlier position; after a vehicle's last position it's kept for MaxAge
+func (r *Replay) At(t time.Time) []*mbta.Vehicle {
+	maxGap, maxAge := r.maxGap(), r.maxAge()
+	var vehicles []*mbta.Vehicle
+	for _, id := range r.ids {
+		ps := r.positions[id]
+		next := sort.Search(len(ps), func(i int) bool { return ps[i].At().After(t) })
+		if next == 0 {
+			continue
+		}
+		prev := ps[next-1]
+		prevAt := prev.At()
+		if next < len(ps) && ps[next].At().Sub(prevAt) <= maxGap {
+			vehicles = append(vehicles, interpolate(prev, ps[next], t))
+			continue
+		}
+		if t.Sub(prevAt) <= maxAge {
+			vehicles = append(vehicles, prev.Vehicle())
+		}
+	}
+	return vehicles
+}
+
+// Snapshots the vehicles every step from from through to, for the analytics package. The error is ErrInvalidStep if
+// step isn't positive
+func (r *Replay) Snapshots(from, to time.Time, step time.Duration) ([]analytics.Snapshot, error) {
+	if step <= 0 {
+		return nil, xerrors.Errorf("%v: %w", step, ErrInvalidStep)
+	}
+	var snapshots []analytics.Snapshot
+	for t := from; !t.After(to); t = t.Add(step) {
+		snapshots = append(snapshots, analytics.Snapshot{Time: t, Vehicles: r.At(t)})
+	}
+	return snapshots, nil
+}
+
+func interpolate(prev, next Position, t time.Time) *mbta.Vehicle {
+	v := prev.Vehicle()
+	if prev.TripID != next.TripID {
+		// a vehicle starting another trip may have jumped to a terminal
+		return v
+	}
+	f := float64(t.Sub(prev.At())) / float64(next.At().Sub(prev.At()))
+	if f == 0 {
+		return v
+	}
+	v.Latitude = prev.Latitude + (next.Latitude-prev.Latitude)*f
+	v.Longitude = prev.Longitude + (next.Longitude-prev.Longitude)*f
+	if prev.Bearing != nil && next.Bearing != nil {
+		bearing := interpolateBearing(*prev.Bearing, *next.Bearing, f)
+		v.Bearing = &bearing
+	}
+	if prev.Speed != nil && next.Speed != nil {
+		speed := *prev.Speed + (*next.Speed-*prev.Speed)*float32(f)
+		v.Speed = &speed
+	}
+	v.UpdatedAt = mbta.TimeISO8601{Time: t.UTC()}
+	return v
+}
+
+// interpolateBearing turns the shorter way around, e.g. from 350 to 10 through 0
+func interpolateBearing(from, to float32, f float64) float32 {
+	delta := math.Mod(float64(to-from)+540, 360) - 180
+	return float32(math.Mod(float64(from)+delta*f+360, 360))
+}
+
+func (r *Replay) maxGap() time.Duration {
+	if r.MaxGap > 0 {
+		return r.MaxGap
+	}
+	return defaultMaxGap
+}
+
+func (r *Replay) maxAge() time.Duration {
+	if r.MaxAge > 0 {
+		return r.MaxAge
+	}
+	return defaultMaxAge
+}